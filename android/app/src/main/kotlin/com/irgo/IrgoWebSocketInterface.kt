@@ -45,18 +45,20 @@ class IrgoWebSocketInterface(private val activity: IrgoActivity) : Irgo.WebSocke
 
     /**
      * Close a virtual WebSocket connection.
-     * Called from JavaScript: IrgoNative.wsClose(sessionID)
+     * Called from JavaScript: IrgoNative.wsClose(sessionID, code, reason)
      */
     @JavascriptInterface
-    fun wsClose(sessionID: String) {
+    fun wsClose(sessionID: String, code: Int, reason: String) {
         try {
-            Irgo.webSocketClose(sessionID)
+            Irgo.webSocketCloseWithCode(sessionID, code.toLong(), reason)
         } catch (e: Exception) {
             // Ignore errors on close
         }
         activeSessions.remove(sessionID)
     }
 
+    fun wsClose(sessionID: String) = wsClose(sessionID, 1000, "")
+
     // WebSocketCallback implementation
 
     override fun onMessage(sessionID: String?, data: String?) {
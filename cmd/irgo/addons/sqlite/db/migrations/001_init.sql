@@ -0,0 +1,7 @@
+-- Initial schema for {{PROJECT_NAME}}.
+-- Add new migrations as 002_*.sql, 003_*.sql, ...; applied files never re-run.
+CREATE TABLE IF NOT EXISTS items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// binding describes one exported declaration of a gomobile package.
+type binding struct {
+	Name      string
+	Kind      string // "func" or "interface"
+	Signature string // Go declaration without the body
+	Doc       string
+	Params    []bindingParam
+	Results   []string      // Go result types
+	Methods   []bindingFunc // interface methods
+}
+
+// bindingFunc is an interface method.
+type bindingFunc struct {
+	Name      string
+	Signature string
+	Params    []bindingParam
+	Results   []string
+}
+
+// bindingParam is a named parameter and its Go type.
+type bindingParam struct {
+	Name string
+	Type string
+}
+
+// bindingsOptions holds flags for `irgo bindings`.
+type bindingsOptions struct {
+	Dir    string // Package directory (default: ./mobile, then the irgo source)
+	Output string // Output file ("" for stdout)
+	Swift  bool   // Include Swift stubs
+	Kotlin bool   // Include Kotlin stubs
+}
+
+// parseBindingsArgs parses `irgo bindings` arguments.
+func parseBindingsArgs(args []string) (bindingsOptions, error) {
+	var opts bindingsOptions
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case (arg == "--dir" || arg == "-o") && i+1 < len(args):
+			if arg == "--dir" {
+				opts.Dir = args[i+1]
+			} else {
+				opts.Output = args[i+1]
+			}
+			i++
+		case strings.HasPrefix(arg, "--dir="):
+			opts.Dir = strings.TrimPrefix(arg, "--dir=")
+		case arg == "--swift":
+			opts.Swift = true
+		case arg == "--kotlin":
+			opts.Kotlin = true
+		default:
+			return opts, fmt.Errorf("unknown argument: %s", arg)
+		}
+	}
+	return opts, nil
+}
+
+// runBindings writes a reference of the mobile package's exported API.
+func runBindings(args []string) error {
+	opts, err := parseBindingsArgs(args)
+	if err != nil {
+		return err
+	}
+
+	dir := opts.Dir
+	if dir == "" {
+		dir = rootPath("mobile")
+		if _, err := os.Stat(dir); err != nil {
+			irgoPath := getIrgoPath()
+			if irgoPath == "" {
+				return fmt.Errorf("no mobile package found - use --dir <path>")
+			}
+			dir = filepath.Join(irgoPath, "mobile")
+		}
+	}
+
+	pkg, bindings, err := loadBindings(dir)
+	if err != nil {
+		return err
+	}
+
+	doc := renderBindings(pkg, bindings, opts.Swift, opts.Kotlin)
+	if opts.Output == "" {
+		fmt.Print(doc)
+		return nil
+	}
+	if err := os.WriteFile(opts.Output, []byte(doc), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", opts.Output, err)
+	}
+	fmt.Printf("Bindings reference written to %s\n", opts.Output)
+	return nil
+}
+
+// loadBindings parses the non-test Go files in dir and returns the package
+// name and its exported functions and interfaces, sorted by name.
+func loadBindings(dir string) (string, []binding, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return "", nil, fmt.Errorf("parsing %s: %w", dir, err)
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+
+	var name string
+	var bindings []binding
+	for pkgName, pkg := range pkgs {
+		name = pkgName
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				bindings = append(bindings, declBindings(fset, decl)...)
+			}
+		}
+	}
+
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].Name < bindings[j].Name
+	})
+	return name, bindings, nil
+}
+
+// declBindings returns bindings for exported top-level functions and
+// interfaces in decl. Methods and structs aren't exported by gomobile in a
+// form native code calls directly, so they're skipped.
+func declBindings(fset *token.FileSet, decl ast.Decl) []binding {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil || !d.Name.IsExported() {
+			return nil
+		}
+		params, results := funcTypeParts(fset, d.Type)
+		return []binding{{
+			Name:      d.Name.Name,
+			Kind:      "func",
+			Signature: "func " + d.Name.Name + strings.TrimPrefix(nodeString(fset, d.Type), "func"),
+			Doc:       strings.TrimSpace(d.Doc.Text()),
+			Params:    params,
+			Results:   results,
+		}}
+
+	case *ast.GenDecl:
+		var out []binding
+		for _, spec := range d.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok || !ts.Name.IsExported() {
+				continue
+			}
+			iface, ok := ts.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+			doc := ts.Doc
+			if doc == nil {
+				doc = d.Doc
+			}
+			b := binding{
+				Name:      ts.Name.Name,
+				Kind:      "interface",
+				Signature: "type " + ts.Name.Name + " interface",
+				Doc:       strings.TrimSpace(doc.Text()),
+			}
+			for _, m := range iface.Methods.List {
+				ft, ok := m.Type.(*ast.FuncType)
+				if !ok || len(m.Names) == 0 {
+					continue
+				}
+				params, results := funcTypeParts(fset, ft)
+				b.Methods = append(b.Methods, bindingFunc{
+					Name:      m.Names[0].Name,
+					Signature: m.Names[0].Name + strings.TrimPrefix(nodeString(fset, ft), "func"),
+					Params:    params,
+					Results:   results,
+				})
+			}
+			out = append(out, b)
+		}
+		return out
+	}
+	return nil
+}
+
+// funcTypeParts flattens a function type into named params and result types.
+func funcTypeParts(fset *token.FileSet, ft *ast.FuncType) ([]bindingParam, []string) {
+	var params []bindingParam
+	for i, field := range ft.Params.List {
+		typ := nodeString(fset, field.Type)
+		if len(field.Names) == 0 {
+			params = append(params, bindingParam{Name: fmt.Sprintf("arg%d", i), Type: typ})
+		}
+		for _, n := range field.Names {
+			params = append(params, bindingParam{Name: n.Name, Type: typ})
+		}
+	}
+
+	var results []string
+	if ft.Results != nil {
+		for _, field := range ft.Results.List {
+			typ := nodeString(fset, field.Type)
+			count := max(len(field.Names), 1)
+			for range count {
+				results = append(results, typ)
+			}
+		}
+	}
+	return params, results
+}
+
+func nodeString(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fset, node)
+	return buf.String()
+}
+
+// renderBindings formats bindings as a Markdown reference.
+func renderBindings(pkg string, bindings []binding, swift, kotlin bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s bindings\n\n", pkg)
+	fmt.Fprintf(&b, "Exported API of package %s as seen from native code via gomobile bind.\n", pkg)
+
+	for _, bind := range bindings {
+		fmt.Fprintf(&b, "\n## %s\n\n", bind.Name)
+		if bind.Doc != "" {
+			b.WriteString(bind.Doc + "\n\n")
+		}
+		b.WriteString("```go\n" + bind.Signature)
+		if bind.Kind == "interface" {
+			b.WriteString(" {\n")
+			for _, m := range bind.Methods {
+				b.WriteString("\t" + m.Signature + "\n")
+			}
+			b.WriteString("}")
+		}
+		b.WriteString("\n```\n")
+
+		if swift {
+			b.WriteString("\n```swift\n" + swiftStub(pkg, bind) + "\n```\n")
+		}
+		if kotlin {
+			b.WriteString("\n```kotlin\n" + kotlinStub(pkg, bind) + "\n```\n")
+		}
+	}
+	return b.String()
+}
+
+// swiftStub returns how bind is declared in the generated Swift framework.
+// Functions are prefixed with the capitalized package name and take a
+// trailing NSErrorPointer for an error result; interfaces become
+// `<Pkg><Name>Protocol`, whose methods throw instead.
+func swiftStub(pkg string, bind binding) string {
+	prefix := exportName(pkg)
+	if bind.Kind == "interface" {
+		var b strings.Builder
+		fmt.Fprintf(&b, "class MyImpl: NSObject, %s%sProtocol {\n", prefix, bind.Name)
+		for _, m := range bind.Methods {
+			fmt.Fprintf(&b, "    func %s(%s)%s { }\n", lowerFirst(m.Name), swiftParams(pkg, m.Params, true), swiftMethodResults(pkg, m.Results))
+		}
+		b.WriteString("}")
+		return b.String()
+	}
+
+	params := swiftParams(pkg, bind.Params, false)
+	results := bind.Results
+	if len(results) > 0 && results[len(results)-1] == "error" {
+		if params != "" {
+			params += ", "
+		}
+		params += "_ error: NSErrorPointer"
+		results = results[:len(results)-1]
+		if len(results) == 0 {
+			// Error-only functions report success as a Bool
+			results = []string{"bool"}
+		}
+	}
+	var ret string
+	if len(results) > 0 {
+		ret = " -> " + swiftType(pkg, results[0])
+	}
+	return fmt.Sprintf("func %s%s(%s)%s", prefix, bind.Name, params, ret)
+}
+
+// swiftParams formats params; C functions are unlabelled, while Objective-C
+// methods label every parameter after the first.
+func swiftParams(pkg string, params []bindingParam, labelled bool) string {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		label := "_ " + p.Name
+		if labelled && i > 0 {
+			label = p.Name
+		}
+		parts[i] = label + ": " + swiftType(pkg, p.Type)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func swiftMethodResults(pkg string, results []string) string {
+	var out string
+	if len(results) > 0 && results[len(results)-1] == "error" {
+		out = " throws"
+		results = results[:len(results)-1]
+	}
+	if len(results) > 0 {
+		out += " -> " + swiftType(pkg, results[0])
+	}
+	return out
+}
+
+func swiftType(pkg, goType string) string {
+	switch goType {
+	case "string":
+		return "String"
+	case "[]byte":
+		return "Data?"
+	case "bool":
+		return "Bool"
+	case "int", "int64":
+		return "Int"
+	case "int32":
+		return "Int32"
+	case "float64":
+		return "Double"
+	case "float32":
+		return "Float"
+	case "error":
+		return "Error?"
+	}
+	return qualifiedName(pkg, goType, "") + "?"
+}
+
+// kotlinStub returns how bind is declared in the generated Android library.
+// Functions are static methods on the package class, errors surface as
+// exceptions, and interfaces are implemented directly.
+func kotlinStub(pkg string, bind binding) string {
+	class := pkg + "." + exportName(pkg)
+	if bind.Kind == "interface" {
+		var b strings.Builder
+		fmt.Fprintf(&b, "class MyImpl : %s.%s {\n", pkg, bind.Name)
+		for _, m := range bind.Methods {
+			fmt.Fprintf(&b, "    override fun %s(%s)%s { }\n", lowerFirst(m.Name), kotlinParams(pkg, m.Params), kotlinResults(pkg, m.Results))
+		}
+		b.WriteString("}")
+		return b.String()
+	}
+	return fmt.Sprintf("%s.%s(%s)%s", class, lowerFirst(bind.Name), kotlinParams(pkg, bind.Params), kotlinResults(pkg, bind.Results))
+}
+
+func kotlinParams(pkg string, params []bindingParam) string {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.Name + ": " + kotlinType(pkg, p.Type)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func kotlinResults(pkg string, results []string) string {
+	if len(results) > 0 && results[len(results)-1] == "error" {
+		results = results[:len(results)-1]
+	}
+	if len(results) == 0 {
+		return ""
+	}
+	return ": " + kotlinType(pkg, results[0])
+}
+
+func kotlinType(pkg, goType string) string {
+	switch goType {
+	case "string":
+		return "String"
+	case "[]byte":
+		return "ByteArray?"
+	case "bool":
+		return "Boolean"
+	case "int", "int64":
+		return "Long"
+	case "int32":
+		return "Int"
+	case "float64":
+		return "Double"
+	case "float32":
+		return "Float"
+	case "error":
+		return "Exception?"
+	}
+	return qualifiedName(pkg, goType, ".") + "?"
+}
+
+// qualifiedName converts a Go type such as *core.Response into the native
+// name for it: CoreResponse with an empty sep, or core.Response with ".".
+func qualifiedName(pkg, goType, sep string) string {
+	goType = strings.TrimPrefix(goType, "*")
+	typePkg, name, ok := strings.Cut(goType, ".")
+	if !ok {
+		typePkg, name = pkg, goType
+	}
+	if sep == "" {
+		return exportName(typePkg) + name
+	}
+	return typePkg + sep + name
+}
+
+func exportName(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
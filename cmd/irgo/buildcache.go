@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// buildHashFile is written next to a mobile build output and holds the
+// sourceHash it was built from.
+const buildHashFile = ".buildhash"
+
+// buildHashSkipDirs are never part of the Go build, or are its outputs.
+var buildHashSkipDirs = map[string]bool{
+	"build":        true,
+	"ios":          true,
+	"android":      true,
+	"node_modules": true,
+}
+
+// sourceHash hashes the project's files along with extra (e.g. the gomobile
+// target) so a different build configuration never matches.
+// Besides .go, .templ, go.mod and go.sum this covers files pulled in with
+// go:embed, such as static/. Hidden files are left out, as is go.work(.sum),
+// which the first mobile build creates.
+func sourceHash(extra ...string) (string, error) {
+	h := sha256.New()
+	for _, e := range extra {
+		io.WriteString(h, e+"\x00")
+	}
+
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != "." && (strings.HasPrefix(name, ".") || buildHashSkipDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasPrefix(name, ".") || strings.HasPrefix(path, "go.work") {
+			return nil
+		}
+
+		// WalkDir visits files in lexical order, so the hash is stable
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		io.WriteString(h, filepath.ToSlash(path)+"\x00")
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		io.WriteString(h, "\x00")
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// buildUpToDate reports whether outPath exists and was built from hash.
+func buildUpToDate(outPath, hash string) bool {
+	if _, err := os.Stat(outPath); err != nil {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(outPath), buildHashFile))
+	return err == nil && strings.TrimSpace(string(data)) == hash
+}
+
+// writeBuildHash records the hash outPath was built from.
+func writeBuildHash(outPath, hash string) error {
+	return os.WriteFile(filepath.Join(filepath.Dir(outPath), buildHashFile), []byte(hash+"\n"), 0644)
+}
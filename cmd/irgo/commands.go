@@ -1,23 +1,27 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
 // runDev starts the development server with hot reload
 func runDev() error {
 	// Check for required tools
-	if err := checkTool("air", "go install github.com/air-verse/air@latest"); err != nil {
+	if err := checkTool("air", installHints["air"]); err != nil {
 		return err
 	}
-	if err := checkTool("templ", "go install github.com/a-h/templ/cmd/templ@latest"); err != nil {
+	if err := checkTool("templ", installHints["templ"]); err != nil {
 		return err
 	}
-	if err := checkTool("entr", "brew install entr"); err != nil {
+	if err := checkTool("entr", installHints["entr"]); err != nil {
 		return err
 	}
 
@@ -38,26 +42,41 @@ func runDev() error {
 	return runCommand("air")
 }
 
-// runServe starts the server without file watching
-func runServe() error {
-	// Check if main.go exists
+// runServe starts the server without file watching.
+// args (e.g. --shutdown-timeout 30s) are passed to the app's serve command.
+func runServe(args []string) error {
+	// Check if main.go exists in the content root or current directory
+	if pkg := mainPackage(); pkg != "." {
+		return commandRunner("go", append([]string{"run", pkg, "serve"}, args...)...)
+	}
 	if _, err := os.Stat("main.go"); err == nil {
 		// User project
-		return runCommand("go", "run", ".", "serve")
+		return commandRunner("go", append([]string{"run", ".", "serve"}, args...)...)
 	}
 
 	// Framework - run example
 	if _, err := os.Stat("examples/todo/main.go"); err == nil {
-		return runCommand("go", "run", "./examples/todo", "serve")
+		return runCommand("go", append([]string{"run", "./examples/todo", "serve"}, args...)...)
 	}
 
 	return fmt.Errorf("no main.go found - are you in an irgo project?")
 }
 
-// runBuild builds for mobile platforms
-func runBuild(target string) error {
+// runBuild builds for mobile platforms.
+// abis optionally limits the Android architectures (see androidTarget);
+// buildvcs is passed to go as -buildvcs if set; force rebuilds even if the
+// sources are unchanged.
+func runBuild(target, abis, buildvcs string, force bool) error {
+	androidTargets, err := androidTarget(abis)
+	if err != nil {
+		return err
+	}
+	if err := checkBuildVCS(buildvcs); err != nil {
+		return err
+	}
+
 	// Check for gomobile
-	if err := checkTool("gomobile", "go install golang.org/x/mobile/cmd/gomobile@latest && gomobile init"); err != nil {
+	if err := checkTool("gomobile", installHints["gomobile"]); err != nil {
 		return err
 	}
 
@@ -74,23 +93,33 @@ func runBuild(target string) error {
 
 	switch target {
 	case "ios":
-		return buildIOS(modulePath)
+		return buildIOS(modulePath, buildvcs, force)
 	case "android":
-		return buildAndroid(modulePath)
+		return buildAndroid(modulePath, androidTargets, buildvcs, force)
 	case "all":
-		if err := buildIOS(modulePath); err != nil {
+		if err := buildIOS(modulePath, buildvcs, force); err != nil {
 			return err
 		}
-		return buildAndroid(modulePath)
+		return buildAndroid(modulePath, androidTargets, buildvcs, force)
 	default:
 		return fmt.Errorf("unknown build target: %s (use ios, android, or all)", target)
 	}
 }
 
-func buildIOS(modulePath string) error {
-	fmt.Println("Building iOS framework...")
-
+// buildIOS builds the xcframework, skipping gomobile when the sources are
+// unchanged since the last build unless force is set.
+func buildIOS(modulePath, buildvcs string, force bool) error {
 	outPath := "build/ios/Irgo.xcframework"
+	hash, err := sourceHash("ios", getGoVersion(), buildvcs)
+	if err != nil {
+		return fmt.Errorf("hashing sources: %w", err)
+	}
+	if !force && buildUpToDate(outPath, hash) {
+		fmt.Printf("iOS framework up to date: %s (use --force to rebuild)\n", outPath)
+		return nil
+	}
+
+	fmt.Println("Building iOS framework...")
 	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
 		return err
 	}
@@ -104,18 +133,71 @@ func buildIOS(modulePath string) error {
 	}
 
 	mobilePackage := modulePath + "/mobile"
-	if err := runGomobileCommand("bind", "-target", "ios", "-o", outPath, mobilePackage); err != nil {
+	args, env := gomobileBindArgs("ios", outPath, mobilePackage), gomobileEnv(buildvcs)
+	if err := runGomobileCommand(env, args...); err != nil {
 		return fmt.Errorf("gomobile bind failed: %w", err)
 	}
+	recordBuild(filepath.Dir(outPath), "ios", mobilePackage, nil, append([]string{"gomobile"}, args...), env, false)
+
+	if err := writeBuildHash(outPath, hash); err != nil {
+		fmt.Printf("Warning: could not record build hash: %v\n", err)
+	}
 
 	fmt.Printf("iOS framework built: %s\n", outPath)
 	return nil
 }
 
-func buildAndroid(modulePath string) error {
-	fmt.Println("Building Android AAR...")
+// androidABIs maps accepted --abi names to gomobile architectures.
+// Both Go arch names and Android ABI names are accepted.
+var androidABIs = map[string]string{
+	"arm":         "arm",
+	"armeabi-v7a": "arm",
+	"arm64":       "arm64",
+	"arm64-v8a":   "arm64",
+	"386":         "386",
+	"x86":         "386",
+	"amd64":       "amd64",
+	"x86_64":      "amd64",
+}
+
+// androidTarget turns a comma-separated --abi list into a gomobile -target
+// value, e.g. "arm64,amd64" becomes "android/arm64,android/amd64".
+// An empty list builds every ABI.
+func androidTarget(abis string) (string, error) {
+	if strings.TrimSpace(abis) == "" {
+		return "android", nil
+	}
 
+	var targets []string
+	for _, name := range strings.Split(abis, ",") {
+		name = strings.TrimSpace(name)
+		arch, ok := androidABIs[name]
+		if !ok {
+			return "", fmt.Errorf("unknown Android ABI %q (use arm, arm64, 386 or amd64)", name)
+		}
+		target := "android/" + arch
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	return strings.Join(targets, ","), nil
+}
+
+// buildAndroid builds the AAR for targets, a gomobile -target value,
+// skipping gomobile when the sources and targets are unchanged since the
+// last build unless force is set.
+func buildAndroid(modulePath, targets, buildvcs string, force bool) error {
 	outPath := "build/android/irgo.aar"
+	hash, err := sourceHash(targets, getGoVersion(), buildvcs)
+	if err != nil {
+		return fmt.Errorf("hashing sources: %w", err)
+	}
+	if !force && buildUpToDate(outPath, hash) {
+		fmt.Printf("Android AAR up to date: %s (use --force to rebuild)\n", outPath)
+		return nil
+	}
+
+	fmt.Println("Building Android AAR...")
 	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
 		return err
 	}
@@ -129,9 +211,15 @@ func buildAndroid(modulePath string) error {
 	}
 
 	mobilePackage := modulePath + "/mobile"
-	if err := runGomobileCommand("bind", "-target", "android", "-o", outPath, mobilePackage); err != nil {
+	args, env := gomobileBindArgs(targets, outPath, mobilePackage), gomobileEnv(buildvcs)
+	if err := runGomobileCommand(env, args...); err != nil {
 		return fmt.Errorf("gomobile bind failed: %w", err)
 	}
+	recordBuild(filepath.Dir(outPath), targets, mobilePackage, nil, append([]string{"gomobile"}, args...), env, false)
+
+	if err := writeBuildHash(outPath, hash); err != nil {
+		fmt.Printf("Warning: could not record build hash: %v\n", err)
+	}
 
 	fmt.Printf("Android AAR built: %s\n", outPath)
 
@@ -151,18 +239,87 @@ func buildAndroid(modulePath string) error {
 
 // runTempl generates templ files
 func runTempl() error {
-	if err := checkTool("templ", "go install github.com/a-h/templ/cmd/templ@latest"); err != nil {
+	if err := checkTool("templ", installHints["templ"]); err != nil {
 		return err
 	}
 
 	fmt.Println("Generating templ files...")
+	if contentRoot != "." {
+		return runCommand("templ", "generate", "-path", contentRoot)
+	}
 	return runCommand("templ", "generate")
 }
 
-// runTest runs the test suite
-func runTest() error {
+// coverProfile is the coverage profile written by `irgo test`
+const coverProfile = "coverage.out"
+
+// testOptions holds the flags accepted by `irgo test`
+type testOptions struct {
+	race     bool
+	cover    bool
+	html     bool
+	short    bool
+	verbose  bool
+	packages []string
+}
+
+// parseTestArgs parses `irgo test` arguments.
+// Race detection and coverage are enabled by default.
+func parseTestArgs(args []string) testOptions {
+	opts := testOptions{
+		race:    !hasFlag(args, "--no-race"),
+		cover:   !hasFlag(args, "--no-cover"),
+		html:    hasFlag(args, "--html"),
+		short:   hasFlag(args, "--short"),
+		verbose: hasFlag(args, "--verbose", "-v"),
+	}
+	if opts.html {
+		opts.cover = true
+	}
+
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			opts.packages = append(opts.packages, arg)
+		}
+	}
+	if len(opts.packages) == 0 {
+		opts.packages = []string{"./..."}
+	}
+	return opts
+}
+
+// goTestArgs assembles the `go test` arguments for the given options
+func goTestArgs(opts testOptions) []string {
+	args := []string{"test"}
+	if opts.verbose {
+		args = append(args, "-v")
+	}
+	if opts.race {
+		args = append(args, "-race")
+	}
+	if opts.cover {
+		args = append(args, "-cover", "-coverprofile="+coverProfile)
+	}
+	if opts.short {
+		args = append(args, "-short")
+	}
+	return append(args, opts.packages...)
+}
+
+// runTest runs the test suite with race detection and coverage
+func runTest(args []string) error {
+	opts := parseTestArgs(args)
+
 	fmt.Println("Running tests...")
-	return runCommand("go", "test", "-v", "./...")
+	if err := commandRunner("go", goTestArgs(opts)...); err != nil {
+		return err
+	}
+
+	if opts.html {
+		fmt.Println("Opening coverage report...")
+		return commandRunner("go", "tool", "cover", "-html="+coverProfile)
+	}
+	return nil
 }
 
 // installTools installs required development tools
@@ -212,24 +369,31 @@ func installTools() error {
 	return nil
 }
 
-// runMobile builds and runs on mobile simulator
-func runMobile(platform string, devMode bool) error {
+// runMobile builds and launches on a simulator/emulator. bundleID overrides
+// the app identifier from irgo.json or the module path (see appIdentifier);
+// force rebuilds the Go library even if the sources are unchanged.
+func runMobile(platform string, devMode bool, bundleID string, force bool) error {
 	switch platform {
 	case "ios":
-		return runIOS(devMode)
+		return runIOS(devMode, bundleID, force)
 	case "android":
-		return runAndroid()
+		return runAndroid(bundleID, force)
 	default:
 		return fmt.Errorf("unknown platform: %s (use ios or android)", platform)
 	}
 }
 
-func runIOS(devMode bool) error {
+func runIOS(devMode bool, bundleFlag string, force bool) error {
 	// Check for Xcode
-	if err := checkTool("xcodebuild", "Install Xcode from the App Store"); err != nil {
+	if err := checkTool("xcodebuild", installHints["xcodebuild"]); err != nil {
 		return err
 	}
-	if err := checkTool("xcrun", "Install Xcode Command Line Tools: xcode-select --install"); err != nil {
+	if err := checkTool("xcrun", installHints["xcrun"]); err != nil {
+		return err
+	}
+
+	bundleID, err := appIdentifier("ios", bundleFlag)
+	if err != nil {
 		return err
 	}
 
@@ -252,7 +416,7 @@ func runIOS(devMode bool) error {
 		fmt.Println()
 
 		// Check for required dev tools
-		if err := checkTool("air", "go install github.com/air-verse/air@latest"); err != nil {
+		if err := checkTool("air", installHints["air"]); err != nil {
 			return err
 		}
 
@@ -262,8 +426,7 @@ func runIOS(devMode bool) error {
 			return fmt.Errorf("could not determine module path: %w", err)
 		}
 
-		fmt.Println("Building iOS framework...")
-		if err := buildIOS(modulePath); err != nil {
+		if err := buildIOS(modulePath, "", force); err != nil {
 			return err
 		}
 
@@ -293,8 +456,7 @@ func runIOS(devMode bool) error {
 			return fmt.Errorf("could not determine module path: %w", err)
 		}
 
-		fmt.Println("Building iOS framework...")
-		if err := buildIOS(modulePath); err != nil {
+		if err := buildIOS(modulePath, "", force); err != nil {
 			return err
 		}
 
@@ -337,31 +499,43 @@ func runIOS(devMode bool) error {
 	}
 
 	// Find an available iPhone simulator
-	simulatorName := findAvailableIPhoneSimulator()
-	if simulatorName == "" {
-		simulatorName = "iPhone 15" // Fallback
+	sim, err := findAvailableIPhoneSimulator()
+	if err != nil {
+		if devServerCmd != nil {
+			devServerCmd.Process.Kill()
+		}
+		return err
 	}
 
 	// Boot simulator if needed
-	fmt.Printf("Launching iOS Simulator (%s)...\n", simulatorName)
-	runCommand("xcrun", "simctl", "boot", simulatorName) // Ignore error if already booted
+	fmt.Printf("Launching iOS Simulator (%s, %s)...\n", sim.Name, sim.UDID)
+	if sim.State != "Booted" {
+		runCommand("xcrun", "simctl", "boot", sim.UDID)
+	}
 
 	// Open Simulator app
 	runCommand("open", "-a", "Simulator")
 
 	// Install app
 	fmt.Println("Installing app...")
-	if err := runCommand("xcrun", "simctl", "install", "booted", appPath); err != nil {
+	if err := runCommand("xcrun", "simctl", "install", sim.UDID, appPath); err != nil {
 		if devServerCmd != nil {
 			devServerCmd.Process.Kill()
 		}
 		return fmt.Errorf("failed to install app: %w", err)
 	}
 
+	// Confirm the app is installed under the expected identifier
+	if err := runCommand("xcrun", "simctl", "get_app_container", sim.UDID, bundleID); err != nil {
+		if devServerCmd != nil {
+			devServerCmd.Process.Kill()
+		}
+		return notInstalledError(bundleID, "PRODUCT_BUNDLE_IDENTIFIER in the Xcode project")
+	}
+
 	// Launch app
 	fmt.Println("Launching app...")
-	bundleID := "com.irgo.Example" // Default bundle ID
-	if err := runCommand("xcrun", "simctl", "launch", "booted", bundleID); err != nil {
+	if err := runCommand("xcrun", "simctl", "launch", sim.UDID, bundleID); err != nil {
 		if devServerCmd != nil {
 			devServerCmd.Process.Kill()
 		}
@@ -387,30 +561,126 @@ func runIOS(devMode bool) error {
 	return nil
 }
 
-// findAvailableIPhoneSimulator finds an available iPhone simulator
-func findAvailableIPhoneSimulator() string {
-	// Get list of available simulators
+// simulator is an iOS simulator device from `xcrun simctl list -j`.
+type simulator struct {
+	UDID                 string `json:"udid"`
+	Name                 string `json:"name"`
+	State                string `json:"state"`
+	IsAvailable          bool   `json:"isAvailable"`
+	DeviceTypeIdentifier string `json:"deviceTypeIdentifier"`
+	Runtime              string `json:"-"`
+}
+
+// simctlDevices is the `xcrun simctl list devices -j` output, keyed by runtime
+// identifier (e.g. "com.apple.CoreSimulator.SimRuntime.iOS-17-2").
+type simctlDevices struct {
+	Devices map[string][]simulator `json:"devices"`
+}
+
+var (
+	iPhoneModelPattern = regexp.MustCompile(`iPhone-(\d+)`)
+	iOSRuntimePattern  = regexp.MustCompile(`\.iOS-(\d+)-(\d+)`)
+)
+
+// findAvailableIPhoneSimulator returns the UDID and name of the best
+// available iPhone simulator, or an error if there are none.
+func findAvailableIPhoneSimulator() (simulator, error) {
 	out, err := exec.Command("xcrun", "simctl", "list", "devices", "available", "-j").Output()
 	if err != nil {
-		return ""
+		return simulator{}, fmt.Errorf("listing simulators: %w", err)
 	}
+	return pickIPhoneSimulator(out)
+}
 
-	// Parse JSON to find an iPhone
-	// Look for common iPhone names in priority order
-	preferences := []string{"iPhone 15 Pro", "iPhone 15", "iPhone 17 Pro", "iPhone 17", "iPhone SE"}
-	outStr := string(out)
-	for _, name := range preferences {
-		if strings.Contains(outStr, name) {
-			return name
+// pickIPhoneSimulator chooses from simctl JSON: an already booted iPhone if
+// there is one, otherwise the newest model on the newest iOS runtime.
+func pickIPhoneSimulator(data []byte) (simulator, error) {
+	var list simctlDevices
+	if err := json.Unmarshal(data, &list); err != nil {
+		return simulator{}, fmt.Errorf("parsing simctl output: %w", err)
+	}
+
+	var best simulator
+	found := false
+	for runtime, devices := range list.Devices {
+		if !iOSRuntimePattern.MatchString(runtime) {
+			continue
+		}
+		for _, d := range devices {
+			if !d.IsAvailable || !strings.Contains(d.DeviceTypeIdentifier, ".iPhone-") {
+				continue
+			}
+			d.Runtime = runtime
+			if !found || simulatorRank(d).better(simulatorRank(best)) {
+				best, found = d, true
+			}
 		}
 	}
 
-	return ""
+	if !found {
+		return simulator{}, fmt.Errorf("no available iPhone simulator found\n\n" +
+			"Create one in Xcode (Window > Devices and Simulators) or with:\n" +
+			"  xcrun simctl create \"iPhone\" <device-type> <runtime>")
+	}
+	return best, nil
+}
+
+// simRank orders simulators; earlier fields take precedence.
+type simRank struct {
+	booted  bool
+	model   int // 0 for models without a number (e.g. SE)
+	major   int // iOS runtime version
+	minor   int
+	variant int // Pro Max > Pro > Plus > base
+	name    string
+}
+
+func simulatorRank(d simulator) simRank {
+	r := simRank{booted: d.State == "Booted", name: d.Name}
+	if m := iPhoneModelPattern.FindStringSubmatch(d.DeviceTypeIdentifier); m != nil {
+		r.model, _ = strconv.Atoi(m[1])
+	}
+	if m := iOSRuntimePattern.FindStringSubmatch(d.Runtime); m != nil {
+		r.major, _ = strconv.Atoi(m[1])
+		r.minor, _ = strconv.Atoi(m[2])
+	}
+	switch id := d.DeviceTypeIdentifier; {
+	case strings.HasSuffix(id, "-Pro-Max"):
+		r.variant = 3
+	case strings.HasSuffix(id, "-Pro"):
+		r.variant = 2
+	case strings.HasSuffix(id, "-Plus"):
+		r.variant = 1
+	}
+	return r
+}
+
+// better reports whether r should be preferred over o.
+// Names break ties so the choice is stable across runs.
+func (r simRank) better(o simRank) bool {
+	switch {
+	case r.booted != o.booted:
+		return r.booted
+	case r.model != o.model:
+		return r.model > o.model
+	case r.major != o.major:
+		return r.major > o.major
+	case r.minor != o.minor:
+		return r.minor > o.minor
+	case r.variant != o.variant:
+		return r.variant > o.variant
+	}
+	return r.name < o.name
 }
 
-func runAndroid() error {
+func runAndroid(bundleFlag string, force bool) error {
 	// Check for Android tools
-	if err := checkTool("adb", "Install Android SDK and add platform-tools to PATH"); err != nil {
+	if err := checkTool("adb", installHints["adb"]); err != nil {
+		return err
+	}
+
+	packageName, err := appIdentifier("android", bundleFlag)
+	if err != nil {
 		return err
 	}
 
@@ -420,8 +690,7 @@ func runAndroid() error {
 		return fmt.Errorf("could not determine module path: %w", err)
 	}
 
-	fmt.Println("Building Android AAR...")
-	if err := buildAndroid(modulePath); err != nil {
+	if err := buildAndroid(modulePath, "android", "", force); err != nil {
 		return err
 	}
 
@@ -462,9 +731,13 @@ func runAndroid() error {
 		return fmt.Errorf("failed to install APK (is an emulator running?): %w", err)
 	}
 
+	// Confirm the app is installed under the expected identifier
+	if err := runCommand("adb", "shell", "pm", "path", packageName); err != nil {
+		return notInstalledError(packageName, "applicationId in app/build.gradle")
+	}
+
 	// Launch app
 	fmt.Println("Launching app...")
-	packageName := "com.irgo.example"
 	activityName := ".MainActivity"
 	if err := runCommand("adb", "shell", "am", "start", "-n", packageName+"/"+packageName+activityName); err != nil {
 		return fmt.Errorf("failed to launch app: %w", err)
@@ -476,6 +749,28 @@ func runAndroid() error {
 
 // Helper functions
 
+// notInstalledError explains how to fix a launch identifier that doesn't
+// match the installed app; source names where the real identifier is set.
+func notInstalledError(id, source string) error {
+	return fmt.Errorf("app is not installed as %q\n\n"+
+		"Make %s match, or set the identifier with:\n"+
+		"  - \"bundleId\" (and \"androidPackage\") in %s\n"+
+		"  - --bundle-id <id> on the command line", id, source, projectConfigFile)
+}
+
+// installHints maps external tools to how to install them.
+var installHints = map[string]string{
+	"go":         "Download from https://go.dev/dl/",
+	"templ":      "go install github.com/a-h/templ/cmd/templ@latest",
+	"air":        "go install github.com/air-verse/air@latest",
+	"entr":       "brew install entr",
+	"gomobile":   "go install golang.org/x/mobile/cmd/gomobile@latest && gomobile init",
+	"xcodebuild": "Install Xcode from the App Store",
+	"xcrun":      "Install Xcode Command Line Tools: xcode-select --install",
+	"adb":        "Install Android SDK and add platform-tools to PATH",
+	"cc":         "Install a C compiler (Xcode CLT, build-essential, or MinGW-w64)",
+}
+
 func checkTool(name, installCmd string) error {
 	_, err := exec.LookPath(name)
 	if err != nil {
@@ -484,6 +779,9 @@ func checkTool(name, installCmd string) error {
 	return nil
 }
 
+// commandRunner runs external commands; replaced in tests
+var commandRunner = runCommand
+
 func runCommand(name string, args ...string) error {
 	cmd := exec.Command(name, args...)
 	cmd.Stdout = os.Stdout
@@ -537,82 +835,81 @@ func copyFile(src, dst string) error {
 	return os.WriteFile(dst, data, 0644)
 }
 
-// ensureMobileBuildSetup ensures the go.work file and x/mobile are set up correctly
+// ensureMobileBuildSetup ensures x/mobile is checked out at the pinned ref
+// (see ensureXMobile) and go.work uses it, reinstalling gomobile and gobind
+// from that checkout whenever either changes.
 func ensureMobileBuildSetup() error {
 	goVersion := getGoVersion()
 
-	// Check if go.work exists with x/mobile
-	if _, err := os.Stat("go.work"); os.IsNotExist(err) {
-		// Get irgo path for replacement
-		irgoPath := getIrgoPath()
+	changed, err := ensureXMobile()
+	if err != nil {
+		return err
+	}
 
-		// Clone x/mobile if not already present
-		mobileDir := filepath.Join(os.TempDir(), "golang-mobile")
-		if _, err := os.Stat(mobileDir); os.IsNotExist(err) {
-			fmt.Println("Cloning golang.org/x/mobile...")
-			cmd := exec.Command("git", "clone", "--depth", "1", "https://github.com/golang/mobile", mobileDir)
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			if err := cmd.Run(); err != nil {
-				return fmt.Errorf("failed to clone x/mobile: %w", err)
+	// Update go.mod in cloned repo to use current Go version
+	mobileModPath := filepath.Join(xmobileDir, "go.mod")
+	if data, err := os.ReadFile(mobileModPath); err == nil {
+		content := string(data)
+		// Replace any go 1.x.x version with current version
+		lines := splitLines(content)
+		for i, line := range lines {
+			if len(line) > 3 && line[:3] == "go " {
+				lines[i] = "go " + goVersion
+				break
 			}
 		}
+		os.WriteFile(mobileModPath, []byte(strings.Join(lines, "\n")), 0644)
+	}
 
-		// Update go.mod in cloned repo to use current Go version
-		mobileModPath := filepath.Join(mobileDir, "go.mod")
-		if data, err := os.ReadFile(mobileModPath); err == nil {
-			content := string(data)
-			// Replace any go 1.x.x version with current version
-			lines := splitLines(content)
-			for i, line := range lines {
-				if len(line) > 3 && line[:3] == "go " {
-					lines[i] = "go " + goVersion
-					break
-				}
-			}
-			os.WriteFile(mobileModPath, []byte(strings.Join(lines, "\n")), 0644)
-		}
+	// Check if go.work exists with x/mobile
+	if _, err := os.Stat("go.work"); os.IsNotExist(err) {
+		// Get irgo path for replacement
+		irgoPath := getIrgoPath()
 
 		// Create go.work file
 		workContent := fmt.Sprintf("go %s\n\nuse (\n\t.\n", goVersion)
 		if irgoPath != "" {
 			workContent += fmt.Sprintf("\t%s\n", irgoPath)
 		}
-		workContent += fmt.Sprintf("\t%s\n)\n", mobileDir)
+		workContent += fmt.Sprintf("\t%s\n)\n", xmobileDir)
 
 		if err := os.WriteFile("go.work", []byte(workContent), 0644); err != nil {
 			return fmt.Errorf("failed to create go.work: %w", err)
 		}
 		fmt.Println("Created go.work for mobile build")
+		changed = true
+	}
 
-		// Install gomobile and gobind from local source
-		fmt.Println("Installing gomobile from source...")
-		cmd := exec.Command("go", "install", "./cmd/gomobile")
-		cmd.Dir = mobileDir
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to install gomobile: %w", err)
-		}
+	if !changed {
+		return nil
+	}
 
-		cmd = exec.Command("go", "install", "./cmd/gobind")
-		cmd.Dir = mobileDir
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to install gobind: %w", err)
-		}
+	// Install gomobile and gobind from local source
+	fmt.Println("Installing gomobile from source...")
+	cmd := exec.Command("go", "install", "./cmd/gomobile")
+	cmd.Dir = xmobileDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to install gomobile: %w", err)
+	}
+
+	cmd = exec.Command("go", "install", "./cmd/gobind")
+	cmd.Dir = xmobileDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to install gobind: %w", err)
 	}
 
 	return nil
 }
 
-// runGomobileCommand runs a gomobile command with the correct GOTOOLCHAIN
-func runGomobileCommand(args ...string) error {
-	goVersion := getGoVersion()
-
+// runGomobileCommand runs a gomobile command with env (see gomobileEnv)
+// added to the environment.
+func runGomobileCommand(env []string, args ...string) error {
 	cmd := exec.Command("gomobile", args...)
-	cmd.Env = append(os.Environ(), "GOTOOLCHAIN=go"+goVersion)
+	cmd.Env = append(os.Environ(), env...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
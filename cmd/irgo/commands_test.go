@@ -0,0 +1,1420 @@
+package main
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// fakeRunner records commands instead of executing them.
+type fakeRunner struct {
+	calls []string
+}
+
+func (f *fakeRunner) run(name string, args ...string) error {
+	f.calls = append(f.calls, name+" "+strings.Join(args, " "))
+	return nil
+}
+
+func withFakeRunner(t *testing.T) *fakeRunner {
+	t.Helper()
+	f := &fakeRunner{}
+	orig := commandRunner
+	commandRunner = f.run
+	t.Cleanup(func() { commandRunner = orig })
+	return f
+}
+
+func TestRunTestDefaults(t *testing.T) {
+	f := withFakeRunner(t)
+
+	if err := runTest(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(f.calls) != 1 {
+		t.Fatalf("expected 1 command, got %d: %v", len(f.calls), f.calls)
+	}
+	want := "go test -race -cover -coverprofile=coverage.out ./..."
+	if f.calls[0] != want {
+		t.Errorf("expected %q, got %q", want, f.calls[0])
+	}
+}
+
+func TestRunTestFlags(t *testing.T) {
+	f := withFakeRunner(t)
+
+	if err := runTest([]string{"--short", "--html", "-v", "./pkg/..."}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(f.calls) != 2 {
+		t.Fatalf("expected 2 commands, got %d: %v", len(f.calls), f.calls)
+	}
+	want := "go test -v -race -cover -coverprofile=coverage.out -short ./pkg/..."
+	if f.calls[0] != want {
+		t.Errorf("expected %q, got %q", want, f.calls[0])
+	}
+	if f.calls[1] != "go tool cover -html=coverage.out" {
+		t.Errorf("expected coverage report command, got %q", f.calls[1])
+	}
+}
+
+func TestRunTestDisableRaceAndCover(t *testing.T) {
+	f := withFakeRunner(t)
+
+	if err := runTest([]string{"--no-race", "--no-cover"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if f.calls[0] != "go test ./..." {
+		t.Errorf("expected plain go test, got %q", f.calls[0])
+	}
+}
+
+func withContentRoot(t *testing.T, root string) {
+	t.Helper()
+	orig := contentRoot
+	contentRoot = root
+	t.Cleanup(func() { contentRoot = orig })
+}
+
+func TestExtractRootFlag(t *testing.T) {
+	t.Setenv(contentRootEnv, "")
+
+	root, rest := extractRootFlag([]string{"build", "desktop", "--root", "web", "macos"})
+	if root != "web" {
+		t.Errorf("expected root 'web', got %q", root)
+	}
+	if strings.Join(rest, " ") != "build desktop macos" {
+		t.Errorf("expected --root to be stripped, got %v", rest)
+	}
+
+	root, _ = extractRootFlag([]string{"serve", "--root=cmd/app"})
+	if root != "cmd/app" {
+		t.Errorf("expected root 'cmd/app', got %q", root)
+	}
+
+	root, _ = extractRootFlag([]string{"serve"})
+	if root != "." {
+		t.Errorf("expected default root '.', got %q", root)
+	}
+
+	t.Setenv(contentRootEnv, "web")
+	root, _ = extractRootFlag([]string{"serve"})
+	if root != "web" {
+		t.Errorf("expected root from env 'web', got %q", root)
+	}
+}
+
+func TestRunServeContentRoot(t *testing.T) {
+	f := withFakeRunner(t)
+
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+	if err := os.MkdirAll(filepath.Join("cmd", "app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("cmd", "app", "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	withContentRoot(t, filepath.Join("cmd", "app"))
+
+	if err := runServe(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(f.calls) != 1 || f.calls[0] != "go run ./cmd/app serve" {
+		t.Errorf("expected serve from content root, got %v", f.calls)
+	}
+}
+
+func TestRunServePassesArgs(t *testing.T) {
+	f := withFakeRunner(t)
+
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("main.go", []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runServe([]string{"--shutdown-timeout", "30s"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(f.calls) != 1 || f.calls[0] != "go run . serve --shutdown-timeout 30s" {
+		t.Errorf("expected flags passed to serve, got %v", f.calls)
+	}
+}
+
+func TestParseNewArgs(t *testing.T) {
+	name, opts, err := parseNewArgs([]string{"myapp", "--with-db", "sqlite"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "myapp" || opts.DB != "sqlite" {
+		t.Errorf("unexpected result: %q %+v", name, opts)
+	}
+
+	if _, opts, _ := parseNewArgs([]string{"--with-db=sqlite", "myapp"}); opts.DB != "sqlite" {
+		t.Errorf("expected --with-db=sqlite form, got %+v", opts)
+	}
+
+	for _, args := range [][]string{nil, {"myapp", "--with-db", "oracle"}, {"myapp", "--bogus"}} {
+		if _, _, err := parseNewArgs(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+func TestParseNewArgsTailwind(t *testing.T) {
+	if _, opts, err := parseNewArgs([]string{"myapp", "--tailwind", "3"}); err != nil || opts.Tailwind != "3" {
+		t.Errorf("expected Tailwind 3, got %+v, %v", opts, err)
+	}
+	if _, opts, err := parseNewArgs([]string{"myapp", "--tailwind=4", "--template", "chat"}); err != nil || opts.Tailwind != "4" {
+		t.Errorf("expected Tailwind 4, got %+v, %v", opts, err)
+	}
+	if _, opts, _ := parseNewArgs([]string{"myapp"}); opts.Tailwind != "" {
+		t.Errorf("expected no Tailwind version by default, got %q", opts.Tailwind)
+	}
+
+	for _, args := range [][]string{{"myapp", "--tailwind", "2"}, {"myapp", "--tailwind", "3", "--template", "minimal"}} {
+		if _, _, err := parseNewArgs(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+func TestUseTailwindV3(t *testing.T) {
+	for _, name := range []string{"default", "chat"} {
+		dir := t.TempDir()
+		if err := copyTemplates(templateFS, "templates/"+name, dir, "myapp", "example.com/myapp"); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if err := useTailwindV3(dir); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		expect := map[string][]string{
+			"package.json":         {`"tailwindcss": "^3.4.0"`},
+			"static/css/input.css": {"@tailwind base;", "@tailwind utilities;"},
+			".air.toml":            {"npx tailwindcss@3 -i"},
+			"dev.sh":               {"npx tailwindcss@3 -i"},
+			"tailwind.config.js":   {"module.exports"},
+		}
+		for file, wants := range expect {
+			content, _ := os.ReadFile(filepath.Join(dir, file))
+			for _, want := range wants {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("%s: expected %s to contain %q", name, file, want)
+				}
+			}
+			if strings.Contains(string(content), "@tailwindcss/cli") || strings.Contains(string(content), `@import "tailwindcss"`) {
+				t.Errorf("%s: %s still set up for Tailwind v4", name, file)
+			}
+		}
+	}
+}
+
+func TestCopyTemplatesSQLiteAddon(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("IRGO_PATH", dir)
+
+	if err := copyTemplates(addonFS, dbAddons["sqlite"], dir, "myapp", "example.com/myapp"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, file := range []string{"db/db.go", "db/migrations/001_init.sql"} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Errorf("expected %s to be scaffolded: %v", file, err)
+			continue
+		}
+		if strings.Contains(string(data), "{{") {
+			t.Errorf("%s: unreplaced placeholder in %q", file, data)
+		}
+	}
+}
+
+func TestParseNewArgsTemplate(t *testing.T) {
+	_, opts, err := parseNewArgs([]string{"myapp"})
+	if err != nil || opts.Template != "default" {
+		t.Errorf("expected default template, got %q (err %v)", opts.Template, err)
+	}
+
+	_, opts, err = parseNewArgs([]string{"myapp", "--template", "chat"})
+	if err != nil || opts.Template != "chat" {
+		t.Errorf("expected chat template, got %q (err %v)", opts.Template, err)
+	}
+
+	_, opts, err = parseNewArgs([]string{"--template=minimal", "myapp"})
+	if err != nil || opts.Template != "minimal" {
+		t.Errorf("expected minimal template, got %q (err %v)", opts.Template, err)
+	}
+
+	_, _, err = parseNewArgs([]string{"myapp", "--template", "nope"})
+	if err == nil {
+		t.Fatal("expected error for unknown template")
+	}
+	for _, name := range []string{"default", "chat", "minimal"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected error to list %q, got %v", name, err)
+		}
+	}
+}
+
+func TestCopyTemplatesVariants(t *testing.T) {
+	t.Setenv("IRGO_PATH", "")
+
+	expected := map[string][]string{
+		"default": {".air.toml", ".gitignore", "go.mod", "main.go", "app/app.go", "handlers/handlers.go", "package.json", "irgo.json"},
+		"chat":    {".air.toml", "go.mod", "main.go", "app/app.go", "handlers/handlers.go", "templates/pages.templ"},
+		"minimal": {".air.toml", "go.mod", "main.go", "app/app.go", "templates/pages.templ", "static/css/app.css"},
+	}
+
+	for _, name := range availableTemplates() {
+		files, ok := expected[name]
+		if !ok {
+			t.Errorf("template %q has no expectations in this test", name)
+			continue
+		}
+
+		dir := t.TempDir()
+		if err := copyTemplates(templateFS, "templates/"+name, dir, "myapp", "example.com/myapp"); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		for _, file := range files {
+			if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+				t.Errorf("%s: expected %s: %v", name, file, err)
+			}
+		}
+
+		gomod, _ := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if !strings.HasPrefix(string(gomod), "module example.com/myapp\n") {
+			t.Errorf("%s: expected module path placeholder replaced, got %q", name, gomod)
+		}
+	}
+}
+
+// withDatastarSource points datastarFiles at url and bundles bundled as the
+// offline copy, restoring both when the test ends.
+func withDatastarSource(t *testing.T, url string, bundled fs.FS) {
+	t.Helper()
+	origFiles, origAssets := datastarFiles, bundledAssets
+	t.Cleanup(func() { datastarFiles, bundledAssets = origFiles, origAssets })
+	datastarFiles = map[string]string{"static/js/datastar.js": url}
+	bundledAssets = bundled
+}
+
+var testBundle = fstest.MapFS{
+	"assets/static/js/datastar.js": {Data: []byte("bundled")},
+}
+
+func readDatastar(t *testing.T, dir string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "static/js/datastar.js"))
+	if err != nil {
+		t.Fatalf("expected datastar.js: %v", err)
+	}
+	return string(data)
+}
+
+func TestParseNewArgsOffline(t *testing.T) {
+	_, opts, err := parseNewArgs([]string{"myapp", "--offline"})
+	if err != nil || !opts.Offline {
+		t.Errorf("expected offline option, got %+v (err %v)", opts, err)
+	}
+}
+
+func TestInstallDatastarDownloads(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("downloaded"))
+	}))
+	defer srv.Close()
+	withDatastarSource(t, srv.URL, testBundle)
+
+	dir := t.TempDir()
+	if err := installDatastar(dir, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readDatastar(t, dir); got != "downloaded" {
+		t.Errorf("expected downloaded copy, got %q", got)
+	}
+}
+
+func TestInstallDatastarOffline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected no network request in offline mode")
+	}))
+	defer srv.Close()
+	withDatastarSource(t, srv.URL, testBundle)
+
+	dir := t.TempDir()
+	if err := installDatastar(dir, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readDatastar(t, dir); got != "bundled" {
+		t.Errorf("expected bundled copy, got %q", got)
+	}
+}
+
+func TestInstallDatastarFallsBackOnDownloadFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	withDatastarSource(t, srv.URL, testBundle)
+
+	dir := t.TempDir()
+	if err := installDatastar(dir, false); err != nil {
+		t.Fatalf("expected fallback to bundled copy, got %v", err)
+	}
+	if got := readDatastar(t, dir); got != "bundled" {
+		t.Errorf("expected bundled copy, got %q", got)
+	}
+}
+
+func TestInstallDatastarWithoutBundle(t *testing.T) {
+	withDatastarSource(t, "http://127.0.0.1:0/datastar.js", fstest.MapFS{})
+
+	if err := installDatastar(t.TempDir(), true); err == nil {
+		t.Error("expected error when no bundled copy is available")
+	}
+}
+
+func TestLoadBindingsMobilePackage(t *testing.T) {
+	pkg, bindings, err := loadBindings(filepath.Join("..", "..", "mobile"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pkg != "mobile" {
+		t.Errorf("expected package mobile, got %q", pkg)
+	}
+
+	byName := make(map[string]binding)
+	for _, b := range bindings {
+		byName[b.Name] = b
+	}
+
+	for name, want := range map[string]string{
+		"HandleRequest":    "func HandleRequest(method, url, headers string, body []byte) *core.Response",
+		"WebSocketConnect": "func WebSocketConnect(url string) (string, error)",
+		"WebSocketClose":   "func WebSocketClose(sessionID string) error",
+		"NativeCallback":   "type NativeCallback interface",
+	} {
+		b, ok := byName[name]
+		if !ok {
+			t.Errorf("expected %s in bindings", name)
+			continue
+		}
+		if b.Signature != want {
+			t.Errorf("%s: expected signature %q, got %q", name, want, b.Signature)
+		}
+	}
+
+	if _, ok := byName["Bridge"]; ok {
+		t.Error("expected structs to be excluded")
+	}
+	if methods := byName["NativeCallback"].Methods; len(methods) != 3 {
+		t.Errorf("expected 3 NativeCallback methods, got %d", len(methods))
+	}
+}
+
+func TestBindingsNativeStubs(t *testing.T) {
+	_, bindings, err := loadBindings(filepath.Join("..", "..", "mobile"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc := renderBindings("mobile", bindings, true, true)
+
+	for _, want := range []string{
+		"func MobileHandleRequest(_ method: String, _ url: String, _ headers: String, _ body: Data?) -> CoreResponse?",
+		"func MobileWebSocketConnect(_ url: String, _ error: NSErrorPointer) -> String",
+		"func MobileWebSocketClose(_ sessionID: String, _ error: NSErrorPointer) -> Bool",
+		"class MyImpl: NSObject, MobileNativeCallbackProtocol {",
+		"func onError(_ code: Int, message: String) { }",
+		"mobile.Mobile.handleRequest(method: String, url: String, headers: String, body: ByteArray?): core.Response?",
+		"mobile.Mobile.webSocketConnect(url: String): String",
+		"override fun onError(code: Long, message: String) { }",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("expected reference to contain %q", want)
+		}
+	}
+}
+
+func TestParseBindingsArgs(t *testing.T) {
+	opts, err := parseBindingsArgs([]string{"--dir", "pkg/mobile", "--swift", "-o", "BINDINGS.md"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Dir != "pkg/mobile" || !opts.Swift || opts.Kotlin || opts.Output != "BINDINGS.md" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if _, err := parseBindingsArgs([]string{"--bogus"}); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+}
+
+// withFakeToolchain makes only the given tools visible to irgo doctor and
+// stubs the cgo link check with linkErr.
+func withFakeToolchain(t *testing.T, linkErr error, tools ...string) {
+	t.Helper()
+	origLook, origLink := lookPath, desktopLinkCheck
+	t.Cleanup(func() { lookPath, desktopLinkCheck = origLook, origLink })
+	t.Setenv("CC", "")
+
+	lookPath = func(name string) (string, error) {
+		for _, tool := range tools {
+			if tool == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", os.ErrNotExist
+	}
+	desktopLinkCheck = func() (string, error) {
+		return "cgo program", linkErr
+	}
+}
+
+func TestDoctorReportsTools(t *testing.T) {
+	withFakeToolchain(t, nil, "go", "templ", "gcc")
+
+	var out strings.Builder
+	if err := runDoctor(&out, ""); err != nil {
+		t.Fatalf("expected no error without a target platform, got %v", err)
+	}
+
+	for _, want := range []string{
+		"go          found (/usr/bin/go)",
+		"cc          found (/usr/bin/gcc)",
+		"gomobile    missing (needed for ios, android)",
+		installHints["gomobile"],
+		"air         missing (optional)",
+		"Go version:",
+		"go.work:",
+		"cgo:        cgo program links",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDoctorRequiredForPlatform(t *testing.T) {
+	withFakeToolchain(t, nil, "go", "templ", "adb")
+
+	for platform, wantMissing := range map[string]string{
+		"ios":     "gomobile, xcodebuild",
+		"android": "gomobile",
+		"desktop": "cc",
+	} {
+		err := runDoctor(io.Discard, platform)
+		if err == nil {
+			t.Errorf("%s: expected error for missing tools", platform)
+			continue
+		}
+		if !strings.HasSuffix(err.Error(), ": "+wantMissing) {
+			t.Errorf("%s: expected missing %q, got %v", platform, wantMissing, err)
+		}
+	}
+
+	if err := runDoctor(io.Discard, "windows"); err == nil {
+		t.Error("expected error for unknown platform")
+	}
+}
+
+func TestDoctorDesktopLinkFailure(t *testing.T) {
+	withFakeToolchain(t, errors.New("ld: library not found"), "go", "templ", "cc")
+
+	var out strings.Builder
+	err := runDoctor(&out, "desktop")
+	if err == nil || !strings.Contains(err.Error(), "cgo") {
+		t.Errorf("expected cgo link failure to fail desktop check, got %v", err)
+	}
+	if !strings.Contains(out.String(), "ld: library not found") {
+		t.Errorf("expected linker output in report, got:\n%s", out.String())
+	}
+
+	if err := runDoctor(io.Discard, ""); err != nil {
+		t.Errorf("expected link failure to be advisory without desktop target, got %v", err)
+	}
+}
+
+const simctlJSON = `{
+  "devices": {
+    "com.apple.CoreSimulator.SimRuntime.iOS-17-2": [
+      {"udid": "AAA-15PRO-172", "name": "iPhone 15 Pro", "state": "Shutdown", "isAvailable": true,
+       "deviceTypeIdentifier": "com.apple.CoreSimulator.SimDeviceType.iPhone-15-Pro"},
+      {"udid": "AAA-IPAD", "name": "iPad Pro (12.9-inch)", "state": "Shutdown", "isAvailable": true,
+       "deviceTypeIdentifier": "com.apple.CoreSimulator.SimDeviceType.iPad-Pro-12-9-inch-6th-generation"}
+    ],
+    "com.apple.CoreSimulator.SimRuntime.iOS-18-1": [
+      {"udid": "BBB-16-181", "name": "iPhone 16", "state": "Shutdown", "isAvailable": true,
+       "deviceTypeIdentifier": "com.apple.CoreSimulator.SimDeviceType.iPhone-16"},
+      {"udid": "BBB-16PRO-181", "name": "iPhone 16 Pro", "state": "Shutdown", "isAvailable": true,
+       "deviceTypeIdentifier": "com.apple.CoreSimulator.SimDeviceType.iPhone-16-Pro"},
+      {"udid": "BBB-SE-181", "name": "iPhone SE (3rd generation)", "state": "Shutdown", "isAvailable": true,
+       "deviceTypeIdentifier": "com.apple.CoreSimulator.SimDeviceType.iPhone-SE-3rd-generation"}
+    ],
+    "com.apple.CoreSimulator.SimRuntime.iOS-18-2": [
+      {"udid": "CCC-17-UNAVAILABLE", "name": "iPhone 17", "state": "Shutdown", "isAvailable": false,
+       "deviceTypeIdentifier": "com.apple.CoreSimulator.SimDeviceType.iPhone-17"}
+    ],
+    "com.apple.CoreSimulator.SimRuntime.watchOS-11-0": [
+      {"udid": "DDD-WATCH", "name": "Apple Watch Series 10", "state": "Shutdown", "isAvailable": true,
+       "deviceTypeIdentifier": "com.apple.CoreSimulator.SimDeviceType.Apple-Watch-Series-10-46mm"}
+    ]
+  }
+}`
+
+func TestPickIPhoneSimulatorNewest(t *testing.T) {
+	sim, err := pickIPhoneSimulator([]byte(simctlJSON))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sim.UDID != "BBB-16PRO-181" {
+		t.Errorf("expected newest available iPhone (16 Pro), got %s (%s)", sim.Name, sim.UDID)
+	}
+}
+
+func TestPickIPhoneSimulatorPrefersBooted(t *testing.T) {
+	data := strings.Replace(simctlJSON,
+		`"udid": "AAA-15PRO-172", "name": "iPhone 15 Pro", "state": "Shutdown"`,
+		`"udid": "AAA-15PRO-172", "name": "iPhone 15 Pro", "state": "Booted"`, 1)
+
+	sim, err := pickIPhoneSimulator([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sim.UDID != "AAA-15PRO-172" {
+		t.Errorf("expected already booted simulator, got %s (%s)", sim.Name, sim.UDID)
+	}
+}
+
+func TestPickIPhoneSimulatorNone(t *testing.T) {
+	for _, data := range []string{
+		`{"devices": {}}`,
+		`{"devices": {"com.apple.CoreSimulator.SimRuntime.iOS-18-2": [
+			{"udid": "X", "name": "iPhone 17", "isAvailable": false,
+			 "deviceTypeIdentifier": "com.apple.CoreSimulator.SimDeviceType.iPhone-17"}]}}`,
+	} {
+		if _, err := pickIPhoneSimulator([]byte(data)); err == nil || !strings.Contains(err.Error(), "no available iPhone simulator") {
+			t.Errorf("expected no-simulator error, got %v", err)
+		}
+	}
+
+	if _, err := pickIPhoneSimulator([]byte("not json")); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestDeriveAppIdentifier(t *testing.T) {
+	tests := []struct {
+		module       string
+		ios, android string
+	}{
+		{"github.com/acme/myapp", "com.github.acme.myapp", "com.github.acme.myapp"},
+		{"github.com/acme/my-app", "com.github.acme.my-app", "com.github.acme.my_app"},
+		{"example.co.uk/tools/2fa", "uk.co.example.tools.2fa", "uk.co.example.tools.app2fa"},
+		{"myapp", "com.example.myapp", "com.example.myapp"},
+	}
+	for _, tt := range tests {
+		if got := deriveAppIdentifier(tt.module, false); got != tt.ios {
+			t.Errorf("%s (ios): expected %q, got %q", tt.module, tt.ios, got)
+		}
+		if got := deriveAppIdentifier(tt.module, true); got != tt.android {
+			t.Errorf("%s (android): expected %q, got %q", tt.module, tt.android, got)
+		}
+	}
+}
+
+func TestAppIdentifierPrecedence(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.WriteFile("go.mod", []byte("module github.com/acme/shop\n"), 0644)
+
+	// Derived from the module path without irgo.json
+	if id, _ := appIdentifier("ios", ""); id != "com.github.acme.shop" {
+		t.Errorf("expected derived bundle ID, got %q", id)
+	}
+
+	os.WriteFile(projectConfigFile, []byte(`{"bundleId": "com.acme.Shop", "androidPackage": "com.acme.shop"}`), 0644)
+	if id, _ := appIdentifier("ios", ""); id != "com.acme.Shop" {
+		t.Errorf("expected bundleId from irgo.json, got %q", id)
+	}
+	if id, _ := appIdentifier("android", ""); id != "com.acme.shop" {
+		t.Errorf("expected androidPackage from irgo.json, got %q", id)
+	}
+
+	// The flag wins over everything
+	if id, _ := appIdentifier("android", "com.override"); id != "com.override" {
+		t.Errorf("expected --bundle-id value, got %q", id)
+	}
+
+	os.WriteFile(projectConfigFile, []byte(`{`), 0644)
+	if _, err := appIdentifier("ios", ""); err == nil {
+		t.Error("expected error for malformed irgo.json")
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	if got := flagValue([]string{"--dev", "--bundle-id", "com.a.b"}, "--bundle-id"); got != "com.a.b" {
+		t.Errorf("expected com.a.b, got %q", got)
+	}
+	if got := flagValue([]string{"--bundle-id=com.c.d"}, "--bundle-id"); got != "com.c.d" {
+		t.Errorf("expected com.c.d, got %q", got)
+	}
+	if got := flagValue([]string{"--dev"}, "--bundle-id"); got != "" {
+		t.Errorf("expected empty value, got %q", got)
+	}
+}
+
+func TestAndroidTarget(t *testing.T) {
+	tests := map[string]string{
+		"":                      "android",
+		"arm64":                 "android/arm64",
+		"arm64,amd64":           "android/arm64,android/amd64",
+		"arm64-v8a, x86_64":     "android/arm64,android/amd64",
+		"armeabi-v7a,arm,x86":   "android/arm,android/386",
+		"arm64,arm64-v8a,arm64": "android/arm64",
+	}
+	for abis, want := range tests {
+		got, err := androidTarget(abis)
+		if err != nil {
+			t.Errorf("androidTarget(%q): %v", abis, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("androidTarget(%q): expected %q, got %q", abis, want, got)
+		}
+	}
+
+	for _, abis := range []string{"mips", "arm64,", "arm64,riscv64"} {
+		if _, err := androidTarget(abis); err == nil {
+			t.Errorf("androidTarget(%q): expected error", abis)
+		}
+	}
+}
+
+func TestRunBuildRejectsUnknownABI(t *testing.T) {
+	// Validation happens before gomobile is looked up or run
+	err := runBuild("android", "arm64,sparc", "", false)
+	if err == nil || !strings.Contains(err.Error(), `"sparc"`) {
+		t.Errorf("expected unknown ABI error, got %v", err)
+	}
+}
+
+func TestSourceHash(t *testing.T) {
+	t.Chdir(t.TempDir())
+	write := func(path, content string) {
+		t.Helper()
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func(extra ...string) string {
+		t.Helper()
+		h, err := sourceHash(extra...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	write("go.mod", "module example.com/app\n")
+	write("main.go", "package main\n")
+	write("templates/page.templ", "templ Page() {}\n")
+	write("static/css/output.css", "body{}\n")
+	base := hash("ios")
+
+	// Outputs, native projects, hidden files and go.work don't count
+	write("build/ios/Irgo.xcframework/Info.plist", "x")
+	write("ios/Example/App.swift", "x")
+	write(".DS_Store", "x")
+	write("go.work", "go 1.24\n")
+	if got := hash("ios"); got != base {
+		t.Error("expected hash to ignore build outputs, native projects and go.work")
+	}
+
+	if hash("android") == base {
+		t.Error("expected build target to change the hash")
+	}
+
+	for _, path := range []string{"main.go", "templates/page.templ", "static/css/output.css", "go.mod"} {
+		write(path, "changed\n")
+		next := hash("ios")
+		if next == base {
+			t.Errorf("expected changing %s to change the hash", path)
+		}
+		base = next
+	}
+}
+
+func TestBuildIOSSkipsWhenUpToDate(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.WriteFile("go.mod", []byte("module example.com/app\n"), 0644)
+	os.WriteFile("main.go", []byte("package main\n"), 0644)
+
+	outPath := "build/ios/Irgo.xcframework"
+	if err := os.MkdirAll(outPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := sourceHash("ios", getGoVersion(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buildUpToDate(outPath, hash) {
+		t.Error("expected build without a recorded hash to be stale")
+	}
+	if err := writeBuildHash(outPath, hash); err != nil {
+		t.Fatal(err)
+	}
+	if !buildUpToDate(outPath, hash) {
+		t.Fatal("expected recorded hash to match")
+	}
+
+	// Up to date: returns before go.work setup or gomobile
+	if err := buildIOS("example.com/app", "", false); err != nil {
+		t.Fatalf("expected cached build to be skipped, got %v", err)
+	}
+	if _, err := os.Stat("go.work"); err == nil {
+		t.Error("expected mobile build setup to be skipped")
+	}
+
+	os.WriteFile("main.go", []byte("package main\n\nfunc main() {}\n"), 0644)
+	if next, _ := sourceHash("ios", getGoVersion(), ""); buildUpToDate(outPath, next) {
+		t.Error("expected edited sources to make the build stale")
+	}
+	os.RemoveAll(outPath)
+	if buildUpToDate(outPath, hash) {
+		t.Error("expected missing output to make the build stale")
+	}
+}
+
+// fakeGit simulates the x/mobile clone for ensureXMobile.
+type fakeGit struct {
+	remote  map[string]string // ref -> commit
+	head    string
+	fetched string
+	calls   []string
+}
+
+func withFakeGit(t *testing.T, remote map[string]string) *fakeGit {
+	t.Helper()
+	g := &fakeGit{remote: remote}
+	origRunner, origDir := gitRunner, xmobileDir
+	xmobileDir = filepath.Join(t.TempDir(), "golang-mobile")
+	gitRunner = func(dir string, args ...string) (string, error) {
+		g.calls = append(g.calls, strings.Join(args, " "))
+		switch args[0] {
+		case "init":
+			return "", os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+		case "fetch":
+			target := args[len(args)-1]
+			if _, ok := g.remote[target]; !ok {
+				return "", fmt.Errorf("couldn't find remote ref %s", target)
+			}
+			g.fetched = target
+		case "checkout":
+			g.head = g.remote[g.fetched]
+		case "rev-parse":
+			return g.head, nil
+		}
+		return "", nil
+	}
+	t.Cleanup(func() { gitRunner, xmobileDir = origRunner, origDir })
+	return g
+}
+
+func (g *fakeGit) fetches() int {
+	n := 0
+	for _, c := range g.calls {
+		if strings.HasPrefix(c, "fetch") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestEnsureXMobilePinsRef(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(xmobileRefEnv, "")
+	os.WriteFile(projectConfigFile, []byte(`{"bundleId": "com.acme.App"}`), 0644)
+	tip := strings.Repeat("a", 40)
+	g := withFakeGit(t, map[string]string{"HEAD": tip, tip: tip})
+
+	// Nothing pinned: fetch the default branch and pin its commit
+	changed, err := ensureXMobile()
+	if err != nil || !changed {
+		t.Fatalf("expected fresh checkout, got changed=%v err=%v", changed, err)
+	}
+	cfg, err := loadProjectConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.XMobileRef != tip || cfg.BundleID != "com.acme.App" {
+		t.Errorf("expected %s pinned alongside existing settings, got %+v", projectConfigFile, cfg)
+	}
+
+	// Pinned and checked out: reused without fetching
+	changed, err = ensureXMobile()
+	if err != nil || changed {
+		t.Errorf("expected clone to be reused, got changed=%v err=%v", changed, err)
+	}
+	if g.fetches() != 1 {
+		t.Errorf("expected a single fetch, got %v", g.calls)
+	}
+}
+
+func TestEnsureXMobileRefetchesStaleClone(t *testing.T) {
+	t.Chdir(t.TempDir())
+	g := withFakeGit(t, map[string]string{
+		"v0.1.0": strings.Repeat("1", 40),
+		"v0.2.0": strings.Repeat("2", 40),
+	})
+
+	t.Setenv(xmobileRefEnv, "v0.1.0")
+	if _, err := ensureXMobile(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A different ref must not reuse the existing clone
+	t.Setenv(xmobileRefEnv, "v0.2.0")
+	changed, err := ensureXMobile()
+	if err != nil || !changed {
+		t.Fatalf("expected refetch for new ref, got changed=%v err=%v", changed, err)
+	}
+	if g.fetched != "v0.2.0" || g.head != strings.Repeat("2", 40) {
+		t.Errorf("expected v0.2.0 checked out, got %s at %s", g.fetched, g.head)
+	}
+
+	// HEAD moved away from the pinned commit (e.g. manual checkout): refetch
+	g.head = strings.Repeat("f", 40)
+	if changed, _ := ensureXMobile(); !changed {
+		t.Error("expected refetch when HEAD doesn't match the pinned commit")
+	}
+	if _, err := os.Stat(projectConfigFile); err == nil {
+		t.Errorf("expected env ref not to be written to %s", projectConfigFile)
+	}
+}
+
+func TestEnsureXMobileUnknownRef(t *testing.T) {
+	t.Chdir(t.TempDir())
+	withFakeGit(t, map[string]string{})
+	t.Setenv(xmobileRefEnv, "v9.9.9")
+
+	if _, err := ensureXMobile(); err == nil || !strings.Contains(err.Error(), "v9.9.9") {
+		t.Errorf("expected fetch error naming the ref, got %v", err)
+	}
+}
+
+func readOverlay(t *testing.T, path string) map[string]string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var overlay struct{ Replace map[string]string }
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		t.Fatal(err)
+	}
+	return overlay.Replace
+}
+
+func TestStaticEmbedOverlay(t *testing.T) {
+	tests := []struct {
+		name    string
+		root    string
+		files   []string
+		pkgDir  string
+		embedAt string
+	}{
+		{"project root", ".", []string{"static/app.css"}, ".", "static"},
+		{"root with main package", "cmd/app", []string{"cmd/app/main.go", "cmd/app/static/app.css"}, "cmd/app", "static"},
+		{"root without main package", "web", []string{"web/static/app.css"}, ".", "web/static"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			withContentRoot(t, tt.root)
+			for _, f := range tt.files {
+				os.MkdirAll(filepath.Dir(f), 0755)
+				os.WriteFile(f, []byte("x"), 0644)
+			}
+
+			buildDir := t.TempDir()
+			replace := map[string]string{}
+			if err := staticEmbedOverlay(buildDir, replace); err != nil {
+				t.Fatalf("staticEmbedOverlay: %v", err)
+			}
+			overlayPath, err := writeOverlay(buildDir, replace)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pkgDir, _ := filepath.Abs(tt.pkgDir)
+			genPath := filepath.Join(buildDir, embedStaticFile)
+			replace = readOverlay(t, overlayPath)
+			if len(replace) != 1 || replace[filepath.Join(pkgDir, embedStaticFile)] != genPath {
+				t.Errorf("overlay = %v, want %s -> %s", replace, filepath.Join(pkgDir, embedStaticFile), genPath)
+			}
+			if _, err := os.Stat(filepath.Join(pkgDir, embedStaticFile)); err == nil {
+				t.Error("embed file written into the project")
+			}
+
+			src, err := os.ReadFile(genPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := parser.ParseFile(token.NewFileSet(), genPath, src, 0); err != nil {
+				t.Fatalf("generated file does not parse: %v", err)
+			}
+			for _, want := range []string{
+				"//go:build desktop",
+				"//go:embed " + tt.embedAt + "\n",
+				fmt.Sprintf("fs.Sub(irgoStaticFiles, %q)", tt.embedAt),
+				"desktop.SetEmbeddedStatic(sub)",
+			} {
+				if !strings.Contains(string(src), want) {
+					t.Errorf("generated file missing %q:\n%s", want, src)
+				}
+			}
+		})
+	}
+}
+
+func TestStaticEmbedOverlayErrors(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := staticEmbedOverlay(t.TempDir(), map[string]string{}); err == nil || !strings.Contains(err.Error(), "no static directory") {
+		t.Errorf("missing static/: err = %v", err)
+	}
+
+	// static/ outside the main package can't be reached by go:embed
+	os.MkdirAll("project", 0755)
+	os.MkdirAll("web/static", 0755)
+	t.Chdir("project")
+	withContentRoot(t, "../web")
+	if err := staticEmbedOverlay(t.TempDir(), map[string]string{}); err == nil || !strings.Contains(err.Error(), "inside the main package") {
+		t.Errorf("static/ outside package: err = %v", err)
+	}
+}
+
+func TestAppVersion(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if v, err := appVersion(); err != nil || v != defaultAppVersion {
+		t.Errorf("without irgo.json: got %q, %v", v, err)
+	}
+	os.WriteFile(projectConfigFile, []byte(`{"version": "2.1.0"}`), 0644)
+	v, err := appVersion()
+	if err != nil || v != "2.1.0" {
+		t.Fatalf("got %q, %v", v, err)
+	}
+	if plist := generateMacOSPlist("App", "com.acme.app", v, ""); !strings.Contains(plist, "<key>CFBundleShortVersionString</key>\n    <string>2.1.0</string>") {
+		t.Errorf("plist missing version:\n%s", plist)
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := map[string][4]uint32{
+		"1.0.0":      {1, 0, 0, 0},
+		"2.3.4.5":    {2, 3, 4, 5},
+		"1.2.3-beta": {1, 2, 3, 0},
+		"v1":         {0, 0, 0, 0},
+		"10":         {10, 0, 0, 0},
+	}
+	for in, want := range tests {
+		if got := parseVersion(in); got != want {
+			t.Errorf("parseVersion(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+// testIcon returns an .ico with two images of the given payload sizes.
+func testIcon(sizes ...int) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, [3]uint16{0, 1, uint16(len(sizes))})
+	offset := 6 + 16*len(sizes)
+	for i, size := range sizes {
+		buf.Write([]byte{byte(16 * (i + 1)), byte(16 * (i + 1)), 0, 0})
+		binary.Write(buf, binary.LittleEndian, [2]uint16{1, 32})
+		binary.Write(buf, binary.LittleEndian, [2]uint32{uint32(size), uint32(offset)})
+		offset += size
+	}
+	for i, size := range sizes {
+		buf.Write(bytes.Repeat([]byte{byte(0xa0 + i)}, size))
+	}
+	return buf.Bytes()
+}
+
+func TestWriteWindowsResources(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.WriteFile("icon.ico", testIcon(40, 68), 0644)
+
+	remove, err := writeWindowsResources("icon.ico", "demo", "2.3.4", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const sysoPath = "irgo_rsrc_windows_amd64.syso"
+	f, err := pe.Open(sysoPath)
+	if err != nil {
+		t.Fatalf("parsing .syso: %v", err)
+	}
+	defer f.Close()
+
+	if f.Machine != pe.IMAGE_FILE_MACHINE_AMD64 || len(f.Sections) != 1 || f.Sections[0].Name != ".rsrc" {
+		t.Fatalf("machine %#x, sections %v", f.Machine, f.Sections)
+	}
+	rsrc := f.Sections[0]
+	// One relocated data entry per resource: 2 icons, the group, the version
+	if len(rsrc.Relocs) != 4 {
+		t.Errorf("expected 4 relocations, got %d", len(rsrc.Relocs))
+	}
+	data, _ := rsrc.Data()
+	for name, want := range map[string][]byte{
+		"icon 1":          bytes.Repeat([]byte{0xa0}, 40),
+		"icon 2":          bytes.Repeat([]byte{0xa1}, 68),
+		"version info":    utf16z("VS_VERSION_INFO"),
+		"product version": utf16z("2.3.4"),
+		"file name":       utf16z("demo.exe"),
+	} {
+		if !bytes.Contains(data, want) {
+			t.Errorf("resources missing %s", name)
+		}
+	}
+
+	remove()
+	if _, err := os.Stat(sysoPath); !os.IsNotExist(err) {
+		t.Errorf("expected %s removed, got %v", sysoPath, err)
+	}
+}
+
+func TestWriteWindowsResourcesSkips(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	// No icon
+	remove, err := writeWindowsResources("icon.ico", "demo", "1.0.0", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remove()
+	if matches, _ := filepath.Glob("*.syso"); len(matches) != 0 {
+		t.Errorf("expected no .syso without an icon, got %v", matches)
+	}
+
+	// The project already has its own resources
+	os.WriteFile("icon.ico", testIcon(40), 0644)
+	os.WriteFile("rsrc.syso", []byte("mine"), 0644)
+	if _, err := writeWindowsResources("icon.ico", "demo", "1.0.0", "amd64"); err != nil {
+		t.Fatal(err)
+	}
+	if matches, _ := filepath.Glob("*.syso"); len(matches) != 1 {
+		t.Errorf("expected only the existing .syso, got %v", matches)
+	}
+
+	// A file that isn't an icon is an error
+	os.Remove("rsrc.syso")
+	os.WriteFile("icon.ico", []byte("not an icon"), 0644)
+	if _, err := writeWindowsResources("icon.ico", "demo", "1.0.0", "amd64"); err == nil {
+		t.Error("expected an error for an invalid icon")
+	}
+}
+
+func TestGenerateMacOSPlist(t *testing.T) {
+	for _, icon := range []string{"", "AppIcon.icns"} {
+		plist := generateMacOSPlist("Shop", "com.acme.shop", "1.2.0", icon)
+
+		dec := xml.NewDecoder(strings.NewReader(plist))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("icon=%q: invalid XML: %v\n%s", icon, err, plist)
+			}
+		}
+
+		for _, want := range []string{
+			"<key>CFBundleIdentifier</key>\n    <string>com.acme.shop</string>",
+			"<key>LSMinimumSystemVersion</key>\n    <string>" + macOSMinimumVersion + "</string>",
+			"<key>NSAllowsLocalNetworking</key>\n        <true/>",
+			"<key>127.0.0.1</key>",
+		} {
+			if !strings.Contains(plist, want) {
+				t.Errorf("icon=%q: plist missing %q", icon, want)
+			}
+		}
+		hasIcon := strings.Contains(plist, "<key>CFBundleIconFile</key>\n    <string>AppIcon.icns</string>")
+		if hasIcon != (icon != "") {
+			t.Errorf("icon=%q: CFBundleIconFile present = %v", icon, hasIcon)
+		}
+	}
+}
+
+func TestMacOSBundleID(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.WriteFile("go.mod", []byte("module github.com/acme/shop\n"), 0644)
+
+	// Derived as reverse DNS rather than the raw module path
+	if id, _ := appIdentifier("macos", ""); id != "com.github.acme.shop" {
+		t.Errorf("derived: got %q", id)
+	}
+	os.WriteFile(projectConfigFile, []byte(`{"bundleId": "com.acme.Shop"}`), 0644)
+	if id, _ := appIdentifier("macos", ""); id != "com.acme.Shop" {
+		t.Errorf("irgo.json: got %q", id)
+	}
+	if id, _ := appIdentifier("macos", "com.acme.desktop"); id != "com.acme.desktop" {
+		t.Errorf("flag: got %q", id)
+	}
+}
+
+func TestBuildCommandsTrimpath(t *testing.T) {
+	withContentRoot(t, ".")
+	t.Setenv("GOFLAGS", "-mod=mod")
+
+	desktop := desktopBuildArgs(goBuildFlags(""), "-o", "build/desktop/linux/app")
+	if !slices.Contains(desktop, "-trimpath") || slices.ContainsFunc(desktop, func(a string) bool { return strings.HasPrefix(a, "-buildvcs") }) {
+		t.Errorf("desktop build args = %v", desktop)
+	}
+	if got := desktopBuildArgs(goBuildFlags("false")); !slices.Contains(got, "-buildvcs=false") {
+		t.Errorf("expected -buildvcs=false, got %v", got)
+	}
+	if got := gomobileBindArgs("ios", "build/ios/Irgo.xcframework", "example.com/app/mobile"); !slices.Contains(got, "-trimpath") {
+		t.Errorf("gomobile bind args = %v", got)
+	}
+
+	// gomobile has no -buildvcs, so it goes through GOFLAGS
+	if env := gomobileEnv(""); slices.ContainsFunc(env, func(e string) bool { return strings.HasPrefix(e, "GOFLAGS=") }) {
+		t.Errorf("expected GOFLAGS left alone, got %v", env)
+	}
+	if env := gomobileEnv("false"); !slices.Contains(env, "GOFLAGS=-mod=mod -buildvcs=false") {
+		t.Errorf("expected -buildvcs added to GOFLAGS, got %v", env)
+	}
+
+	for _, v := range []string{"", "true", "false", "auto"} {
+		if err := checkBuildVCS(v); err != nil {
+			t.Errorf("checkBuildVCS(%q): %v", v, err)
+		}
+	}
+	if err := checkBuildVCS("--force"); err == nil {
+		t.Error("expected invalid --buildvcs value to be rejected")
+	}
+}
+
+func TestBuildManifest(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.WriteFile("go.sum", []byte(`github.com/go-chi/chi/v5 v5.2.4 h1:chi=
+github.com/go-chi/chi/v5 v5.2.4/go.mod h1:chimod=
+example.com/fork v1.0.1 h1:fork=
+`), 0644)
+
+	var calls []string
+	orig := goOutput
+	t.Cleanup(func() { goOutput = orig })
+	goOutput = func(args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if args[0] == "env" {
+			return []byte("go1.24.12\nlinux\namd64\n"), nil
+		}
+		// One object per package; stdlib packages have no module
+		return []byte(`{"Module": {"Path": "github.com/go-chi/chi/v5", "Version": "v5.2.4"}}
+{}
+{"Module": {"Path": "example.com/app", "Main": true}}
+{"Module": {"Path": "github.com/go-chi/chi/v5", "Version": "v5.2.4"}}
+{"Module": {"Path": "example.com/lib", "Version": "v1.0.0", "Replace": {"Path": "example.com/fork", "Version": "v1.0.1"}}}
+`), nil
+	}
+
+	command := desktopBuildArgs(goBuildFlags("false"), "-o", "app")
+	m, err := newBuildManifest("desktop/linux", ".", []string{"desktop"}, command, desktopEnv, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "list -deps -json=Module -tags desktop ."; !slices.Contains(calls, want) {
+		t.Errorf("expected %q in go calls %v", want, calls)
+	}
+	if err := writeBuildManifest(".", m); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(buildManifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	for field, want := range map[string]any{
+		"irgoVersion": version,
+		"target":      "desktop/linux",
+		"goVersion":   "go1.24.12",
+		"goos":        "linux",
+		"goarch":      "amd64",
+		"package":     ".",
+	} {
+		if got[field] != want {
+			t.Errorf("%s = %v, want %v", field, got[field], want)
+		}
+	}
+	if cmd := fmt.Sprint(got["command"]); !strings.Contains(cmd, "-trimpath") || !strings.Contains(cmd, "-buildvcs=false") {
+		t.Errorf("command = %s", cmd)
+	}
+	if env := fmt.Sprint(got["env"]); env != "[CGO_ENABLED=1]" {
+		t.Errorf("env = %s", env)
+	}
+
+	want := []manifestModule{
+		{Path: "example.com/app", Main: true},
+		{Path: "example.com/lib", Version: "v1.0.0", Sum: "h1:fork=", Replace: "example.com/fork v1.0.1"},
+		{Path: "github.com/go-chi/chi/v5", Version: "v5.2.4", Sum: "h1:chi="},
+	}
+	if !slices.Equal(m.Modules, want) {
+		t.Errorf("modules = %+v, want %+v", m.Modules, want)
+	}
+
+	// Mobile manifests leave out GOOS/GOARCH, which gomobile chooses
+	m, err = newBuildManifest("ios", "example.com/app/mobile", nil, nil, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.GOOS != "" || m.GOARCH != "" {
+		t.Errorf("expected no GOOS/GOARCH for mobile, got %s/%s", m.GOOS, m.GOARCH)
+	}
+}
+
+// withCSSProject creates static/css/input.css in a temp project, and
+// output.css too if built is set.
+func withCSSProject(t *testing.T, built bool) string {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	withContentRoot(t, ".")
+	if err := os.MkdirAll(filepath.Join("static", "css"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("static", "css", "input.css"), []byte(`@import "tailwindcss";`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if built {
+		if err := os.WriteFile(filepath.Join("static", "css", "output.css"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join("static", "css")
+}
+
+func TestBuildCSSTailwindCommand(t *testing.T) {
+	css := withCSSProject(t, true)
+	args := " -i " + filepath.Join(css, "input.css") + " -o " + filepath.Join(css, "output.css") + " --minify"
+
+	tests := []struct {
+		name  string
+		tools []string
+		local bool
+		want  string
+	}{
+		{"node_modules", []string{"tailwindcss", "bunx"}, true, filepath.Join("node_modules", ".bin", "tailwindcss")},
+		{"path", []string{"tailwindcss", "bunx"}, false, "tailwindcss"},
+		{"bunx", []string{"bunx", "npx"}, false, "bunx @tailwindcss/cli"},
+		{"npx", []string{"npx"}, false, "npx @tailwindcss/cli"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withFakeToolchain(t, nil, tt.tools...)
+			os.RemoveAll("node_modules")
+			if tt.local {
+				bin := filepath.Join("node_modules", ".bin")
+				os.MkdirAll(bin, 0755)
+				os.WriteFile(filepath.Join(bin, "tailwindcss"), nil, 0755)
+			}
+			f := withFakeRunner(t)
+
+			if err := buildCSS(""); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(f.calls) != 1 || f.calls[0] != tt.want+args {
+				t.Errorf("expected %q, got %v", tt.want+args, f.calls)
+			}
+		})
+	}
+
+	// A tailwind.config.js marks a Tailwind v3 project
+	t.Run("v3", func(t *testing.T) {
+		withFakeToolchain(t, nil, "npx")
+		os.RemoveAll("node_modules")
+		os.WriteFile("tailwind.config.js", nil, 0644)
+		f := withFakeRunner(t)
+
+		if err := buildCSS(""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := "npx tailwindcss@3" + args; len(f.calls) != 1 || f.calls[0] != want {
+			t.Errorf("expected %q, got %v", want, f.calls)
+		}
+	})
+}
+
+func TestBuildCSSSkip(t *testing.T) {
+	withCSSProject(t, false)
+	withFakeToolchain(t, nil, "tailwindcss")
+	f := withFakeRunner(t)
+
+	if err := buildCSS("none"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	os.WriteFile(projectConfigFile, []byte(`{"css": "none"}`), 0644)
+	if err := buildCSS(""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(f.calls) != 0 {
+		t.Errorf("expected no commands, got %v", f.calls)
+	}
+
+	// The flag overrides irgo.json
+	if err := buildCSS("tailwind"); err == nil || !strings.Contains(err.Error(), "did not write") {
+		t.Errorf("expected missing output error, got %v", err)
+	}
+	if err := buildCSS("sass"); err == nil {
+		t.Error("expected error for unknown css setting")
+	}
+}
+
+func TestBuildCSSWithoutTailwind(t *testing.T) {
+	t.Chdir(t.TempDir())
+	withContentRoot(t, ".")
+	withFakeToolchain(t, nil)
+	f := withFakeRunner(t)
+
+	// No input.css: nothing to build unless Tailwind was asked for
+	if err := buildCSS(""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := buildCSS("tailwind"); err == nil {
+		t.Error("expected error for missing input.css")
+	}
+
+	withCSSProject(t, true)
+	withFakeToolchain(t, nil)
+	if err := buildCSS(""); err == nil || !strings.Contains(err.Error(), "--css none") {
+		t.Errorf("expected missing Tailwind CLI error, got %v", err)
+	}
+	if len(f.calls) != 0 {
+		t.Errorf("expected no commands, got %v", f.calls)
+	}
+}
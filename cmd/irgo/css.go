@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CSS settings for --css and "css" in irgo.json. The default builds with
+// Tailwind if the project has static/css/input.css.
+const (
+	cssTailwind = "tailwind"
+	cssNone     = "none"
+)
+
+// buildCSS compiles static/css/input.css to output.css with Tailwind, so a
+// build never ships stale CSS. mode is the --css flag, falling back to
+// irgo.json; "none" skips it, for projects that build their CSS some other
+// way.
+func buildCSS(mode string) error {
+	if mode == "" {
+		cfg, err := loadProjectConfig()
+		if err != nil {
+			return err
+		}
+		mode = cfg.CSS
+	}
+	switch mode {
+	case "", cssTailwind:
+	case cssNone:
+		return nil
+	default:
+		return fmt.Errorf("invalid css setting %q (use tailwind or none)", mode)
+	}
+
+	input := rootPath("static", "css", "input.css")
+	if _, err := os.Stat(input); err != nil {
+		if mode == cssTailwind {
+			return fmt.Errorf("css is set to tailwind but %s is missing", input)
+		}
+		// Plain CSS, as in the minimal template
+		return nil
+	}
+	output := rootPath("static", "css", "output.css")
+
+	tailwind, err := tailwindCommand()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Building CSS...")
+	args := append(tailwind[1:], "-i", input, "-o", output, "--minify")
+	if err := commandRunner(tailwind[0], args...); err != nil {
+		return fmt.Errorf("tailwind build failed: %w (skip it with --css none)", err)
+	}
+	if _, err := os.Stat(output); err != nil {
+		return fmt.Errorf("tailwind build did not write %s", output)
+	}
+	return nil
+}
+
+// tailwindCommand finds the Tailwind CLI: installed in node_modules, on
+// PATH, or fetched by bunx or npx. Projects with a tailwind.config.js are
+// on Tailwind v3, so they fetch tailwindcss@3 rather than the v4 CLI.
+func tailwindCommand() ([]string, error) {
+	local := filepath.Join("node_modules", ".bin", "tailwindcss")
+	if _, err := os.Stat(local); err == nil {
+		return []string{local}, nil
+	}
+	if _, err := lookPath("tailwindcss"); err == nil {
+		return []string{"tailwindcss"}, nil
+	}
+	cli := "@tailwindcss/cli"
+	if _, err := os.Stat("tailwind.config.js"); err == nil {
+		cli = "tailwindcss@3"
+	}
+	for _, runner := range []string{"bunx", "npx"} {
+		if _, err := lookPath(runner); err == nil {
+			return []string{runner, cli}, nil
+		}
+	}
+	return nil, errors.New("static/css/input.css needs the Tailwind CLI: run `bun install` or `npm install`, or skip the CSS build with --css none")
+}
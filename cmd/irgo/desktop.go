@@ -12,7 +12,7 @@ import (
 func runDesktop(devMode bool) error {
 	fmt.Println("Starting desktop app...")
 
-	args := []string{"run", "-tags", "desktop", "."}
+	args := []string{"run", "-tags", "desktop", mainPackage()}
 	if devMode {
 		args = append(args, "--dev")
 	}
@@ -25,11 +25,17 @@ func runDesktop(devMode bool) error {
 	return cmd.Run()
 }
 
-// buildDesktop builds desktop app for target platform
-func buildDesktop(target string) error {
+// buildDesktop builds desktop app for target platform. With embed, static/
+// is compiled into the binary instead of being copied next to it.
+// bundleFlag overrides the macOS bundle identifier; buildvcs is passed to go
+// build as -buildvcs if set.
+func buildDesktop(target string, embed bool, bundleFlag, buildvcs string) error {
 	if target == "" {
 		target = runtime.GOOS
 	}
+	if err := checkBuildVCS(buildvcs); err != nil {
+		return err
+	}
 
 	fmt.Printf("Building desktop app for %s...\n", target)
 
@@ -43,19 +49,119 @@ func buildDesktop(target string) error {
 		return fmt.Errorf("could not determine module path: %w", err)
 	}
 
+	switch target {
+	case "darwin", "macos", "windows", "linux":
+	default:
+		return fmt.Errorf("unsupported desktop platform: %s (use darwin, windows, or linux)", target)
+	}
+
+	// The embed file is generated outside the project and added to the
+	// build with -overlay, so the user's source is left untouched
+	flags := goBuildFlags(buildvcs)
+	if embed {
+		buildDir, err := os.MkdirTemp("", "irgo-embed-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(buildDir)
+		replace := map[string]string{}
+		if err := staticEmbedOverlay(buildDir, replace); err != nil {
+			return err
+		}
+		overlay, err := writeOverlay(buildDir, replace)
+		if err != nil {
+			return err
+		}
+		flags = append(flags, "-overlay", overlay)
+	}
+
+	version, err := appVersion()
+	if err != nil {
+		return err
+	}
+	if target == "windows" {
+		remove, err := writeWindowsResources(rootPath(windowsIconFile), filepath.Base(modulePath), version, targetGOARCH())
+		if err != nil {
+			return fmt.Errorf("generating Windows resources: %w", err)
+		}
+		defer remove()
+	}
+
 	switch target {
 	case "darwin", "macos":
-		return buildDesktopMacOS(modulePath)
+		bundleID, err := appIdentifier("macos", bundleFlag)
+		if err != nil {
+			return err
+		}
+		return buildDesktopMacOS(modulePath, bundleID, version, flags, embed)
 	case "windows":
-		return buildDesktopWindows(modulePath)
-	case "linux":
-		return buildDesktopLinux(modulePath)
+		return buildDesktopWindows(modulePath, flags, embed)
 	default:
-		return fmt.Errorf("unsupported desktop platform: %s (use darwin, windows, or linux)", target)
+		return buildDesktopLinux(modulePath, flags, embed)
+	}
+}
+
+const (
+	// windowsIconFile is the application icon for Windows builds, in the
+	// content root.
+	windowsIconFile = "icon.ico"
+
+	// macOSIconFile is the application icon for macOS builds, in the
+	// content root. It's copied into the bundle's Resources.
+	macOSIconFile = "AppIcon.icns"
+
+	// macOSMinimumVersion is the oldest macOS the app bundle declares
+	// support for (LSMinimumSystemVersion).
+	macOSMinimumVersion = "10.13"
+)
+
+// targetGOARCH returns the architecture go build will target.
+func targetGOARCH() string {
+	if goarch := os.Getenv("GOARCH"); goarch != "" {
+		return goarch
+	}
+	return runtime.GOARCH
+}
+
+// desktopBuildArgs returns the go build arguments for the main package:
+// flags common to every platform (goBuildFlags and -overlay for generated
+// files), then the platform's args.
+func desktopBuildArgs(flags []string, args ...string) []string {
+	buildArgs := append([]string{"build", "-tags", "desktop"}, flags...)
+	buildArgs = append(buildArgs, args...)
+	return append(buildArgs, mainPackage())
+}
+
+// desktopEnv is added to the environment of desktop builds; webview needs cgo.
+var desktopEnv = []string{"CGO_ENABLED=1"}
+
+// runDesktopBuild runs go build with args and records the build manifest
+// for platform in outDir.
+func runDesktopBuild(platform, outDir string, args []string) error {
+	cmd := exec.Command("go", args...)
+	cmd.Env = append(os.Environ(), desktopEnv...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go build failed: %w", err)
 	}
+	recordBuild(outDir, "desktop/"+platform, mainPackage(), []string{"desktop"}, append([]string{"go"}, args...), desktopEnv, true)
+	return nil
 }
 
-func buildDesktopMacOS(modulePath string) error {
+// copyStatic copies static/ to dst unless it was embedded in the binary.
+func copyStatic(dst string, embed bool) {
+	if embed {
+		return
+	}
+	if _, err := os.Stat(rootPath("static")); err == nil {
+		if err := copyDir(rootPath("static"), dst); err != nil {
+			fmt.Printf("Warning: could not copy static assets: %v\n", err)
+		}
+	}
+}
+
+func buildDesktopMacOS(modulePath, bundleID, version string, flags []string, embed bool) error {
 	appName := filepath.Base(modulePath)
 	outDir := "build/desktop/macos"
 	appBundle := filepath.Join(outDir, appName+".app")
@@ -70,23 +176,26 @@ func buildDesktopMacOS(modulePath string) error {
 
 	// Build the binary with CGO enabled (required for webview)
 	binaryPath := filepath.Join(appBundle, "Contents", "MacOS", appName)
-	cmd := exec.Command("go", "build", "-tags", "desktop", "-o", binaryPath, ".")
-	cmd.Env = append(os.Environ(), "CGO_ENABLED=1")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("go build failed: %w", err)
+	if err := runDesktopBuild("macos", outDir, desktopBuildArgs(flags, "-o", binaryPath)); err != nil {
+		return err
 	}
 
 	// Copy static assets to Resources
-	if _, err := os.Stat("static"); err == nil {
-		if err := copyDir("static", filepath.Join(appBundle, "Contents", "Resources", "static")); err != nil {
-			fmt.Printf("Warning: could not copy static assets: %v\n", err)
+	copyStatic(filepath.Join(appBundle, "Contents", "Resources", "static"), embed)
+
+	// Copy the app icon to Resources
+	iconFile := ""
+	if _, err := os.Stat(rootPath(macOSIconFile)); err == nil {
+		if err := copyFile(rootPath(macOSIconFile), filepath.Join(appBundle, "Contents", "Resources", macOSIconFile)); err != nil {
+			return fmt.Errorf("could not copy %s: %w", macOSIconFile, err)
 		}
+		iconFile = macOSIconFile
+	} else {
+		fmt.Printf("No %s found; the app will use the default icon\n", rootPath(macOSIconFile))
 	}
 
 	// Generate Info.plist
-	plistContent := generateMacOSPlist(appName, modulePath)
+	plistContent := generateMacOSPlist(appName, bundleID, version, iconFile)
 	plistPath := filepath.Join(appBundle, "Contents", "Info.plist")
 	if err := os.WriteFile(plistPath, []byte(plistContent), 0644); err != nil {
 		return fmt.Errorf("could not write Info.plist: %w", err)
@@ -96,7 +205,7 @@ func buildDesktopMacOS(modulePath string) error {
 	return nil
 }
 
-func buildDesktopWindows(modulePath string) error {
+func buildDesktopWindows(modulePath string, flags []string, embed bool) error {
 	appName := filepath.Base(modulePath)
 	outDir := "build/desktop/windows"
 
@@ -105,31 +214,22 @@ func buildDesktopWindows(modulePath string) error {
 	}
 
 	binaryPath := filepath.Join(outDir, appName+".exe")
-	cmd := exec.Command("go", "build",
-		"-tags", "desktop",
+	args := desktopBuildArgs(flags,
 		"-ldflags", "-H windowsgui", // Hide console window
 		"-o", binaryPath,
-		".",
 	)
-	cmd.Env = append(os.Environ(), "CGO_ENABLED=1")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("go build failed: %w", err)
+	if err := runDesktopBuild("windows", outDir, args); err != nil {
+		return err
 	}
 
 	// Copy static assets
-	if _, err := os.Stat("static"); err == nil {
-		if err := copyDir("static", filepath.Join(outDir, "static")); err != nil {
-			fmt.Printf("Warning: could not copy static assets: %v\n", err)
-		}
-	}
+	copyStatic(filepath.Join(outDir, "static"), embed)
 
 	fmt.Printf("Windows app built: %s\n", binaryPath)
 	return nil
 }
 
-func buildDesktopLinux(modulePath string) error {
+func buildDesktopLinux(modulePath string, flags []string, embed bool) error {
 	appName := filepath.Base(modulePath)
 	outDir := "build/desktop/linux"
 
@@ -138,30 +238,29 @@ func buildDesktopLinux(modulePath string) error {
 	}
 
 	binaryPath := filepath.Join(outDir, appName)
-	cmd := exec.Command("go", "build",
-		"-tags", "desktop",
-		"-o", binaryPath,
-		".",
-	)
-	cmd.Env = append(os.Environ(), "CGO_ENABLED=1")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("go build failed: %w", err)
+	if err := runDesktopBuild("linux", outDir, desktopBuildArgs(flags, "-o", binaryPath)); err != nil {
+		return err
 	}
 
 	// Copy static assets
-	if _, err := os.Stat("static"); err == nil {
-		if err := copyDir("static", filepath.Join(outDir, "static")); err != nil {
-			fmt.Printf("Warning: could not copy static assets: %v\n", err)
-		}
-	}
+	copyStatic(filepath.Join(outDir, "static"), embed)
 
 	fmt.Printf("Linux app built: %s\n", binaryPath)
 	return nil
 }
 
-func generateMacOSPlist(appName, bundleID string) string {
+// generateMacOSPlist returns the app bundle's Info.plist. version comes from
+// appVersion, as for the Windows version resource; iconFile is the icon in
+// Contents/Resources, or "" for none.
+// App Transport Security allows plain HTTP to localhost only, which the
+// loopback transport needs.
+func generateMacOSPlist(appName, bundleID, version, iconFile string) string {
+	icon := ""
+	if iconFile != "" {
+		icon = fmt.Sprintf(`
+    <key>CFBundleIconFile</key>
+    <string>%s</string>`, iconFile)
+	}
 	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
 <plist version="1.0">
@@ -171,17 +270,37 @@ func generateMacOSPlist(appName, bundleID string) string {
     <key>CFBundleIdentifier</key>
     <string>%s</string>
     <key>CFBundleName</key>
-    <string>%s</string>
+    <string>%s</string>%s
     <key>CFBundleVersion</key>
-    <string>1.0.0</string>
+    <string>%s</string>
     <key>CFBundleShortVersionString</key>
-    <string>1.0.0</string>
+    <string>%s</string>
     <key>CFBundlePackageType</key>
     <string>APPL</string>
+    <key>LSMinimumSystemVersion</key>
+    <string>%s</string>
     <key>NSHighResolutionCapable</key>
     <true/>
+    <key>NSAppTransportSecurity</key>
+    <dict>
+        <key>NSAllowsLocalNetworking</key>
+        <true/>
+        <key>NSExceptionDomains</key>
+        <dict>
+            <key>localhost</key>
+            <dict>
+                <key>NSExceptionAllowsInsecureHTTPLoads</key>
+                <true/>
+            </dict>
+            <key>127.0.0.1</key>
+            <dict>
+                <key>NSExceptionAllowsInsecureHTTPLoads</key>
+                <true/>
+            </dict>
+        </dict>
+    </dict>
 </dict>
-</plist>`, appName, bundleID, appName)
+</plist>`, appName, bundleID, appName, icon, version, version, macOSMinimumVersion)
 }
 
 func copyDir(src, dst string) error {
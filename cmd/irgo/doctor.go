@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// doctorTool is a prerequisite checked by `irgo doctor`.
+type doctorTool struct {
+	name     string
+	binaries []string // any one of these satisfies the check
+	required []string // platforms needing it ("" for every platform)
+}
+
+// doctorTools lists prerequisites in the order they're reported.
+// Install hints come from installHints, keyed by name.
+var doctorTools = []doctorTool{
+	{name: "go", binaries: []string{"go"}, required: []string{""}},
+	{name: "templ", binaries: []string{"templ"}, required: []string{""}},
+	{name: "air", binaries: []string{"air"}},
+	{name: "gomobile", binaries: []string{"gomobile"}, required: []string{"ios", "android"}},
+	{name: "xcodebuild", binaries: []string{"xcodebuild"}, required: []string{"ios"}},
+	{name: "adb", binaries: []string{"adb"}, required: []string{"android"}},
+	{name: "cc", binaries: []string{"cc", "gcc", "clang"}, required: []string{"desktop"}},
+}
+
+// doctorPlatforms are the accepted `irgo doctor` arguments.
+var doctorPlatforms = []string{"ios", "android", "desktop"}
+
+// lookPath finds executables; replaced in tests
+var lookPath = exec.LookPath
+
+// desktopLinkCheck reports whether a cgo desktop program links; replaced in tests
+var desktopLinkCheck = checkDesktopLink
+
+// requiredFor reports whether t is required when targeting platform.
+func (t doctorTool) requiredFor(platform string) bool {
+	return slices.Contains(t.required, "") || (platform != "" && slices.Contains(t.required, platform))
+}
+
+// find returns the first of t's binaries on PATH, or "".
+func (t doctorTool) find() string {
+	if t.name == "cc" {
+		if cc := os.Getenv("CC"); cc != "" {
+			if path, err := lookPath(strings.Fields(cc)[0]); err == nil {
+				return path
+			}
+		}
+	}
+	for _, bin := range t.binaries {
+		if path, err := lookPath(bin); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// runDoctor checks the toolchain and reports each prerequisite to w.
+// platform ("", ios, android or desktop) selects which tools are required;
+// an error is returned if any of them are missing.
+func runDoctor(w io.Writer, platform string) error {
+	if platform != "" && !slices.Contains(doctorPlatforms, platform) {
+		return fmt.Errorf("unknown platform: %s (use %s)", platform, strings.Join(doctorPlatforms, ", "))
+	}
+
+	fmt.Fprintln(w, "Checking irgo toolchain...")
+	fmt.Fprintln(w)
+
+	var missing []string
+	for _, tool := range doctorTools {
+		if path := tool.find(); path != "" {
+			fmt.Fprintf(w, "  %-11s found (%s)\n", tool.name, path)
+			continue
+		}
+
+		note := "optional"
+		if tool.requiredFor(platform) {
+			note = "REQUIRED"
+			missing = append(missing, tool.name)
+		} else if len(tool.required) > 0 {
+			note = "needed for " + strings.Join(tool.required, ", ")
+		}
+		fmt.Fprintf(w, "  %-11s missing (%s)\n", tool.name, note)
+		fmt.Fprintf(w, "  %-11s %s\n", "", installHints[tool.name])
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  Go version: %s\n", getGoVersion())
+	if _, err := os.Stat("go.work"); err == nil {
+		fmt.Fprintln(w, "  go.work:    present")
+	} else {
+		fmt.Fprintln(w, "  go.work:    not found (created by irgo build ios/android)")
+	}
+
+	// Linking needs a C compiler, so only try it when one exists
+	if doctorToolNamed("cc").find() == "" {
+		fmt.Fprintln(w, "  cgo:        skipped (no C compiler)")
+	} else if desc, err := desktopLinkCheck(); err != nil {
+		fmt.Fprintf(w, "  cgo:        %s failed to link\n", desc)
+		fmt.Fprintf(w, "              %s\n", strings.ReplaceAll(strings.TrimSpace(err.Error()), "\n", "\n              "))
+		if platform == "desktop" {
+			missing = append(missing, "cgo")
+		}
+	} else {
+		fmt.Fprintf(w, "  cgo:        %s links\n", desc)
+	}
+
+	fmt.Fprintln(w)
+	if len(missing) > 0 {
+		target := "irgo"
+		if platform != "" {
+			target = platform + " builds"
+		}
+		return fmt.Errorf("missing prerequisites for %s: %s", target, strings.Join(missing, ", "))
+	}
+	fmt.Fprintln(w, "All required tools found.")
+	return nil
+}
+
+func doctorToolNamed(name string) doctorTool {
+	for _, tool := range doctorTools {
+		if tool.name == name {
+			return tool
+		}
+	}
+	return doctorTool{name: name, binaries: []string{name}}
+}
+
+// webviewProbe is the smallest program that links the webview library.
+const webviewProbe = `package main
+
+import webview "github.com/webview/webview_go"
+
+func main() { webview.New(false).Destroy() }
+`
+
+// cgoProbe is used outside projects that depend on webview.
+const cgoProbe = `package main
+
+// int answer(void) { return 42; }
+import "C"
+
+func main() { _ = C.answer() }
+`
+
+// checkDesktopLink builds a throwaway cgo program with CGO_ENABLED=1.
+// Inside a project that requires webview_go it links webview itself;
+// otherwise it only checks that cgo can link. Returns what was built.
+func checkDesktopLink() (string, error) {
+	gomod, _ := os.ReadFile("go.mod")
+	if strings.Contains(string(gomod), "github.com/webview/webview_go") {
+		// Build within the project so its go.mod resolves webview
+		dir, err := os.MkdirTemp(".", ".irgo-doctor-")
+		if err != nil {
+			return "webview program", err
+		}
+		defer os.RemoveAll(dir)
+		return "webview program", buildProbe(dir, "./"+filepath.Base(dir), webviewProbe)
+	}
+
+	dir, err := os.MkdirTemp("", "irgo-doctor-")
+	if err != nil {
+		return "cgo program", err
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module probe\n"), 0644); err != nil {
+		return "cgo program", err
+	}
+	return "cgo program", buildProbe(dir, ".", cgoProbe)
+}
+
+// buildProbe writes src to dir/main.go and builds pkg without keeping output.
+func buildProbe(dir, pkg, src string) error {
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644); err != nil {
+		return err
+	}
+
+	out := filepath.Join(dir, "probe")
+	cmd := exec.Command("go", "build", "-o", out, pkg)
+	if pkg == "." {
+		cmd.Dir = dir
+	}
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=1")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
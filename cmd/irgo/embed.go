@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// embedStaticFile is the name the generated embed file appears under in the
+// main package. It only exists in the build overlay, never on disk there.
+const embedStaticFile = "irgo_embed_static.go"
+
+// embedStaticSource registers static/ with desktop.SetEmbeddedStatic so
+// desktop.StaticFiles serves it when there's no static dir on disk.
+const embedStaticSource = `// Code generated by irgo build desktop --embed. DO NOT EDIT.
+
+//go:build desktop
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/stukennedy/irgo/desktop"
+)
+
+//go:embed %s
+var irgoStaticFiles embed.FS
+
+func init() {
+	sub, err := fs.Sub(irgoStaticFiles, %q)
+	if err != nil {
+		panic(err)
+	}
+	desktop.SetEmbeddedStatic(sub)
+}
+`
+
+// staticEmbedOverlay generates the embed file in buildDir and adds it to
+// overlay, the `go build -overlay` replacements for the main package.
+// static/ must be inside the main package's directory, as go:embed can't
+// reach outside it.
+func staticEmbedOverlay(buildDir string, overlay map[string]string) error {
+	pkgDir, err := filepath.Abs(mainPackage())
+	if err != nil {
+		return err
+	}
+	staticDir, err := filepath.Abs(rootPath("static"))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(staticDir); err != nil {
+		return fmt.Errorf("--embed: no static directory at %s", rootPath("static"))
+	}
+
+	rel, err := filepath.Rel(pkgDir, staticDir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("--embed: %s must be inside the main package directory (%s)", rootPath("static"), mainPackage())
+	}
+	rel = filepath.ToSlash(rel)
+
+	genPath := filepath.Join(buildDir, embedStaticFile)
+	if err := os.WriteFile(genPath, []byte(fmt.Sprintf(embedStaticSource, rel, rel)), 0644); err != nil {
+		return err
+	}
+	overlay[filepath.Join(pkgDir, embedStaticFile)] = genPath
+	return nil
+}
+
+// writeOverlay writes overlay as a `go build -overlay` file in buildDir and
+// returns its path, or "" if there's nothing to replace.
+func writeOverlay(buildDir string, overlay map[string]string) (string, error) {
+	if len(overlay) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(map[string]map[string]string{"Replace": overlay})
+	if err != nil {
+		return "", err
+	}
+	overlayPath := filepath.Join(buildDir, "overlay.json")
+	if err := os.WriteFile(overlayPath, data, 0644); err != nil {
+		return "", err
+	}
+	return overlayPath, nil
+}
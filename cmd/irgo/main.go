@@ -4,11 +4,16 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 var version = "0.3.1"
 
 func main() {
+	root, args := extractRootFlag(os.Args[1:])
+	setContentRoot(root)
+	os.Args = append(os.Args[:1], args...)
+
 	if len(os.Args) < 2 {
 		printUsage()
 		os.Exit(1)
@@ -17,37 +22,46 @@ func main() {
 	var err error
 	switch os.Args[1] {
 	case "new":
-		if len(os.Args) < 3 {
-			fmt.Println("Usage: irgo new <project-name>")
+		name, opts, perr := parseNewArgs(os.Args[2:])
+		if perr != nil {
+			fmt.Println(perr)
+			fmt.Println("Usage: irgo new <project-name> [--template <name>] [--with-db sqlite] [--tailwind 3|4] [--offline]")
 			os.Exit(1)
 		}
-		err = newProject(os.Args[2])
+		err = newProject(name, opts)
 
 	case "dev":
+		if err = buildCSS(flagValue(os.Args[2:], "--css")); err != nil {
+			// air rebuilds the CSS on change, so this isn't fatal
+			fmt.Printf("Warning: %v\n", err)
+		}
 		err = runDev()
 
 	case "serve":
-		err = runServe()
+		err = runServe(os.Args[2:])
 
 	case "build":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: irgo build <ios|android|desktop|all>")
+			fmt.Println("Usage: irgo build <ios|android|desktop|all> [--abi <list>] [--force] [--embed] [--bundle-id <id>] [--buildvcs <bool>] [--css none]")
 			os.Exit(1)
 		}
 		target := os.Args[2]
+		if err = buildCSS(flagValue(os.Args[3:], "--css")); err != nil {
+			break
+		}
 		if target == "desktop" {
 			platform := ""
-			if len(os.Args) > 3 {
+			if len(os.Args) > 3 && !strings.HasPrefix(os.Args[3], "-") {
 				platform = os.Args[3]
 			}
-			err = buildDesktop(platform)
+			err = buildDesktop(platform, hasFlag(os.Args[3:], "--embed"), flagValue(os.Args[3:], "--bundle-id"), flagValue(os.Args[3:], "--buildvcs"))
 		} else {
-			err = runBuild(target)
+			err = runBuild(target, flagValue(os.Args[3:], "--abi"), flagValue(os.Args[3:], "--buildvcs"), hasFlag(os.Args[3:], "--force"))
 		}
 
 	case "run":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: irgo run <ios|android|desktop> [--dev]")
+			fmt.Println("Usage: irgo run <ios|android|desktop> [--dev] [--bundle-id <id>] [--force]")
 			os.Exit(1)
 		}
 		platform := os.Args[2]
@@ -56,18 +70,28 @@ func main() {
 		if platform == "desktop" {
 			err = runDesktop(devMode)
 		} else {
-			err = runMobile(platform, devMode)
+			err = runMobile(platform, devMode, flagValue(os.Args[3:], "--bundle-id"), hasFlag(os.Args[3:], "--force"))
 		}
 
 	case "templ":
 		err = runTempl()
 
 	case "test":
-		err = runTest()
+		err = runTest(os.Args[2:])
 
 	case "install-tools":
 		err = installTools()
 
+	case "bindings":
+		err = runBindings(os.Args[2:])
+
+	case "doctor":
+		platform := ""
+		if len(os.Args) > 2 {
+			platform = os.Args[2]
+		}
+		err = runDoctor(os.Stdout, platform)
+
 	case "version", "-v", "--version":
 		fmt.Printf("irgo %s\n", version)
 
@@ -94,7 +118,7 @@ func printUsage() {
 	fmt.Println(`irgo - Hypermedia framework for mobile and desktop apps
 
 Usage:
-  irgo <command> [arguments]
+  irgo <command> [arguments] [--root <dir>]
 
 Commands:
   new <name>       Create a new irgo project
@@ -103,11 +127,17 @@ Commands:
   build <target>   Build for mobile/desktop (ios, android, desktop, or all)
   run <platform>   Build and run on simulator or desktop
   templ            Generate templ files
-  test             Run tests
+  test             Run tests with race detector and coverage
   install-tools    Install required dev tools (gomobile, templ, air)
+  bindings         Print the mobile package API for Swift/Kotlin integration
+  doctor [target]  Check required tools (optionally for ios, android, or desktop)
   version          Print version information
   help [command]   Show help for a command
 
+Global flags:
+  --root <dir>     Content root containing static/, templates/ and handlers/
+                   (default: current directory, or $IRGO_ROOT)
+
 Examples:
   irgo new myapp         Create a new project
   irgo dev               Start dev server with hot reload
@@ -129,6 +159,17 @@ Usage:
   irgo new <project-name>
   irgo new .              Initialize in current directory
 
+Options:
+  --template <name>       Project template (default, chat, minimal)
+  --with-db sqlite        Add a db/ package with SQLite and migrations
+  --tailwind <3|4>        Tailwind CSS version (default: 4)
+  --offline               Use the bundled Datastar instead of downloading it
+
+Templates:
+  default                 Interactive Datastar demo with Tailwind
+  chat                    Real-time chat room streamed over SSE
+  minimal                 Single page, plain CSS, no Node tooling
+
 Creates a new project with:
   - main.go           App entry point
   - handlers/         Route handlers
@@ -141,14 +182,16 @@ Creates a new project with:
 		fmt.Println(`irgo dev - Run development server with hot reload
 
 Usage:
-  irgo dev
+  irgo dev [--css none]
 
-Starts:
+Builds static/css/output.css with Tailwind first (see irgo help build), then
+starts:
   - Air for Go hot reloading
   - Templ file watcher
   - Tailwind CSS watcher (if configured)
 
-Server runs at http://localhost:8080`)
+Server runs at http://localhost:8080, on 127.0.0.1 only (irgo serve --host
+0.0.0.0 serves the network, e.g. for real devices)`)
 
 	case "build":
 		fmt.Println(`irgo build - Build for mobile and desktop platforms
@@ -160,8 +203,36 @@ Usage:
   irgo build desktop macos   Build desktop app for macOS
   irgo build desktop windows Build desktop app for Windows
   irgo build desktop linux   Build desktop app for Linux
+  irgo build desktop --embed Embed static/ in the binary (single executable)
   irgo build all             Build all mobile platforms
 
+Options:
+  --abi <list>  Android architectures to build, comma-separated
+                (arm, arm64, 386, amd64 or armeabi-v7a, arm64-v8a, x86, x86_64).
+                Defaults to all of them.
+  --force       Rebuild even if no source files changed since the last build
+  --embed       Desktop only: compile static/ into the executable instead of
+                copying it next to it. A static/ dir found on disk at runtime
+                still takes precedence (see desktop.StaticFiles).
+  --bundle-id   macOS only: the bundle identifier (default: "bundleId" in
+                irgo.json, else derived from the module path)
+  --buildvcs    true, false or auto: whether go stamps the binary with VCS
+                info (go build -buildvcs). Defaults to go's own default.
+  --css         tailwind or none. Before building, static/css/input.css is
+                compiled to output.css with Tailwind (from node_modules, PATH,
+                bunx or npx), and the build fails if that fails. none skips
+                it; set "css": "none" in irgo.json to always skip.
+
+Reproducibility:
+  Builds use -trimpath, so binaries don't record local paths. Each output
+  directory gets a build-manifest.json listing the Go version, the modules
+  linked (with go.sum hashes) and the exact build command and flags.
+
+Environment:
+  IRGO_XMOBILE_REF  golang.org/x/mobile tag or commit to build gomobile from.
+                    Defaults to "xmobileRef" in irgo.json, which the first
+                    mobile build pins to the commit it fetched.
+
 Requirements:
   - iOS: Xcode and gomobile
   - Android: Android SDK and gomobile
@@ -170,6 +241,13 @@ Requirements:
     - Windows: MinGW-w64 or similar
     - Linux: GCC and WebKit2GTK dev packages
 
+Desktop metadata:
+  The app version comes from "version" in irgo.json (default 1.0.0) and is
+  used for the macOS Info.plist and the Windows version resource. Windows
+  builds embed icon.ico from the project as the app icon; without one the
+  .exe gets the default icon and no version resource. macOS builds copy
+  AppIcon.icns into the bundle's Resources.
+
 Output:
   - iOS: build/ios/Irgo.xcframework
   - Android: build/android/irgo.aar
@@ -177,6 +255,21 @@ Output:
   - Desktop Windows: build/desktop/windows/<app>.exe
   - Desktop Linux: build/desktop/linux/<app>`)
 
+	case "test":
+		fmt.Println(`irgo test - Run tests with race detector and coverage
+
+Usage:
+  irgo test [flags] [packages]
+
+Flags:
+  --html        Write coverage.out and open an HTML coverage report
+  --short       Pass -short to go test
+  --verbose, -v Verbose test output
+  --no-race     Disable the race detector
+  --no-cover    Disable coverage
+
+Packages default to ./... when none are given.`)
+
 	case "templ":
 		fmt.Println(`irgo templ - Generate templ files
 
@@ -199,6 +292,11 @@ Flags:
   --dev, -d    Development mode.
                - Mobile: Connects to localhost:8080 for hot-reload
                - Desktop: Enables browser devtools in webview
+  --bundle-id <id>
+               iOS bundle ID / Android package to launch. Defaults to
+               "bundleId" ("androidPackage") in irgo.json, else derived
+               from the module path (github.com/acme/app -> com.github.acme.app)
+  --force      Rebuild the mobile library even if no source files changed
 
 Requirements:
   - iOS: Xcode with iOS Simulator
@@ -221,6 +319,36 @@ Desktop mode:
   2. Opens native webview window pointing to localhost
   3. Closes server when window is closed`)
 
+	case "doctor":
+		fmt.Println(`irgo doctor - Check the toolchain before building
+
+Usage:
+  irgo doctor             Check tools needed for any irgo project
+  irgo doctor ios         Also require gomobile and Xcode
+  irgo doctor android     Also require gomobile and adb
+  irgo doctor desktop     Also require a C compiler and a working cgo link
+
+Reports each tool as found or missing with how to install it, the Go
+version, whether go.work exists, and whether a cgo (webview, inside a
+desktop project) program links with CGO_ENABLED=1.
+
+Exits non-zero if a tool required for the target is missing.`)
+
+	case "bindings":
+		fmt.Println(`irgo bindings - Document the mobile package for native integration
+
+Usage:
+  irgo bindings [--dir <path>] [--swift] [--kotlin] [-o <file>]
+
+Options:
+  --dir <path>   Go package to document (default: ./mobile, else the irgo source)
+  --swift        Include Swift declarations as generated by gomobile
+  --kotlin       Include Kotlin calls as generated by gomobile
+  -o <file>      Write the Markdown reference to a file instead of stdout
+
+Lists each exported function and callback interface with its Go signature,
+for wiring up IrgoBridge.swift or the Android equivalent.`)
+
 	default:
 		fmt.Printf("Unknown command: %s\n", cmd)
 		printUsage()
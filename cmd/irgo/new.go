@@ -8,21 +8,170 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/stukennedy/irgo/pkg/render"
 )
 
-//go:embed templates/*
+// templateFS holds one project template per subdirectory of templates/,
+// selected with `irgo new --template <name>`.
+//
+//go:embed templates/*/*
 var templateFS embed.FS
 
+// defaultTemplate is used when --template isn't given.
+const defaultTemplate = "default"
+
+// availableTemplates returns the names of the embedded project templates.
+func availableTemplates() []string {
+	entries, _ := fs.ReadDir(templateFS, "templates")
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addonFS holds optional scaffolding layered on top of templates/,
+// e.g. addons/sqlite for `irgo new --with-db sqlite`.
+//
+//go:embed addons
+var addonFS embed.FS
+
+// dbAddons maps --with-db values to their addon directory.
+var dbAddons = map[string]string{
+	"sqlite": "addons/sqlite",
+}
+
+// newOptions holds flags for `irgo new`.
+type newOptions struct {
+	Template string // Project template under templates/ (default: "default")
+	DB       string // Database addon to scaffold ("" for none)
+	Offline  bool   // Use bundled Datastar instead of downloading it
+	Tailwind string // Tailwind CSS major version, "3" or "4" ("" for the template's own)
+}
+
+// parseNewArgs parses `irgo new` arguments into a project name and options.
+func parseNewArgs(args []string) (string, newOptions, error) {
+	var name string
+	opts := newOptions{Template: defaultTemplate}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--template" && i+1 < len(args):
+			opts.Template = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--template="):
+			opts.Template = strings.TrimPrefix(arg, "--template=")
+		case arg == "--with-db" && i+1 < len(args):
+			opts.DB = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--with-db="):
+			opts.DB = strings.TrimPrefix(arg, "--with-db=")
+		case arg == "--offline":
+			opts.Offline = true
+		case arg == "--tailwind" && i+1 < len(args):
+			opts.Tailwind = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--tailwind="):
+			opts.Tailwind = strings.TrimPrefix(arg, "--tailwind=")
+		case strings.HasPrefix(arg, "-"):
+			return "", opts, fmt.Errorf("unknown flag: %s", arg)
+		case name == "":
+			name = arg
+		default:
+			return "", opts, fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+
+	if name == "" {
+		return "", opts, fmt.Errorf("missing project name")
+	}
+	if !slices.Contains(availableTemplates(), opts.Template) {
+		return "", opts, fmt.Errorf("unknown template %q (available: %s)", opts.Template, strings.Join(availableTemplates(), ", "))
+	}
+	if _, ok := dbAddons[opts.DB]; opts.DB != "" && !ok {
+		return "", opts, fmt.Errorf("unsupported database %q (supported: sqlite)", opts.DB)
+	}
+	if opts.Tailwind != "" {
+		if opts.Tailwind != "3" && opts.Tailwind != "4" {
+			return "", opts, fmt.Errorf("unsupported Tailwind version %q (supported: 3, 4)", opts.Tailwind)
+		}
+		if _, err := fs.Stat(templateFS, "templates/"+opts.Template+"/package.json.tmpl"); err != nil {
+			return "", opts, fmt.Errorf("template %q doesn't use Tailwind", opts.Template)
+		}
+	}
+	return name, opts, nil
+}
+
+// tailwindV3Edits turn a template's Tailwind v4 setup into v3: the
+// tailwindcss package instead of @tailwindcss/cli, @tailwind directives
+// instead of the CSS import, and a CLI air and dev.sh can fetch with npx.
+var tailwindV3Edits = []struct {
+	file     string
+	old, new string
+}{
+	{"package.json", `"@tailwindcss/cli": "^4.0.0"`, `"tailwindcss": "^3.4.0"`},
+	{"static/css/input.css", `@import "tailwindcss";`, "@tailwind base;\n@tailwind components;\n@tailwind utilities;"},
+	{".air.toml", "npx @tailwindcss/cli ", "npx tailwindcss@3 "},
+	{"dev.sh", "npx @tailwindcss/cli ", "npx tailwindcss@3 "},
+}
+
+// useTailwindV3 converts a generated project to Tailwind CSS v3 and writes
+// its tailwind.config.js.
+func useTailwindV3(projectDir string) error {
+	for _, edit := range tailwindV3Edits {
+		path := filepath.Join(projectDir, edit.file)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(content), edit.old) {
+			return fmt.Errorf("%s: no %s to replace", edit.file, edit.old)
+		}
+		content = []byte(strings.Replace(string(content), edit.old, edit.new, 1))
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filepath.Join(projectDir, "tailwind.config.js"), []byte(render.TailwindConfig), 0644)
+}
+
 // Datastar files to download during project creation
 var datastarFiles = map[string]string{
 	"static/js/datastar.js": "https://cdn.jsdelivr.net/gh/starfederation/datastar@v1.0.0-RC.7/bundles/datastar.js",
 }
 
-// downloadDatastar downloads Datastar files to the project's static/js directory
-func downloadDatastar(projectDir string) error {
+// bundledFS holds offline copies of datastarFiles under assets/, keyed by
+// destination path. They're used with --offline and when a download fails.
+// Refresh them with `make vendor-datastar`.
+//
+//go:embed all:assets
+var bundledFS embed.FS
+
+// bundledAssets is the filesystem read for offline copies (swapped in tests).
+var bundledAssets fs.FS = bundledFS
+
+// downloadClient fetches datastarFiles, honoring HTTP_PROXY/HTTPS_PROXY.
+var downloadClient = &http.Client{
+	Timeout:   30 * time.Second,
+	Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+}
+
+// installDatastar writes Datastar files to the project's static/js directory.
+// Files are downloaded unless offline is set; failed downloads fall back to
+// the copies bundled into the irgo binary with a warning.
+func installDatastar(projectDir string, offline bool) error {
 	for destPath, url := range datastarFiles {
 		fullPath := filepath.Join(projectDir, destPath)
 
@@ -31,21 +180,21 @@ func downloadDatastar(projectDir string) error {
 			return fmt.Errorf("creating directory for %s: %w", destPath, err)
 		}
 
-		// Download the file
-		resp, err := http.Get(url)
-		if err != nil {
-			return fmt.Errorf("downloading %s: %w", url, err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("downloading %s: status %d", url, resp.StatusCode)
+		var content []byte
+		var err error
+		if !offline {
+			content, err = download(url)
+			if err != nil {
+				fmt.Printf("  warning: %v; using bundled copy\n", err)
+			}
 		}
-
-		// Read the content
-		content, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("reading %s: %w", url, err)
+		source := "downloaded"
+		if offline || err != nil {
+			content, err = fs.ReadFile(bundledAssets, path.Join("assets", destPath))
+			if err != nil {
+				return fmt.Errorf("no bundled copy of %s in this irgo build", destPath)
+			}
+			source = "bundled"
 		}
 
 		// Write to file
@@ -53,12 +202,31 @@ func downloadDatastar(projectDir string) error {
 			return fmt.Errorf("writing %s: %w", destPath, err)
 		}
 
-		fmt.Printf("  downloaded: %s\n", destPath)
+		fmt.Printf("  %s: %s\n", source, destPath)
 	}
 
 	return nil
 }
 
+// download fetches url with downloadClient.
+func download(url string) ([]byte, error) {
+	resp, err := downloadClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: status %d", url, resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", url, err)
+	}
+	return content, nil
+}
+
 // getGoVersion returns the current Go version (e.g., "1.24.12")
 func getGoVersion() string {
 	out, err := exec.Command("go", "version").Output()
@@ -179,7 +347,7 @@ func isRemoteModulePath(path string) bool {
 	return false
 }
 
-func newProject(name string) error {
+func newProject(name string, opts newOptions) error {
 	// Determine project directory, project name, and module path
 	var projectDir string
 	var projectName string
@@ -222,84 +390,31 @@ func newProject(name string) error {
 
 	fmt.Printf("Creating new irgo project: %s\n", projectName)
 
-	// Create project structure
-	dirs := []string{
-		"handlers",
-		"templates",
-		"static/css",
-		"static/js",
-	}
-
-	for _, dir := range dirs {
-		path := filepath.Join(projectDir, dir)
-		if err := os.MkdirAll(path, 0755); err != nil {
-			return fmt.Errorf("creating directory %s: %w", path, err)
-		}
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		return fmt.Errorf("creating directory %s: %w", projectDir, err)
 	}
 
 	// Copy template files
-	err := fs.WalkDir(templateFS, "templates", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip the root templates directory
-		if path == "templates" {
-			return nil
-		}
-
-		// Get relative path from templates/
-		relPath := strings.TrimPrefix(path, "templates/")
-		destPath := filepath.Join(projectDir, relPath)
-
-		if d.IsDir() {
-			return os.MkdirAll(destPath, 0755)
-		}
-
-		// Read template file
-		content, err := templateFS.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("reading template %s: %w", path, err)
-		}
-
-		// Handle .tmpl extension (remove it) - do this before checking file type
-		if strings.HasSuffix(destPath, ".tmpl") {
-			destPath = strings.TrimSuffix(destPath, ".tmpl")
-			relPath = strings.TrimSuffix(relPath, ".tmpl")
-		}
-
-		// Replace placeholders
-		contentStr := string(content)
-		contentStr = strings.ReplaceAll(contentStr, "{{PROJECT_NAME}}", projectName)
-		contentStr = strings.ReplaceAll(contentStr, "{{MODULE_PATH}}", modulePath)
-		contentStr = strings.ReplaceAll(contentStr, "{{GO_VERSION}}", getGoVersion())
+	if err := copyTemplates(templateFS, "templates/"+opts.Template, projectDir, projectName, modulePath); err != nil {
+		return fmt.Errorf("copying templates: %w", err)
+	}
 
-		// Add replace directive for local development if irgo isn't published
-		irgoPath := getIrgoPath()
-		if irgoPath != "" && strings.HasSuffix(relPath, "go.mod") {
-			contentStr = strings.ReplaceAll(contentStr, "{{REPLACE_DIRECTIVE}}",
-				fmt.Sprintf("\nreplace github.com/stukennedy/irgo => %s\n", irgoPath))
-		} else {
-			contentStr = strings.ReplaceAll(contentStr, "{{REPLACE_DIRECTIVE}}", "")
+	if opts.Tailwind == "3" {
+		if err := useTailwindV3(projectDir); err != nil {
+			return fmt.Errorf("setting up Tailwind v3: %w", err)
 		}
+	}
 
-		// Write file
-		if err := os.WriteFile(destPath, []byte(contentStr), 0644); err != nil {
-			return fmt.Errorf("writing %s: %w", destPath, err)
+	if opts.DB != "" {
+		if err := copyTemplates(addonFS, dbAddons[opts.DB], projectDir, projectName, modulePath); err != nil {
+			return fmt.Errorf("copying %s files: %w", opts.DB, err)
 		}
-
-		fmt.Printf("  created: %s\n", relPath)
-		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("copying templates: %w", err)
 	}
 
 	// Download Datastar files
-	fmt.Println("Downloading Datastar...")
-	if err := downloadDatastar(projectDir); err != nil {
-		return fmt.Errorf("downloading Datastar: %w", err)
+	fmt.Println("Installing Datastar...")
+	if err := installDatastar(projectDir, opts.Offline); err != nil {
+		return fmt.Errorf("installing Datastar: %w", err)
 	}
 
 	// Make scripts executable
@@ -344,9 +459,73 @@ func newProject(name string) error {
 	fmt.Println()
 	fmt.Println("Next steps:")
 	fmt.Printf("  cd %s\n", projectDir)
-	fmt.Println("  bun install        # or: npm install")
+	if _, err := os.Stat(filepath.Join(projectDir, "package.json")); err == nil {
+		fmt.Println("  bun install        # or: npm install")
+	}
 	fmt.Println("  irgo dev           # start development server")
+	if opts.DB != "" {
+		fmt.Println()
+		fmt.Println("Database: open it with db.Open(\"app.db\") - migrations in db/migrations run automatically")
+	}
 	fmt.Println()
 
 	return nil
 }
+
+// copyTemplates copies the tree under root in fsys into projectDir,
+// stripping .tmpl extensions and replacing placeholders.
+func copyTemplates(fsys fs.ReadFileFS, root, projectDir, projectName, modulePath string) error {
+	return fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip the root directory
+		if path == root {
+			return nil
+		}
+
+		// Get relative path from root
+		relPath := strings.TrimPrefix(path, root+"/")
+		destPath := filepath.Join(projectDir, relPath)
+
+		if d.IsDir() {
+			return os.MkdirAll(destPath, 0755)
+		}
+
+		// Read template file
+		content, err := fsys.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading template %s: %w", path, err)
+		}
+
+		// Handle .tmpl extension (remove it) - do this before checking file type
+		if strings.HasSuffix(destPath, ".tmpl") {
+			destPath = strings.TrimSuffix(destPath, ".tmpl")
+			relPath = strings.TrimSuffix(relPath, ".tmpl")
+		}
+
+		// Replace placeholders
+		contentStr := string(content)
+		contentStr = strings.ReplaceAll(contentStr, "{{PROJECT_NAME}}", projectName)
+		contentStr = strings.ReplaceAll(contentStr, "{{MODULE_PATH}}", modulePath)
+		contentStr = strings.ReplaceAll(contentStr, "{{GO_VERSION}}", getGoVersion())
+
+		// Add replace directive for local development if irgo isn't published
+		irgoPath := getIrgoPath()
+		if irgoPath != "" && strings.HasSuffix(relPath, "go.mod") {
+			contentStr = strings.ReplaceAll(contentStr, "{{REPLACE_DIRECTIVE}}",
+				fmt.Sprintf("\nreplace github.com/stukennedy/irgo => %s\n", irgoPath))
+		} else {
+			contentStr = strings.ReplaceAll(contentStr, "{{REPLACE_DIRECTIVE}}", "")
+		}
+
+		// Write file
+		if err := os.WriteFile(destPath, []byte(contentStr), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", destPath, err)
+		}
+
+		fmt.Printf("  created: %s\n", relPath)
+		return nil
+	})
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// projectConfigFile holds per-project CLI settings in the project root.
+const projectConfigFile = "irgo.json"
+
+// projectConfig is the contents of irgo.json.
+type projectConfig struct {
+	// BundleID is the iOS bundle identifier (PRODUCT_BUNDLE_IDENTIFIER),
+	// also used as the Android package unless AndroidPackage is set.
+	BundleID string `json:"bundleId"`
+
+	// AndroidPackage is the Android applicationId, if it differs.
+	AndroidPackage string `json:"androidPackage"`
+
+	// XMobileRef is the golang.org/x/mobile tag or commit gomobile is built
+	// from. The first mobile build pins it if unset.
+	XMobileRef string `json:"xmobileRef"`
+
+	// Version is the app version shown in desktop builds (macOS
+	// CFBundleShortVersionString, Windows version resource).
+	Version string `json:"version"`
+
+	// CSS is "tailwind" or "none"; see buildCSS. Empty builds with
+	// Tailwind if the project has static/css/input.css.
+	CSS string `json:"css"`
+}
+
+// defaultAppVersion is used when irgo.json has no "version".
+const defaultAppVersion = "1.0.0"
+
+// appVersion returns "version" from irgo.json, or defaultAppVersion.
+func appVersion() (string, error) {
+	cfg, err := loadProjectConfig()
+	if err != nil {
+		return "", err
+	}
+	if cfg.Version == "" {
+		return defaultAppVersion, nil
+	}
+	return cfg.Version, nil
+}
+
+// loadProjectConfig reads irgo.json, returning an empty config if it's missing.
+func loadProjectConfig() (projectConfig, error) {
+	var cfg projectConfig
+	data, err := os.ReadFile(projectConfigFile)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing %s: %w", projectConfigFile, err)
+	}
+	return cfg, nil
+}
+
+// appIdentifier returns the bundle ID (ios, macos) or package name
+// (android) to launch or build with: the --bundle-id flag, then irgo.json,
+// then one derived from the module path.
+func appIdentifier(platform, flag string) (string, error) {
+	if flag != "" {
+		return flag, nil
+	}
+
+	cfg, err := loadProjectConfig()
+	if err != nil {
+		return "", err
+	}
+	if platform == "android" && cfg.AndroidPackage != "" {
+		return cfg.AndroidPackage, nil
+	}
+	if cfg.BundleID != "" {
+		return cfg.BundleID, nil
+	}
+
+	modulePath, err := getModulePath()
+	if err != nil {
+		return "", fmt.Errorf("no bundle ID: set \"bundleId\" in %s or pass --bundle-id", projectConfigFile)
+	}
+	return deriveAppIdentifier(modulePath, platform == "android"), nil
+}
+
+// deriveAppIdentifier turns a module path into a reverse-DNS identifier,
+// e.g. github.com/acme/my-app becomes com.github.acme.my-app (iOS) or
+// com.github.acme.my_app (Android, which doesn't allow hyphens).
+// Module paths without a domain get a com.example prefix.
+func deriveAppIdentifier(modulePath string, android bool) string {
+	parts := strings.Split(modulePath, "/")
+	var segments []string
+	if strings.Contains(parts[0], ".") {
+		host := strings.Split(parts[0], ".")
+		for i := len(host) - 1; i >= 0; i-- {
+			segments = append(segments, host[i])
+		}
+		parts = parts[1:]
+	} else {
+		segments = []string{"com", "example"}
+	}
+	segments = append(segments, parts...)
+
+	invalid := '-'
+	if android {
+		invalid = '_'
+	}
+	var out []string
+	for _, seg := range segments {
+		seg = strings.Map(func(r rune) rune {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				return r
+			}
+			return invalid
+		}, seg)
+		if seg == "" {
+			continue
+		}
+		// Android package segments must start with a letter
+		if android && !unicode.IsLetter(rune(seg[0])) {
+			seg = "app" + seg
+		}
+		out = append(out, seg)
+	}
+	return strings.Join(out, ".")
+}
+
+// flagValue returns the value of --name <value> or --name=<value> in args.
+func flagValue(args []string, name string) string {
+	for i, arg := range args {
+		if arg == name && i+1 < len(args) {
+			return args[i+1]
+		}
+		if v, ok := strings.CutPrefix(arg, name+"="); ok {
+			return v
+		}
+	}
+	return ""
+}
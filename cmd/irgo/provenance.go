@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// buildManifestFile is written to each build's output directory and records
+// what the build was made from.
+const buildManifestFile = "build-manifest.json"
+
+// buildManifest lists the toolchain, modules and flags behind a build, so a
+// release can be audited and reproduced.
+type buildManifest struct {
+	IrgoVersion string           `json:"irgoVersion"`
+	Target      string           `json:"target"` // e.g. "ios", "android/arm64", "desktop/linux"
+	GoVersion   string           `json:"goVersion"`
+	GOOS        string           `json:"goos,omitempty"` // Desktop builds only
+	GOARCH      string           `json:"goarch,omitempty"`
+	Package     string           `json:"package"`
+	Command     []string         `json:"command"` // The go or gomobile command line
+	Env         []string         `json:"env,omitempty"`
+	Modules     []manifestModule `json:"modules"`
+}
+
+// manifestModule is a module the build linked in.
+type manifestModule struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"` // Empty for the main module
+	Sum     string `json:"sum,omitempty"`     // From go.sum
+	Replace string `json:"replace,omitempty"` // "path version" or a local dir
+	Main    bool   `json:"main,omitempty"`
+}
+
+// goOutput runs the go command and returns its stdout; replaced in tests
+var goOutput = func(args ...string) ([]byte, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Stderr = os.Stderr
+	return cmd.Output()
+}
+
+// buildVCSValues are the values go build accepts for -buildvcs.
+var buildVCSValues = []string{"true", "false", "auto"}
+
+// checkBuildVCS validates a --buildvcs value; empty leaves go's default.
+func checkBuildVCS(buildvcs string) error {
+	if buildvcs == "" {
+		return nil
+	}
+	for _, v := range buildVCSValues {
+		if buildvcs == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --buildvcs %q (use true, false or auto)", buildvcs)
+}
+
+// goBuildFlags returns the flags every irgo go build uses: -trimpath, so
+// binaries don't record local paths and build the same on any machine, and
+// -buildvcs when set.
+func goBuildFlags(buildvcs string) []string {
+	flags := []string{"-trimpath"}
+	if buildvcs != "" {
+		flags = append(flags, "-buildvcs="+buildvcs)
+	}
+	return flags
+}
+
+// gomobileBindArgs returns the gomobile arguments to bind pkg for target.
+func gomobileBindArgs(target, outPath, pkg string) []string {
+	return []string{"bind", "-trimpath", "-target", target, "-o", outPath, pkg}
+}
+
+// gomobileEnv returns the environment for gomobile beyond os.Environ.
+// gomobile has no -buildvcs flag, so it's passed to go via GOFLAGS.
+func gomobileEnv(buildvcs string) []string {
+	env := []string{"GOTOOLCHAIN=go" + getGoVersion()}
+	if buildvcs != "" {
+		goflags := strings.TrimSpace(os.Getenv("GOFLAGS") + " -buildvcs=" + buildvcs)
+		env = append(env, "GOFLAGS="+goflags)
+	}
+	return env
+}
+
+// newBuildManifest describes a build of pkg for target, run as command with
+// env. tags are the build tags, used to list the modules it links. GOOS and
+// GOARCH are only recorded for desktop builds; gomobile picks its own.
+func newBuildManifest(target, pkg string, tags []string, command, env []string, desktop bool) (buildManifest, error) {
+	m := buildManifest{
+		IrgoVersion: version,
+		Target:      target,
+		Package:     pkg,
+		Command:     command,
+		Env:         env,
+	}
+
+	out, err := goOutput("env", "GOVERSION", "GOOS", "GOARCH")
+	if err != nil {
+		return m, fmt.Errorf("go env: %w", err)
+	}
+	goEnv := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(goEnv) != 3 {
+		return m, fmt.Errorf("unexpected go env output %q", out)
+	}
+	m.GoVersion = strings.TrimSpace(goEnv[0])
+	if desktop {
+		m.GOOS, m.GOARCH = strings.TrimSpace(goEnv[1]), strings.TrimSpace(goEnv[2])
+	}
+
+	args := []string{"list", "-deps", "-json=Module"}
+	if len(tags) > 0 {
+		args = append(args, "-tags", strings.Join(tags, ","))
+	}
+	out, err = goOutput(append(args, pkg)...)
+	if err != nil {
+		return m, fmt.Errorf("go list: %w", err)
+	}
+	if m.Modules, err = parseModules(out); err != nil {
+		return m, err
+	}
+	addModuleSums(m.Modules, "go.sum")
+	return m, nil
+}
+
+// parseModules reads the modules from `go list -deps -json=Module` output,
+// one per module, sorted by path.
+func parseModules(out []byte) ([]manifestModule, error) {
+	type listModule struct {
+		Path    string
+		Version string
+		Main    bool
+		Replace *struct {
+			Path    string
+			Version string
+		}
+	}
+
+	seen := map[string]bool{}
+	var modules []manifestModule
+	dec := json.NewDecoder(strings.NewReader(string(out)))
+	for {
+		var pkg struct{ Module *listModule }
+		err := dec.Decode(&pkg)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing go list output: %w", err)
+		}
+		// Standard library packages have no module
+		if pkg.Module == nil || seen[pkg.Module.Path] {
+			continue
+		}
+		seen[pkg.Module.Path] = true
+		mod := manifestModule{Path: pkg.Module.Path, Version: pkg.Module.Version, Main: pkg.Module.Main}
+		if r := pkg.Module.Replace; r != nil {
+			mod.Replace = strings.TrimSpace(r.Path + " " + r.Version)
+		}
+		modules = append(modules, mod)
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Path < modules[j].Path })
+	return modules, nil
+}
+
+// addModuleSums fills in each module's hash from the go.sum at path, if any.
+func addModuleSums(modules []manifestModule, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// "<path> <version> h1:..."; "<version>/go.mod" lines hash go.mod only
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && !strings.HasSuffix(fields[1], "/go.mod") {
+			sums[fields[0]+" "+fields[1]] = fields[2]
+		}
+	}
+	for i, mod := range modules {
+		key := mod.Path + " " + mod.Version
+		if mod.Replace != "" {
+			// A local directory replacement has no version, and no sum
+			key = mod.Replace
+		}
+		if mod.Version != "" {
+			modules[i].Sum = sums[key]
+		}
+	}
+}
+
+// writeBuildManifest writes m to dir as buildManifestFile.
+func writeBuildManifest(dir string, m buildManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, buildManifestFile), append(data, '\n'), 0644)
+}
+
+// recordBuild writes the manifest for a finished build to dir. Failing to
+// is only a warning, as the build itself succeeded.
+func recordBuild(dir, target, pkg string, tags []string, command, env []string, desktop bool) {
+	m, err := newBuildManifest(target, pkg, tags, command, env, desktop)
+	if err == nil {
+		err = writeBuildManifest(dir, m)
+	}
+	if err != nil {
+		fmt.Printf("Warning: could not write %s: %v\n", buildManifestFile, err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// contentRootEnv matches desktop.ContentRootEnv; it's duplicated here so the
+// CLI doesn't depend on the cgo-only desktop package.
+const contentRootEnv = "IRGO_ROOT"
+
+// contentRoot is the directory containing static/, templates/ and handlers/.
+// Set from --root or IRGO_ROOT; defaults to the current directory.
+var contentRoot = "."
+
+// extractRootFlag removes --root <dir> (or --root=<dir>) from args and
+// returns the root along with the remaining arguments.
+// Falls back to IRGO_ROOT, then ".".
+func extractRootFlag(args []string) (string, []string) {
+	root := os.Getenv(contentRootEnv)
+	rest := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--root" && i+1 < len(args):
+			root = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--root="):
+			root = strings.TrimPrefix(arg, "--root=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	if root == "" {
+		root = "."
+	}
+	return filepath.Clean(root), rest
+}
+
+// setContentRoot records the content root and exports it to child
+// processes so desktop.FindStaticDir and generated apps can find assets.
+func setContentRoot(root string) {
+	contentRoot = root
+	if root != "." {
+		os.Setenv(contentRootEnv, root)
+	}
+}
+
+// rootPath joins elem onto the content root.
+func rootPath(elem ...string) string {
+	return filepath.Join(append([]string{contentRoot}, elem...)...)
+}
+
+// mainPackage returns the Go package to build or run: the content root if it
+// contains main.go (e.g. cmd/app layouts), otherwise the current directory.
+func mainPackage() string {
+	if contentRoot != "." {
+		if _, err := os.Stat(rootPath("main.go")); err == nil {
+			return "./" + filepath.ToSlash(contentRoot)
+		}
+	}
+	return "."
+}
@@ -63,9 +63,14 @@ if [ -f "package.json" ] && [ ! -d "node_modules" ]; then
 fi
 
 log_info "Starting development server on http://localhost:8080"
-log_info "Air will handle templ generation and Tailwind CSS builds"
+log_info "Air will handle templ generation and Go rebuilds"
+log_info "CSS changes are swapped in without a page reload"
 log_info "Press Ctrl+C to exit."
 echo ""
 
+# Rebuild the CSS as it changes; the dev server swaps it into open pages
+npx @tailwindcss/cli -i ./static/css/input.css -o ./static/css/output.css --watch=always &
+trap 'kill $! 2>/dev/null' EXIT
+
 # Run air for Go hot reloading (also runs templ generate and tailwindcss)
 air
@@ -0,0 +1,2 @@
+// Application JavaScript
+// Add your custom JavaScript here
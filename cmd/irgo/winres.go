@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Windows resources are linked into the executable from a COFF object
+// (.syso) holding a single .rsrc section, the same format tools like
+// go-winres and rsrc produce. Only what irgo needs is supported: the
+// application icon and a version resource.
+
+const (
+	rtIcon      = 3
+	rtGroupIcon = 14
+	rtVersion   = 16
+
+	// langEnUS is the language of every resource (en-US)
+	langEnUS = 0x0409
+)
+
+// coffMachines maps GOARCH to the COFF machine type and the relocation
+// type for a 32-bit image-relative address (ADDR32NB).
+var coffMachines = map[string]struct{ machine, reloc uint16 }{
+	"amd64": {0x8664, 0x0003},
+	"386":   {0x014c, 0x0007},
+	"arm64": {0xaa64, 0x0002},
+}
+
+type winResource struct {
+	typ, id uint16
+	data    []byte
+}
+
+// writeWindowsResources generates a .syso with the icon at iconPath and a
+// version resource in the main package, where go build links it in. go
+// build -overlay doesn't apply to .syso files, so it's written alongside the
+// source; call remove once the build is done.
+// Nothing is generated, without error, if there's no icon or the package
+// already has its own .syso.
+func writeWindowsResources(iconPath, appName, version, goarch string) (remove func(), err error) {
+	remove = func() {}
+	ico, err := os.ReadFile(iconPath)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("No %s found; building without an icon or version resource\n", iconPath)
+		return remove, nil
+	}
+	if err != nil {
+		return remove, err
+	}
+	if existing, _ := filepath.Glob(filepath.Join(mainPackage(), "*.syso")); len(existing) > 0 {
+		fmt.Printf("Using existing %s; not generating Windows resources\n", existing[0])
+		return remove, nil
+	}
+
+	resources, err := iconResources(ico)
+	if err != nil {
+		return remove, fmt.Errorf("%s: %w", iconPath, err)
+	}
+	resources = append(resources, winResource{rtVersion, 1, versionInfo(appName, version)})
+
+	syso, err := writeSyso(resources, goarch)
+	if err != nil {
+		return remove, err
+	}
+	path := filepath.Join(mainPackage(), "irgo_rsrc_windows_"+goarch+".syso")
+	if err := os.WriteFile(path, syso, 0644); err != nil {
+		return remove, err
+	}
+	return func() { os.Remove(path) }, nil
+}
+
+// iconResources splits an .ico file into one RT_ICON resource per image
+// and the RT_GROUP_ICON directory that refers to them.
+func iconResources(ico []byte) ([]winResource, error) {
+	if len(ico) < 6 || binary.LittleEndian.Uint16(ico[2:]) != 1 {
+		return nil, errors.New("not an .ico file")
+	}
+	count := int(binary.LittleEndian.Uint16(ico[4:]))
+	if count == 0 || len(ico) < 6+16*count {
+		return nil, errors.New("truncated .ico file")
+	}
+
+	var resources []winResource
+	group := new(bytes.Buffer)
+	binary.Write(group, binary.LittleEndian, [3]uint16{0, 1, uint16(count)})
+	for i := 0; i < count; i++ {
+		entry := ico[6+16*i : 6+16*(i+1)]
+		size := binary.LittleEndian.Uint32(entry[8:])
+		offset := binary.LittleEndian.Uint32(entry[12:])
+		if uint64(offset)+uint64(size) > uint64(len(ico)) {
+			return nil, fmt.Errorf("icon image %d is out of bounds", i)
+		}
+		id := uint16(i + 1)
+		resources = append(resources, winResource{rtIcon, id, ico[offset : offset+size]})
+
+		// GRPICONDIRENTRY is ICONDIRENTRY with the file offset replaced by
+		// the RT_ICON id
+		group.Write(entry[:12])
+		binary.Write(group, binary.LittleEndian, id)
+	}
+	return append(resources, winResource{rtGroupIcon, 1, group.Bytes()}), nil
+}
+
+// versionInfo builds a VS_VERSIONINFO resource for the application.
+func versionInfo(appName, version string) []byte {
+	v := parseVersion(version)
+	fixed := new(bytes.Buffer)
+	binary.Write(fixed, binary.LittleEndian, [13]uint32{
+		0xfeef04bd,      // signature
+		0x00010000,      // structure version
+		v[0]<<16 | v[1], // file version
+		v[2]<<16 | v[3],
+		v[0]<<16 | v[1], // product version
+		v[2]<<16 | v[3],
+		0x3f,       // flags mask
+		0,          // flags
+		0x00040004, // VOS_NT_WINDOWS32
+		0x00000001, // VFT_APP
+		0, 0, 0,    // subtype, date
+	})
+
+	strs := [][2]string{
+		{"CompanyName", ""},
+		{"FileDescription", appName},
+		{"FileVersion", version},
+		{"InternalName", appName},
+		{"OriginalFilename", appName + ".exe"},
+		{"ProductName", appName},
+		{"ProductVersion", version},
+	}
+	var entries [][]byte
+	for _, s := range strs {
+		value := utf16z(s[1])
+		entries = append(entries, versionBlock(s[0], value, uint16(len(value)/2), 1, nil))
+	}
+	stringTable := versionBlock("040904B0", nil, 0, 1, entries)
+	stringFileInfo := versionBlock("StringFileInfo", nil, 0, 1, [][]byte{stringTable})
+
+	translation := []byte{0x09, 0x04, 0xb0, 0x04} // en-US, Unicode
+	varInfo := versionBlock("Translation", translation, uint16(len(translation)), 0, nil)
+	varFileInfo := versionBlock("VarFileInfo", nil, 0, 1, [][]byte{varInfo})
+
+	return versionBlock("VS_VERSION_INFO", fixed.Bytes(), uint16(fixed.Len()), 0,
+		[][]byte{stringFileInfo, varFileInfo})
+}
+
+// versionBlock encodes one node of a version resource: its header and key,
+// then value and children, each aligned to 32 bits. valueLen is in bytes
+// for binary values and in characters for text (typ 1).
+func versionBlock(key string, value []byte, valueLen, typ uint16, children [][]byte) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, [3]uint16{0, valueLen, typ})
+	buf.Write(utf16z(key))
+	pad4(buf)
+	buf.Write(value)
+	for _, child := range children {
+		pad4(buf)
+		buf.Write(child)
+	}
+	out := buf.Bytes()
+	binary.LittleEndian.PutUint16(out, uint16(len(out)))
+	return out
+}
+
+// parseVersion reads up to four dot-separated numbers from version,
+// ignoring any suffix such as "-beta" ("1.2.3-beta" is 1.2.3.0).
+func parseVersion(version string) [4]uint32 {
+	var v [4]uint32
+	for i, part := range strings.SplitN(version, ".", 4) {
+		end := 0
+		for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+			end++
+		}
+		n, _ := strconv.ParseUint(part[:end], 10, 16)
+		v[i] = uint32(n)
+		if end < len(part) {
+			break
+		}
+	}
+	return v
+}
+
+// writeSyso lays out resources as a resource directory tree (type, id,
+// language) in a COFF object's .rsrc section. Data entries hold section
+// offsets that the linker relocates to RVAs.
+func writeSyso(resources []winResource, goarch string) ([]byte, error) {
+	arch, ok := coffMachines[goarch]
+	if !ok {
+		return nil, fmt.Errorf("no Windows resource support for GOARCH=%s", goarch)
+	}
+
+	// Directory entries must be sorted by id
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].typ != resources[j].typ {
+			return resources[i].typ < resources[j].typ
+		}
+		return resources[i].id < resources[j].id
+	})
+	var types []uint16
+	byType := map[uint16][]int{}
+	for i, r := range resources {
+		if len(byType[r.typ]) == 0 {
+			types = append(types, r.typ)
+		}
+		byType[r.typ] = append(byType[r.typ], i)
+	}
+
+	const dirSize, entrySize, dataEntrySize = 16, 8, 16
+	const subdir = 0x80000000
+
+	// Offsets of each part of the tree
+	offset := uint32(dirSize + entrySize*len(types))
+	typeDirs := map[uint16]uint32{}
+	for _, typ := range types {
+		typeDirs[typ] = offset
+		offset += uint32(dirSize + entrySize*len(byType[typ]))
+	}
+	langDirs := make([]uint32, len(resources))
+	for i := range resources {
+		langDirs[i] = offset
+		offset += dirSize + entrySize
+	}
+	dataEntries := make([]uint32, len(resources))
+	for i := range resources {
+		dataEntries[i] = offset
+		offset += dataEntrySize
+	}
+	data := make([]uint32, len(resources))
+	for i, r := range resources {
+		offset = (offset + 7) &^ 7
+		data[i] = offset
+		offset += uint32(len(r.data))
+	}
+
+	rsrc := new(bytes.Buffer)
+	le := func(v any) { binary.Write(rsrc, binary.LittleEndian, v) }
+	dir := func(entries int) { le([4]uint32{0, 0, 0, uint32(entries) << 16}) }
+
+	dir(len(types))
+	for _, typ := range types {
+		le([2]uint32{uint32(typ), subdir | typeDirs[typ]})
+	}
+	for _, typ := range types {
+		dir(len(byType[typ]))
+		for _, i := range byType[typ] {
+			le([2]uint32{uint32(resources[i].id), subdir | langDirs[i]})
+		}
+	}
+	for i := range resources {
+		dir(1)
+		le([2]uint32{langEnUS, dataEntries[i]})
+	}
+	for i, r := range resources {
+		le([4]uint32{data[i], uint32(len(r.data)), 0, 0})
+	}
+	for i, r := range resources {
+		rsrc.Write(make([]byte, int(data[i])-rsrc.Len()))
+		rsrc.Write(r.data)
+	}
+
+	const fileHeaderSize, sectionHeaderSize, relocSize = 20, 40, 10
+	rawOffset := uint32(fileHeaderSize + sectionHeaderSize)
+	relocOffset := rawOffset + uint32(rsrc.Len())
+	symOffset := relocOffset + uint32(relocSize*len(resources))
+
+	out := new(bytes.Buffer)
+	w := func(v any) { binary.Write(out, binary.LittleEndian, v) }
+
+	// File header
+	w(arch.machine)
+	w(uint16(1)) // sections
+	w(uint32(0)) // timestamp
+	w(symOffset) // symbol table
+	w(uint32(1)) // symbols
+	w(uint16(0)) // optional header size
+	w(uint16(0)) // characteristics
+
+	// Section header
+	w([8]byte{'.', 'r', 's', 'r', 'c'})
+	w([2]uint32{0, 0}) // virtual size, address
+	w(uint32(rsrc.Len()))
+	w(rawOffset)
+	w(relocOffset)
+	w(uint32(0)) // line numbers
+	w(uint16(len(resources)))
+	w(uint16(0))
+	w(uint32(0x40000040)) // initialized data, readable
+
+	out.Write(rsrc.Bytes())
+
+	// Each data entry's offset is relative to the section symbol
+	for _, off := range dataEntries {
+		w(off)
+		w(uint32(0))
+		w(arch.reloc)
+	}
+
+	// Section symbol, then an empty string table
+	w([8]byte{'.', 'r', 's', 'r', 'c'})
+	w(uint32(0)) // value
+	w(int16(1))  // section number
+	w(uint16(0)) // type
+	w(uint8(3))  // IMAGE_SYM_CLASS_STATIC
+	w(uint8(0))  // aux symbols
+	w(uint32(4))
+	return out.Bytes(), nil
+}
+
+func utf16z(s string) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, utf16.Encode([]rune(s+"\x00")))
+	return buf.Bytes()
+}
+
+func pad4(buf *bytes.Buffer) {
+	for buf.Len()%4 != 0 {
+		buf.WriteByte(0)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// xmobileRepo is cloned to build gomobile against the current Go version.
+	xmobileRepo = "https://github.com/golang/mobile"
+
+	// xmobileRefEnv overrides the x/mobile tag or commit to build from.
+	xmobileRefEnv = "IRGO_XMOBILE_REF"
+
+	// xmobileMarker records, inside the clone's .git, the ref requested and
+	// the commit it resolved to: "<ref> <sha>".
+	xmobileMarker = "irgo-ref"
+)
+
+// xmobileDir is where x/mobile is cloned
+var xmobileDir = filepath.Join(os.TempDir(), "golang-mobile")
+
+// gitRunner runs git in dir and returns its trimmed output; replaced in tests
+var gitRunner = func(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+var fullSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// xmobileRef returns the x/mobile ref to build from and where it came from:
+// IRGO_XMOBILE_REF, then "xmobileRef" in irgo.json. An empty ref means
+// none is pinned yet.
+func xmobileRef() (ref, source string, err error) {
+	if ref := os.Getenv(xmobileRefEnv); ref != "" {
+		return ref, xmobileRefEnv, nil
+	}
+	cfg, err := loadProjectConfig()
+	if err != nil {
+		return "", "", err
+	}
+	if cfg.XMobileRef != "" {
+		return cfg.XMobileRef, projectConfigFile, nil
+	}
+	return "", "", nil
+}
+
+// ensureXMobile makes xmobileDir a checkout of the pinned ref, fetching it
+// if the clone is missing or was made from a different ref. With nothing
+// pinned it fetches the default branch and pins the commit in irgo.json so
+// later builds (and other machines) use the same one.
+// Reports whether the checkout changed.
+func ensureXMobile() (bool, error) {
+	ref, source, err := xmobileRef()
+	if err != nil {
+		return false, err
+	}
+
+	if ref != "" {
+		if sha, ok := xmobileCheckout(ref); ok {
+			fmt.Printf("Using golang.org/x/mobile %s (%s, from %s)\n", ref, shortSHA(sha), source)
+			return false, nil
+		}
+		fmt.Printf("Fetching golang.org/x/mobile %s (from %s)...\n", ref, source)
+	} else {
+		fmt.Println("Fetching golang.org/x/mobile (default branch; no ref pinned)...")
+	}
+
+	sha, err := fetchXMobile(ref)
+	if err != nil {
+		return false, err
+	}
+
+	if ref == "" {
+		if err := pinXMobileRef(sha); err != nil {
+			fmt.Printf("Warning: could not pin x/mobile in %s: %v\n", projectConfigFile, err)
+		} else if err := markXMobile(sha, sha); err != nil {
+			return false, err
+		} else {
+			fmt.Printf("Pinned golang.org/x/mobile %s in %s (override with %s)\n", shortSHA(sha), projectConfigFile, xmobileRefEnv)
+		}
+	} else {
+		fmt.Printf("Using golang.org/x/mobile %s (%s)\n", ref, shortSHA(sha))
+	}
+	return true, nil
+}
+
+// xmobileCheckout reports whether the existing clone was fetched for ref
+// and its HEAD is still the commit ref resolved to.
+func xmobileCheckout(ref string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(xmobileDir, ".git", xmobileMarker))
+	if err != nil {
+		return "", false
+	}
+	marked, sha, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	if marked != ref || sha == "" {
+		return "", false
+	}
+	head, err := gitRunner(xmobileDir, "rev-parse", "HEAD")
+	if err != nil || head != sha {
+		return "", false
+	}
+	return sha, true
+}
+
+// fetchXMobile checks out ref (or the default branch if empty) in
+// xmobileDir, reusing the directory if it's already a clone, and returns
+// the commit checked out.
+func fetchXMobile(ref string) (string, error) {
+	if _, err := os.Stat(filepath.Join(xmobileDir, ".git")); err != nil {
+		os.RemoveAll(xmobileDir)
+		if err := os.MkdirAll(xmobileDir, 0755); err != nil {
+			return "", err
+		}
+		if _, err := gitRunner(xmobileDir, "init", "--quiet"); err != nil {
+			return "", fmt.Errorf("failed to clone x/mobile: %w", err)
+		}
+	}
+
+	target := ref
+	if target == "" {
+		target = "HEAD"
+	}
+	if _, err := gitRunner(xmobileDir, "fetch", "--quiet", "--depth", "1", xmobileRepo, target); err != nil {
+		return "", fmt.Errorf("failed to fetch x/mobile %s: %w", target, err)
+	}
+	// --force discards the go.mod edit made for the previous checkout
+	if _, err := gitRunner(xmobileDir, "checkout", "--quiet", "--force", "--detach", "FETCH_HEAD"); err != nil {
+		return "", fmt.Errorf("failed to check out x/mobile %s: %w", target, err)
+	}
+
+	sha, err := gitRunner(xmobileDir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to read x/mobile HEAD: %w", err)
+	}
+	if fullSHA.MatchString(ref) && sha != ref {
+		return "", fmt.Errorf("x/mobile HEAD is %s, expected %s", sha, ref)
+	}
+
+	if err := markXMobile(ref, sha); err != nil {
+		return "", err
+	}
+	return sha, nil
+}
+
+// markXMobile records that the clone holds sha, fetched for ref.
+func markXMobile(ref, sha string) error {
+	marker := filepath.Join(xmobileDir, ".git", xmobileMarker)
+	return os.WriteFile(marker, []byte(ref+" "+sha+"\n"), 0644)
+}
+
+// pinXMobileRef records sha as "xmobileRef" in irgo.json, keeping its
+// other settings.
+func pinXMobileRef(sha string) error {
+	settings := map[string]any{}
+	data, err := os.ReadFile(projectConfigFile)
+	if err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("parsing %s: %w", projectConfigFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	settings["xmobileRef"] = sha
+	data, err = json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(projectConfigFile, append(data, '\n'), 0644)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
@@ -5,7 +5,9 @@ package desktop
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"sync"
@@ -13,6 +15,7 @@ import (
 
 	webview "github.com/webview/webview_go"
 
+	"github.com/stukennedy/irgo/pkg/supervisor"
 	"github.com/stukennedy/irgo/pkg/transport"
 	ws "github.com/stukennedy/irgo/pkg/websocket"
 )
@@ -23,13 +26,67 @@ type Config struct {
 	Width     int
 	Height    int
 	Resizable bool
-	Debug     bool   // Enable webview devtools
+	Debug     bool   // Enable webview devtools and expose handler errors to its console
 	Port      int    // 0 = auto-select available port
 	Transport string // "loopback" (default) or "inprocess"
 	Version   string // App version (shown in About menu on macOS)
 	SetupMenu bool   // Setup native menu bar (macOS)
+
+	// ShutdownTimeout is how long Shutdown waits for in-flight requests and
+	// streams to drain before closing them (0 = DefaultShutdownTimeout)
+	ShutdownTimeout time.Duration
+
+	// DisableSecret turns off the loopback server's per-launch secret, for
+	// trusted single-user setups (see transport.Config.DisableSecret)
+	DisableSecret bool
+
+	// TLS serves the loopback server over https:// (see transport.Config.TLS),
+	// for webviews that require it. Without a PEM certificate and key a
+	// self-signed one is generated, which the webview must trust.
+	TLS        bool
+	TLSCertPEM []byte
+	TLSKeyPEM  []byte
+
+	// Protection from runaway page code (loopback only, 0 = unlimited):
+	// a cap on open WebSockets and a per-client request rate (see
+	// transport.WithMaxWebSocketConnections and transport.WithRateLimit)
+	MaxWebSocketConnections int
+	RequestRateLimit        float64
+	RequestRateBurst        int
+
+	// WebSocket buffer sizes in bytes (loopback only, 0 = 4 KiB; see
+	// transport.Config.WSReadBufferSize)
+	WSReadBufferSize  int
+	WSWriteBufferSize int
+
+	// Compression gzips loopback responses for the webview (see
+	// transport.Config.Compression)
+	Compression bool
+
+	// SingleInstance, if set, is an app ID such as "com.example.todo" that
+	// keeps the app to one instance: a second launch hands its arguments
+	// and OpenPath to the running instance, which comes to the front, and
+	// Run returns ErrAlreadyRunning. See AcquireInstanceLock.
+	SingleInstance string
+
+	// OpenPath is the local path the window opens at instead of "/", e.g.
+	// from a deep link on the command line. With SingleInstance, a second
+	// launch's OpenPath is opened in the running instance instead.
+	OpenPath string
+
+	// OnSecondInstance is called in the running instance, on a background
+	// goroutine, when a second launch hands over (after the window is
+	// focused and any Path opened)
+	OnSecondInstance func(msg InstanceMessage)
+
+	// Logger receives loopback server events such as failed WebSocket
+	// upgrades (see transport.Config.Logger; nil discards them)
+	Logger *slog.Logger
 }
 
+// DefaultShutdownTimeout is used when Config.ShutdownTimeout is zero.
+const DefaultShutdownTimeout = 5 * time.Second
+
 // DefaultConfig returns sensible defaults for a desktop app
 func DefaultConfig() Config {
 	return Config{
@@ -42,6 +99,8 @@ func DefaultConfig() Config {
 		Transport: "loopback",
 		Version:   "1.0.0",
 		SetupMenu: true,
+
+		ShutdownTimeout: DefaultShutdownTimeout,
 	}
 }
 
@@ -51,8 +110,13 @@ type App struct {
 	handler   http.Handler
 	wsHub     *ws.Hub
 	transport transport.Transport
+	jobs      *supervisor.Supervisor
 	wv        webview.WebView
 	wg        sync.WaitGroup
+
+	// ui is wv as seen from other goroutines; see Reload
+	ui   webviewUI
+	uiMu sync.RWMutex
 }
 
 // New creates a new desktop app with the given HTTP handler
@@ -61,6 +125,7 @@ func New(handler http.Handler, config Config) *App {
 		config:  config,
 		handler: handler,
 		wsHub:   ws.NewHub(),
+		jobs:    supervisor.New(),
 	}
 }
 
@@ -70,11 +135,30 @@ func NewWithHub(handler http.Handler, wsHub *ws.Hub, config Config) *App {
 		config:  config,
 		handler: handler,
 		wsHub:   wsHub,
+		jobs:    supervisor.New(),
 	}
 }
 
-// Run starts the desktop app (blocking until window is closed)
+// Run starts the desktop app (blocking until window is closed). With
+// Config.SingleInstance it returns ErrAlreadyRunning straight away if
+// another instance is running, having handed over to it.
 func (a *App) Run() error {
+	if id := a.config.SingleInstance; id != "" {
+		lock, err := AcquireInstanceLock(id)
+		if errors.Is(err, ErrAlreadyRunning) {
+			msg := InstanceMessage{Args: os.Args[1:], Path: a.config.OpenPath}
+			if err := SignalInstance(id, msg); err != nil {
+				return err
+			}
+			return ErrAlreadyRunning
+		}
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+		lock.Serve(a.handleSecondInstance)
+	}
+
 	// Setup native menu bar if enabled
 	if a.config.SetupMenu {
 		SetupMenu(a.config.Title, a.config.Version)
@@ -92,11 +176,28 @@ func (a *App) Run() error {
 	case "inprocess":
 		t = transport.NewInProcessTransport(a.handler, a.wsHub,
 			transport.WithPort(a.config.Port),
+			transport.WithDebug(a.config.Debug),
+			transport.WithShutdownTimeout(a.shutdownTimeout()),
+			transport.WithDisableSecret(a.config.DisableSecret),
+			transport.WithJobs(a.jobs),
 		)
 	default:
-		t = transport.NewLoopbackTransport(a.handler, a.wsHub,
+		opts := []transport.Option{
 			transport.WithPort(a.config.Port),
-		)
+			transport.WithDebug(a.config.Debug),
+			transport.WithShutdownTimeout(a.shutdownTimeout()),
+			transport.WithDisableSecret(a.config.DisableSecret),
+			transport.WithJobs(a.jobs),
+			transport.WithMaxWebSocketConnections(a.config.MaxWebSocketConnections),
+			transport.WithRateLimit(a.config.RequestRateLimit, a.config.RequestRateBurst),
+			transport.WithLogger(a.config.Logger),
+			transport.WithCompression(a.config.Compression),
+			transport.WithWSBufferSizes(a.config.WSReadBufferSize, a.config.WSWriteBufferSize),
+		}
+		if a.config.TLS {
+			opts = append(opts, transport.WithTLS(a.config.TLSCertPEM, a.config.TLSKeyPEM))
+		}
+		t = transport.NewLoopbackTransport(a.handler, a.wsHub, opts...)
 	}
 	a.transport = t
 
@@ -124,14 +225,14 @@ func (a *App) Port() int {
 	return 0
 }
 
-// URL returns the local server URL (empty for inprocess transport)
+// URL returns the local server URL, https:// with Config.TLS (empty for
+// inprocess transport)
 func (a *App) URL() string {
 	if a.transport == nil {
 		return ""
 	}
-	cfg := a.transport.Config()
-	if cfg != nil && cfg.Address != "" {
-		return fmt.Sprintf("http://%s:%d", cfg.Address, cfg.Port)
+	if cfg := a.transport.Config(); cfg != nil {
+		return cfg.BaseURL()
 	}
 	return ""
 }
@@ -153,14 +254,52 @@ func (a *App) Transport() transport.Transport {
 	return a.transport
 }
 
+// Pause makes the embedded server reject requests with 503 until Resume is called
+func (a *App) Pause() {
+	if a.transport != nil {
+		a.transport.Pause()
+	}
+}
+
+// Resume undoes Pause
+func (a *App) Resume() {
+	if a.transport != nil {
+		a.transport.Resume()
+	}
+}
+
+// ConnectionCount returns the number of open WebSocket connections
+func (a *App) ConnectionCount() int {
+	if a.transport == nil {
+		return 0
+	}
+	return a.transport.ConnectionCount()
+}
+
 // Hub returns the WebSocket hub for registering handlers
 func (a *App) Hub() *ws.Hub {
 	return a.wsHub
 }
 
+// Go starts a background job that's cancelled when the app shuts down
+// (see supervisor.Supervisor.Go). Jobs are listed at transport.DebugJobsPath
+// in Debug mode.
+func (a *App) Go(name string, fn func(ctx context.Context), opts ...supervisor.Option) {
+	a.jobs.Go(name, fn, opts...)
+}
+
+// Jobs returns the supervisor running the app's background jobs
+func (a *App) Jobs() *supervisor.Supervisor {
+	return a.jobs
+}
+
 func (a *App) runWebview() {
 	a.wv = webview.New(a.config.Debug)
-	defer a.wv.Destroy()
+	a.setUI(a.wv)
+	defer func() {
+		a.setUI(nil)
+		a.wv.Destroy()
+	}()
 
 	a.wv.SetTitle(a.config.Title)
 
@@ -181,6 +320,11 @@ func (a *App) runWebview() {
 	// Navigate to the server URL
 	url := a.URL()
 	if url != "" {
+		if a.config.OpenPath != "" {
+			if target, err := reloadURL(url, a.config.OpenPath); err == nil {
+				url = target
+			}
+		}
 		a.wv.Navigate(url)
 	}
 
@@ -188,17 +332,30 @@ func (a *App) runWebview() {
 	a.wv.Run()
 }
 
-// Shutdown gracefully stops the app
+// Shutdown gracefully stops the app, giving in-flight requests, streams
+// and background jobs up to Config.ShutdownTimeout to finish. It returns
+// context.DeadlineExceeded if they had to be cut off.
 func (a *App) Shutdown() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout())
 	defer cancel()
 
+	var err error
 	if a.transport != nil {
-		a.transport.Stop(ctx)
+		err = a.transport.Stop(ctx)
+	}
+	if jobsErr := a.jobs.Shutdown(ctx); err == nil {
+		err = jobsErr
 	}
 
 	a.wg.Wait()
-	return nil
+	return err
+}
+
+func (a *App) shutdownTimeout() time.Duration {
+	if a.config.ShutdownTimeout > 0 {
+		return a.config.ShutdownTimeout
+	}
+	return DefaultShutdownTimeout
 }
 
 // Bind binds a Go function to a JavaScript name in the webview
@@ -1,8 +1,15 @@
 package desktop
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"testing"
+	"time"
+
+	"github.com/stukennedy/irgo/pkg/core"
+	"github.com/stukennedy/irgo/pkg/transport"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -92,6 +99,114 @@ func TestAppPortBeforeRun(t *testing.T) {
 	}
 }
 
+func TestAppInProcessURLAndPort(t *testing.T) {
+	config := DefaultConfig()
+	config.Port = 8080
+	app := New(http.NotFoundHandler(), config)
+
+	// As Run sets it up for IRGO_TRANSPORT=inprocess
+	app.transport = transport.NewInProcessTransport(app.handler, app.wsHub, transport.WithPort(config.Port))
+	if url := app.URL(); url != "" {
+		t.Errorf("expected no URL for the in-process transport, got %q", url)
+	}
+	if port := app.Port(); port != 0 {
+		t.Errorf("expected port 0 for the in-process transport, got %d", port)
+	}
+}
+
+func TestAppTLSURL(t *testing.T) {
+	app := New(http.NotFoundHandler(), DefaultConfig())
+
+	// As Run sets it up with Config.TLS
+	app.transport = transport.NewLoopbackTransport(app.handler, app.wsHub, transport.WithTLS(nil, nil))
+	if err := app.transport.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer app.Shutdown()
+	if want := fmt.Sprintf("https://127.0.0.1:%d", app.Port()); app.URL() != want {
+		t.Errorf("expected %s, got %s", want, app.URL())
+	}
+}
+
+func TestAppControlBeforeRun(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	app := New(handler, DefaultConfig())
+
+	// Before Run(), control methods are no-ops and there are no connections
+	app.Pause()
+	app.Resume()
+	if n := app.ConnectionCount(); n != 0 {
+		t.Errorf("expected 0 connections before Run(), got %d", n)
+	}
+}
+
+func TestAppShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		timeout time.Duration
+		wantErr error
+	}{
+		{50 * time.Millisecond, context.DeadlineExceeded},
+		{5 * time.Second, nil},
+	}
+
+	for _, tt := range tests {
+		started := make(chan struct{})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(300 * time.Millisecond)
+		})
+		config := DefaultConfig()
+		config.ShutdownTimeout = tt.timeout
+		app := New(handler, config)
+
+		// Start the transport as Run would, without a webview
+		app.transport = transport.NewLoopbackTransport(handler, app.wsHub)
+		if err := app.transport.Start(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		go app.transport.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+		<-started
+
+		begin := time.Now()
+		err := app.Shutdown()
+		elapsed := time.Since(begin)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("timeout %v: expected %v, got %v", tt.timeout, tt.wantErr, err)
+		}
+		if tt.wantErr != nil && elapsed >= 250*time.Millisecond {
+			t.Errorf("timeout %v: Shutdown took %v", tt.timeout, elapsed)
+		}
+		if tt.wantErr == nil && elapsed < 200*time.Millisecond {
+			t.Errorf("timeout %v: Shutdown returned before request finished (%v)", tt.timeout, elapsed)
+		}
+	}
+
+	if got := New(nil, Config{}).shutdownTimeout(); got != DefaultShutdownTimeout {
+		t.Errorf("expected zero ShutdownTimeout to use default, got %v", got)
+	}
+}
+
+func TestAppShutdownCancelsJobs(t *testing.T) {
+	app := New(http.NotFoundHandler(), DefaultConfig())
+	cancelled := make(chan struct{})
+	app.Go("sync", func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+	if jobs := app.Jobs().Jobs(); len(jobs) != 1 || jobs[0].Name != "sync" {
+		t.Errorf("unexpected jobs %+v", jobs)
+	}
+
+	if err := app.Shutdown(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case <-cancelled:
+	default:
+		t.Error("expected Shutdown to wait for the job to be cancelled")
+	}
+}
+
 func TestAppSecretBeforeRun(t *testing.T) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
 	config := DefaultConfig()
@@ -0,0 +1,32 @@
+//go:build darwin
+
+package desktop
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+
+#import <Cocoa/Cocoa.h>
+
+void focusWindow(void *window) {
+    @autoreleasepool {
+        NSWindow *w = (NSWindow *)window;
+        [NSApp activateIgnoringOtherApps:YES];
+        if ([w isMiniaturized]) {
+            [w deminiaturize:nil];
+        }
+        [w makeKeyAndOrderFront:nil];
+    }
+}
+*/
+import "C"
+
+import "unsafe"
+
+// focusWindow activates the app and brings its window to the front,
+// restoring it if minimized. Call it on the main thread.
+func focusWindow(window unsafe.Pointer) {
+	if window != nil {
+		C.focusWindow(window)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package desktop
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32                  = syscall.NewLazyDLL("user32.dll")
+	procIsIconic            = user32.NewProc("IsIconic")
+	procShowWindow          = user32.NewProc("ShowWindow")
+	procSetForegroundWindow = user32.NewProc("SetForegroundWindow")
+)
+
+// swRestore is ShowWindow's SW_RESTORE.
+const swRestore = 9
+
+// focusWindow brings the window to the front, restoring it if minimized.
+// Call it on the main thread.
+func focusWindow(window unsafe.Pointer) {
+	if window == nil {
+		return
+	}
+	hwnd := uintptr(window)
+	if iconic, _, _ := procIsIconic.Call(hwnd); iconic != 0 {
+		procShowWindow.Call(hwnd, swRestore)
+	}
+	procSetForegroundWindow.Call(hwnd)
+}
@@ -0,0 +1,174 @@
+package desktop
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAlreadyRunning is returned by AcquireInstanceLock, and by Run with
+// Config.SingleInstance, when another instance of the app is running.
+// Run has already handed over to it, so main should just exit.
+var ErrAlreadyRunning = errors.New("another instance is already running")
+
+// instanceTimeout bounds each exchange between instances.
+const instanceTimeout = 5 * time.Second
+
+// InstanceMessage is what a second launch of a single-instance app sends
+// the running instance.
+type InstanceMessage struct {
+	Args []string `json:"args"`           // Command-line arguments of the second launch, without the program name
+	Path string   `json:"path,omitempty"` // Local path to open (its Config.OpenPath), e.g. from a deep link
+}
+
+// InstanceLock marks the running instance of an app. It's a Unix domain
+// socket named after the app ID, so it goes away with the process, even
+// after a crash, and later launches reach the running instance through it.
+type InstanceLock struct {
+	ln   net.Listener
+	path string
+	wg   sync.WaitGroup
+}
+
+// AcquireInstanceLock takes the single-instance lock for the app id (such
+// as "com.example.todo"), or returns ErrAlreadyRunning if another process
+// holds it. A socket left behind by a crashed instance is replaced.
+func AcquireInstanceLock(id string) (*InstanceLock, error) {
+	path, err := instanceSocketPath(id)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; ; attempt++ {
+		ln, err := net.Listen("unix", path)
+		if err == nil {
+			return &InstanceLock{ln: ln, path: path}, nil
+		}
+		// The socket exists: either an instance is listening or it's stale
+		if conn, dialErr := net.DialTimeout("unix", path, instanceTimeout); dialErr == nil {
+			conn.Close()
+			return nil, ErrAlreadyRunning
+		}
+		if attempt > 0 {
+			return nil, fmt.Errorf("acquiring instance lock: %w", err)
+		}
+		os.Remove(path)
+	}
+}
+
+// Serve calls fn, on its own goroutine, with each message from a later
+// launch (see SignalInstance), until the lock is released.
+func (l *InstanceLock) Serve(fn func(InstanceMessage)) {
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		for {
+			conn, err := l.ln.Accept()
+			if err != nil {
+				return
+			}
+			msg, err := readInstanceMessage(conn)
+			conn.Close()
+			if err == nil {
+				fn(msg)
+			}
+		}
+	}()
+}
+
+// readInstanceMessage reads a message and acknowledges it.
+func readInstanceMessage(conn net.Conn) (InstanceMessage, error) {
+	conn.SetDeadline(time.Now().Add(instanceTimeout))
+	var msg InstanceMessage
+	if err := json.NewDecoder(io.LimitReader(conn, 1<<20)).Decode(&msg); err != nil {
+		return msg, err
+	}
+	_, err := conn.Write([]byte("ok\n"))
+	return msg, err
+}
+
+// Release gives up the lock, waiting for Serve's goroutine to finish.
+func (l *InstanceLock) Release() error {
+	err := l.ln.Close()
+	l.wg.Wait()
+	return err
+}
+
+// SignalInstance sends msg to the running instance of the app id and
+// waits for it to be received.
+func SignalInstance(id string, msg InstanceMessage) error {
+	path, err := instanceSocketPath(id)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("unix", path, instanceTimeout)
+	if err != nil {
+		return fmt.Errorf("signalling instance: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(instanceTimeout))
+
+	if err := json.NewEncoder(conn).Encode(msg); err != nil {
+		return fmt.Errorf("signalling instance: %w", err)
+	}
+	ack, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || ack != "ok\n" {
+		return fmt.Errorf("signalling instance: no acknowledgement: %v", err)
+	}
+	return nil
+}
+
+// maxSocketPath keeps socket paths within the smallest platform limit
+// (104 bytes on macOS).
+const maxSocketPath = 100
+
+// instanceSocketPath returns the socket for the app id in the temp
+// directory, which is per user on macOS and Windows; elsewhere the user
+// ID is part of the name.
+func instanceSocketPath(id string) (string, error) {
+	if id == "" {
+		return "", errors.New("empty instance id")
+	}
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, id)
+	if uid := os.Getuid(); uid >= 0 {
+		name += "-" + strconv.Itoa(uid)
+	}
+	path := filepath.Join(os.TempDir(), "irgo-"+name+".sock")
+	if len(path) > maxSocketPath {
+		sum := sha256.Sum256([]byte(name))
+		path = filepath.Join(os.TempDir(), "irgo-"+hex.EncodeToString(sum[:8])+".sock")
+	}
+	return path, nil
+}
+
+// handleSecondInstance brings the window to the front for a second launch
+// of a single-instance app and opens the path it was given.
+func (a *App) handleSecondInstance(msg InstanceMessage) {
+	a.dispatch(func(ui webviewUI) {
+		focusWindow(ui.Window())
+	})
+	if msg.Path != "" {
+		if err := a.ReloadTo(msg.Path); err != nil && a.config.Logger != nil {
+			a.config.Logger.Warn("second instance path not opened", slog.String("path", msg.Path), slog.Any("error", err))
+		}
+	}
+	if a.config.OnSecondInstance != nil {
+		a.config.OnSecondInstance(msg)
+	}
+}
@@ -0,0 +1,157 @@
+package desktop
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstanceLock(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	lock, err := AcquireInstanceLock("com.example.todo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A second launch finds the running instance
+	if _, err := AcquireInstanceLock("com.example.todo"); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("expected ErrAlreadyRunning, got %v", err)
+	}
+	// Other apps have their own lock
+	other, err := AcquireInstanceLock("com.example.notes")
+	if err != nil {
+		t.Fatalf("expected a separate lock for another app, got %v", err)
+	}
+	other.Release()
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lock, err = AcquireInstanceLock("com.example.todo")
+	if err != nil {
+		t.Fatalf("expected the lock to be free after Release, got %v", err)
+	}
+	lock.Release()
+}
+
+func TestInstanceLockStaleSocket(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	path, _ := instanceSocketPath("com.example.todo")
+
+	// A crashed instance leaves its socket behind
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected a stale socket: %v", err)
+	}
+
+	lock, err := AcquireInstanceLock("com.example.todo")
+	if err != nil {
+		t.Fatalf("expected a stale socket to be replaced, got %v", err)
+	}
+	lock.Release()
+}
+
+func TestSignalInstance(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	if err := SignalInstance("com.example.todo", InstanceMessage{}); err == nil {
+		t.Error("expected an error with no running instance")
+	}
+
+	lock, err := AcquireInstanceLock("com.example.todo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(chan InstanceMessage, 1)
+	lock.Serve(func(msg InstanceMessage) { got <- msg })
+
+	sent := InstanceMessage{Args: []string{"--open", "todo://lists/2"}, Path: "/lists/2"}
+	if err := SignalInstance("com.example.todo", sent); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case msg := <-got:
+		if strings.Join(msg.Args, " ") != "--open todo://lists/2" || msg.Path != "/lists/2" {
+			t.Errorf("got %+v, want %+v", msg, sent)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+
+	// A bad client doesn't stop the server
+	conn, err := net.Dial("unix", lock.path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conn.Write([]byte("not json\n"))
+	conn.Close()
+	if err := SignalInstance("com.example.todo", InstanceMessage{Path: "/"}); err != nil {
+		t.Fatalf("unexpected error after a bad client: %v", err)
+	}
+	<-got
+
+	lock.Release()
+	if err := SignalInstance("com.example.todo", sent); err == nil {
+		t.Error("expected an error after Release")
+	}
+}
+
+func TestInstanceSocketPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	path, err := instanceSocketPath("com.example/todo app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name := filepath.Base(path); filepath.Dir(path) != dir ||
+		!strings.HasPrefix(name, "irgo-com.example_todo_app") || !strings.HasSuffix(name, ".sock") {
+		t.Errorf("unexpected path %q", path)
+	}
+
+	long, _ := instanceSocketPath(strings.Repeat("x", 200))
+	if len(long) > maxSocketPath {
+		t.Errorf("expected long IDs to be shortened, got %d bytes", len(long))
+	}
+	if _, err := instanceSocketPath(""); err == nil {
+		t.Error("expected an error for an empty ID")
+	}
+}
+
+func TestAppSecondInstance(t *testing.T) {
+	var got []InstanceMessage
+	config := DefaultConfig()
+	config.OnSecondInstance = func(msg InstanceMessage) { got = append(got, msg) }
+	app := New(http.NotFoundHandler(), config)
+	ui := &fakeUI{}
+	app.setUI(ui)
+
+	app.handleSecondInstance(InstanceMessage{Args: []string{"x"}, Path: "/lists/2"})
+	app.handleSecondInstance(InstanceMessage{})
+
+	if ui.focused != 2 {
+		t.Errorf("expected the window to be focused twice, got %d", ui.focused)
+	}
+	if len(ui.navigated) != 1 || ui.navigated[0] != "/lists/2" {
+		t.Errorf("expected navigation to /lists/2 only, got %v", ui.navigated)
+	}
+	if len(got) != 2 || got[0].Path != "/lists/2" {
+		t.Errorf("expected OnSecondInstance for each launch, got %+v", got)
+	}
+	for i, onMain := range ui.mainChecks {
+		if !onMain {
+			t.Errorf("call %d ran outside Dispatch", i)
+		}
+	}
+}
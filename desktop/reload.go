@@ -0,0 +1,80 @@
+package desktop
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unsafe"
+)
+
+// ErrNoWebview is returned when the webview hasn't been created yet
+// (before Run) or has already been destroyed.
+var ErrNoWebview = errors.New("webview not running")
+
+// webviewUI is the subset of webview.WebView used to drive the window
+// from other goroutines.
+type webviewUI interface {
+	Dispatch(f func())
+	Navigate(url string)
+	Eval(js string)
+	Window() unsafe.Pointer
+}
+
+// Reload reloads the current page in the webview.
+// Safe to call from any goroutine, e.g. a handler after a major state change.
+func (a *App) Reload() error {
+	return a.dispatch(func(ui webviewUI) {
+		ui.Eval("window.location.reload()")
+	})
+}
+
+// ReloadTo navigates the webview to path on the app's server.
+// path must be a local path such as "/settings?tab=1"; use Eval to leave
+// the app. Safe to call from any goroutine.
+func (a *App) ReloadTo(path string) error {
+	target, err := reloadURL(a.URL(), path)
+	if err != nil {
+		return err
+	}
+	return a.dispatch(func(ui webviewUI) {
+		ui.Navigate(target)
+	})
+}
+
+// dispatch runs fn with the webview on its main thread.
+// The read lock is held while queueing so the webview can't be destroyed
+// underneath the call.
+func (a *App) dispatch(fn func(ui webviewUI)) error {
+	a.uiMu.RLock()
+	defer a.uiMu.RUnlock()
+	ui := a.ui
+	if ui == nil {
+		return ErrNoWebview
+	}
+	ui.Dispatch(func() { fn(ui) })
+	return nil
+}
+
+// setUI records the webview used by dispatch (nil once it's destroyed).
+func (a *App) setUI(ui webviewUI) {
+	a.uiMu.Lock()
+	a.ui = ui
+	a.uiMu.Unlock()
+}
+
+// reloadURL resolves path against the server base URL, rejecting anything
+// that would navigate to a different origin.
+func reloadURL(base, path string) (string, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid reload path %q: %w", path, err)
+	}
+	if u.IsAbs() || u.Host != "" || strings.HasPrefix(path, "//") {
+		return "", fmt.Errorf("reload path %q must be a local path", path)
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return strings.TrimSuffix(base, "/") + path, nil
+}
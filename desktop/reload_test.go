@@ -0,0 +1,151 @@
+package desktop
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+// fakeUI records calls and runs dispatched functions on its own goroutine,
+// standing in for the webview main thread.
+type fakeUI struct {
+	mu         sync.Mutex
+	dispatched int
+	navigated  []string
+	evaluated  []string
+	focused    int
+	onMain     bool
+	mainChecks []bool
+}
+
+func (f *fakeUI) Dispatch(fn func()) {
+	f.mu.Lock()
+	f.dispatched++
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.mu.Lock()
+		f.onMain = true
+		f.mu.Unlock()
+		fn()
+		f.mu.Lock()
+		f.onMain = false
+		f.mu.Unlock()
+	}()
+	<-done
+}
+
+func (f *fakeUI) Navigate(url string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.navigated = append(f.navigated, url)
+	f.mainChecks = append(f.mainChecks, f.onMain)
+}
+
+func (f *fakeUI) Eval(js string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.evaluated = append(f.evaluated, js)
+	f.mainChecks = append(f.mainChecks, f.onMain)
+}
+
+// Window counts focus requests; the nil window makes focusWindow a no-op.
+func (f *fakeUI) Window() unsafe.Pointer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.focused++
+	f.mainChecks = append(f.mainChecks, f.onMain)
+	return nil
+}
+
+func TestReloadURL(t *testing.T) {
+	tests := []struct {
+		base, path, want string
+		wantErr          bool
+	}{
+		{"http://127.0.0.1:8080", "/settings", "http://127.0.0.1:8080/settings", false},
+		{"http://127.0.0.1:8080", "settings?tab=2", "http://127.0.0.1:8080/settings?tab=2", false},
+		{"http://127.0.0.1:8080/", "/", "http://127.0.0.1:8080/", false},
+		{"http://127.0.0.1:8080", "/items#top", "http://127.0.0.1:8080/items#top", false},
+		{"http://127.0.0.1:8080", "https://example.com/", "", true},
+		{"http://127.0.0.1:8080", "//example.com/x", "", true},
+		{"http://127.0.0.1:8080", "javascript:alert(1)", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := reloadURL(tt.base, tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("reloadURL(%q): expected error, got %q", tt.path, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("reloadURL(%q): unexpected error: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("reloadURL(%q): expected %q, got %q", tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestReloadBeforeRun(t *testing.T) {
+	app := New(http.NotFoundHandler(), DefaultConfig())
+
+	if err := app.Reload(); err != ErrNoWebview {
+		t.Errorf("expected ErrNoWebview from Reload, got %v", err)
+	}
+	if err := app.ReloadTo("/"); err != ErrNoWebview {
+		t.Errorf("expected ErrNoWebview from ReloadTo, got %v", err)
+	}
+}
+
+func TestReloadDispatchesToMainThread(t *testing.T) {
+	app := New(http.NotFoundHandler(), DefaultConfig())
+	ui := &fakeUI{}
+	app.setUI(ui)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := app.Reload(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := app.ReloadTo("/dashboard"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := app.ReloadTo("https://example.com"); err == nil {
+		t.Error("expected error for non-local path")
+	}
+
+	if ui.dispatched != 5 {
+		t.Errorf("expected 5 dispatches, got %d", ui.dispatched)
+	}
+	if len(ui.evaluated) != 4 || ui.evaluated[0] != "window.location.reload()" {
+		t.Errorf("expected 4 reload evals, got %v", ui.evaluated)
+	}
+	// No transport before Run, so the path is used as-is
+	if len(ui.navigated) != 1 || ui.navigated[0] != "/dashboard" {
+		t.Errorf("expected navigation to /dashboard, got %v", ui.navigated)
+	}
+	for i, onMain := range ui.mainChecks {
+		if !onMain {
+			t.Errorf("call %d ran outside Dispatch", i)
+		}
+	}
+
+	app.setUI(nil)
+	if err := app.Reload(); err != ErrNoWebview {
+		t.Errorf("expected ErrNoWebview after the webview closes, got %v", err)
+	}
+}
@@ -21,6 +21,39 @@ func StaticFS(embedded fs.FS, devPath string) http.FileSystem {
 	return http.FS(embedded)
 }
 
+// embeddedStatic holds static files compiled into the binary by
+// `irgo build desktop --embed`; nil otherwise.
+var embeddedStatic fs.FS
+
+// SetEmbeddedStatic registers static files compiled into the binary.
+// `irgo build desktop --embed` generates a call to it from an init function,
+// with the contents of static/ at the root of fsys.
+func SetEmbeddedStatic(fsys fs.FS) {
+	embeddedStatic = fsys
+}
+
+// EmbeddedStatic returns the static files registered with
+// SetEmbeddedStatic, or nil if the binary wasn't built with --embed.
+func EmbeddedStatic() fs.FS {
+	return embeddedStatic
+}
+
+// StaticFiles returns the app's static files for serving under /static/.
+//
+// FindStaticDir only looks on disk (content root, then next to the
+// executable). When it finds a directory, that is served, so editing files in
+// a checkout still works. Otherwise an embedded copy from
+// `irgo build desktop --embed` is used via StaticFS, which is how a
+// self-contained binary serves its assets. Without either, the fallback
+// path from FindStaticDir is served (and 404s).
+func StaticFiles() http.FileSystem {
+	dir := FindStaticDir()
+	if embeddedStatic == nil {
+		return http.Dir(dir)
+	}
+	return StaticFS(embeddedStatic, dir)
+}
+
 // FindResourcePath finds the path to bundled resources.
 // Handles platform-specific app bundle locations.
 func FindResourcePath() string {
@@ -54,12 +87,32 @@ func FindResourcePath() string {
 	return "."
 }
 
+// ContentRootEnv is the environment variable holding the project's content
+// root (the directory containing static/ and templates/). The irgo CLI sets
+// it when run with --root.
+const ContentRootEnv = "IRGO_ROOT"
+
+// ContentRoot returns the configured content root, or "." if unset.
+func ContentRoot() string {
+	if root := os.Getenv(ContentRootEnv); root != "" {
+		return root
+	}
+	return "."
+}
+
 // FindStaticDir finds the static files directory, checking multiple locations.
-// Returns the first valid path found, or "static" as fallback.
+// The content root from IRGO_ROOT is checked first.
+// Returns the first valid path found, or "static" under the root as fallback.
 func FindStaticDir() string {
-	// Check current directory first (development)
-	if _, err := os.Stat("static"); err == nil {
-		return "static"
+	return FindStaticDirIn(ContentRoot())
+}
+
+// FindStaticDirIn finds the static files directory for the given content root.
+func FindStaticDirIn(root string) string {
+	// Check content root first (development)
+	staticDir := filepath.Join(root, "static")
+	if _, err := os.Stat(staticDir); err == nil {
+		return staticDir
 	}
 
 	// Check relative to executable
@@ -71,5 +124,5 @@ func FindStaticDir() string {
 	}
 
 	// Fallback
-	return "static"
+	return staticDir
 }
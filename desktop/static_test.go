@@ -126,3 +126,78 @@ func TestFindStaticDir_Fallback(t *testing.T) {
 		t.Errorf("expected fallback 'static', got %q", result)
 	}
 }
+
+func TestFindStaticDirIn_ContentRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	staticDir := filepath.Join(tmpDir, "web", "static")
+	if err := os.MkdirAll(staticDir, 0755); err != nil {
+		t.Fatalf("failed to create static dir: %v", err)
+	}
+
+	result := FindStaticDirIn(filepath.Join(tmpDir, "web"))
+	if result != staticDir {
+		t.Errorf("expected %q, got %q", staticDir, result)
+	}
+}
+
+func TestFindStaticDir_ContentRootEnv(t *testing.T) {
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "web", "static"), 0755); err != nil {
+		t.Fatalf("failed to create static dir: %v", err)
+	}
+	os.Chdir(tmpDir)
+	t.Setenv(ContentRootEnv, "web")
+
+	if root := ContentRoot(); root != "web" {
+		t.Errorf("expected content root 'web', got %q", root)
+	}
+
+	result := FindStaticDir()
+	if result != filepath.Join("web", "static") {
+		t.Errorf("expected 'web/static', got %q", result)
+	}
+}
+
+func TestStaticFiles_Embedded(t *testing.T) {
+	t.Cleanup(func() { SetEmbeddedStatic(nil) })
+	t.Chdir(t.TempDir())
+	t.Setenv(ContentRootEnv, "")
+
+	SetEmbeddedStatic(fstest.MapFS{
+		"css/app.css": &fstest.MapFile{Data: []byte("embedded")},
+	})
+
+	// No static dir on disk: served from the embedded copy
+	f, err := StaticFiles().Open("/css/app.css")
+	if err != nil {
+		t.Fatalf("expected embedded file, got %v", err)
+	}
+	f.Close()
+
+	// A static dir on disk takes precedence
+	os.MkdirAll(filepath.Join("static", "css"), 0755)
+	os.WriteFile(filepath.Join("static", "css", "disk.css"), []byte("disk"), 0644)
+	if _, err := StaticFiles().Open("/css/disk.css"); err != nil {
+		t.Errorf("expected disk file to be served, got %v", err)
+	}
+	if _, err := StaticFiles().Open("/css/app.css"); err == nil {
+		t.Error("expected embedded copy to be unused when static/ exists on disk")
+	}
+}
+
+func TestStaticFiles_NotEmbedded(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(ContentRootEnv, "")
+	os.MkdirAll("static", 0755)
+	os.WriteFile(filepath.Join("static", "app.js"), []byte("js"), 0644)
+
+	if EmbeddedStatic() != nil {
+		t.Fatal("expected no embedded static files by default")
+	}
+	if _, err := StaticFiles().Open("/app.js"); err != nil {
+		t.Errorf("expected static dir on disk to be served, got %v", err)
+	}
+}
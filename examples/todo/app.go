@@ -10,6 +10,7 @@ import (
 	"github.com/stukennedy/irgo/examples/todo/templates"
 	"github.com/stukennedy/irgo/pkg/render"
 	"github.com/stukennedy/irgo/pkg/router"
+	"github.com/stukennedy/irgo/pkg/services"
 )
 
 // TodoStore is a simple in-memory store
@@ -70,22 +71,31 @@ func (s *TodoStore) Delete(id int64) {
 	delete(s.todos, id)
 }
 
-// Global store and renderer
-var (
-	store    = NewTodoStore()
-	renderer = render.NewTemplRenderer()
-)
+// newServices creates the store and renderer, which handlers get through
+// ctx.Services rather than package globals
+func newServices() services.Services {
+	return services.Services{
+		"store":    NewTodoStore(),
+		"renderer": render.NewTemplRenderer(),
+	}
+}
+
+// todoStore returns the store from the router's services
+func todoStore(ctx *router.Context) *TodoStore {
+	store, _ := services.Get[*TodoStore](ctx.Services(), "store")
+	return store
+}
 
-func setupRouter() *router.Router {
-	r := router.New()
+func setupRouter(svc services.Services) *router.Router {
+	r := router.New(router.WithServices(svc))
 
 	// Serve static files (CSS, JS)
 	r.Static("/static", http.Dir("static"))
 
 	// Home page - renders full page with all todos
 	r.GET("/", func(ctx *router.Context) (string, error) {
-		todos := store.All()
-		return renderer.Render(templates.HomePage(todos))
+		renderer, _ := services.Get[*render.TemplRenderer](ctx.Services(), "renderer")
+		return renderer.Render(templates.HomePage(todoStore(ctx).All()))
 	})
 
 	// Add new todo (Datastar SSE)
@@ -101,7 +111,7 @@ func setupRouter() *router.Router {
 			return ctx.SSE().PatchTempl(templates.ErrorMessage("Title is required"))
 		}
 
-		todo := store.Add(signals.Title)
+		todo := todoStore(ctx).Add(signals.Title)
 		sse := ctx.SSE()
 
 		// Prepend new todo to list
@@ -118,8 +128,11 @@ func setupRouter() *router.Router {
 
 	// Toggle todo completion (Datastar SSE)
 	r.DSPost("/todos/{id}/toggle", func(ctx *router.Context) error {
-		id := parseID(ctx.Param("id"))
-		todo := store.Toggle(id)
+		id, err := ctx.ParamInt("id")
+		if err != nil {
+			return err
+		}
+		todo := todoStore(ctx).Toggle(id)
 		if todo == nil {
 			ctx.NotFound("Todo not found")
 			return nil
@@ -129,24 +142,38 @@ func setupRouter() *router.Router {
 
 	// Delete todo (Datastar SSE)
 	r.DSDelete("/todos/{id}", func(ctx *router.Context) error {
-		id := parseID(ctx.Param("id"))
-		store.Delete(id)
+		id, err := ctx.ParamInt("id")
+		if err != nil {
+			return err
+		}
+		todoStore(ctx).Delete(id)
 
 		// Remove the element from DOM
 		return ctx.SSE().Remove(fmt.Sprintf("#todo-%d", id))
 	})
 
+	// JSON API for non-browser clients, served from the same router
+	r.GET("/api/todos", func(ctx *router.Context) (string, error) {
+		return "", ctx.JSON(todoStore(ctx).All())
+	})
+
+	r.POST("/api/todos", func(ctx *router.Context) (string, error) {
+		var input struct {
+			Title string `json:"title"`
+		}
+		if err := ctx.Bind(&input); err != nil || input.Title == "" {
+			return "", router.NewHTTPError(http.StatusBadRequest, "title is required")
+		}
+		ctx.Status(http.StatusCreated)
+		return "", ctx.JSON(todoStore(ctx).Add(input.Title))
+	})
+
 	return r
 }
 
-func addSampleData() {
+func addSampleData(svc services.Services) {
+	store, _ := services.Get[*TodoStore](svc, "store")
 	store.Add("Learn irgo framework")
 	store.Add("Build a mobile app with Datastar")
 	store.Add("Deploy to iOS and Android")
 }
-
-func parseID(s string) int64 {
-	var id int64
-	fmt.Sscanf(s, "%d", &id)
-	return id
-}
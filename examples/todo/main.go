@@ -5,20 +5,26 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/stukennedy/irgo/examples/todo/templates"
 	"github.com/stukennedy/irgo/mobile"
 	"github.com/stukennedy/irgo/pkg/livereload"
+	"github.com/stukennedy/irgo/pkg/render"
+	"github.com/stukennedy/irgo/pkg/router"
 )
 
 func main() {
 	// Check if running as desktop dev server or mobile initialization
 	if len(os.Args) > 1 && os.Args[1] == "serve" {
-		runDevServer()
+		runDevServer(os.Args[2:])
 		return
 	}
 
@@ -30,34 +36,67 @@ func main() {
 func initMobile() {
 	mobile.Initialize()
 
-	r := setupRouter()
+	svc := newServices()
+	r := setupRouter(svc)
 	mobile.SetHandler(r.Handler())
 
 	// Add sample data
-	addSampleData()
+	addSampleData(svc)
 
 	fmt.Println("Todo app initialized for mobile")
 }
 
-// runDevServer starts an HTTP server for development with live reload
-func runDevServer() {
+// runDevServer starts an HTTP server for development with live reload.
+// It listens on 127.0.0.1 only; --host 0.0.0.0 opts in to serving the
+// network, e.g. for real devices. On Ctrl+C it waits up to
+// --shutdown-timeout for open requests to finish.
+func runDevServer(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	host := flags.String("host", livereload.DefaultHost, "address to listen on (0.0.0.0 to allow other devices)")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 5*time.Second, "how long to wait for open requests on shutdown")
+	flags.Parse(args)
+
 	// Enable dev mode for templates (enables live reload script)
-	templates.DevMode = true
+	render.DevMode = true
 
-	r := setupRouter()
+	svc := newServices()
+	r := setupRouter(svc)
 	lr := livereload.New()
 
 	// Add sample data
-	addSampleData()
+	addSampleData(svc)
 
 	// Set up mux with live reload endpoint
 	mux := http.NewServeMux()
 	mux.HandleFunc("/dev/livereload", lr.Handler())
 	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
-	mux.Handle("/", r.Handler())
+	// Name the route and handler behind each response (X-Irgo-Route, HTML comment)
+	mux.Handle("/", router.DevRoutesMiddleware(r.Handler()))
 
-	port := ":8080"
-	fmt.Printf("Starting dev server at http://localhost%s\n", port)
+	fmt.Printf("Starting dev server at %s\n", livereload.URL(*host, livereload.DefaultPort))
+	if warning := livereload.ExposureWarning(*host); warning != "" {
+		log.Print(warning)
+	}
 	fmt.Printf("Live reload enabled (build time: %d)\n", lr.BuildTime())
-	log.Fatal(http.ListenAndServe(port, mux))
+
+	// Gzip pages, fragments and scripts like a production server would
+	srv := &http.Server{Addr: livereload.Addr(*host, livereload.DefaultPort), Handler: router.CompressionMiddleware(0)(mux)}
+	go func() {
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	fmt.Println("Shutting down...")
+	lr.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		log.Printf("Open requests cut off after %s: %v", *shutdownTimeout, err)
+	}
 }
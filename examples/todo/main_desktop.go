@@ -8,19 +8,20 @@ import (
 	"net/http"
 
 	"github.com/stukennedy/irgo/desktop"
+	"github.com/stukennedy/irgo/pkg/router"
 )
 
 func main() {
 	devMode := flag.Bool("dev", false, "Enable devtools")
 	flag.Parse()
 
-	r := setupRouter()
-	addSampleData()
+	svc := newServices()
+	r := setupRouter(svc)
+	addSampleData(svc)
 
 	// Create HTTP mux with static file serving
 	mux := http.NewServeMux()
-	staticDir := desktop.FindStaticDir()
-	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(staticDir))))
+	mux.Handle("/static/", http.StripPrefix("/static/", router.FileServer(desktop.StaticFiles())))
 	mux.Handle("/", r.Handler())
 
 	// Configure desktop app
@@ -54,10 +54,11 @@ public class IrgoWebSocketBridge: NSObject {
         return response
     }
 
-    /// Close a virtual WebSocket connection
-    public func close(sessionID: String) {
+    /// Close a virtual WebSocket connection with the code and reason passed
+    /// to WebSocket.close in the page
+    public func close(sessionID: String, code: Int = 1000, reason: String = "") {
         do {
-            try MobileWebSocketClose(sessionID)
+            try MobileWebSocketCloseWithCode(sessionID, code, reason)
         } catch {
             print("Error closing WebSocket: \(error)")
         }
@@ -53,7 +53,7 @@
       return url;
     }
     // Don't add if already present
-    if (url.includes("secret=")) {
+    if (/[?&]secret=/.test(url)) {
       return url;
     }
     const separator = url.includes("?") ? "&" : "?";
@@ -163,6 +163,7 @@
       this.extensions = "";
       this.protocol = "";
       this.binaryType = "blob";
+      this.lastPing = null;
 
       // Event handlers
       this.onopen = null;
@@ -201,6 +202,9 @@
             this._dispatchEvent("open", e);
           };
           this._native.onmessage = (e) => {
+            if (this._handlePing(e.data)) {
+              return;
+            }
             this._dispatchEvent("message", e);
           };
           this._native.onclose = (e) => {
@@ -257,6 +261,47 @@
       }
     }
 
+    // Keep-alive envelopes (channel "ping") update lastPing for liveness
+    // checks and aren't passed on to message listeners
+    _handlePing(data) {
+      if (typeof data !== "string" || !data.includes('"channel":"ping"')) {
+        return false;
+      }
+      try {
+        if (JSON.parse(data).channel !== "ping") {
+          return false;
+        }
+      } catch (e) {
+        return false;
+      }
+      this.lastPing = Date.now();
+      return true;
+    }
+
+    // Binary envelopes cross the native bridge as JSON with a base64
+    // payload; turn them back into a Blob or ArrayBuffer (per binaryType)
+    // as a real WebSocket delivers binary frames
+    _decodeBinary(data) {
+      if (typeof data !== "string" || !data.includes('"format":"binary"')) {
+        return data;
+      }
+      let envelope;
+      try {
+        envelope = JSON.parse(data);
+      } catch (e) {
+        return data;
+      }
+      if (envelope.format !== "binary") {
+        return data;
+      }
+      const raw = atob(envelope.payload || "");
+      const bytes = new Uint8Array(raw.length);
+      for (let i = 0; i < raw.length; i++) {
+        bytes[i] = raw.charCodeAt(i);
+      }
+      return this.binaryType === "arraybuffer" ? bytes.buffer : new Blob([bytes]);
+    }
+
     addEventListener(type, listener) {
       if (this._listeners[type]) {
         this._listeners[type].push(listener);
@@ -338,8 +383,8 @@
   // Called by native code when WebSocket message arrives
   window._irgo_ws_message = function (sessionId, data) {
     const ws = VirtualWebSocket._sessions.get(sessionId);
-    if (ws) {
-      ws._dispatchEvent("message", { data, target: ws });
+    if (ws && !ws._handlePing(data)) {
+      ws._dispatchEvent("message", { data: ws._decodeBinary(data), target: ws });
     }
   };
 
@@ -468,6 +513,56 @@
     };
   }
 
+  // ========================================
+  // DEBUG - LOG FRAMEWORK ERRORS TO CONSOLE
+  // ========================================
+
+  // In debug mode the server adds X-Irgo-Error (and X-Request-Id) to error
+  // responses; release builds never send them, so this is a no-op there.
+  function logFrameworkError(method, url, status, getHeader) {
+    const message = getHeader("X-Irgo-Error");
+    if (!message) {
+      return;
+    }
+    const requestId = getHeader("X-Request-Id") || "-";
+    console.error(
+      `[irgo] ${method} ${url} -> ${status} (request ${requestId}): ${message}`,
+    );
+  }
+
+  // X-Irgo-Route names the route and handler that served a response
+  // (dev builds only).
+  function logFrameworkRoute(method, url, getHeader) {
+    const route = getHeader("X-Irgo-Route");
+    if (route) {
+      console.debug(`[irgo] ${method} ${url} <- ${route}`);
+    }
+  }
+
+  const ErrorLoggingFetch = window.fetch;
+  window.fetch = function (input, init) {
+    const isRequest = typeof Request !== "undefined" && input instanceof Request;
+    const method = (init && init.method) || (isRequest ? input.method : "GET");
+    const url = isRequest ? input.url : String(input);
+
+    return ErrorLoggingFetch.call(window, input, init).then((response) => {
+      const getHeader = (name) => response.headers.get(name);
+      logFrameworkError(method, url, response.status, getHeader);
+      logFrameworkRoute(method, url, getHeader);
+      return response;
+    });
+  };
+
+  const ErrorLoggingXHROpen = NativeXHR.prototype.open;
+  NativeXHR.prototype.open = function (method, url) {
+    this.addEventListener("load", () => {
+      const getHeader = (name) => this.getResponseHeader(name);
+      logFrameworkError(method, url, this.status, getHeader);
+      logFrameworkRoute(method, url, getHeader);
+    });
+    return ErrorLoggingXHROpen.apply(this, arguments);
+  };
+
   // ========================================
   // GLOBAL EXPORTS
   // ========================================
@@ -477,6 +572,23 @@
     window.WebSocket = VirtualWebSocket;
   }
 
+  // Desktop: the server rejects WebSocket upgrades without the secret, so
+  // make sure connections opened directly via WebSocket carry it too
+  if (!isNative && getSecret()) {
+    const SecureWebSocket = function (url, protocols) {
+      const secureUrl = addSecretToWsUrl(normalizeWebSocketUrl(String(url)));
+      return protocols === undefined
+        ? new NativeWebSocket(secureUrl)
+        : new NativeWebSocket(secureUrl, protocols);
+    };
+    SecureWebSocket.prototype = NativeWebSocket.prototype;
+    SecureWebSocket.CONNECTING = NativeWebSocket.CONNECTING;
+    SecureWebSocket.OPEN = NativeWebSocket.OPEN;
+    SecureWebSocket.CLOSING = NativeWebSocket.CLOSING;
+    SecureWebSocket.CLOSED = NativeWebSocket.CLOSED;
+    window.WebSocket = SecureWebSocket;
+  }
+
   // Export irgo namespace
   window.irgo = {
     isNative,
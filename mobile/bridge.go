@@ -4,11 +4,19 @@
 package mobile
 
 import (
+	"context"
+	"fmt"
+	"html"
+	"html/template"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/stukennedy/irgo/pkg/adapter"
 	"github.com/stukennedy/irgo/pkg/core"
+	"github.com/stukennedy/irgo/pkg/datastar"
+	"github.com/stukennedy/irgo/pkg/router"
+	"github.com/stukennedy/irgo/pkg/supervisor"
 	"github.com/stukennedy/irgo/pkg/websocket"
 )
 
@@ -21,9 +29,20 @@ var (
 type Bridge struct {
 	adapter *adapter.HTTPAdapter
 	wsHub   *websocket.Hub
+	cookies *core.CookieJar
+	jobs    *supervisor.Supervisor
 	mu      sync.RWMutex
 }
 
+// newBridge creates the global bridge. Must hold bridgeMu.
+func newBridge() *Bridge {
+	return &Bridge{
+		wsHub:   newBridgeHub(),
+		cookies: core.NewCookieJar(),
+		jobs:    supervisor.New(),
+	}
+}
+
 // NativeCallback is implemented by Swift/Kotlin to receive async callbacks.
 type NativeCallback interface {
 	// OnHTMLUpdate is called when new HTML should be swapped into the WebView.
@@ -46,9 +65,7 @@ func Initialize() {
 	defer bridgeMu.Unlock()
 
 	if globalBridge == nil {
-		globalBridge = &Bridge{
-			wsHub: websocket.NewHub(),
-		}
+		globalBridge = newBridge()
 	}
 }
 
@@ -59,9 +76,7 @@ func SetHandler(handler http.Handler) {
 	defer bridgeMu.Unlock()
 
 	if globalBridge == nil {
-		globalBridge = &Bridge{
-			wsHub: websocket.NewHub(),
-		}
+		globalBridge = newBridge()
 	}
 	globalBridge.adapter = adapter.NewHTTPAdapter(handler)
 }
@@ -91,6 +106,12 @@ func GetHub() *websocket.Hub {
 //   - url: Full URL path with query string
 //   - headers: JSON-encoded map[string]string
 //   - body: Request body bytes
+//
+// It's safe to call from several native threads at once; see
+// SetRequestWorkers to bound how many requests run concurrently.
+//
+// Cookies set by responses are kept in the bridge's jar and sent with
+// later requests, alongside any Cookie header passed in; see GetCookies.
 func HandleRequest(method, url, headers string, body []byte) *core.Response {
 	bridgeMu.RLock()
 	b := globalBridge
@@ -106,8 +127,63 @@ func HandleRequest(method, url, headers string, body []byte) *core.Response {
 		Headers: headers,
 		Body:    body,
 	}
+	b.cookies.Apply(req)
+
+	var resp *core.Response
+	runRequest(func() {
+		resp = b.adapter.HandleRequest(req)
+		if isBareNotFound(resp) {
+			// Handlers that don't use the irgo router (e.g. http.ServeMux) fall
+			// back to net/http's plain-text 404; show the styled page instead
+			resp = adapter.NewHTTPAdapter(http.HandlerFunc(router.NotFoundPage)).HandleRequest(req)
+		}
+		if location := resp.GetHeader("Location"); isRedirect(resp.Status) && location != "" {
+			resp = followableRedirect(req, resp, location)
+		}
+	})
+	b.cookies.Update(req, resp)
+	return resp
+}
+
+func isRedirect(status int) bool {
+	return status >= 300 && status < 400 && status != http.StatusNotModified
+}
+
+// followableRedirect turns a handler's redirect to location into a response
+// the WebView can follow, as native scheme handlers can't return redirects
+// (Android rejects 3xx statuses outright). Datastar requests get the SSE
+// redirect router.Context.Redirect sends; navigations get a page that
+// replaces itself with location. Cookies set with the redirect are kept.
+func followableRedirect(req *core.Request, redirect *core.Response, location string) *core.Response {
+	return adapter.NewHTTPAdapter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, cookie := range redirect.GetHeaderValues("Set-Cookie") {
+			w.Header().Add("Set-Cookie", cookie)
+		}
+		if router.IsDatastarRequest(r) {
+			datastar.NewSSE(w, r).Redirect(location)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, redirectPage, html.EscapeString(location), template.JSEscapeString(location))
+	})).HandleRequest(req)
+}
 
-	return b.adapter.HandleRequest(req)
+// redirectPage navigates to the URL given HTML-escaped, then JS-escaped.
+const redirectPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="0;url=%[1]s">
+<script>location.replace("%[2]s")</script>
+</head>
+<body><a href="%[1]s">Continue</a></body>
+</html>`
+
+// isBareNotFound reports whether resp is net/http's default plain-text 404.
+func isBareNotFound(resp *core.Response) bool {
+	return resp.Status == http.StatusNotFound &&
+		resp.GetHeader(router.RouteNotFoundHeader) == "" &&
+		strings.HasPrefix(resp.GetHeader("Content-Type"), "text/plain")
 }
 
 // HandleRequestSimple is a simplified version for basic requests.
@@ -117,8 +193,14 @@ func HandleRequestSimple(method, url string) *core.Response {
 
 // RenderInitialPage renders the initial HTML page for the WebView.
 // This is called once at app startup to get the initial content.
+//
+// If no route matches "/", the not-found page is shown as-is; any other
+// error status means a handler failed and a generic error page is shown.
 func RenderInitialPage() string {
 	resp := HandleRequestSimple("GET", "/")
+	if resp.Status == http.StatusNotFound && resp.GetHeader(router.RouteNotFoundHeader) != "" {
+		return resp.BodyString()
+	}
 	if resp.Status >= 400 {
 		return "<html><body><h1>Error loading app</h1></body></html>"
 	}
@@ -132,15 +214,21 @@ func IsReady() bool {
 	return globalBridge != nil && globalBridge.adapter != nil
 }
 
-// Shutdown cleans up the bridge and closes all connections.
+// Shutdown cleans up the bridge and closes all connections, then cancels
+// background jobs, waiting a few seconds for them to return.
 func Shutdown() {
 	bridgeMu.Lock()
-	defer bridgeMu.Unlock()
+	b := globalBridge
+	globalBridge = nil
+	if b != nil && b.wsHub != nil {
+		b.wsHub.Close()
+	}
+	bridgeMu.Unlock()
 
-	if globalBridge != nil {
-		if globalBridge.wsHub != nil {
-			globalBridge.wsHub.Close()
-		}
-		globalBridge = nil
+	// Outside the lock, so jobs finishing up can still call the bridge
+	if b != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), jobShutdownTimeout)
+		defer cancel()
+		b.jobs.Shutdown(ctx)
 	}
 }
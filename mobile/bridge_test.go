@@ -0,0 +1,468 @@
+package mobile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stukennedy/irgo/pkg/router"
+	"github.com/stukennedy/irgo/pkg/websocket"
+)
+
+func withHandler(t *testing.T, handler http.Handler) {
+	t.Helper()
+	SetHandler(handler)
+	t.Cleanup(Shutdown)
+}
+
+func TestHandleRequestUnmatchedRoute(t *testing.T) {
+	r := router.New()
+	r.GET("/", func(ctx *router.Context) (string, error) {
+		return "<div>Home</div>", nil
+	})
+	withHandler(t, r.Handler())
+
+	resp := HandleRequest("GET", "/missing", `{"Accept":"text/event-stream"}`, nil)
+	if resp.Status != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", resp.Status)
+	}
+	if !strings.Contains(resp.BodyString(), `class="error"`) {
+		t.Errorf("expected styled 404 fragment, got %q", resp.BodyString())
+	}
+
+	resp = HandleRequestSimple("GET", "/missing")
+	if !strings.Contains(resp.BodyString(), "<!DOCTYPE html>") {
+		t.Errorf("expected full 404 page for navigation, got %q", resp.BodyString())
+	}
+}
+
+func TestHandleRequestBareNotFound(t *testing.T) {
+	withHandler(t, http.NewServeMux())
+
+	resp := HandleRequestSimple("GET", "/missing")
+	if resp.Status != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", resp.Status)
+	}
+	if strings.Contains(resp.BodyString(), "404 page not found") {
+		t.Error("expected net/http's plain-text 404 to be replaced")
+	}
+	if resp.GetHeader(router.RouteNotFoundHeader) == "" {
+		t.Errorf("expected %s header", router.RouteNotFoundHeader)
+	}
+}
+
+func TestHandleRequestCustomNotFound(t *testing.T) {
+	r := router.New()
+	r.NotFound(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("<div>Custom 404</div>"))
+	})
+	withHandler(t, r.Handler())
+
+	resp := HandleRequestSimple("GET", "/missing")
+	if resp.BodyString() != "<div>Custom 404</div>" {
+		t.Errorf("expected app's 404 page, got %q", resp.BodyString())
+	}
+}
+
+func TestRenderInitialPageRoutingNotFound(t *testing.T) {
+	withHandler(t, router.New().Handler())
+
+	page := RenderInitialPage()
+	if !strings.Contains(page, "404") || strings.Contains(page, "Error loading app") {
+		t.Errorf("expected not-found page for unmatched root, got %q", page)
+	}
+}
+
+func TestRenderInitialPageHandlerError(t *testing.T) {
+	r := router.New()
+	r.GET("/", func(ctx *router.Context) (string, error) {
+		return "", router.NewHTTPError(http.StatusNotFound, "no such user")
+	})
+	withHandler(t, r.Handler())
+
+	if page := RenderInitialPage(); !strings.Contains(page, "Error loading app") {
+		t.Errorf("expected generic error page for handler error, got %q", page)
+	}
+}
+
+func TestWebSocketStats(t *testing.T) {
+	if got := WebSocketStats(); got != "" {
+		t.Errorf("uninitialized: got %q", got)
+	}
+
+	withHandler(t, http.NotFoundHandler())
+	GetHub().HandleFunc("/ws/chat/", func(s *websocket.Session, req *websocket.Request) (*websocket.Envelope, error) {
+		return nil, nil
+	})
+	id, err := WebSocketConnect("/ws/chat/lobby")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WebSocketSend(id, `{"type":"request"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	var stats websocket.HubStats
+	if err := json.Unmarshal([]byte(WebSocketStats()), &stats); err != nil {
+		t.Fatal(err)
+	}
+	chat := stats.Patterns["/ws/chat/"]
+	if stats.Sessions != 1 || chat.Sessions != 1 || chat.MessagesIn != 1 || stats.URLs["/ws/chat/lobby"] != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+type closeRecorder struct {
+	mu     sync.Mutex
+	closed []string
+}
+
+func (r *closeRecorder) OnMessage(sessionID, data string)   {}
+func (r *closeRecorder) OnError(sessionID, errorMsg string) {}
+func (r *closeRecorder) OnClose(sessionID string, code int, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = append(r.closed, sessionID)
+}
+
+// count returns how many of ids have been closed.
+func (r *closeRecorder) count(ids []string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, id := range r.closed {
+		if slices.Contains(ids, id) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestWebSocketConnectReplacesSessionForURL(t *testing.T) {
+	rec := &closeRecorder{}
+	SetWebSocketCallback(rec)
+	t.Cleanup(func() { SetWebSocketCallback(nil) })
+
+	withHandler(t, http.NotFoundHandler())
+	GetHub().HandleFunc("/ws/chat/", func(s *websocket.Session, req *websocket.Request) (*websocket.Envelope, error) {
+		return nil, nil
+	})
+
+	var ids []string
+	for i := 0; i < 20; i++ {
+		id := "lobby-" + strconv.Itoa(i)
+		if err := WebSocketConnectWithID(id, "/ws/chat/lobby"); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if got := WebSocketSessionCount(); got != 1 {
+		t.Errorf("expected 1 session after repeated connects, got %d", got)
+	}
+	deadline := time.Now().Add(time.Second)
+	for rec.count(ids[:19]) < 19 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := rec.count(ids); got != 19 {
+		t.Errorf("expected OnClose for 19 replaced sessions, got %d", got)
+	}
+
+	// The limit can be lifted for pages that open several sockets
+	SetWebSocketMaxSessionsPerURL(0)
+	t.Cleanup(func() { SetWebSocketMaxSessionsPerURL(1) })
+	WebSocketConnect("/ws/chat/lobby")
+	if got := WebSocketSessionCount(); got != 2 {
+		t.Errorf("expected 2 sessions without a limit, got %d", got)
+	}
+}
+
+func TestWebSocketSendMaxMessageSize(t *testing.T) {
+	withHandler(t, http.NotFoundHandler())
+	GetHub().HandleFunc("/ws/chat/", func(s *websocket.Session, req *websocket.Request) (*websocket.Envelope, error) {
+		return websocket.NewEnvelope("ok"), nil
+	})
+
+	SetWebSocketMaxMessageSize(64, false)
+	t.Cleanup(func() { SetWebSocketMaxMessageSize(1<<20, false) })
+
+	id, err := WebSocketConnect("/ws/chat/lobby")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WebSocketSend(id, `{"type":"request"}`); err != nil {
+		t.Fatalf("expected a small message to be handled, got %v", err)
+	}
+
+	big := `{"type":"request","values":{"text":"` + strings.Repeat("x", 100) + `"}}`
+	if _, err := WebSocketSend(id, big); !errors.Is(err, websocket.ErrMessageTooLarge) {
+		t.Errorf("expected ErrMessageTooLarge, got %v", err)
+	}
+	if got := WebSocketSessionCount(); got != 1 {
+		t.Errorf("expected the session to stay open, got %d sessions", got)
+	}
+
+	SetWebSocketMaxMessageSize(64, true)
+	if _, err := WebSocketSend(id, big); !errors.Is(err, websocket.ErrMessageTooLarge) {
+		t.Errorf("expected ErrMessageTooLarge, got %v", err)
+	}
+	if got := WebSocketSessionCount(); got != 0 {
+		t.Errorf("expected the session to be closed, got %d sessions", got)
+	}
+}
+
+type closeStatusRecorder struct {
+	closeRecorder
+	mu     sync.Mutex
+	status map[string]string
+}
+
+func (r *closeStatusRecorder) OnClose(sessionID string, code int, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status[sessionID] = strconv.Itoa(code) + " " + reason
+}
+
+// wait returns the close status reported for sessionID.
+func (r *closeStatusRecorder) wait(t *testing.T, sessionID string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		got, ok := r.status[sessionID]
+		r.mu.Unlock()
+		if ok {
+			return got
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("OnClose was never called for %s", sessionID)
+	return ""
+}
+
+func TestWebSocketCloseCodes(t *testing.T) {
+	rec := &closeStatusRecorder{status: make(map[string]string)}
+	SetWebSocketCallback(rec)
+	t.Cleanup(func() { SetWebSocketCallback(nil) })
+
+	withHandler(t, http.NotFoundHandler())
+	GetHub().HandleFunc("/ws/", func(s *websocket.Session, req *websocket.Request) (*websocket.Envelope, error) {
+		s.CloseWithCode(websocket.ClosePolicyViolation, "not allowed")
+		return nil, nil
+	})
+
+	// A handler closing the session
+	id, _ := WebSocketConnect("/ws/a")
+	WebSocketSend(id, `{"type":"request"}`)
+	if got := rec.wait(t, id); got != "1008 not allowed" {
+		t.Errorf("unexpected close %q", got)
+	}
+
+	// The page closing it, and a plain close
+	id, _ = WebSocketConnect("/ws/b")
+	WebSocketCloseWithCode(id, 4001, "logged out")
+	if got := rec.wait(t, id); got != "4001 logged out" {
+		t.Errorf("unexpected close %q", got)
+	}
+	id, _ = WebSocketConnect("/ws/c")
+	WebSocketClose(id)
+	if got := rec.wait(t, id); got != "1000 Session closed" {
+		t.Errorf("unexpected close %q", got)
+	}
+}
+
+func TestSetRequestWorkersBoundsConcurrency(t *testing.T) {
+	const workers = 3
+	var mu sync.Mutex
+	active, peak := 0, 0
+	withHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		peak = max(peak, active)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		w.Write([]byte(r.URL.Query().Get("n")))
+	}))
+	SetRequestWorkers(workers)
+	t.Cleanup(func() { SetRequestWorkers(0) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(n string) {
+			defer wg.Done()
+			resp := HandleRequestSimple("GET", "/?n="+n)
+			if resp.Status != http.StatusOK || resp.BodyString() != n {
+				t.Errorf("request %s: got %d %q", n, resp.Status, resp.BodyString())
+			}
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	if peak > workers {
+		t.Errorf("expected at most %d concurrent requests, got %d", workers, peak)
+	}
+}
+
+func TestSetRequestWorkersResize(t *testing.T) {
+	withHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(func() { SetRequestWorkers(0) })
+
+	for _, n := range []int{2, 1, 0, 4} {
+		SetRequestWorkers(n)
+		if resp := HandleRequestSimple("GET", "/"); resp.BodyString() != "ok" {
+			t.Errorf("workers=%d: got %q", n, resp.BodyString())
+		}
+	}
+
+	// A panic reaches the caller, not the worker, which keeps serving
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the handler's panic to reach the caller")
+			}
+		}()
+		HandleRequestSimple("GET", "/panic")
+	}()
+	if resp := HandleRequestSimple("GET", "/"); resp.BodyString() != "ok" {
+		t.Errorf("after panic: got %q", resp.BodyString())
+	}
+}
+
+func TestHandleRequestFollowableRedirect(t *testing.T) {
+	r := router.New()
+	r.POST("/login", func(ctx *router.Context) (string, error) {
+		http.SetCookie(ctx.Response, &http.Cookie{Name: "session", Value: "abc"})
+		ctx.Redirect("/home?tab=1&x=<y>")
+		return "", nil
+	})
+	withHandler(t, r.Handler())
+
+	// A navigation gets a page the WebView follows, not a 3xx
+	resp := HandleRequest("POST", "/login", "{}", nil)
+	if resp.Status != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.Status)
+	}
+	if resp.GetHeader("Location") != "" {
+		t.Errorf("expected no Location header, got %q", resp.GetHeader("Location"))
+	}
+	body := resp.BodyString()
+	if !strings.Contains(body, `content="0;url=/home?tab=1&amp;x=&lt;y&gt;"`) {
+		t.Errorf("expected escaped meta refresh, got %q", body)
+	}
+	if !strings.Contains(body, `location.replace("/home?tab\u003D1\u0026x\u003D\u003Cy\u003E")`) {
+		t.Errorf("expected escaped location.replace, got %q", body)
+	}
+	if got := resp.GetHeaderValues("Set-Cookie"); len(got) != 1 || got[0] != "session=abc" {
+		t.Errorf("expected the redirect's cookie to be kept, got %q", got)
+	}
+}
+
+func TestHandleRequestDatastarRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/save", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/done", http.StatusFound)
+	})
+	mux.HandleFunc("/cached", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	})
+	withHandler(t, mux)
+
+	resp := HandleRequest("POST", "/save", `{"Accept":"text/event-stream"}`, nil)
+	if resp.Status != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.Status)
+	}
+	if ct := resp.GetHeader("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("expected an SSE response, got Content-Type %q", ct)
+	}
+	if !strings.Contains(resp.BodyString(), `window.location.href = "/done"`) {
+		t.Errorf("expected SSE redirect, got %q", resp.BodyString())
+	}
+
+	if resp := HandleRequestSimple("GET", "/cached"); resp.Status != http.StatusNotModified {
+		t.Errorf("expected 304 to pass through, got %d", resp.Status)
+	}
+}
+
+func TestHandleRequestKeepsCookies(t *testing.T) {
+	r := router.New()
+	r.POST("/login", func(ctx *router.Context) (string, error) {
+		http.SetCookie(ctx.Response, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		return "<p>logged in</p>", nil
+	})
+	r.GET("/me", func(ctx *router.Context) (string, error) {
+		c, err := ctx.Request.Cookie("session")
+		if err != nil {
+			return "<p>anonymous</p>", nil
+		}
+		return "<p>" + c.Value + "</p>", nil
+	})
+	withHandler(t, r.Handler())
+
+	if resp := HandleRequestSimple("GET", "/me"); resp.BodyString() != "<p>anonymous</p>" {
+		t.Errorf("expected no session yet, got %q", resp.BodyString())
+	}
+	resp := HandleRequest("POST", "/login", "{}", nil)
+	if got := resp.GetHeaderValues("Set-Cookie"); len(got) != 1 || got[0] != "session=abc; Path=/" {
+		t.Errorf("expected Set-Cookie passed back to native code, got %q", got)
+	}
+	if resp := HandleRequestSimple("GET", "/me"); resp.BodyString() != "<p>abc</p>" {
+		t.Errorf("expected the session cookie to be sent, got %q", resp.BodyString())
+	}
+
+	// Persisted by native code, then restored after a restart
+	saved := GetCookies()
+	Shutdown()
+	SetHandler(r.Handler())
+	if err := SetCookies(saved); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp := HandleRequestSimple("GET", "/me"); resp.BodyString() != "<p>abc</p>" {
+		t.Errorf("expected the restored cookie to be sent, got %q", resp.BodyString())
+	}
+
+	ClearCookies()
+	if resp := HandleRequestSimple("GET", "/me"); resp.BodyString() != "<p>anonymous</p>" {
+		t.Errorf("expected no session after ClearCookies, got %q", resp.BodyString())
+	}
+}
+
+func TestBackgroundJobs(t *testing.T) {
+	Shutdown()
+	if got := BackgroundJobs(); got != "" {
+		t.Errorf("expected no jobs without a bridge, got %s", got)
+	}
+
+	cancelled := make(chan struct{})
+	Go("sync", func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+	if got := BackgroundJobs(); !strings.Contains(got, `"name":"sync"`) {
+		t.Errorf("expected the sync job listed, got %s", got)
+	}
+
+	Shutdown()
+	select {
+	case <-cancelled:
+	default:
+		t.Error("expected Shutdown to wait for the job to be cancelled")
+	}
+}
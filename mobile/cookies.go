@@ -0,0 +1,36 @@
+package mobile
+
+import "errors"
+
+// GetCookies returns the bridge's cookies as a JSON array of objects with
+// name, value, path and (for persistent cookies) expires. Native code can
+// save it, e.g. when the app goes to the background, and restore it with
+// SetCookies on the next launch so sessions survive restarts.
+func GetCookies() string {
+	bridgeMu.RLock()
+	defer bridgeMu.RUnlock()
+	if globalBridge == nil {
+		return "[]"
+	}
+	return globalBridge.cookies.Export()
+}
+
+// SetCookies replaces the bridge's cookies with those from GetCookies.
+// Call it after Initialize or SetHandler.
+func SetCookies(cookies string) error {
+	bridgeMu.RLock()
+	defer bridgeMu.RUnlock()
+	if globalBridge == nil {
+		return errors.New("bridge not initialized")
+	}
+	return globalBridge.cookies.Import(cookies)
+}
+
+// ClearCookies removes the bridge's cookies, e.g. on logout.
+func ClearCookies() {
+	bridgeMu.RLock()
+	defer bridgeMu.RUnlock()
+	if globalBridge != nil {
+		globalBridge.cookies.Clear()
+	}
+}
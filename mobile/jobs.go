@@ -0,0 +1,43 @@
+package mobile
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/stukennedy/irgo/pkg/supervisor"
+)
+
+// jobShutdownTimeout is how long Shutdown waits for background jobs to
+// return once they're cancelled.
+const jobShutdownTimeout = 5 * time.Second
+
+// Go starts a background job owned by the bridge, cancelled by Shutdown
+// (see supervisor.Supervisor.Go). Call it from Go app code, e.g. after
+// SetHandler; it creates the bridge if needed.
+func Go(name string, fn func(ctx context.Context), opts ...supervisor.Option) {
+	bridgeMu.Lock()
+	if globalBridge == nil {
+		globalBridge = newBridge()
+	}
+	jobs := globalBridge.jobs
+	bridgeMu.Unlock()
+
+	jobs.Go(name, fn, opts...)
+}
+
+// BackgroundJobs returns the running jobs (supervisor.JobStatus) as JSON,
+// or an empty string if the bridge isn't initialized.
+func BackgroundJobs() string {
+	bridgeMu.RLock()
+	b := globalBridge
+	bridgeMu.RUnlock()
+	if b == nil {
+		return ""
+	}
+	data, err := json.Marshal(b.jobs.Jobs())
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
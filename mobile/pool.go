@@ -0,0 +1,84 @@
+package mobile
+
+import "sync"
+
+var (
+	// requestPool runs HandleRequest calls when set; see SetRequestWorkers.
+	// requestPoolMu is read-locked for the duration of each call.
+	requestPool   *workerPool
+	requestPoolMu sync.RWMutex
+)
+
+// SetRequestWorkers makes HandleRequest run requests on a pool of n Go
+// workers, so however many native threads call it at once, at most n
+// requests are handled concurrently and the rest wait their turn. Each call
+// still blocks until its response is ready. 0, the default, handles each
+// request directly on the calling thread with no limit.
+//
+// Changing the size waits for requests in flight to finish.
+func SetRequestWorkers(n int) {
+	requestPoolMu.Lock()
+	defer requestPoolMu.Unlock()
+	if requestPool != nil {
+		requestPool.stop()
+		requestPool = nil
+	}
+	if n > 0 {
+		requestPool = newWorkerPool(n)
+	}
+}
+
+// runRequest calls fn on the request pool, or directly if there is none,
+// and returns once it has finished.
+func runRequest(fn func()) {
+	requestPoolMu.RLock()
+	defer requestPoolMu.RUnlock()
+	if requestPool == nil {
+		fn()
+		return
+	}
+	requestPool.run(fn)
+}
+
+// workerPool runs functions on a fixed set of goroutines.
+type workerPool struct {
+	jobs chan func()
+	wg   sync.WaitGroup
+}
+
+func newWorkerPool(size int) *workerPool {
+	p := &workerPool{jobs: make(chan func())}
+	p.wg.Add(size)
+	for i := 0; i < size; i++ {
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				job()
+			}
+		}()
+	}
+	return p
+}
+
+// run queues fn for the next free worker and waits for it to return.
+func (p *workerPool) run(fn func()) {
+	done := make(chan struct{})
+	var recovered any
+	p.jobs <- func() {
+		defer close(done)
+		// Hand a panic back to the caller, as if fn ran there
+		defer func() { recovered = recover() }()
+		fn()
+	}
+	<-done
+	if recovered != nil {
+		panic(recovered)
+	}
+}
+
+// stop shuts the workers down once they're idle. No run may be in progress
+// or start after it.
+func (p *workerPool) stop() {
+	close(p.jobs)
+	p.wg.Wait()
+}
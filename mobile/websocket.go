@@ -3,6 +3,7 @@ package mobile
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/stukennedy/irgo/pkg/websocket"
@@ -28,8 +29,55 @@ var (
 	// pollChannels stores channels for sessions using polling instead of callbacks.
 	pollChannels   = make(map[string]chan string)
 	pollChannelsMu sync.RWMutex
+
+	// wsMaxSessionsPerURL is applied to the bridge's hub; see
+	// SetWebSocketMaxSessionsPerURL.
+	wsMaxSessionsPerURL = 1
+
+	// wsMaxMessageSize and wsCloseOversized are applied to the bridge's
+	// hub and WebSocketSend; see SetWebSocketMaxMessageSize.
+	wsMaxMessageSize = 1 << 20
+	wsCloseOversized = false
 )
 
+// newBridgeHub creates the hub for a new bridge. Must hold bridgeMu.
+func newBridgeHub() *websocket.Hub {
+	hub := websocket.NewHub()
+	hub.SetMaxSessionsPerURL(wsMaxSessionsPerURL)
+	hub.SetMaxMessageSize(wsMaxMessageSize)
+	return hub
+}
+
+// SetWebSocketMaxSessionsPerURL limits how many WebSocket sessions may be
+// open for the same URL. A WebView can race reconnects and open a second
+// socket before closing the first, so by default a new connect replaces
+// the previous session for its URL (1), whose OnClose is then called.
+// Pages that deliberately open several sockets to one URL can raise the
+// limit; 0 means no limit.
+func SetWebSocketMaxSessionsPerURL(n int) {
+	bridgeMu.Lock()
+	defer bridgeMu.Unlock()
+	wsMaxSessionsPerURL = n
+	if globalBridge != nil && globalBridge.wsHub != nil {
+		globalBridge.wsHub.SetMaxSessionsPerURL(n)
+	}
+}
+
+// SetWebSocketMaxMessageSize limits messages passed to WebSocketSend to n
+// bytes (1 MiB by default; 0 means no limit), so a runaway page can't
+// exhaust the app's memory. Larger messages are rejected with an error
+// before they're parsed, and if closeSession is true their session is
+// closed too.
+func SetWebSocketMaxMessageSize(n int, closeSession bool) {
+	bridgeMu.Lock()
+	defer bridgeMu.Unlock()
+	wsMaxMessageSize = n
+	wsCloseOversized = closeSession
+	if globalBridge != nil && globalBridge.wsHub != nil {
+		globalBridge.wsHub.SetMaxMessageSize(n)
+	}
+}
+
 // SetWebSocketCallback registers the native callback handler for WebSocket messages.
 // Called from Swift/Kotlin during initialization.
 func SetWebSocketCallback(cb WebSocketCallback) {
@@ -53,7 +101,7 @@ func WebSocketConnect(url string) (string, error) {
 	}
 
 	// Start goroutine to forward messages from Go to native
-	go forwardSessionMessages(session)
+	go forwardSessionMessages(session, currentWebSocketCallback())
 
 	return session.ID, nil
 }
@@ -70,7 +118,7 @@ func WebSocketConnectWithID(sessionID, url string) error {
 		return err
 	}
 
-	go forwardSessionMessages(session)
+	go forwardSessionMessages(session, currentWebSocketCallback())
 	return nil
 }
 
@@ -84,6 +132,15 @@ func WebSocketSend(sessionID string, data string) (string, error) {
 	}
 
 	envelope, err := hub.HandleMessage(sessionID, []byte(data))
+	if errors.Is(err, websocket.ErrMessageTooLarge) {
+		bridgeMu.RLock()
+		closeSession := wsCloseOversized
+		bridgeMu.RUnlock()
+		if closeSession {
+			WebSocketCloseWithCode(sessionID, websocket.CloseMessageTooBig, "message too large")
+		}
+		return "", fmt.Errorf("%w (%d bytes, limit %d)", err, len(data), hub.MaxMessageSize())
+	}
 	if err != nil {
 		return "", err
 	}
@@ -103,12 +160,19 @@ func WebSocketSend(sessionID string, data string) (string, error) {
 
 // WebSocketClose closes a WebSocket session.
 func WebSocketClose(sessionID string) error {
+	return WebSocketCloseWithCode(sessionID, websocket.CloseNormalClosure, "")
+}
+
+// WebSocketCloseWithCode closes a WebSocket session with the code and
+// reason the page passed to WebSocket.close, for handlers to read from
+// Session.CloseStatus in OnClose.
+func WebSocketCloseWithCode(sessionID string, code int, reason string) error {
 	hub := GetHub()
 	if hub == nil {
 		return errors.New("bridge not initialized")
 	}
 
-	hub.Disconnect(sessionID)
+	hub.DisconnectWithCode(sessionID, code, reason)
 
 	// Clean up poll channel if exists
 	pollChannelsMu.Lock()
@@ -174,12 +238,15 @@ func WebSocketPollBlocking(sessionID string, timeoutMs int) string {
 	return string(data)
 }
 
-// forwardSessionMessages forwards messages from a session to native code.
-func forwardSessionMessages(session *websocket.Session) {
+func currentWebSocketCallback() WebSocketCallback {
 	wsCallbackMu.RLock()
-	cb := wsCallback
-	wsCallbackMu.RUnlock()
+	defer wsCallbackMu.RUnlock()
+	return wsCallback
+}
 
+// forwardSessionMessages forwards messages from a session to cb, the
+// callback registered when it connected.
+func forwardSessionMessages(session *websocket.Session, cb WebSocketCallback) {
 	for envelope := range session.SendChan {
 		data, err := json.Marshal(envelope)
 		if err != nil {
@@ -207,9 +274,13 @@ func forwardSessionMessages(session *websocket.Session) {
 		}
 	}
 
-	// Session closed
+	// Session closed: report the code and reason it was closed with
 	if cb != nil {
-		cb.OnClose(session.ID, 1000, "Session closed")
+		code, reason := session.CloseStatus()
+		if code == websocket.CloseNormalClosure && reason == "" {
+			reason = "Session closed"
+		}
+		cb.OnClose(session.ID, code, reason)
 	}
 }
 
@@ -263,3 +334,18 @@ func WebSocketSessionCountForURL(urlPattern string) int {
 	}
 	return len(hub.SessionsForURL(urlPattern))
 }
+
+// WebSocketStats returns the hub's websocket.HubStats as JSON: session
+// counts by handler pattern and URL, and messages in, out and dropped.
+// Returns an empty string if the bridge isn't initialized.
+func WebSocketStats() string {
+	hub := GetHub()
+	if hub == nil {
+		return ""
+	}
+	data, err := json.Marshal(hub.Stats())
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
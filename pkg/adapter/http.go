@@ -4,9 +4,11 @@ package adapter
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 
 	"github.com/stukennedy/irgo/pkg/core"
 )
@@ -15,12 +17,43 @@ import (
 // This is the key component that enables "virtual HTTP" - executing
 // HTTP handlers without any network I/O.
 type HTTPAdapter struct {
-	handler http.Handler
+	handler         http.Handler
+	maxBodySize     int64 // 0 means no limit
+	maxResponseSize int64
 }
 
+// Option configures an HTTPAdapter.
+type Option func(*HTTPAdapter)
+
+// WithMaxBodySize rejects requests whose body is larger than n bytes with
+// 413 Request Entity Too Large, without calling the handler. The default,
+// 0, accepts any size.
+func WithMaxBodySize(n int64) Option {
+	return func(a *HTTPAdapter) {
+		a.maxBodySize = n
+	}
+}
+
+// WithMaxResponseSize stops buffering a response once its body passes n
+// bytes: further writes fail with ErrResponseTooLarge and the response
+// becomes a 500. The default, 0, buffers any size.
+func WithMaxResponseSize(n int64) Option {
+	return func(a *HTTPAdapter) {
+		a.maxResponseSize = n
+	}
+}
+
+// ErrResponseTooLarge is returned from a handler's Write once the response
+// passes the adapter's WithMaxResponseSize limit.
+var ErrResponseTooLarge = errors.New("adapter: response body too large")
+
 // NewHTTPAdapter creates an adapter for the given http.Handler.
-func NewHTTPAdapter(handler http.Handler) *HTTPAdapter {
-	return &HTTPAdapter{handler: handler}
+func NewHTTPAdapter(handler http.Handler, opts ...Option) *HTTPAdapter {
+	a := &HTTPAdapter{handler: handler}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
 }
 
 // HandleRequest converts a core.Request, executes through the http.Handler,
@@ -29,6 +62,10 @@ func NewHTTPAdapter(handler http.Handler) *HTTPAdapter {
 // No sockets are opened. The request is processed entirely in memory
 // using httptest.ResponseRecorder.
 func (a *HTTPAdapter) HandleRequest(req *core.Request) *core.Response {
+	if a.maxBodySize > 0 && req.ContentLength() > a.maxBodySize {
+		return core.ErrorResponse(http.StatusRequestEntityTooLarge, "Request body too large")
+	}
+
 	// Convert core.Request to *http.Request
 	var body io.Reader
 	if len(req.Body) > 0 {
@@ -43,33 +80,75 @@ func (a *HTTPAdapter) HandleRequest(req *core.Request) *core.Response {
 		httpReq.Header.Set(k, v)
 	}
 
+	// The body is authoritative for length; handlers checking upload sizes
+	// rely on both ContentLength and the header
+	httpReq.ContentLength = req.ContentLength()
+	if httpReq.ContentLength > 0 {
+		httpReq.Header.Set("Content-Length", strconv.FormatInt(httpReq.ContentLength, 10))
+	} else {
+		httpReq.Header.Del("Content-Length")
+	}
+
 	// Create ResponseRecorder to capture output
 	recorder := httptest.NewRecorder()
+	var w http.ResponseWriter = recorder
+	var limited *limitedWriter
+	if a.maxResponseSize > 0 {
+		limited = &limitedWriter{ResponseRecorder: recorder, max: a.maxResponseSize}
+		w = limited
+	}
 
 	// Execute handler directly - no network!
-	a.handler.ServeHTTP(recorder, httpReq)
+	a.handler.ServeHTTP(w, httpReq)
+
+	if limited != nil && limited.exceeded {
+		return core.InternalErrorResponse("Response too large")
+	}
 
-	// Convert back to core.Response
+	// Convert back to core.Response. The recorded body is used as is
+	// rather than copied.
 	result := recorder.Result()
 	defer result.Body.Close()
 
-	respBody, _ := io.ReadAll(result.Body)
-
 	resp := &core.Response{
 		Status: result.StatusCode,
-		Body:   respBody,
+		Body:   recorder.Body.Bytes(),
 	}
 
-	// Flatten response headers
-	respHeaders := make(map[string]string)
-	for k, v := range result.Header {
+	resp.SetHTTPHeader(result.Header)
+	resp.SetTrailers(flattenHeader(result.Trailer))
+
+	return resp
+}
+
+// limitedWriter records a response until its body passes max bytes.
+type limitedWriter struct {
+	*httptest.ResponseRecorder
+	max      int64
+	exceeded bool
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.exceeded || int64(w.Body.Len()+len(p)) > w.max {
+		w.exceeded = true
+		return 0, ErrResponseTooLarge
+	}
+	return w.ResponseRecorder.Write(p)
+}
+
+func (w *limitedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// flattenHeader keeps the first value of each key.
+func flattenHeader(h http.Header) map[string]string {
+	flat := make(map[string]string, len(h))
+	for k, v := range h {
 		if len(v) > 0 {
-			respHeaders[k] = v[0]
+			flat[k] = v[0]
 		}
 	}
-	resp.SetHeaders(respHeaders)
-
-	return resp
+	return flat
 }
 
 // Handler returns the underlying http.Handler.
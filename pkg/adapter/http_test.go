@@ -1,7 +1,10 @@
 package adapter
 
 import (
+	"bytes"
+	"io"
 	"net/http"
+	"strconv"
 	"testing"
 
 	"github.com/stukennedy/irgo/pkg/core"
@@ -128,3 +131,140 @@ func TestHTTPAdapterNotFound(t *testing.T) {
 		t.Errorf("expected status 404, got %d", resp.Status)
 	}
 }
+
+func TestHTTPAdapterBinaryBody(t *testing.T) {
+	// PNG header plus bytes that aren't valid UTF-8
+	payload := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n', 0x00, 0xff, 0xfe, 0x80}
+
+	var gotLength int64
+	var gotHeader string
+	var gotBody []byte
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLength = r.ContentLength
+		gotHeader = r.Header.Get("Content-Length")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	adapter := NewHTTPAdapter(handler)
+
+	req := core.NewRequest("POST", "/upload")
+	req.SetHeader("Content-Type", "image/png")
+	req.SetHeader("Content-Length", "999") // ignored in favour of the actual body
+	req.Body = payload
+
+	resp := adapter.HandleRequest(req)
+
+	if resp.Status != http.StatusCreated {
+		t.Errorf("expected status 201, got %d", resp.Status)
+	}
+	if gotLength != int64(len(payload)) {
+		t.Errorf("expected ContentLength %d, got %d", len(payload), gotLength)
+	}
+	if gotHeader != strconv.Itoa(len(payload)) {
+		t.Errorf("expected Content-Length header %d, got %q", len(payload), gotHeader)
+	}
+	if !bytes.Equal(gotBody, payload) {
+		t.Errorf("expected body bytes unmodified, got %v", gotBody)
+	}
+}
+
+func TestHTTPAdapterEmptyBodyContentLength(t *testing.T) {
+	var gotLength int64 = -1
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLength = r.ContentLength
+	})
+
+	NewHTTPAdapter(handler).HandleRequest(core.NewRequest("GET", "/"))
+
+	if gotLength != 0 {
+		t.Errorf("expected ContentLength 0 for empty body, got %d", gotLength)
+	}
+}
+
+func TestHTTPAdapterMaxBodySize(t *testing.T) {
+	called := false
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(strconv.Itoa(len(body))))
+	})
+	adapter := NewHTTPAdapter(handler, WithMaxBodySize(8))
+
+	req := core.NewRequest("POST", "/upload")
+	req.Body = bytes.Repeat([]byte("x"), 9)
+	resp := adapter.HandleRequest(req)
+	if resp.Status != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d", resp.Status)
+	}
+	if called {
+		t.Error("expected the handler not to be called")
+	}
+
+	req.Body = bytes.Repeat([]byte("x"), 8)
+	resp = adapter.HandleRequest(req)
+	if resp.Status != http.StatusOK || resp.BodyString() != "8" {
+		t.Errorf("expected a body at the limit to be accepted, got %d %q", resp.Status, resp.BodyString())
+	}
+}
+
+func TestHTTPAdapterMaxResponseSize(t *testing.T) {
+	var writeErr error
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("12345"))
+		_, writeErr = w.Write([]byte("6"))
+	})
+
+	resp := NewHTTPAdapter(handler, WithMaxResponseSize(6)).HandleRequest(core.NewRequest("GET", "/"))
+	if resp.Status != http.StatusOK || resp.BodyString() != "123456" {
+		t.Errorf("expected a response at the limit, got %d %q", resp.Status, resp.BodyString())
+	}
+
+	resp = NewHTTPAdapter(handler, WithMaxResponseSize(5)).HandleRequest(core.NewRequest("GET", "/"))
+	if resp.Status != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", resp.Status)
+	}
+	if writeErr != ErrResponseTooLarge {
+		t.Errorf("expected ErrResponseTooLarge from Write, got %v", writeErr)
+	}
+}
+
+func TestHTTPAdapterUnlimitedByDefault(t *testing.T) {
+	large := bytes.Repeat([]byte("x"), 4<<20)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+
+	req := core.NewRequest("POST", "/echo")
+	req.Body = large
+	resp := NewHTTPAdapter(handler).HandleRequest(req)
+	if resp.Status != http.StatusOK || len(resp.Body) != len(large) {
+		t.Errorf("expected %d bytes echoed, got %d %d bytes", len(large), resp.Status, len(resp.Body))
+	}
+}
+
+func TestHTTPAdapterMultipleCookies(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+		w.Header().Set("Content-Type", "text/html")
+	})
+
+	resp := NewHTTPAdapter(handler).HandleRequest(core.NewRequest("GET", "/"))
+
+	cookies := resp.GetHeaderValues("Set-Cookie")
+	if len(cookies) != 2 || cookies[0] != "session=abc; Path=/" || cookies[1] != "theme=dark" {
+		t.Errorf("expected both cookies, got %q", cookies)
+	}
+	// Native code only sees Headers, where they're joined
+	if got := resp.GetHeader("Set-Cookie"); got != "session=abc; Path=/, theme=dark" {
+		t.Errorf("unexpected joined Set-Cookie %q", got)
+	}
+	if got := resp.GetHeaderValues("Content-Type"); len(got) != 1 || got[0] != "text/html" {
+		t.Errorf("expected a single Content-Type, got %q", got)
+	}
+	if got := resp.GetHeaderValues("X-Missing"); got != nil {
+		t.Errorf("expected no values, got %q", got)
+	}
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	req := NewRequestBuilder("POST", "/todos?list=1").
+		Header("X-Custom", "yes").
+		FormBody(map[string]string{"title": "Milk"}).
+		HXTarget("#todo-list").
+		Build()
+
+	if req.Method != "POST" || req.URL != "/todos?list=1" {
+		t.Errorf("unexpected request line %s %s", req.Method, req.URL)
+	}
+	if req.GetHeader("X-Custom") != "yes" {
+		t.Errorf("expected X-Custom header, got %q", req.GetHeader("X-Custom"))
+	}
+	if req.ContentType() != "application/x-www-form-urlencoded" || req.BodyString() != "title=Milk" {
+		t.Errorf("unexpected form body %q (%s)", req.BodyString(), req.ContentType())
+	}
+	if !req.HXRequest() || req.GetHeader("HX-Target") != "#todo-list" || req.HXBoosted() {
+		t.Errorf("unexpected HTMX headers %s", req.Headers)
+	}
+	if got := req.FormValue("title"); got != "Milk" {
+		t.Errorf("expected form value Milk, got %q", got)
+	}
+}
+
+func TestRequestBuilderJSONAndMarkers(t *testing.T) {
+	b := NewRequestBuilder("PUT", "/api/todos/1").JSONBody(map[string]any{"done": true})
+	req := b.Build()
+
+	var body map[string]bool
+	if err := json.Unmarshal(req.Body, &body); err != nil || !body["done"] {
+		t.Errorf("unexpected JSON body %q: %v", req.BodyString(), err)
+	}
+	if req.ContentType() != "application/json" {
+		t.Errorf("expected application/json, got %q", req.ContentType())
+	}
+	if req.HXRequest() || req.IsDatastar() {
+		t.Error("expected no HTMX or Datastar markers")
+	}
+
+	// Later changes don't affect built requests
+	boosted := b.HXBoosted().Build()
+	if !boosted.HXRequest() || !boosted.HXBoosted() {
+		t.Errorf("expected a boosted HTMX request, got %s", boosted.Headers)
+	}
+	if req.HXBoosted() {
+		t.Error("expected the earlier request unchanged")
+	}
+	if !NewRequestBuilder("GET", "/").Datastar().Build().IsDatastar() {
+		t.Error("expected a Datastar request")
+	}
+	if got := NewRequestBuilder("GET", "/").Build(); got.Headers != "{}" || got.Body != nil {
+		t.Errorf("expected an empty request, got %+v", got)
+	}
+}
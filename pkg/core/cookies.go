@@ -0,0 +1,218 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CookieJar keeps the cookies set by responses and sends them with later
+// requests, as a browser would. The mobile bridge has no browser cookie
+// store in front of it, so it keeps one of these between calls.
+//
+// Everything is served from one origin, so domains are ignored; paths and
+// expiry are honoured. It's safe for concurrent use.
+type CookieJar struct {
+	mu      sync.Mutex
+	cookies map[string]jarCookie // Keyed by path and name
+}
+
+// jarCookie is a stored cookie, also its exported JSON form.
+type jarCookie struct {
+	Name    string    `json:"name"`
+	Value   string    `json:"value"`
+	Path    string    `json:"path"`
+	Expires time.Time `json:"expires,omitzero"` // Zero for session cookies
+}
+
+// NewCookieJar creates an empty jar.
+func NewCookieJar() *CookieJar {
+	return &CookieJar{cookies: make(map[string]jarCookie)}
+}
+
+// Apply adds the jar's cookies for req's path to its Cookie header,
+// after any cookies it already has. A cookie already in the header
+// isn't sent twice.
+func (j *CookieJar) Apply(req *Request) {
+	headers := req.GetHeaders()
+	key := headerKey(headers, "Cookie")
+	existing := headers[key]
+	sent := make(map[string]bool)
+	for _, c := range parseCookieHeader(existing) {
+		sent[c.Name] = true
+	}
+
+	var pairs []string
+	if existing != "" {
+		pairs = append(pairs, existing)
+	}
+	for _, c := range j.matching(req.Path()) {
+		if !sent[c.Name] {
+			sent[c.Name] = true
+			pairs = append(pairs, c.Name+"="+c.Value)
+		}
+	}
+	if len(pairs) == 0 {
+		return
+	}
+	if key == "" {
+		key = "Cookie"
+	}
+	headers[key] = strings.Join(pairs, "; ")
+	req.SetHeaders(headers)
+}
+
+// Update stores the cookies resp sets in reply to req, and removes those it
+// expires.
+func (j *CookieJar) Update(req *Request, resp *Response) {
+	now := time.Now()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, line := range resp.GetHeaderValues("Set-Cookie") {
+		c, err := http.ParseSetCookie(line)
+		if err != nil {
+			continue
+		}
+		stored := jarCookie{Name: c.Name, Value: c.Value, Path: c.Path}
+		if !strings.HasPrefix(stored.Path, "/") {
+			stored.Path = defaultCookiePath(req.Path())
+		}
+		switch {
+		case c.MaxAge < 0:
+			stored.Expires = now.Add(-time.Second)
+		case c.MaxAge > 0:
+			stored.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		case !c.Expires.IsZero():
+			stored.Expires = c.Expires
+		}
+		k := stored.Path + "\x00" + stored.Name
+		if !stored.Expires.IsZero() && !stored.Expires.After(now) {
+			delete(j.cookies, k)
+			continue
+		}
+		j.cookies[k] = stored
+	}
+}
+
+// Header returns the Cookie header the jar would send for path, or "".
+func (j *CookieJar) Header(path string) string {
+	var pairs []string
+	for _, c := range j.matching(path) {
+		pairs = append(pairs, c.Name+"="+c.Value)
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// Export returns the jar's unexpired cookies as JSON, for native code to
+// persist and restore with Import.
+func (j *CookieJar) Export() string {
+	now := time.Now()
+	j.mu.Lock()
+	cookies := make([]jarCookie, 0, len(j.cookies))
+	for _, c := range j.cookies {
+		if c.Expires.IsZero() || c.Expires.After(now) {
+			cookies = append(cookies, c)
+		}
+	}
+	j.mu.Unlock()
+	sortCookies(cookies)
+	data, _ := json.Marshal(cookies)
+	return string(data)
+}
+
+// Import replaces the jar's cookies with those from Export.
+func (j *CookieJar) Import(data string) error {
+	var cookies []jarCookie
+	if err := json.Unmarshal([]byte(data), &cookies); err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.cookies = make(map[string]jarCookie, len(cookies))
+	for _, c := range cookies {
+		if c.Path == "" {
+			c.Path = "/"
+		}
+		j.cookies[c.Path+"\x00"+c.Name] = c
+	}
+	return nil
+}
+
+// Clear removes every cookie.
+func (j *CookieJar) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.cookies = make(map[string]jarCookie)
+}
+
+// matching returns the unexpired cookies for path, most specific path
+// first as browsers send them.
+func (j *CookieJar) matching(path string) []jarCookie {
+	now := time.Now()
+	j.mu.Lock()
+	var cookies []jarCookie
+	for _, c := range j.cookies {
+		if (c.Expires.IsZero() || c.Expires.After(now)) && cookiePathMatch(path, c.Path) {
+			cookies = append(cookies, c)
+		}
+	}
+	j.mu.Unlock()
+	sortCookies(cookies)
+	return cookies
+}
+
+func sortCookies(cookies []jarCookie) {
+	sort.Slice(cookies, func(a, b int) bool {
+		if len(cookies[a].Path) != len(cookies[b].Path) {
+			return len(cookies[a].Path) > len(cookies[b].Path)
+		}
+		return cookies[a].Name < cookies[b].Name
+	})
+}
+
+// cookiePathMatch implements RFC 6265 path matching.
+func cookiePathMatch(path, cookiePath string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, cookiePath) {
+		return false
+	}
+	return len(path) == len(cookiePath) || strings.HasSuffix(cookiePath, "/") || path[len(cookiePath)] == '/'
+}
+
+// defaultCookiePath is the RFC 6265 default path for a cookie set in reply
+// to a request for path: its directory.
+func defaultCookiePath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// parseCookieHeader parses a Cookie request header.
+func parseCookieHeader(header string) []*http.Cookie {
+	if header == "" {
+		return nil
+	}
+	cookies, err := http.ParseCookie(header)
+	if err != nil {
+		return nil
+	}
+	return cookies
+}
+
+// headerKey returns the key in headers matching name case-insensitively,
+// or "".
+func headerKey(headers map[string]string, name string) string {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func setCookieResponse(cookies ...string) *Response {
+	r := NewResponse(200)
+	r.SetHTTPHeader(map[string][]string{"Set-Cookie": cookies})
+	return r
+}
+
+func TestCookieJarRoundTrip(t *testing.T) {
+	jar := NewCookieJar()
+	jar.Update(NewRequest("POST", "/login"), setCookieResponse(
+		"session=abc; Path=/; HttpOnly",
+		"theme=dark; Path=/",
+		"cart=1; Path=/shop",
+	))
+
+	req := NewRequest("GET", "/shop/items?page=2")
+	jar.Apply(req)
+	if got := req.GetHeader("Cookie"); got != "cart=1; session=abc; theme=dark" {
+		t.Errorf("unexpected Cookie header %q", got)
+	}
+
+	if got := jar.Header("/shopping"); got != "session=abc; theme=dark" {
+		t.Errorf("expected /shop cookie not to match /shopping, got %q", got)
+	}
+}
+
+func TestCookieJarApplyMergesExisting(t *testing.T) {
+	jar := NewCookieJar()
+	jar.Update(NewRequest("GET", "/"), setCookieResponse("session=abc; Path=/", "theme=dark; Path=/"))
+
+	req := NewRequest("GET", "/")
+	req.SetHeader("cookie", "theme=light")
+	jar.Apply(req)
+	if got := req.GetHeader("cookie"); got != "theme=light; session=abc" {
+		t.Errorf("unexpected Cookie header %q", got)
+	}
+
+	// No cookies, no header
+	req = NewRequest("GET", "/")
+	NewCookieJar().Apply(req)
+	if req.Headers != "{}" {
+		t.Errorf("expected headers untouched, got %s", req.Headers)
+	}
+}
+
+func TestCookieJarExpiry(t *testing.T) {
+	jar := NewCookieJar()
+	jar.Update(NewRequest("GET", "/account/login"), setCookieResponse(
+		"session=abc; Path=/",
+		"scoped=1", // Default path is the request's directory
+		"remember=yes; Max-Age=3600; Path=/",
+	))
+	if got := jar.Header("/account/settings"); got != "scoped=1; remember=yes; session=abc" {
+		t.Errorf("unexpected cookies %q", got)
+	}
+
+	// Logout expires the session
+	jar.Update(NewRequest("POST", "/logout"), setCookieResponse(
+		"session=; Path=/; Max-Age=0",
+		"remember=; Path=/; Expires="+time.Unix(0, 0).UTC().Format(time.RFC1123),
+	))
+	if got := jar.Header("/"); got != "" {
+		t.Errorf("expected cookies to be removed, got %q", got)
+	}
+}
+
+func TestCookieJarExportImport(t *testing.T) {
+	jar := NewCookieJar()
+	jar.Update(NewRequest("GET", "/"), setCookieResponse("session=abc; Path=/", "remember=yes; Max-Age=3600; Path=/"))
+	data := jar.Export()
+	if !strings.Contains(data, `"name":"session"`) || !strings.Contains(data, `"expires"`) {
+		t.Errorf("unexpected export %s", data)
+	}
+
+	restored := NewCookieJar()
+	if err := restored.Import(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := restored.Header("/"); got != "remember=yes; session=abc" {
+		t.Errorf("unexpected restored cookies %q", got)
+	}
+
+	restored.Clear()
+	if got := restored.Header("/"); got != "" {
+		t.Errorf("expected no cookies after Clear, got %q", got)
+	}
+	if err := restored.Import("not json"); err == nil {
+		t.Error("expected error for invalid data")
+	}
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+)
+
+var (
+	// ErrNotForm is returned when the body isn't form-encoded or multipart.
+	ErrNotForm = errors.New("request body is not a form")
+
+	// ErrNotMultipart is returned by MultipartFile for non-multipart bodies.
+	ErrNotMultipart = errors.New("request body is not multipart/form-data")
+
+	// ErrMissingFile is returned by MultipartFile when no file was uploaded
+	// in the field.
+	ErrMissingFile = errors.New("no file uploaded in field")
+)
+
+// FormFile is a file uploaded in a multipart form.
+type FormFile struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// FormValues parses the body of a form post, either
+// application/x-www-form-urlencoded or the non-file fields of
+// multipart/form-data. Unlike FormValue it leaves out the query string.
+func (r *Request) FormValues() (url.Values, error) {
+	mediaType, params, err := r.mediaType()
+	if err != nil {
+		return nil, err
+	}
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(r.Body))
+		if err != nil {
+			return nil, fmt.Errorf("parsing form body: %w", err)
+		}
+		return values, nil
+	case "multipart/form-data":
+		values := url.Values{}
+		err := r.eachPart(params, func(part *multipart.Part) (bool, error) {
+			if part.FileName() != "" {
+				return false, nil
+			}
+			data, err := io.ReadAll(part)
+			if err != nil {
+				return false, err
+			}
+			values.Add(part.FormName(), string(data))
+			return false, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("%w (Content-Type %q)", ErrNotForm, mediaType)
+	}
+}
+
+// FormValue returns the first value for key from the form body, then the
+// query string, like net/http's Request.FormValue. It returns "" if the key
+// is missing or the body can't be parsed.
+func (r *Request) FormValue(key string) string {
+	if values, err := r.FormValues(); err == nil {
+		if vs := values[key]; len(vs) > 0 {
+			return vs[0]
+		}
+	}
+	return r.QueryValue(key)
+}
+
+// MultipartFile returns the first file uploaded in field of a
+// multipart/form-data body.
+func (r *Request) MultipartFile(field string) (*FormFile, error) {
+	mediaType, params, err := r.mediaType()
+	if err != nil {
+		return nil, err
+	}
+	if mediaType != "multipart/form-data" {
+		return nil, fmt.Errorf("%w (Content-Type %q)", ErrNotMultipart, mediaType)
+	}
+
+	var file *FormFile
+	err = r.eachPart(params, func(part *multipart.Part) (bool, error) {
+		if part.FormName() != field || part.FileName() == "" {
+			return false, nil
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return false, err
+		}
+		file = &FormFile{
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Data:        data,
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%w %q", ErrMissingFile, field)
+	}
+	return file, nil
+}
+
+// mediaType parses the Content-Type header.
+func (r *Request) mediaType() (string, map[string]string, error) {
+	ct := r.ContentType()
+	if ct == "" {
+		return "", nil, fmt.Errorf("%w (no Content-Type)", ErrNotForm)
+	}
+	mediaType, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return "", nil, fmt.Errorf("parsing Content-Type %q: %w", ct, err)
+	}
+	return mediaType, params, nil
+}
+
+// eachPart calls fn for each part of a multipart body until it returns
+// true or an error.
+func (r *Request) eachPart(params map[string]string, fn func(*multipart.Part) (bool, error)) error {
+	boundary := params["boundary"]
+	if boundary == "" {
+		return errors.New("multipart body has no boundary")
+	}
+	mr := multipart.NewReader(bytes.NewReader(r.Body), boundary)
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("parsing multipart body: %w", err)
+		}
+		done, err := fn(part)
+		part.Close()
+		if err != nil {
+			return fmt.Errorf("parsing multipart body: %w", err)
+		}
+		if done {
+			return nil
+		}
+	}
+}
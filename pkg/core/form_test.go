@@ -0,0 +1,125 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"testing"
+)
+
+func multipartRequest(t *testing.T) *Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("title", "Report")
+	w.WriteField("tag", "a")
+	w.WriteField("tag", "b")
+	fw, err := w.CreateFormFile("upload", "report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte("id,name\n1,widget\n"))
+	w.Close()
+
+	req := NewRequest("POST", "/upload?title=ignored")
+	req.SetHeader("Content-Type", w.FormDataContentType())
+	req.Body = body.Bytes()
+	return req
+}
+
+func TestRequestFormValues(t *testing.T) {
+	req := NewRequest("POST", "/todos?page=2")
+	req.SetHeader("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	req.Body = []byte("title=Buy+milk&tag=a&tag=b")
+
+	values, err := req.FormValues()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values.Get("title") != "Buy milk" || len(values["tag"]) != 2 {
+		t.Errorf("unexpected values %v", values)
+	}
+	if values.Has("page") {
+		t.Error("expected FormValues to leave out the query string")
+	}
+
+	if got := req.FormValue("title"); got != "Buy milk" {
+		t.Errorf("expected title from body, got %q", got)
+	}
+	if got := req.FormValue("page"); got != "2" {
+		t.Errorf("expected page from query, got %q", got)
+	}
+	if got := req.FormValue("missing"); got != "" {
+		t.Errorf("expected empty value, got %q", got)
+	}
+}
+
+func TestRequestFormValuesErrors(t *testing.T) {
+	req := NewRequest("POST", "/api?q=x")
+	req.SetHeader("Content-Type", "application/json")
+	req.Body = []byte(`{"title":"x"}`)
+	if _, err := req.FormValues(); !errors.Is(err, ErrNotForm) {
+		t.Errorf("expected ErrNotForm, got %v", err)
+	}
+	if got := req.FormValue("q"); got != "x" {
+		t.Errorf("expected query fallback, got %q", got)
+	}
+
+	req = NewRequest("POST", "/")
+	if _, err := req.FormValues(); !errors.Is(err, ErrNotForm) {
+		t.Errorf("expected ErrNotForm without Content-Type, got %v", err)
+	}
+
+	req.SetHeader("Content-Type", "application/x-www-form-urlencoded")
+	req.Body = []byte("title=%zz")
+	if _, err := req.FormValues(); err == nil {
+		t.Error("expected error for malformed body")
+	}
+
+	req.SetHeader("Content-Type", "multipart/form-data")
+	if _, err := req.FormValues(); err == nil {
+		t.Error("expected error for multipart without boundary")
+	}
+
+	req.SetHeader("Content-Type", "multipart/form-data; boundary=xyz")
+	req.Body = []byte("--xyz\r\ntruncated")
+	if _, err := req.FormValues(); err == nil {
+		t.Error("expected error for truncated multipart body")
+	}
+}
+
+func TestRequestMultipart(t *testing.T) {
+	req := multipartRequest(t)
+
+	values, err := req.FormValues()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values.Get("title") != "Report" || len(values["tag"]) != 2 || values.Has("upload") {
+		t.Errorf("unexpected values %v", values)
+	}
+	if got := req.FormValue("title"); got != "Report" {
+		t.Errorf("expected body value before query, got %q", got)
+	}
+
+	file, err := req.MultipartFile("upload")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if file.Filename != "report.csv" || file.ContentType != "application/octet-stream" {
+		t.Errorf("unexpected file %q %q", file.Filename, file.ContentType)
+	}
+	if string(file.Data) != "id,name\n1,widget\n" {
+		t.Errorf("unexpected file data %q", file.Data)
+	}
+
+	if _, err := req.MultipartFile("title"); !errors.Is(err, ErrMissingFile) {
+		t.Errorf("expected ErrMissingFile for a plain field, got %v", err)
+	}
+
+	form := NewRequest("POST", "/")
+	form.SetHeader("Content-Type", "application/x-www-form-urlencoded")
+	if _, err := form.MultipartFile("upload"); !errors.Is(err, ErrNotMultipart) {
+		t.Errorf("expected ErrNotMultipart, got %v", err)
+	}
+}
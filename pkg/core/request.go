@@ -8,6 +8,11 @@ import (
 	"strings"
 )
 
+// WebSocketMessageHeader marks requests made for WebSocket messages by
+// websocket.HTTPHandler, naming the URL of the socket the message arrived
+// on (router.AccessLog tags them "ws").
+const WebSocketMessageHeader = "X-Irgo-Websocket"
+
 // Request represents an HTTP-like request from the mobile bridge.
 // All fields use gomobile-compatible types.
 type Request struct {
@@ -105,11 +110,27 @@ func (r *Request) IsDatastar() bool {
 	return r.GetHeader("Accept") == "text/event-stream"
 }
 
+// HXRequest returns true if this is an HTMX request (HX-Request header is "true").
+func (r *Request) HXRequest() bool {
+	return r.GetHeader("HX-Request") == "true"
+}
+
+// HXBoosted returns true if this is an hx-boost navigation (HX-Boosted header
+// is "true"), which expects a full page rather than a fragment.
+func (r *Request) HXBoosted() bool {
+	return r.GetHeader("HX-Boosted") == "true"
+}
+
 // ContentType returns the Content-Type header value.
 func (r *Request) ContentType() string {
 	return r.GetHeader("Content-Type")
 }
 
+// ContentLength returns the body length in bytes.
+func (r *Request) ContentLength() int64 {
+	return int64(len(r.Body))
+}
+
 // BodyString returns the body as a string.
 func (r *Request) BodyString() string {
 	return string(r.Body)
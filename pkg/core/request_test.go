@@ -90,6 +90,23 @@ func TestRequestIsDatastar(t *testing.T) {
 	}
 }
 
+func TestRequestHTMX(t *testing.T) {
+	req := NewRequest("GET", "/")
+	if req.HXRequest() || req.HXBoosted() {
+		t.Error("expected HXRequest() and HXBoosted() = false for new request")
+	}
+
+	req.SetHeader("HX-Request", "true")
+	if !req.HXRequest() || req.HXBoosted() {
+		t.Error("expected an HTMX request that isn't boosted")
+	}
+
+	req.SetHeader("HX-Boosted", "true")
+	if !req.HXBoosted() {
+		t.Error("expected HXBoosted() = true after setting HX-Boosted header")
+	}
+}
+
 func TestRequestBody(t *testing.T) {
 	req := NewRequest("POST", "/api")
 	req.Body = []byte(`{"name": "test"}`)
@@ -98,3 +115,15 @@ func TestRequestBody(t *testing.T) {
 		t.Errorf("BodyString() = %q, want %q", s, `{"name": "test"}`)
 	}
 }
+
+func TestRequestContentLength(t *testing.T) {
+	req := NewRequest("POST", "/upload")
+	if n := req.ContentLength(); n != 0 {
+		t.Errorf("ContentLength() = %d, want 0", n)
+	}
+
+	req.Body = []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+	if n := req.ContentLength(); n != 6 {
+		t.Errorf("ContentLength() = %d, want 6", n)
+	}
+}
@@ -4,14 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 // Response represents the hypermedia response back to the WebView.
 // Body contains HTML fragments or SSE events for Datastar to process.
 type Response struct {
-	Status  int    // HTTP status code (200, 404, 500, etc.)
-	Headers string // JSON-encoded response headers
-	Body    []byte // HTML fragment (or JSON for capability responses)
+	Status       int    // HTTP status code (200, 404, 500, etc.)
+	Headers      string // JSON-encoded response headers
+	HeaderValues string // JSON-encoded map[string][]string of headers set more than once (empty if none)
+	Body         []byte // HTML fragment (or JSON for capability responses)
+	Trailers     string // JSON-encoded trailers sent after the body (empty if none)
 }
 
 // NewResponse creates a new Response with the given status.
@@ -74,6 +77,79 @@ func (r *Response) SetHeaders(headers map[string]string) {
 	r.Headers = string(data)
 }
 
+// SetHTTPHeader sets the headers from h. A header set more than once, such
+// as Set-Cookie, is joined with ", " in Headers, which native code passes
+// to the WebView (whose cookie parsing accepts the joined form), and kept
+// as separate values in HeaderValues.
+func (r *Response) SetHTTPHeader(h http.Header) {
+	headers := make(map[string]string, len(h))
+	multi := make(map[string][]string)
+	for k, v := range h {
+		if len(v) == 0 {
+			continue
+		}
+		headers[k] = strings.Join(v, ", ")
+		if len(v) > 1 {
+			multi[k] = v
+		}
+	}
+	r.SetHeaders(headers)
+	r.HeaderValues = ""
+	if len(multi) > 0 {
+		data, _ := json.Marshal(multi)
+		r.HeaderValues = string(data)
+	}
+}
+
+// GetHeaderValues returns every value of a response header, such as each
+// Set-Cookie. Lookup is case-insensitive like GetHeader.
+func (r *Response) GetHeaderValues(key string) []string {
+	if r.HeaderValues != "" {
+		var multi map[string][]string
+		if err := json.Unmarshal([]byte(r.HeaderValues), &multi); err == nil {
+			if v, ok := multi[key]; ok {
+				return v
+			}
+			if v, ok := multi[http.CanonicalHeaderKey(key)]; ok {
+				return v
+			}
+		}
+	}
+	if v := r.GetHeader(key); v != "" {
+		return []string{v}
+	}
+	return nil
+}
+
+// GetTrailer returns a response trailer value.
+// Trailer lookup is case-insensitive like GetHeader.
+func (r *Response) GetTrailer(key string) string {
+	trailers := r.GetTrailers()
+	if v, ok := trailers[key]; ok {
+		return v
+	}
+	return trailers[http.CanonicalHeaderKey(key)]
+}
+
+// GetTrailers returns all trailers as a map.
+func (r *Response) GetTrailers() map[string]string {
+	trailers := make(map[string]string)
+	if r.Trailers != "" {
+		json.Unmarshal([]byte(r.Trailers), &trailers)
+	}
+	return trailers
+}
+
+// SetTrailers sets all trailers from a map.
+func (r *Response) SetTrailers(trailers map[string]string) {
+	if len(trailers) == 0 {
+		r.Trailers = ""
+		return
+	}
+	data, _ := json.Marshal(trailers)
+	r.Trailers = string(data)
+}
+
 // BodyString returns the body as a string.
 func (r *Response) BodyString() string {
 	return string(r.Body)
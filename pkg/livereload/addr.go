@@ -0,0 +1,57 @@
+package livereload
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// DefaultHost is the dev server's default bind address: loopback only, so
+// the app under development can't be reached from the network.
+const DefaultHost = "127.0.0.1"
+
+// DefaultPort is the dev server's default port.
+const DefaultPort = 8080
+
+// Addr returns the dev server's listen address for host and port, binding
+// DefaultHost if host is empty.
+func Addr(host string, port int) string {
+	if host == "" {
+		host = DefaultHost
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// URL returns the URL to open the dev server at on this machine.
+func URL(host string, port int) string {
+	if host == "" || IsLoopback(host) || isUnspecified(host) {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// IsLoopback reports whether binding host only accepts connections from
+// this machine.
+func IsLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ExposureWarning returns a warning to print if binding host makes the dev
+// server reachable from other machines, or "" if it's loopback only.
+func ExposureWarning(host string) string {
+	if host == "" || IsLoopback(host) {
+		return ""
+	}
+	return fmt.Sprintf("WARNING: dev server bound to %s is reachable from your network; "+
+		"use it only on trusted networks (the default is %s)", host, DefaultHost)
+}
+
+// isUnspecified reports whether host binds every interface, e.g. 0.0.0.0.
+func isUnspecified(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
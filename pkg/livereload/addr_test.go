@@ -0,0 +1,41 @@
+package livereload
+
+import "testing"
+
+func TestAddrDefaultsToLoopback(t *testing.T) {
+	if got := Addr("", DefaultPort); got != "127.0.0.1:8080" {
+		t.Errorf("expected 127.0.0.1:8080, got %s", got)
+	}
+	if warning := ExposureWarning(""); warning != "" {
+		t.Errorf("expected no warning for the default host, got %q", warning)
+	}
+}
+
+func TestAddrHostOptIn(t *testing.T) {
+	tests := []struct {
+		host     string
+		addr     string
+		url      string
+		loopback bool
+	}{
+		{"127.0.0.1", "127.0.0.1:8080", "http://localhost:8080", true},
+		{"localhost", "localhost:8080", "http://localhost:8080", true},
+		{"::1", "[::1]:8080", "http://localhost:8080", true},
+		{"0.0.0.0", "0.0.0.0:8080", "http://localhost:8080", false},
+		{"192.168.1.20", "192.168.1.20:8080", "http://192.168.1.20:8080", false},
+	}
+	for _, tt := range tests {
+		if got := Addr(tt.host, 8080); got != tt.addr {
+			t.Errorf("%s: expected address %s, got %s", tt.host, tt.addr, got)
+		}
+		if got := URL(tt.host, 8080); got != tt.url {
+			t.Errorf("%s: expected URL %s, got %s", tt.host, tt.url, got)
+		}
+		if got := IsLoopback(tt.host); got != tt.loopback {
+			t.Errorf("%s: expected IsLoopback %v, got %v", tt.host, tt.loopback, got)
+		}
+		if warned := ExposureWarning(tt.host) != ""; warned == tt.loopback {
+			t.Errorf("%s: expected a warning only when not loopback", tt.host)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package livereload
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Action is the set of steps a dev loop takes for changed files.
+type Action uint8
+
+const (
+	// Regenerate runs templ generate
+	Regenerate Action = 1 << iota
+	// BuildCSS rebuilds the Tailwind output
+	BuildCSS
+	// Rebuild rebuilds and restarts the Go server
+	Rebuild
+	// ReloadCSS swaps the page's stylesheets without reloading it
+	// (Server.NotifyCSSReload)
+	ReloadCSS
+	// Reload reloads the page
+	Reload
+)
+
+var actionNames = []string{"regenerate", "build-css", "rebuild", "reload-css", "reload"}
+
+// Has reports whether a includes every step in b.
+func (a Action) Has(b Action) bool {
+	return a&b == b
+}
+
+// String lists the steps, e.g. "regenerate+build-css+reload", or "none".
+func (a Action) String() string {
+	var steps []string
+	for i, name := range actionNames {
+		if a&(1<<i) != 0 {
+			steps = append(steps, name)
+		}
+	}
+	if len(steps) == 0 {
+		return "none"
+	}
+	return strings.Join(steps, "+")
+}
+
+// ignoredDirs hold dependencies and build output, never sources.
+var ignoredDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "tmp": true, "build": true,
+}
+
+// Classify returns the least a change to path needs:
+//
+//   - .templ: regenerate and reload, and rebuild the CSS as Tailwind
+//     scans templates for class names
+//   - .go: rebuild and reload
+//   - .css: rebuild the CSS and swap it in, without a page reload
+//   - .html: rebuild the CSS and reload; .js: reload
+//
+// Generated files (*_templ.go, *_templ.txt, Tailwind's output.css) are
+// produced by those steps and need nothing more, except that a new
+// output.css is swapped in. Tests and anything else are ignored.
+func Classify(path string) Action {
+	path = filepath.ToSlash(path)
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if ignoredDirs[dir] {
+			return 0
+		}
+	}
+
+	name := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(name, "_templ.go"), strings.HasSuffix(name, "_templ.txt"), strings.HasSuffix(name, "_test.go"):
+		return 0
+	case name == "output.css":
+		return ReloadCSS
+	}
+	switch filepath.Ext(name) {
+	case ".templ":
+		return Regenerate | BuildCSS | Reload
+	case ".go":
+		return Rebuild | Reload
+	case ".css":
+		return BuildCSS | ReloadCSS
+	case ".html":
+		return BuildCSS | Reload
+	case ".js":
+		return Reload
+	}
+	return 0
+}
+
+// Plan combines the actions for a set of changed files. A page reload
+// picks up new CSS too, so it replaces ReloadCSS.
+func Plan(paths []string) Action {
+	var action Action
+	for _, path := range paths {
+		action |= Classify(path)
+	}
+	if action.Has(Reload) {
+		action &^= ReloadCSS
+	}
+	return action
+}
+
+// Coalescer batches file changes, so a save that touches several files
+// (or a tool that writes in bursts) runs one combined action. Each batch
+// runs once no change has arrived for the debounce window, and batches
+// never run concurrently: changes made meanwhile go in the next one.
+type Coalescer struct {
+	window time.Duration
+	run    func(Action, []string)
+
+	mu      sync.Mutex
+	pending map[string]bool
+	timer   *time.Timer
+	stopped bool
+
+	runMu sync.Mutex
+}
+
+// NewCoalescer creates a Coalescer that calls run with each batch's
+// combined action and its changed files, sorted. Batches needing no
+// action are dropped.
+func NewCoalescer(window time.Duration, run func(action Action, paths []string)) *Coalescer {
+	return &Coalescer{window: window, run: run, pending: make(map[string]bool)}
+}
+
+// Add records changed files, restarting the debounce window.
+func (c *Coalescer) Add(paths ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		return
+	}
+	for _, path := range paths {
+		c.pending[path] = true
+	}
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.timer = time.AfterFunc(c.window, c.Flush)
+}
+
+// Flush runs the pending batch now, if any.
+func (c *Coalescer) Flush() {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	c.mu.Lock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	paths := make([]string, 0, len(c.pending))
+	for path := range c.pending {
+		paths = append(paths, path)
+	}
+	c.pending = make(map[string]bool)
+	c.mu.Unlock()
+
+	sort.Strings(paths)
+	if action := Plan(paths); action != 0 {
+		c.run(action, paths)
+	}
+}
+
+// Stop discards pending changes and ignores any added later.
+func (c *Coalescer) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.pending = make(map[string]bool)
+}
@@ -0,0 +1,127 @@
+package livereload
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		path string
+		want Action
+	}{
+		{"templates/home.templ", Regenerate | BuildCSS | Reload},
+		{"templates/home_templ.go", 0},
+		{"templates/home_templ.txt", 0},
+		{"handlers/todo.go", Rebuild | Reload},
+		{"handlers/todo_test.go", 0},
+		{"static/css/input.css", BuildCSS | ReloadCSS},
+		{"static/css/output.css", ReloadCSS},
+		{"static/index.html", BuildCSS | Reload},
+		{"static/js/app.js", Reload},
+		{"node_modules/pkg/index.js", 0},
+		{"tmp/main.go", 0},
+		{"build/ios/main.go", 0},
+		{"README.md", 0},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.path); got != tt.want {
+			t.Errorf("Classify(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  Action
+	}{
+		{"templ only", []string{"templates/a.templ", "templates/a_templ.go"}, Regenerate | BuildCSS | Reload},
+		{"css only", []string{"static/css/input.css", "static/css/output.css"}, BuildCSS | ReloadCSS},
+		{"go only", []string{"main.go", "handlers/todo.go"}, Rebuild | Reload},
+		{"css and go", []string{"static/css/input.css", "main.go"}, BuildCSS | Rebuild | Reload},
+		{"templ and go", []string{"templates/a.templ", "main.go"}, Regenerate | BuildCSS | Rebuild | Reload},
+		{"generated only", []string{"templates/a_templ.go", "tmp/main"}, 0},
+		{"empty", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Plan(tt.paths); got != tt.want {
+				t.Errorf("Plan(%v) = %s, want %s", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActionString(t *testing.T) {
+	if got := (Regenerate | Rebuild | Reload).String(); got != "regenerate+rebuild+reload" {
+		t.Errorf("unexpected %q", got)
+	}
+	if got := Action(0).String(); got != "none" {
+		t.Errorf("unexpected %q", got)
+	}
+}
+
+func TestCoalescerBatchesChanges(t *testing.T) {
+	var mu sync.Mutex
+	var actions []Action
+	var batches [][]string
+	done := make(chan struct{}, 4)
+	c := NewCoalescer(20*time.Millisecond, func(action Action, paths []string) {
+		mu.Lock()
+		actions = append(actions, action)
+		batches = append(batches, paths)
+		mu.Unlock()
+		done <- struct{}{}
+	})
+	defer c.Stop()
+
+	c.Add("templates/a.templ")
+	c.Add("templates/a_templ.go", "static/css/output.css")
+	c.Add("templates/a.templ")
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("batch never ran")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(actions) != 1 {
+		t.Fatalf("expected one batch, got %d", len(actions))
+	}
+	if actions[0] != Regenerate|BuildCSS|Reload {
+		t.Errorf("unexpected action %s", actions[0])
+	}
+	if len(batches[0]) != 3 {
+		t.Errorf("expected 3 deduplicated paths, got %v", batches[0])
+	}
+}
+
+func TestCoalescerFlushAndStop(t *testing.T) {
+	var runs int
+	c := NewCoalescer(time.Hour, func(Action, []string) { runs++ })
+
+	c.Add("README.md")
+	c.Flush()
+	if runs != 0 {
+		t.Errorf("expected a batch needing no action to be dropped")
+	}
+
+	c.Add("main.go")
+	c.Flush()
+	if runs != 1 {
+		t.Errorf("expected Flush to run the batch, got %d runs", runs)
+	}
+
+	c.Stop()
+	c.Add("main.go")
+	c.Flush()
+	if runs != 1 {
+		t.Errorf("expected changes after Stop to be ignored, got %d runs", runs)
+	}
+}
@@ -3,7 +3,10 @@ package livereload
 
 import (
 	"fmt"
+	"io/fs"
+	"maps"
 	"net/http"
+	"path/filepath"
 	"sync"
 	"time"
 )
@@ -13,6 +16,8 @@ type Server struct {
 	buildTime int64
 	clients   map[chan string]struct{}
 	mu        sync.RWMutex
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 // New creates a new livereload server with the current build time.
@@ -20,9 +25,16 @@ func New() *Server {
 	return &Server{
 		buildTime: time.Now().UnixNano(),
 		clients:   make(map[chan string]struct{}),
+		done:      make(chan struct{}),
 	}
 }
 
+// Close ends all open live reload streams so http.Server.Shutdown doesn't
+// wait on them; clients reconnect to the next server. Call it before Shutdown.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
 // BuildTime returns the server's build timestamp.
 func (s *Server) BuildTime() int64 {
 	return s.buildTime
@@ -66,8 +78,10 @@ func (s *Server) Handler() http.HandlerFunc {
 			select {
 			case <-r.Context().Done():
 				return
-			case msg := <-clientChan:
-				fmt.Fprintf(w, "event: reload\ndata: %s\n\n", msg)
+			case <-s.done:
+				return
+			case event := <-clientChan:
+				fmt.Fprintf(w, "event: %s\ndata: %d\n\n", event, time.Now().UnixMilli())
 				if f, ok := w.(http.Flusher); ok {
 					f.Flush()
 				}
@@ -83,17 +97,88 @@ func (s *Server) Handler() http.HandlerFunc {
 
 // NotifyReload sends a reload signal to all connected clients.
 func (s *Server) NotifyReload() {
+	s.notify("reload")
+}
+
+// NotifyCSSReload tells connected clients to swap in fresh copies of their
+// stylesheets (a cssreload event) instead of reloading the page, keeping
+// scroll position and form state. Pages with no stylesheet of their own
+// origin to swap reload in full.
+func (s *Server) NotifyCSSReload() {
+	s.notify("cssreload")
+}
+
+// NotifyCSS is NotifyCSSReload.
+//
+// Deprecated: use NotifyCSSReload.
+func (s *Server) NotifyCSS() {
+	s.NotifyCSSReload()
+}
+
+func (s *Server) notify(event string) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	for ch := range s.clients {
 		select {
-		case ch <- "reload":
+		case ch <- event:
 		default:
 			// Skip if channel is full
 		}
 	}
 }
 
+// cssPollInterval is how often WatchCSS checks for changed stylesheets.
+var cssPollInterval = 250 * time.Millisecond
+
+// WatchCSS polls dir for changed .css files and calls NotifyCSSReload when
+// any change, so style edits apply without the full reload a server
+// restart causes (keep .css out of the rebuild watcher's extensions). It
+// runs until the returned stop func is called or the server is closed.
+func (s *Server) WatchCSS(dir string) (stop func()) {
+	quit := make(chan struct{})
+	var once sync.Once
+	go func() {
+		last := cssStamps(dir)
+		ticker := time.NewTicker(cssPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-s.done:
+				return
+			case <-ticker.C:
+				if stamps := cssStamps(dir); !maps.Equal(stamps, last) {
+					last = stamps
+					s.NotifyCSSReload()
+				}
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(quit) }) }
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	size    int64
+	modTime int64
+}
+
+// cssStamps returns the stamp of each .css file under dir.
+func cssStamps(dir string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".css" {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			stamps[path] = fileStamp{info.Size(), info.ModTime().UnixNano()}
+		}
+		return nil
+	})
+	return stamps
+}
+
 // Script returns the JavaScript code to enable live reload.
 // Include this in your HTML during development.
 func Script() string {
@@ -118,11 +203,34 @@ func Script() string {
       retryDelay = 1000;
     });
 
-    es.addEventListener('reload', function(e) {
+    es.addEventListener('reload', function() {
       console.log('[livereload] Reload signal received');
       window.location.reload();
     });
 
+    // Swap each same-origin stylesheet for a fresh copy, removing the old
+    // one once the new one loads so the page never goes unstyled. Anything
+    // that can't be swapped falls back to a full reload.
+    es.addEventListener('cssreload', function(e) {
+      var swapped = 0;
+      document.querySelectorAll('link[rel="stylesheet"]').forEach(function(link) {
+        var url = new URL(link.href, window.location.href);
+        if (url.origin !== window.location.origin) return;
+        url.searchParams.set('livereload', e.data);
+        var next = link.cloneNode();
+        next.href = url.toString();
+        next.onload = function() { link.remove(); };
+        next.onerror = function() { window.location.reload(); };
+        link.after(next);
+        swapped++;
+      });
+      if (swapped === 0) {
+        window.location.reload();
+        return;
+      }
+      console.log('[livereload] Swapped ' + swapped + ' stylesheet(s)');
+    });
+
     es.onerror = function() {
       es.close();
       console.log('[livereload] Connection lost, reconnecting in ' + retryDelay + 'ms...');
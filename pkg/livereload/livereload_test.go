@@ -0,0 +1,105 @@
+package livereload
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// subscribe opens the server's event stream and returns a func reading
+// the next event's name, skipping the initial buildtime event.
+func subscribe(t *testing.T, s *Server) func() string {
+	t.Helper()
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	t.Cleanup(s.Close)
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	events := make(chan string, 8)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if name, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+				events <- name
+			}
+		}
+	}()
+	next := func() string {
+		select {
+		case name := <-events:
+			return name
+		case <-time.After(2 * time.Second):
+			return "timeout"
+		}
+	}
+	if name := next(); name != "buildtime" {
+		t.Fatalf("expected buildtime event first, got %s", name)
+	}
+	// Wait until the stream's client is registered
+	for i := 0; i < 100; i++ {
+		s.mu.RLock()
+		n := len(s.clients)
+		s.mu.RUnlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return next
+}
+
+func TestNotifyEvents(t *testing.T) {
+	s := New()
+	next := subscribe(t, s)
+
+	s.NotifyCSSReload()
+	if name := next(); name != "cssreload" {
+		t.Errorf("expected cssreload event, got %s", name)
+	}
+	s.NotifyReload()
+	if name := next(); name != "reload" {
+		t.Errorf("expected reload event, got %s", name)
+	}
+}
+
+func TestWatchCSS(t *testing.T) {
+	orig := cssPollInterval
+	cssPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { cssPollInterval = orig })
+
+	dir := t.TempDir()
+	css := filepath.Join(dir, "output.css")
+	os.WriteFile(css, []byte("a{}"), 0644)
+
+	s := New()
+	next := subscribe(t, s)
+	stop := s.WatchCSS(dir)
+	defer stop()
+	time.Sleep(3 * cssPollInterval)
+
+	// Only stylesheets count
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644)
+	os.WriteFile(css, []byte("a{color:red}"), 0644)
+	if name := next(); name != "cssreload" {
+		t.Fatalf("expected cssreload event, got %s", name)
+	}
+
+	stop()
+	stop()
+	os.WriteFile(css, []byte("a{color:blue}"), 0644)
+	time.Sleep(5 * cssPollInterval)
+	s.NotifyReload()
+	if name := next(); name != "reload" {
+		t.Errorf("expected no events after stop, got %s", name)
+	}
+}
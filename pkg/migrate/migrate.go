@@ -0,0 +1,160 @@
+// Package migrate applies ordered SQL migrations from an embedded filesystem.
+//
+// It works with any database/sql driver, so apps choose their own (e.g.
+// modernc.org/sqlite for pure-Go desktop and mobile builds):
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//
+//	sub, _ := fs.Sub(migrations, "migrations")
+//	err := migrate.Run(db, sub)
+//
+// Files are applied in lexical order (001_init.sql, 002_add_todos.sql, ...),
+// each in its own transaction. Applied versions are recorded in a table so
+// re-running is a no-op.
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultTable is the table used to track applied migrations.
+const DefaultTable = "schema_migrations"
+
+// Config holds migration settings.
+type Config struct {
+	Table       string           // Tracking table name (default: schema_migrations)
+	Placeholder func(int) string // Bind parameter for the nth argument (default: "?")
+}
+
+// Option configures a migration run.
+type Option func(*Config)
+
+// WithTable sets the tracking table name.
+func WithTable(name string) Option {
+	return func(c *Config) {
+		c.Table = name
+	}
+}
+
+// WithDollarPlaceholders uses $1-style bind parameters (PostgreSQL).
+func WithDollarPlaceholders() Option {
+	return func(c *Config) {
+		c.Placeholder = func(n int) string { return fmt.Sprintf("$%d", n) }
+	}
+}
+
+// Migration is a single versioned SQL file.
+type Migration struct {
+	Version string // File name without the .sql extension
+	SQL     string
+}
+
+// Load reads all .sql files in the root of fsys, sorted by name.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{
+			Version: strings.TrimSuffix(entry.Name(), ".sql"),
+			SQL:     string(data),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Run applies any migrations in fsys that haven't been applied to db yet.
+func Run(db *sql.DB, fsys fs.FS, opts ...Option) error {
+	config := &Config{
+		Table:       DefaultTable,
+		Placeholder: func(int) string { return "?" },
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS " + config.Table + " (version TEXT PRIMARY KEY)"); err != nil {
+		return fmt.Errorf("creating %s: %w", config.Table, err)
+	}
+
+	done, err := appliedVersions(db, config.Table)
+	if err != nil {
+		return err
+	}
+
+	insert := "INSERT INTO " + config.Table + " (version) VALUES (" + config.Placeholder(1) + ")"
+
+	for _, m := range migrations {
+		if done[m.Version] {
+			continue
+		}
+		if err := apply(db, m, insert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appliedVersions returns the set of versions already recorded in table.
+func appliedVersions(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query("SELECT version FROM " + table)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	done := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", table, err)
+		}
+		done[version] = true
+	}
+	return done, rows.Err()
+}
+
+// apply runs a migration and records its version in one transaction.
+func apply(db *sql.DB, m Migration, insert string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("migration %s: %w", m.Version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return fmt.Errorf("migration %s: %w", m.Version, err)
+	}
+	if _, err := tx.Exec(insert, m.Version); err != nil {
+		return fmt.Errorf("migration %s: recording version: %w", m.Version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("migration %s: %w", m.Version, err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package migrate
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// fakeDB is an in-memory stand-in for a SQL database. It understands the
+// tracking-table statements Run issues and records everything else.
+type fakeDB struct {
+	mu       sync.Mutex
+	versions []string
+	executed []string
+}
+
+func (f *fakeDB) Open(string) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+
+type fakeConn struct {
+	db      *fakeDB
+	pending *fakeTx
+}
+
+type fakeTx struct {
+	conn     *fakeConn
+	versions []string
+	executed []string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.pending = &fakeTx{conn: c}
+	return c.pending, nil
+}
+
+func (tx *fakeTx) Commit() error {
+	db := tx.conn.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.versions = append(db.versions, tx.versions...)
+	db.executed = append(db.executed, tx.executed...)
+	tx.conn.pending = nil
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.conn.pending = nil
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return strings.Count(s.query, "?") }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db := s.conn.db
+	tx := s.conn.pending
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+	case strings.HasPrefix(s.query, "INSERT INTO schema_migrations"):
+		tx.versions = append(tx.versions, args[0].(string))
+	case strings.Contains(s.query, "FAIL"):
+		return nil, errors.New("syntax error")
+	default:
+		if tx == nil {
+			db.mu.Lock()
+			db.executed = append(db.executed, s.query)
+			db.mu.Unlock()
+		} else {
+			tx.executed = append(tx.executed, s.query)
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	db := s.conn.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return &fakeRows{versions: append([]string(nil), db.versions...)}, nil
+}
+
+type fakeRows struct {
+	versions []string
+}
+
+func (r *fakeRows) Columns() []string { return []string{"version"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.versions) == 0 {
+		return io.EOF
+	}
+	dest[0] = r.versions[0]
+	r.versions = r.versions[1:]
+	return nil
+}
+
+// openFakeDB opens a fresh fake database.
+func openFakeDB(t *testing.T) (*sql.DB, *fakeDB) {
+	t.Helper()
+	f := &fakeDB{}
+	name := "migrate-fake-" + t.Name()
+	sql.Register(name, f)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db, f
+}
+
+func TestRunAppliesMigrationsOnce(t *testing.T) {
+	db, f := openFakeDB(t)
+	fsys := fstest.MapFS{
+		"002_add_done.sql": {Data: []byte("ALTER TABLE todos ADD done")},
+		"001_init.sql":     {Data: []byte("CREATE TABLE todos")},
+		"README.md":        {Data: []byte("not a migration")},
+	}
+
+	if err := Run(db, fsys); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"CREATE TABLE todos", "ALTER TABLE todos ADD done"}
+	if strings.Join(f.executed, ";") != strings.Join(want, ";") {
+		t.Errorf("expected migrations in order %v, got %v", want, f.executed)
+	}
+	if strings.Join(f.versions, ",") != "001_init,002_add_done" {
+		t.Errorf("expected versions recorded, got %v", f.versions)
+	}
+
+	// Re-running skips applied migrations
+	if err := Run(db, fsys); err != nil {
+		t.Fatalf("unexpected error on re-run: %v", err)
+	}
+	if len(f.executed) != 2 {
+		t.Errorf("expected no migrations on re-run, got %v", f.executed)
+	}
+
+	// New migrations are picked up
+	fsys["003_add_index.sql"] = &fstest.MapFile{Data: []byte("CREATE INDEX todos_done")}
+	if err := Run(db, fsys); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(f.executed) != 3 || f.executed[2] != "CREATE INDEX todos_done" {
+		t.Errorf("expected only the new migration to run, got %v", f.executed)
+	}
+}
+
+func TestRunStopsOnFailure(t *testing.T) {
+	db, f := openFakeDB(t)
+	fsys := fstest.MapFS{
+		"001_init.sql":   {Data: []byte("CREATE TABLE todos")},
+		"002_broken.sql": {Data: []byte("FAIL")},
+		"003_later.sql":  {Data: []byte("CREATE TABLE later")},
+	}
+
+	err := Run(db, fsys)
+	if err == nil || !strings.Contains(err.Error(), "002_broken") {
+		t.Fatalf("expected error naming the failed migration, got %v", err)
+	}
+	if strings.Join(f.versions, ",") != "001_init" {
+		t.Errorf("expected only successful migrations recorded, got %v", f.versions)
+	}
+	if len(f.executed) != 1 {
+		t.Errorf("expected later migrations not to run, got %v", f.executed)
+	}
+}
+
+func TestLoadSortsByVersion(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"010_c.sql": {Data: []byte("c")},
+		"002_b.sql": {Data: []byte("b")},
+		"001_a.sql": {Data: []byte("a")},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var versions []string
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+	if strings.Join(versions, ",") != "001_a,002_b,010_c" {
+		t.Errorf("unexpected order: %v", versions)
+	}
+}
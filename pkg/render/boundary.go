@@ -0,0 +1,46 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"runtime/debug"
+
+	"github.com/a-h/templ"
+)
+
+// Boundary renders component, or fallback if it fails, so one broken
+// widget doesn't take the whole page down:
+//
+//	@render.Boundary(widgets.Weather(city), render.Raw(`<p>Weather unavailable</p>`))
+//
+// component is rendered to a buffer first, so none of its partial output
+// reaches the page. If it returns an error or panics, the failure is
+// logged (with the stack, for panics) and fallback is rendered instead; a
+// nil fallback renders nothing. Errors from fallback are returned.
+func Boundary(component, fallback templ.Component) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if err := renderRecovered(ctx, component, buf); err != nil {
+			log.Printf("irgo: render boundary: %v", err)
+			if fallback == nil {
+				return nil
+			}
+			return fallback.Render(ctx, w)
+		}
+		_, err := buf.WriteTo(w)
+		return err
+	})
+}
+
+// renderRecovered renders component, turning a panic into an error.
+func renderRecovered(ctx context.Context, component templ.Component, w io.Writer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return component.Render(ctx, w)
+}
@@ -0,0 +1,57 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+func TestBoundary(t *testing.T) {
+	var logs bytes.Buffer
+	orig := log.Writer()
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(orig) })
+
+	panics := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		io.WriteString(w, "<div>half")
+		var m map[string]int
+		m["boom"]++
+		return nil
+	})
+	fails := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		return errors.New("weather service down")
+	})
+	fallback := Raw(`<p>unavailable</p>`)
+
+	page := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		for _, c := range []templ.Component{
+			Raw("<h1>Home</h1>"),
+			Boundary(panics, fallback),
+			Boundary(Raw("<p>ok</p>"), fallback),
+			Boundary(fails, nil),
+			Raw("<footer></footer>"),
+		} {
+			if err := c.Render(ctx, w); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	html, err := RenderComponent(page)
+	if err != nil {
+		t.Fatalf("expected the page to render, got %v", err)
+	}
+	if want := "<h1>Home</h1><p>unavailable</p><p>ok</p><footer></footer>"; html != want {
+		t.Errorf("expected %q, got %q", want, html)
+	}
+	if !strings.Contains(logs.String(), "assignment to entry in nil map") || !strings.Contains(logs.String(), "weather service down") {
+		t.Errorf("expected both failures to be logged, got:\n%s", logs.String())
+	}
+}
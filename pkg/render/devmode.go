@@ -0,0 +1,24 @@
+package render
+
+import "github.com/stukennedy/irgo/pkg/livereload"
+
+// DevMode enables development-only output: LiveReloadScript, and so the
+// live reload script in BaseHTML layouts. Dev servers set it at startup:
+//
+//	render.DevMode = true
+//
+// It's a plain variable, read on every render without locking, so set it
+// once before serving and don't change it while requests are in flight.
+var DevMode bool
+
+// LiveReloadScript returns the live reload script (livereload.Script) in
+// DevMode, and nothing otherwise, so layouts can include it
+// unconditionally. In a templ layout, before </body>:
+//
+//	@render.LiveReloadScript()
+func LiveReloadScript() RawHTML {
+	if !DevMode {
+		return ""
+	}
+	return RawHTML(livereload.Script())
+}
@@ -0,0 +1,51 @@
+package render
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stukennedy/irgo/pkg/livereload"
+)
+
+func TestLiveReloadScript(t *testing.T) {
+	t.Cleanup(func() { DevMode = false })
+
+	if got := LiveReloadScript(); got != "" {
+		t.Errorf("expected no script outside DevMode, got %q", got)
+	}
+	DevMode = true
+	if got := LiveReloadScript(); string(got) != livereload.Script() {
+		t.Errorf("expected livereload.Script in DevMode, got %q", got)
+	}
+}
+
+func TestBaseHTMLLiveReload(t *testing.T) {
+	t.Cleanup(func() { DevMode = false })
+	r := NewTemplRenderer()
+	layout := MustLayout(BaseHTML)
+	req := httptest.NewRequest("GET", "/", nil)
+
+	html, err := r.RenderPage(req, layout, "Home", Raw("<p>hi</p>"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(html, "/dev/livereload") {
+		t.Errorf("expected no live reload script outside DevMode:\n%s", html)
+	}
+
+	DevMode = true
+	html, err = r.RenderPage(req, layout, "Home", Raw("<p>hi</p>"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(html, livereload.Script()+"\n</body>") {
+		t.Errorf("expected the live reload script before </body> in DevMode:\n%s", html)
+	}
+
+	// Fragments never get it
+	req.Header.Set("HX-Request", "true")
+	if html, _ := r.RenderPage(req, layout, "Home", Raw("<p>hi</p>")); html != "<p>hi</p>" {
+		t.Errorf("expected the bare fragment, got %q", html)
+	}
+}
@@ -0,0 +1,71 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"reflect"
+
+	"github.com/a-h/templ"
+)
+
+// EscapeHTML escapes s for use as HTML text or a quoted attribute value.
+// Use it for any user input placed in string-built fragments or
+// websocket.Envelope payloads, which are inserted into the page as HTML.
+func EscapeHTML(s string) string {
+	return html.EscapeString(s)
+}
+
+// RawHTML is markup that is trusted to be safe and is written unescaped.
+// It is a templ.Component, so it can be used directly in templ templates.
+type RawHTML string
+
+var _ templ.Component = RawHTML("")
+
+// Raw marks s as trusted HTML. Only use it for markup you generated or
+// sanitized yourself, never for user input.
+func Raw(s string) RawHTML {
+	return RawHTML(s)
+}
+
+// Render writes the HTML unescaped.
+func (h RawHTML) Render(ctx context.Context, w io.Writer) error {
+	_, err := io.WriteString(w, string(h))
+	return err
+}
+
+// String returns the HTML.
+func (h RawHTML) String() string {
+	return string(h)
+}
+
+// HTMLf formats a fragment like fmt.Sprintf, escaping every argument except
+// RawHTML values:
+//
+//	render.HTMLf(`<li class="msg">%s: %s</li>`, user.Name, render.Raw(badge))
+func HTMLf(format string, args ...any) string {
+	escaped := make([]any, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case RawHTML:
+			escaped[i] = string(v)
+		case string:
+			escaped[i] = EscapeHTML(v)
+		case fmt.Stringer:
+			escaped[i] = EscapeHTML(v.String())
+		default:
+			// Numbers and bools can't carry markup and keep their verbs
+			// (%d, %.2f); anything else is formatted, then escaped
+			switch reflect.ValueOf(arg).Kind() {
+			case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Float32, reflect.Float64:
+				escaped[i] = arg
+			default:
+				escaped[i] = EscapeHTML(fmt.Sprint(v))
+			}
+		}
+	}
+	return fmt.Sprintf(format, escaped...)
+}
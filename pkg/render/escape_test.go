@@ -0,0 +1,53 @@
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestEscapeHTML(t *testing.T) {
+	got := EscapeHTML(`<script>alert("x")</script>`)
+	want := `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;`
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	attr := EscapeHTML(`" onmouseover='steal()'`)
+	if strings.ContainsAny(attr, `"'<>`) {
+		t.Errorf("expected quotes escaped for attribute use, got %q", attr)
+	}
+}
+
+func TestRawPassesThrough(t *testing.T) {
+	markup := `<b class="badge">admin</b>`
+
+	var buf strings.Builder
+	if err := Raw(markup).Render(context.Background(), &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != markup {
+		t.Errorf("expected raw markup %q, got %q", markup, buf.String())
+	}
+
+	html, err := RenderComponent(Raw(markup))
+	if err != nil || html != markup {
+		t.Errorf("expected Raw to render as a templ component, got %q (%v)", html, err)
+	}
+}
+
+func TestHTMLf(t *testing.T) {
+	got := HTMLf(`<li id="msg-%d">%s: %s %s</li>`, 7, "<script>x()</script>", Raw("<b>ok</b>"), stringer("a&b"))
+	want := `<li id="msg-7">&lt;script&gt;x()&lt;/script&gt;: <b>ok</b> a&amp;b</li>`
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if got := HTMLf("%.1f%% %v", 99.5, true); got != "99.5% true" {
+		t.Errorf("expected numbers and bools formatted as-is, got %q", got)
+	}
+}
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
@@ -0,0 +1,112 @@
+package render
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"net/http"
+
+	"github.com/a-h/templ"
+)
+
+// Layout wraps page content in a full HTML document, such as BaseHTML.
+// Use it with TemplRenderer.RenderPage so one handler serves both full
+// page loads and fragment requests.
+type Layout struct {
+	wrap func(title string, content templ.Component) templ.Component
+}
+
+// layoutData is what a template Layout is executed with.
+type layoutData struct {
+	Title      string
+	Content    template.HTML
+	LiveReload template.HTML
+}
+
+// NewLayout parses text as an html/template layout, with DefaultFuncs,
+// where {{.Title}} is the page title (escaped) and {{.Content}} the
+// rendered content (inserted as is), as in BaseHTML. {{.LiveReload}} is
+// LiveReloadScript():
+//
+//	layout := render.MustLayout(render.BaseHTMLWith(page))
+func NewLayout(text string) (*Layout, error) {
+	tmpl, err := template.New("layout").Funcs(DefaultFuncs()).Parse(text)
+	if err != nil {
+		return nil, &TemplateError{Name: "layout", Err: err}
+	}
+	return &Layout{wrap: func(title string, content templ.Component) templ.Component {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			buf := getBuffer()
+			defer putBuffer(buf)
+			if err := content.Render(ctx, buf); err != nil {
+				return err
+			}
+			data := layoutData{
+				Title:      title,
+				Content:    template.HTML(buf.String()),
+				LiveReload: template.HTML(LiveReloadScript()),
+			}
+			if err := tmpl.Execute(w, data); err != nil {
+				return &TemplateError{Name: "layout", Err: err}
+			}
+			return nil
+		})
+	}}, nil
+}
+
+// MustLayout is NewLayout, panicking on a parse error.
+func MustLayout(text string) *Layout {
+	l, err := NewLayout(text)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// TemplLayout makes a Layout from a templ layout component that renders
+// its content with { children... }:
+//
+//	templ Layout(title string) {
+//		<html>...<body>{ children... }</body></html>
+//	}
+//
+//	layout := render.TemplLayout(templates.Layout)
+func TemplLayout(layout func(title string) templ.Component) *Layout {
+	return &Layout{wrap: func(title string, content templ.Component) templ.Component {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			return layout(title).Render(templ.WithChildren(ctx, content), w)
+		})
+	}}
+}
+
+// Page returns content wrapped in the layout.
+func (l *Layout) Page(title string, content templ.Component) templ.Component {
+	return l.wrap(title, content)
+}
+
+// RenderPage renders content for req: on its own for HTMX and Datastar
+// requests, which swap it into the current page, and wrapped in layout
+// for everything else (page loads, hx-boost navigations and HTMX history
+// restores), so a route needs one handler for both:
+//
+//	r.GET("/todos", func(ctx *router.Context) (string, error) {
+//		return renderer.RenderPage(ctx.Request, layout, "Todos", templates.TodoList(todos))
+//	})
+func (r *TemplRenderer) RenderPage(req *http.Request, layout *Layout, title string, content templ.Component) (string, error) {
+	if WantsFragment(req) {
+		return r.Render(content)
+	}
+	return r.Render(layout.Page(title, content))
+}
+
+// WantsFragment reports whether req is asking for a fragment to swap into
+// the current page rather than a full page: an HTMX request that isn't an
+// hx-boost navigation or history restore, or a Datastar request.
+func WantsFragment(req *http.Request) bool {
+	if req.Header.Get("Accept") == "text/event-stream" {
+		return true
+	}
+	return req.Header.Get("HX-Request") == "true" &&
+		req.Header.Get("HX-Boosted") != "true" &&
+		req.Header.Get("HX-History-Restore-Request") != "true"
+}
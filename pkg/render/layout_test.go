@@ -0,0 +1,91 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+func TestRenderPage(t *testing.T) {
+	r := NewTemplRenderer()
+	layout := MustLayout(BaseHTML)
+	content := Raw(`<ul id="list"><li>Buy milk</li></ul>`)
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		fragment bool
+	}{
+		{"page load", nil, false},
+		{"htmx", map[string]string{"HX-Request": "true"}, true},
+		{"hx-boost", map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, false},
+		{"history restore", map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true"}, false},
+		{"datastar", map[string]string{"Accept": "text/event-stream"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/todos", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			html, err := r.RenderPage(req, layout, "Todos & more", content)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.fragment {
+				if html != content.String() {
+					t.Errorf("expected the bare fragment, got %q", html)
+				}
+				return
+			}
+			if !strings.HasPrefix(html, "<!DOCTYPE html>") {
+				t.Errorf("expected a full page, got %q", html)
+			}
+			if !strings.Contains(html, "<title>Todos &amp; more</title>") {
+				t.Errorf("expected the escaped title, got %q", html)
+			}
+			if !strings.Contains(html, `<div id="app">`+"\n        "+content.String()) {
+				t.Errorf("expected the content unescaped in #app, got %q", html)
+			}
+		})
+	}
+}
+
+func TestTemplLayout(t *testing.T) {
+	layout := TemplLayout(func(title string) templ.Component {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			io.WriteString(w, "<html><title>"+title+"</title><body>")
+			if err := templ.GetChildren(ctx).Render(ctx, w); err != nil {
+				return err
+			}
+			_, err := io.WriteString(w, "</body></html>")
+			return err
+		})
+	})
+
+	html, err := NewTemplRenderer().RenderPage(httptest.NewRequest("GET", "/", nil), layout, "Home", Raw("<p>hi</p>"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "<html><title>Home</title><body><p>hi</p></body></html>"; html != want {
+		t.Errorf("got %q, want %q", html, want)
+	}
+}
+
+func TestLayoutErrors(t *testing.T) {
+	if _, err := NewLayout("{{.Title"); err == nil {
+		t.Error("expected a parse error")
+	}
+
+	boom := errors.New("boom")
+	fails := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error { return boom })
+	_, err := NewTemplRenderer().Render(MustLayout(BaseHTML).Page("Home", fails))
+	if !errors.Is(err, boom) {
+		t.Errorf("expected the content's error, got %v", err)
+	}
+}
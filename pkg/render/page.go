@@ -0,0 +1,45 @@
+package render
+
+import (
+	"strings"
+)
+
+// PageOptions configures the head tags that make an app look at home when
+// installed or added to a mobile home screen. Empty fields are left out.
+type PageOptions struct {
+	ThemeColor     string // Browser/status bar color, e.g. "#0f172a"
+	Favicon        string // URL of the favicon, e.g. "/static/favicon.png"
+	AppleTouchIcon string // URL of the iOS home screen icon (180x180 PNG)
+	Manifest       string // URL of the web app manifest
+	StatusBarStyle string // iOS status bar: "default" (the default), "black" or "black-translucent"
+}
+
+// Head returns the tags for o, for use in the <head> of a page. It is a
+// templ.Component, so a layout can render it with @opts.Head().
+func (o PageOptions) Head() RawHTML {
+	style := o.StatusBarStyle
+	if style == "" {
+		style = "default"
+	}
+	tags := []string{HTMLf(`<meta name="apple-mobile-web-app-status-bar-style" content="%s">`, style)}
+	if o.ThemeColor != "" {
+		tags = append(tags, HTMLf(`<meta name="theme-color" content="%s">`, o.ThemeColor))
+	}
+	if o.Favicon != "" {
+		tags = append(tags, HTMLf(`<link rel="icon" href="%s">`, o.Favicon))
+	}
+	if o.AppleTouchIcon != "" {
+		tags = append(tags, HTMLf(`<link rel="apple-touch-icon" href="%s">`, o.AppleTouchIcon))
+	}
+	if o.Manifest != "" {
+		tags = append(tags, HTMLf(`<link rel="manifest" href="%s">`, o.Manifest))
+	}
+	return RawHTML(strings.Join(tags, "\n    "))
+}
+
+// BaseHTMLWith returns BaseHTML with the head tags from opts.
+// BaseHTMLWith(PageOptions{}) is BaseHTML.
+func BaseHTMLWith(opts PageOptions) string {
+	const statusBar = `<meta name="apple-mobile-web-app-status-bar-style" content="default">`
+	return strings.Replace(BaseHTML, statusBar, opts.Head().String(), 1)
+}
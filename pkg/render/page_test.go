@@ -0,0 +1,62 @@
+package render
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestBaseHTMLWithDefaults(t *testing.T) {
+	if got := BaseHTMLWith(PageOptions{}); got != BaseHTML {
+		t.Errorf("expected zero options to give BaseHTML, got:\n%s", got)
+	}
+}
+
+func TestBaseHTMLWithOptions(t *testing.T) {
+	page := BaseHTMLWith(PageOptions{
+		ThemeColor:     "#0f172a",
+		Favicon:        "/static/favicon.png",
+		AppleTouchIcon: "/static/apple-touch-icon.png",
+		Manifest:       "/static/manifest.json",
+		StatusBarStyle: "black-translucent",
+	})
+
+	head, _, ok := strings.Cut(page, "</head>")
+	if !ok {
+		t.Fatalf("no </head> in:\n%s", page)
+	}
+	for _, want := range []string{
+		`<meta name="theme-color" content="#0f172a">`,
+		`<link rel="icon" href="/static/favicon.png">`,
+		`<link rel="apple-touch-icon" href="/static/apple-touch-icon.png">`,
+		`<link rel="manifest" href="/static/manifest.json">`,
+		`<meta name="apple-mobile-web-app-status-bar-style" content="black-translucent">`,
+	} {
+		if !strings.Contains(head, want) {
+			t.Errorf("head missing %s:\n%s", want, head)
+		}
+	}
+	if strings.Contains(head, `content="default"`) {
+		t.Errorf("default status bar style not replaced:\n%s", head)
+	}
+
+	// The result is still a template with the Title and Content fields
+	tmpl, err := template.New("base").Parse(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, map[string]any{"Title": "Todo", "Content": "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "<title>Todo</title>") {
+		t.Errorf("template did not render title:\n%s", out.String())
+	}
+}
+
+func TestPageOptionsHeadEscapes(t *testing.T) {
+	head := PageOptions{ThemeColor: `red"><script>x()</script>`}.Head().String()
+	if strings.Contains(head, "<script>") {
+		t.Errorf("expected attribute value escaped, got %s", head)
+	}
+}
@@ -0,0 +1,97 @@
+package render
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+)
+
+// Platform names used to select static asset variants.
+const (
+	PlatformDesktop = "desktop"
+	PlatformMobile  = "mobile"
+)
+
+// PlatformHeader is the request header that overrides the platform hint.
+// Native shells (or tests) can set it to request a specific asset variant.
+const PlatformHeader = "X-Irgo-Platform"
+
+// PlatformFS serves platform-specific static asset variants.
+// Files are looked up in a platform subdirectory first ("desktop/" or
+// "mobile/"), falling back to the shared root. For example, with:
+//
+//	static/
+//	├── app.css           shared
+//	├── desktop/hero.png  desktop only
+//	└── mobile/hero.png   mobile only
+//
+// a request for /static/hero.png serves the variant for the platform, and
+// /static/app.css is served from the shared root.
+type PlatformFS struct {
+	root     fs.FS
+	platform string
+}
+
+// NewPlatformFS creates a PlatformFS over root. The platform is the default
+// variant; pass BuildPlatform to select by build tag.
+func NewPlatformFS(root fs.FS, platform string) *PlatformFS {
+	if platform == "" {
+		platform = BuildPlatform
+	}
+	return &PlatformFS{root: root, platform: platform}
+}
+
+// Platform returns the default platform variant.
+func (p *PlatformFS) Platform() string {
+	return p.platform
+}
+
+// For returns a filesystem that resolves files for the given platform,
+// falling back to shared files.
+func (p *PlatformFS) For(platform string) fs.FS {
+	if platform == "" {
+		platform = p.platform
+	}
+	return &platformVariantFS{root: p.root, platform: platform}
+}
+
+// Handler returns an http.Handler serving files for the platform given by
+// the X-Irgo-Platform request header, or the default platform if absent.
+// Mount it with http.StripPrefix, e.g.:
+//
+//	mux.Handle("/static/", http.StripPrefix("/static/", pfs.Handler()))
+func (p *PlatformFS) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		platform := r.Header.Get(PlatformHeader)
+		if platform != PlatformDesktop && platform != PlatformMobile {
+			platform = p.platform
+		}
+		http.FileServer(http.FS(p.For(platform))).ServeHTTP(w, r)
+	})
+}
+
+// platformVariantFS overlays a platform subdirectory on the shared root.
+type platformVariantFS struct {
+	root     fs.FS
+	platform string
+}
+
+// Open implements fs.FS.
+func (v *platformVariantFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name != "." {
+		f, err := v.root.Open(path.Join(v.platform, name))
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return v.root.Open(name)
+}
@@ -0,0 +1,6 @@
+//go:build !desktop
+
+package render
+
+// BuildPlatform is the platform selected by build tags.
+const BuildPlatform = PlatformMobile
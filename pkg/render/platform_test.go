@@ -0,0 +1,89 @@
+package render
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func testStaticFS() fstest.MapFS {
+	return fstest.MapFS{
+		"app.css":          {Data: []byte("shared css")},
+		"hero.png":         {Data: []byte("shared hero")},
+		"desktop/hero.png": {Data: []byte("desktop hero")},
+		"mobile/hero.png":  {Data: []byte("mobile hero")},
+	}
+}
+
+func readFile(t *testing.T, fsys fs.FS, name string) string {
+	t.Helper()
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		t.Fatalf("reading %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestPlatformFSSelectsVariant(t *testing.T) {
+	pfs := NewPlatformFS(testStaticFS(), PlatformDesktop)
+
+	if got := readFile(t, pfs.For(PlatformDesktop), "hero.png"); got != "desktop hero" {
+		t.Errorf("expected desktop variant, got %q", got)
+	}
+	if got := readFile(t, pfs.For(PlatformMobile), "hero.png"); got != "mobile hero" {
+		t.Errorf("expected mobile variant, got %q", got)
+	}
+}
+
+func TestPlatformFSSharedFallback(t *testing.T) {
+	pfs := NewPlatformFS(testStaticFS(), PlatformMobile)
+
+	if got := readFile(t, pfs.For(""), "app.css"); got != "shared css" {
+		t.Errorf("expected shared file, got %q", got)
+	}
+}
+
+func TestPlatformFSDefaultsToBuildPlatform(t *testing.T) {
+	pfs := NewPlatformFS(testStaticFS(), "")
+	if pfs.Platform() != BuildPlatform {
+		t.Errorf("expected build platform %q, got %q", BuildPlatform, pfs.Platform())
+	}
+}
+
+func TestPlatformFSHandler(t *testing.T) {
+	handler := http.StripPrefix("/static/", NewPlatformFS(testStaticFS(), PlatformDesktop).Handler())
+
+	tests := []struct {
+		name     string
+		header   string
+		path     string
+		expected string
+	}{
+		{"default platform", "", "/static/hero.png", "desktop hero"},
+		{"header hint", PlatformMobile, "/static/hero.png", "mobile hero"},
+		{"unknown hint uses default", "tv", "/static/hero.png", "desktop hero"},
+		{"shared file", PlatformMobile, "/static/app.css", "shared css"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(PlatformHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			body, _ := io.ReadAll(w.Body)
+			if string(body) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/a-h/templ"
+	"golang.org/x/net/html"
+)
+
+// ErrNoMatch is returned when rendering a Select whose selector matches no
+// element.
+var ErrNoMatch = errors.New("no element matches selector")
+
+// Select renders component and keeps only the first element matching
+// selector, with everything inside it: the server-side equivalent of
+// hx-select, for handlers that render a full page but answer a fragment
+// request with part of it:
+//
+//	if ctx.IsHTMX() {
+//		return renderer.Render(render.Select(pages.Todos(todos), "#list"))
+//	}
+//	return renderer.Render(pages.Todos(todos))
+//
+// Selectors are a CSS subset: a tag or "*", #id, .class, [attr] and
+// [attr=value], combined as in "ul.todos > li[data-done]" with descendant
+// (space) and child (>) combinators.
+//
+// The output is parsed as an HTML document and rendered again, so it's
+// normalized (quoting, entities), and elements only valid in context,
+// such as a <tr> outside a table, are dropped by the parser. Rendering
+// fails with ErrNoMatch if nothing matches, or with an error for an
+// invalid selector.
+func Select(component templ.Component, selector string) templ.Component {
+	sel, err := parseSelector(selector)
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		if err != nil {
+			return fmt.Errorf("select %q: %w", selector, err)
+		}
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if err := component.Render(ctx, buf); err != nil {
+			return err
+		}
+		doc, err := html.Parse(buf)
+		if err != nil {
+			return fmt.Errorf("select %q: %w", selector, err)
+		}
+		match := sel.find(doc)
+		if match == nil {
+			return fmt.Errorf("select %q: %w", selector, ErrNoMatch)
+		}
+		return html.Render(w, match)
+	})
+}
+
+// selector is a parsed selector: compound selectors joined by combinators.
+type selector []selectorStep
+
+// selectorStep is one compound selector and how it relates to the step
+// before it.
+type selectorStep struct {
+	compound
+	child bool // ">" rather than descendant
+}
+
+// compound matches a single element.
+type compound struct {
+	tag     string // "" or "*" for any
+	id      string
+	classes []string
+	attrs   []attrSelector
+}
+
+// attrSelector is [name] or [name=value].
+type attrSelector struct {
+	name     string
+	value    string
+	hasValue bool
+}
+
+// find returns the first element under n, in document order, matching s.
+func (s selector) find(n *html.Node) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && s.matchAt(c, len(s)-1) {
+			return c
+		}
+		if found := s.find(c); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// matchAt reports whether n matches step i with its ancestors matching the
+// steps before it.
+func (s selector) matchAt(n *html.Node, i int) bool {
+	if !s[i].matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	if s[i].child {
+		p := n.Parent
+		return p != nil && p.Type == html.ElementNode && s.matchAt(p, i-1)
+	}
+	for p := n.Parent; p != nil && p.Type == html.ElementNode; p = p.Parent {
+		if s.matchAt(p, i-1) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c compound) matches(n *html.Node) bool {
+	if c.tag != "" && c.tag != "*" && c.tag != n.Data {
+		return false
+	}
+	if c.id != "" && nodeAttr(n, "id") != c.id {
+		return false
+	}
+	if len(c.classes) > 0 {
+		classes := strings.Fields(nodeAttr(n, "class"))
+		for _, want := range c.classes {
+			if !contains(classes, want) {
+				return false
+			}
+		}
+	}
+	for _, a := range c.attrs {
+		value, ok := lookupAttr(n, a.name)
+		if !ok || (a.hasValue && value != a.value) {
+			return false
+		}
+	}
+	return true
+}
+
+func nodeAttr(n *html.Node, name string) string {
+	value, _ := lookupAttr(n, name)
+	return value
+}
+
+func lookupAttr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSelector parses the selector syntax Select supports.
+func parseSelector(s string) (selector, error) {
+	var sel selector
+	child := false
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case ' ', '\t', '\n', '\r', '\f':
+			i++
+			continue
+		case '>':
+			if child || len(sel) == 0 {
+				return nil, errors.New("invalid selector: unexpected '>'")
+			}
+			child = true
+			i++
+			continue
+		}
+		c, n, err := parseCompound(s[i:])
+		if err != nil {
+			return nil, err
+		}
+		sel = append(sel, selectorStep{compound: c, child: child})
+		child = false
+		i += n
+	}
+	if len(sel) == 0 {
+		return nil, errors.New("invalid selector: empty")
+	}
+	if child {
+		return nil, errors.New("invalid selector: trailing '>'")
+	}
+	return sel, nil
+}
+
+// parseCompound parses a compound selector at the start of s, returning it
+// and the number of bytes it used.
+func parseCompound(s string) (compound, int, error) {
+	var c compound
+	i := 0
+	if s[0] == '*' {
+		c.tag = "*"
+		i = 1
+	} else {
+		c.tag = strings.ToLower(s[:identEnd(s)])
+		i = len(c.tag)
+	}
+
+	for i < len(s) {
+		switch s[i] {
+		case '#', '.':
+			name := s[i+1 : i+1+identEnd(s[i+1:])]
+			if name == "" {
+				return c, 0, fmt.Errorf("invalid selector: empty name after %q", s[i])
+			}
+			if s[i] == '#' {
+				c.id = name
+			} else {
+				c.classes = append(c.classes, name)
+			}
+			i += 1 + len(name)
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return c, 0, errors.New("invalid selector: unclosed '['")
+			}
+			a, err := parseAttrSelector(s[i+1 : i+end])
+			if err != nil {
+				return c, 0, err
+			}
+			c.attrs = append(c.attrs, a)
+			i += end + 1
+		case ' ', '\t', '\n', '\r', '\f', '>':
+			return c, i, nil
+		default:
+			return c, 0, fmt.Errorf("invalid selector: unsupported %q", s[i:])
+		}
+	}
+	return c, i, nil
+}
+
+// parseAttrSelector parses the inside of [name] or [name=value], where the
+// value may be quoted.
+func parseAttrSelector(s string) (attrSelector, error) {
+	name, value, hasValue := strings.Cut(s, "=")
+	a := attrSelector{name: strings.ToLower(strings.TrimSpace(name)), hasValue: hasValue}
+	if a.name == "" || identEnd(a.name) != len(a.name) {
+		return a, fmt.Errorf("invalid selector: attribute [%s]", s)
+	}
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	a.value = value
+	return a, nil
+}
+
+// identEnd returns the length of the identifier at the start of s.
+func identEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '_' || c >= 0x80) {
+			return i
+		}
+	}
+	return len(s)
+}
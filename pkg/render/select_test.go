@@ -0,0 +1,93 @@
+package render
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const selectPage = `<!DOCTYPE html>
+<html><head><title>Todos</title></head>
+<body>
+<header><h1>Todos</h1></header>
+<main>
+<ul id="list" class="todos">
+<li class="todo" data-done>Buy milk</li>
+<li class="todo urgent">Call &amp; book</li>
+</ul>
+<ul id="archive"><li class="todo">Old</li></ul>
+</main>
+</body></html>`
+
+func TestSelect(t *testing.T) {
+	r := NewTemplRenderer()
+	page := Raw(selectPage)
+
+	html, err := r.Render(Select(page, "#list"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `<ul id="list" class="todos">
+<li class="todo" data-done="">Buy milk</li>
+<li class="todo urgent">Call &amp; book</li>
+</ul>`
+	if html != want {
+		t.Errorf("expected only the #list element, got:\n%s", html)
+	}
+
+	tests := []struct {
+		selector string
+		want     string
+	}{
+		{"li", `<li class="todo" data-done="">Buy milk</li>`},
+		{".urgent", `<li class="todo urgent">Call &amp; book</li>`},
+		{"li.todo.urgent", `<li class="todo urgent">Call &amp; book</li>`},
+		{"[data-done]", `<li class="todo" data-done="">Buy milk</li>`},
+		{`ul[id="archive"] li`, `<li class="todo">Old</li>`},
+		{"main > #archive > li", `<li class="todo">Old</li>`},
+		{"UL#archive *", `<li class="todo">Old</li>`},
+		{"header h1", `<h1>Todos</h1>`},
+		{"title", `<title>Todos</title>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := r.Render(Select(page, tt.selector))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectFragment(t *testing.T) {
+	got, err := NewTemplRenderer().Render(Select(Raw(`<div id="a"><p>one</p></div><div id="b"><p>two</p></div>`), "#b p"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "<p>two</p>" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	r := NewTemplRenderer()
+	page := Raw(selectPage)
+
+	if _, err := r.Render(Select(page, "#missing")); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch, got %v", err)
+	}
+	// A child combinator needs a direct parent
+	if _, err := r.Render(Select(page, "main > li")); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch for a non-child, got %v", err)
+	}
+
+	for _, selector := range []string{"", "  ", "> li", "ul >", "ul > > li", "#", "li.", "[data", "[=x]", "li:first-child", "ul, ol"} {
+		_, err := r.Render(Select(page, selector))
+		if err == nil || errors.Is(err, ErrNoMatch) || !strings.Contains(err.Error(), "invalid selector") {
+			t.Errorf("%q: expected an invalid selector error, got %v", selector, err)
+		}
+	}
+}
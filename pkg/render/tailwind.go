@@ -1,6 +1,8 @@
 package render
 
 // TailwindConfig provides default Tailwind CSS configuration for irgo apps.
+// It, TailwindCSS and PackageJSON target Tailwind CSS v3; see TailwindV4CSS
+// and TailwindV4PackageJSON for v4.
 const TailwindConfig = `/** @type {import('tailwindcss').Config} */
 module.exports = {
   content: [
@@ -143,11 +145,132 @@ const PackageJSON = `{
 }
 `
 
+// TailwindV4CSS is the Tailwind CSS v4 counterpart of TailwindConfig and
+// TailwindCSS. v4 has no tailwind.config.js: the theme lives in the CSS
+// under @theme, and template sources are detected automatically.
+const TailwindV4CSS = `@import "tailwindcss";
+
+@theme {
+  /* Datastar and general animations */
+  --animate-morph-in: morphIn 0.3s ease-out;
+  --animate-morph-out: morphOut 0.3s ease-out;
+  --animate-fade-in: fadeIn 0.3s ease-out;
+  --animate-fade-out: fadeOut 0.3s ease-out;
+  --animate-slide-in: slideIn 0.3s ease-out;
+  --animate-slide-out: slideOut 0.3s ease-out;
+
+  @keyframes morphIn {
+    0% { opacity: 0; transform: translateY(-10px); }
+    100% { opacity: 1; transform: translateY(0); }
+  }
+  @keyframes morphOut {
+    0% { opacity: 1; }
+    100% { opacity: 0; }
+  }
+  @keyframes fadeIn {
+    0% { opacity: 0; }
+    100% { opacity: 1; }
+  }
+  @keyframes fadeOut {
+    0% { opacity: 1; }
+    100% { opacity: 0; }
+  }
+  @keyframes slideIn {
+    0% { opacity: 0; transform: translateX(-10px); }
+    100% { opacity: 1; transform: translateX(0); }
+  }
+  @keyframes slideOut {
+    0% { opacity: 1; transform: translateX(0); }
+    100% { opacity: 0; transform: translateX(10px); }
+  }
+}
+
+/* Datastar loading indicator styles */
+/* These work with data-indicator:loading attribute */
+[data-indicator] {
+  transition: opacity 200ms ease-in;
+}
+
+/* General loading state styling */
+.loading {
+  cursor: wait;
+}
+
+.loading button,
+.loading input[type="submit"] {
+  pointer-events: none;
+  opacity: 0.7;
+}
+
+/* Morph animation for patched elements */
+.morph-in {
+  animation: var(--animate-morph-in);
+}
+
+.morph-out {
+  animation: var(--animate-morph-out);
+}
+
+/* Error styling */
+.error {
+  @apply bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded relative;
+}
+
+/* Success styling */
+.success {
+  @apply bg-green-50 border border-green-200 text-green-700 px-4 py-3 rounded relative;
+}
+
+/* Loading spinner */
+.spinner {
+  @apply animate-spin rounded-full h-5 w-5 border-2 border-gray-300 border-t-blue-600;
+}
+
+/* Mobile-first responsive utilities */
+@utility safe-top {
+  padding-top: env(safe-area-inset-top);
+}
+@utility safe-bottom {
+  padding-bottom: env(safe-area-inset-bottom);
+}
+@utility safe-left {
+  padding-left: env(safe-area-inset-left);
+}
+@utility safe-right {
+  padding-right: env(safe-area-inset-right);
+}
+@utility safe-area {
+  padding-top: env(safe-area-inset-top);
+  padding-bottom: env(safe-area-inset-bottom);
+  padding-left: env(safe-area-inset-left);
+  padding-right: env(safe-area-inset-right);
+}
+`
+
+// TailwindV4PackageJSON is PackageJSON for Tailwind CSS v4, whose CLI is
+// the separate @tailwindcss/cli package.
+const TailwindV4PackageJSON = `{
+  "name": "irgo-app",
+  "version": "1.0.0",
+  "scripts": {
+    "build:css": "tailwindcss -i ./assets/css/input.css -o ./assets/css/output.css --minify",
+    "watch:css": "tailwindcss -i ./assets/css/input.css -o ./assets/css/output.css --watch"
+  },
+  "devDependencies": {
+    "@tailwindcss/cli": "^4.0.0",
+    "tailwindcss": "^4.0.0"
+  }
+}
+`
+
 // DatastarScript returns the script tag for Datastar.
 // For mobile apps, this would be bundled locally.
 const DatastarScript = `<script type="module" src="https://cdn.jsdelivr.net/gh/starfederation/datastar/bundles/datastar.js"></script>`
 
 // BaseHTML provides a minimal HTML template with Datastar and Tailwind.
+// Its {{.LiveReload}} is the live reload script in DevMode: layouts from
+// NewLayout fill it in; when executing BaseHTML yourself, pass
+// LiveReloadScript() as LiveReload.
 const BaseHTML = `<!DOCTYPE html>
 <html lang="en">
 <head>
@@ -164,6 +287,7 @@ const BaseHTML = `<!DOCTYPE html>
     <div id="app">
         {{.Content}}
     </div>
+    {{.LiveReload}}
 </body>
 </html>
 `
@@ -4,10 +4,34 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"sync"
 
 	"github.com/a-h/templ"
 )
 
+// maxPooledBuffer is the largest buffer returned to bufferPool, so one
+// huge page doesn't stay allocated for the life of the app.
+const maxPooledBuffer = 64 << 10
+
+// bufferPool holds render buffers for reuse across renders.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns buf to the pool.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
 // TemplRenderer wraps templ components for use with the router.
 type TemplRenderer struct {
 	ctx context.Context
@@ -25,10 +49,12 @@ func (r *TemplRenderer) WithContext(ctx context.Context) *TemplRenderer {
 	return &TemplRenderer{ctx: ctx}
 }
 
-// Render renders a templ component to a string.
+// Render renders a templ component to a string. Renders share a pool of
+// buffers, so the string is the only allocation for the output.
 func (r *TemplRenderer) Render(component templ.Component) (string, error) {
-	var buf bytes.Buffer
-	if err := component.Render(r.ctx, &buf); err != nil {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := component.Render(r.ctx, buf); err != nil {
 		return "", err
 	}
 	return buf.String(), nil
@@ -43,7 +69,10 @@ func (r *TemplRenderer) MustRender(component templ.Component) string {
 	return html
 }
 
-// RenderTo renders a templ component to a writer.
+// RenderTo renders a templ component to a writer, such as an
+// http.ResponseWriter, without building a string first. Output is written
+// as it's rendered, so a component that fails part way leaves partial
+// output in w; wrap it in Boundary if that matters.
 func (r *TemplRenderer) RenderTo(w io.Writer, component templ.Component) error {
 	return component.Render(r.ctx, w)
 }
@@ -0,0 +1,111 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+// listComponent renders a list of n items, like a typical fragment.
+func listComponent(n int) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		io.WriteString(w, `<ul id="todos">`)
+		for i := 0; i < n; i++ {
+			io.WriteString(w, `<li class="todo"><input type="checkbox"><span>Buy milk</span></li>`)
+		}
+		_, err := io.WriteString(w, `</ul>`)
+		return err
+	})
+}
+
+func TestTemplRendererRender(t *testing.T) {
+	r := NewTemplRenderer()
+
+	// Reused buffers don't carry output between renders
+	for _, n := range []int{50, 1, 0} {
+		html, err := r.Render(listComponent(n))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := strings.Count(html, "<li"); got != n {
+			t.Errorf("expected %d items, got %d in %q", n, got, html)
+		}
+	}
+
+	fails := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		io.WriteString(w, "<div>half")
+		return errors.New("boom")
+	})
+	if html, err := r.Render(fails); err == nil || html != "" {
+		t.Errorf("expected no output and an error, got %q, %v", html, err)
+	}
+	if html, _ := r.Render(Raw("<p>next</p>")); html != "<p>next</p>" {
+		t.Errorf("expected failed output not to leak into the next render, got %q", html)
+	}
+}
+
+func TestTemplRendererRenderTo(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewTemplRenderer().RenderTo(&buf, listComponent(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Count(buf.String(), "<li"); got != 2 {
+		t.Errorf("expected 2 items, got %q", buf.String())
+	}
+}
+
+func TestPutBufferDropsLargeBuffers(t *testing.T) {
+	buf := getBuffer()
+	buf.Grow(maxPooledBuffer + 1)
+	putBuffer(buf)
+	for i := 0; i < 10; i++ {
+		if b := getBuffer(); b == buf {
+			t.Fatal("expected oversized buffer not to be pooled")
+		}
+	}
+}
+
+// benchmarkItems is the size of the fragment the benchmarks render.
+const benchmarkItems = 100
+
+// BenchmarkRenderUnpooled is the baseline: a fresh buffer per render, as
+// Render did before pooling.
+func BenchmarkRenderUnpooled(b *testing.B) {
+	component := listComponent(benchmarkItems)
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		if err := component.Render(ctx, &buf); err != nil {
+			b.Fatal(err)
+		}
+		_ = buf.String()
+	}
+}
+
+func BenchmarkTemplRendererRender(b *testing.B) {
+	component := listComponent(benchmarkItems)
+	r := NewTemplRenderer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.Render(component); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTemplRendererRenderTo(b *testing.B) {
+	component := listComponent(benchmarkItems)
+	r := NewTemplRenderer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := r.RenderTo(io.Discard, component); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
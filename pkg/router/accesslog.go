@@ -0,0 +1,115 @@
+package router
+
+import (
+	"context"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stukennedy/irgo/pkg/core"
+)
+
+// Traffic classes AccessLog tags requests with.
+const (
+	TrafficPage      = "page"     // Full page loads and other plain requests
+	TrafficHTMX      = "htmx"     // HTMX fragment requests (HX-Request)
+	TrafficDatastar  = "datastar" // Datastar SSE requests
+	TrafficAPI       = "api"      // JSON API calls
+	TrafficWebSocket = "ws"       // WebSocket upgrades and messages served by websocket.HTTPHandler
+)
+
+// accessLogKey is the context key for the request's accessEntry.
+const accessLogKey contextKey = "access-log"
+
+// accessEntry is what routes tell AccessLog about the request.
+type accessEntry struct {
+	apiRoute bool
+}
+
+// markAPIRoute records that an API route is serving r.
+func markAPIRoute(r *http.Request) {
+	if entry, ok := r.Context().Value(accessLogKey).(*accessEntry); ok {
+		entry.apiRoute = true
+	}
+}
+
+// TrafficClass classifies r by its headers: WebSocket traffic, then
+// Datastar, HTMX, JSON (an Accept or Content-Type of application/json),
+// and otherwise a page load. AccessLog also tags requests served by API
+// routes as TrafficAPI.
+func TrafficClass(r *http.Request) string {
+	switch {
+	case strings.EqualFold(r.Header.Get("Upgrade"), "websocket"), r.Header.Get(core.WebSocketMessageHeader) != "":
+		return TrafficWebSocket
+	case IsDatastarRequest(r):
+		return TrafficDatastar
+	case IsHTMXRequest(r):
+		return TrafficHTMX
+	case isJSONRequest(r):
+		return TrafficAPI
+	}
+	return TrafficPage
+}
+
+func isJSONRequest(r *http.Request) bool {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		return true
+	}
+	return (&Context{Request: r}).WantsJSON()
+}
+
+// AccessLog returns middleware that logs each request to logger
+// (slog.Default() if nil) once it's served, tagged with its traffic class:
+//
+//	msg=request class=htmx method=POST path=/todos status=200 bytes=312 duration=1.2ms target=#todo-list request_id=...
+//
+// target is the HTMX target (HX-Target) and ws the WebSocket URL a message
+// arrived on, when present. Add it with Use:
+//
+//	r.Use(router.AccessLog(nil))
+func AccessLog(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := logger
+			if log == nil {
+				log = slog.Default()
+			}
+			entry := &accessEntry{}
+			r = r.WithContext(context.WithValue(r.Context(), accessLogKey, entry))
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+
+			next.ServeHTTP(ww, r)
+
+			class := TrafficClass(r)
+			if entry.apiRoute && class == TrafficPage {
+				class = TrafficAPI
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			attrs := []slog.Attr{
+				slog.String("class", class),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if target := r.Header.Get("HX-Target"); target != "" {
+				attrs = append(attrs, slog.String("target", target))
+			}
+			if url := r.Header.Get(core.WebSocketMessageHeader); url != "" {
+				attrs = append(attrs, slog.String("ws", url))
+			}
+			if id := middleware.GetReqID(r.Context()); id != "" {
+				attrs = append(attrs, slog.String("request_id", id))
+			}
+			log.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+		})
+	}
+}
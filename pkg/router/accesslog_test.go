@@ -0,0 +1,55 @@
+package router
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stukennedy/irgo/pkg/core"
+)
+
+func TestAccessLogTrafficClasses(t *testing.T) {
+	var buf bytes.Buffer
+	r := New()
+	r.Use(AccessLog(slog.New(slog.NewTextHandler(&buf, nil))))
+	r.GET("/", func(ctx *Context) (string, error) { return "<html></html>", nil })
+	r.POST("/todos", func(ctx *Context) (string, error) { return "<li>Milk</li>", nil })
+	r.API(http.MethodGet, "/api/todos", func(ctx *Context) (any, error) { return []string{"Milk"}, nil })
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		want    []string
+	}{
+		{"page", "GET", "/", nil, []string{"class=page", "method=GET", "path=/", "status=200", "bytes=13"}},
+		{"htmx", "POST", "/todos", map[string]string{"HX-Request": "true", "HX-Target": "#todo-list"}, []string{"class=htmx", "target=#todo-list"}},
+		{"datastar", "POST", "/todos", map[string]string{"Accept": "text/event-stream"}, []string{"class=datastar"}},
+		{"api route", "GET", "/api/todos", nil, []string{"class=api"}},
+		{"json", "POST", "/todos", map[string]string{"Accept": "application/json"}, []string{"class=api"}},
+		{"websocket message", "POST", "/todos", map[string]string{core.WebSocketMessageHeader: "/ws/todos"}, []string{"class=ws", "ws=/ws/todos"}},
+		{"not found", "GET", "/missing", nil, []string{"class=page", "status=404"}},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		line := buf.String()
+		if strings.Count(line, "\n") != 1 || !strings.Contains(line, "msg=request") || !strings.Contains(line, "request_id=") {
+			t.Errorf("%s: expected one request line, got %q", tt.name, line)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(line, want) {
+				t.Errorf("%s: expected %q in %q", tt.name, want, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Principal is the authenticated caller of a request, as returned by the
+// Verifier given to AuthMiddleware.
+type Principal struct {
+	ID     string         // User or client identifier
+	Roles  []string       // Roles granted to the caller
+	Claims map[string]any // Anything else the verifier knows, e.g. token claims
+}
+
+// Verifier authenticates a request, returning its principal or an error
+// if it carries no valid credentials.
+type Verifier func(r *http.Request) (Principal, error)
+
+// ErrUnauthorized is returned by verifiers for requests without valid
+// credentials. Returned from a handler, it produces a 401 response.
+var ErrUnauthorized = &HTTPError{Status: http.StatusUnauthorized, Message: "Unauthorized"}
+
+// PrincipalKey is the context key for the Principal set by AuthMiddleware.
+const PrincipalKey contextKey = "principal"
+
+// WithPrincipal returns a copy of ctx carrying p, as AuthMiddleware does
+// for authenticated requests.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, PrincipalKey, &p)
+}
+
+// PrincipalFromContext returns the principal AuthMiddleware stored in ctx,
+// or nil if the request wasn't authenticated.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(PrincipalKey).(*Principal)
+	return p
+}
+
+// Principal returns the request's authenticated principal (see
+// AuthMiddleware), or nil.
+func (c *Context) Principal() *Principal {
+	return PrincipalFromContext(c.Request.Context())
+}
+
+// AuthOption configures AuthMiddleware.
+type AuthOption func(*authConfig)
+
+type authConfig struct {
+	loginURL string
+}
+
+// WithLoginRedirect sends unauthenticated page requests to url instead of
+// answering 401. HTMX requests get an HX-Redirect header, Datastar requests
+// an SSE redirect, and JSON clients still get 401.
+func WithLoginRedirect(url string) AuthOption {
+	return func(c *authConfig) {
+		c.loginURL = url
+	}
+}
+
+// AuthMiddleware returns middleware that authenticates every request with
+// verifier. On success the principal is stored in the request context for
+// Context.Principal; on failure the handler doesn't run and the client gets
+// 401 (a JSON envelope if it wants JSON), or a redirect with
+// WithLoginRedirect. A verifier error that is an HTTPError with another
+// status, e.g. 503 when the session store is down, is written as is.
+//
+//	r.Use(router.AuthMiddleware(router.BearerVerifier(lookupToken)))
+func AuthMiddleware(verifier Verifier, opts ...AuthOption) Middleware {
+	var cfg authConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, err := verifier(r)
+			if err == nil {
+				next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+				return
+			}
+
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) || httpErr.Status == http.StatusUnauthorized {
+				httpErr = ErrUnauthorized
+			}
+			deny(NewContext(w, r), httpErr, cfg.loginURL)
+		})
+	}
+}
+
+// deny writes err for a request that failed an auth check, redirecting
+// HTML clients to loginURL instead if it's set and err is a 401.
+func deny(c *Context, err *HTTPError, loginURL string) {
+	if err.Status != http.StatusUnauthorized {
+		loginURL = ""
+	}
+	switch {
+	case c.WantsJSON():
+		c.APIError(err)
+	case loginURL != "" && IsHTMXRequest(c.Request):
+		c.SetHeader("HX-Redirect", c.URL(loginURL))
+		c.ErrorStatus(err.Status, err.Message)
+	case loginURL != "":
+		c.Redirect(loginURL)
+	default:
+		c.Error(err)
+	}
+}
+
+// BearerVerifier returns a Verifier for requests carrying
+// "Authorization: Bearer <token>", resolving the token with lookup.
+// Requests without one fail with ErrUnauthorized before lookup is called.
+func BearerVerifier(lookup func(ctx context.Context, token string) (Principal, error)) Verifier {
+	return func(r *http.Request) (Principal, error) {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		token = strings.TrimSpace(token)
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return Principal{}, ErrUnauthorized
+		}
+		return lookup(r.Context(), token)
+	}
+}
+
+// SessionCookieVerifier returns a Verifier for requests carrying the
+// session cookie name, resolving its value with lookup, e.g. in a session
+// table. Requests without the cookie fail with ErrUnauthorized before
+// lookup is called.
+func SessionCookieVerifier(name string, lookup func(ctx context.Context, session string) (Principal, error)) Verifier {
+	return func(r *http.Request) (Principal, error) {
+		cookie, err := r.Cookie(name)
+		if err != nil || cookie.Value == "" {
+			return Principal{}, ErrUnauthorized
+		}
+		return lookup(r.Context(), cookie.Value)
+	}
+}
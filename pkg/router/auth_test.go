@@ -0,0 +1,151 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testTokens are the bearer tokens lookupToken accepts.
+var testTokens = map[string]Principal{
+	"alice-token": {ID: "alice", Roles: []string{"admin"}},
+	"bob-token":   {ID: "bob"},
+}
+
+func lookupToken(_ context.Context, token string) (Principal, error) {
+	if p, ok := testTokens[token]; ok {
+		return p, nil
+	}
+	return Principal{}, ErrUnauthorized
+}
+
+// authRouter serves GET /me, answering with the request's principal ID.
+func authRouter(verifier Verifier, opts ...AuthOption) *Router {
+	r := New()
+	r.Use(AuthMiddleware(verifier, opts...))
+	r.GET("/me", func(ctx *Context) (string, error) {
+		return "hello " + ctx.Principal().ID, nil
+	})
+	return r
+}
+
+func TestAuthMiddlewareBearer(t *testing.T) {
+	r := authRouter(BearerVerifier(lookupToken))
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+		body   string
+	}{
+		{"authorized", "Bearer alice-token", http.StatusOK, "hello alice"},
+		{"scheme case", "bearer bob-token", http.StatusOK, "hello bob"},
+		{"unknown token", "Bearer nope", http.StatusUnauthorized, "Unauthorized"},
+		{"other scheme", "Basic YWxpY2U6cHc=", http.StatusUnauthorized, "Unauthorized"},
+		{"empty token", "Bearer ", http.StatusUnauthorized, "Unauthorized"},
+		{"missing", "", http.StatusUnauthorized, "Unauthorized"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/me", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.status || !strings.Contains(rec.Body.String(), tt.body) {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.body, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareSessionCookie(t *testing.T) {
+	var looked string
+	r := authRouter(SessionCookieVerifier("sid", func(_ context.Context, session string) (Principal, error) {
+		looked = session
+		if session == "s1" {
+			return Principal{ID: "carol"}, nil
+		}
+		return Principal{}, ErrUnauthorized
+	}))
+
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "s1"})
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello carol" {
+		t.Errorf("expected carol's page, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	looked = ""
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/me", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a cookie, got %d", rec.Code)
+	}
+	if looked != "" {
+		t.Errorf("expected no lookup without a cookie, got %q", looked)
+	}
+}
+
+func TestAuthMiddlewareFailureResponses(t *testing.T) {
+	r := authRouter(BearerVerifier(lookupToken), WithLoginRedirect("/login"))
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		status   int
+		header   string // Response header to check
+		value    string
+		contains string
+	}{
+		{"page", nil, http.StatusSeeOther, "Location", "/login", ""},
+		{"htmx", map[string]string{"HX-Request": "true"}, http.StatusUnauthorized, "HX-Redirect", "/login", ""},
+		{"datastar", map[string]string{"Accept": "text/event-stream"}, http.StatusOK, "Content-Type", "text/event-stream", "/login"},
+		{"json", map[string]string{"Accept": "application/json"}, http.StatusUnauthorized, "Content-Type", "application/json", `"unauthorized"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/me", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, rec.Code)
+			}
+			if got := rec.Header().Get(tt.header); !strings.HasPrefix(got, tt.value) {
+				t.Errorf("expected %s %q, got %q", tt.header, tt.value, got)
+			}
+			if !strings.Contains(rec.Body.String(), tt.contains) {
+				t.Errorf("expected body to contain %q, got %q", tt.contains, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareVerifierStatus(t *testing.T) {
+	r := authRouter(func(*http.Request) (Principal, error) {
+		return Principal{}, NewHTTPError(http.StatusServiceUnavailable, "session store unavailable")
+	}, WithLoginRedirect("/login"))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/me", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected the verifier's 503, got %d", rec.Code)
+	}
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	if p := PrincipalFromContext(context.Background()); p != nil {
+		t.Errorf("expected no principal, got %+v", p)
+	}
+	ctx := WithPrincipal(context.Background(), Principal{ID: "dave", Roles: []string{"editor"}})
+	if p := PrincipalFromContext(ctx); p == nil || p.ID != "dave" || p.Roles[0] != "editor" {
+		t.Errorf("expected dave, got %+v", p)
+	}
+}
@@ -0,0 +1,128 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// BasePathKey is the context key holding the base path the app is mounted under.
+const BasePathKey contextKey = "base-path"
+
+// BasePathMiddleware mounts the app under prefix (e.g. "/app").
+// The prefix is stripped before routing and recorded in the request context
+// so URLs generated with Context.URL and Router.URL include it.
+//
+// A request for "/" redirects to the prefix, so a webview opening the server
+// root lands on the app. Other requests outside the prefix get NotFoundPage.
+func BasePathMiddleware(prefix string) func(http.Handler) http.Handler {
+	prefix = cleanBasePath(prefix)
+	return func(next http.Handler) http.Handler {
+		if prefix == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := r.URL.Path
+			if path == "/" {
+				http.Redirect(w, r, prefix+"/", http.StatusFound)
+				return
+			}
+
+			rest, ok := strings.CutPrefix(path, prefix)
+			if !ok || (rest != "" && rest[0] != '/') {
+				NotFoundPage(w, r)
+				return
+			}
+			if rest == "" {
+				rest = "/"
+			}
+
+			r2 := r.Clone(context.WithValue(r.Context(), BasePathKey, prefix))
+			r2.URL.Path = rest
+			if r2.URL.RawPath != "" {
+				r2.URL.RawPath = strings.TrimPrefix(r2.URL.RawPath, prefix)
+				if r2.URL.RawPath == "" {
+					r2.URL.RawPath = "/"
+				}
+			}
+			next.ServeHTTP(w, r2)
+		})
+	}
+}
+
+// BasePath returns the base path the request was routed under, or "".
+func BasePath(r *http.Request) string {
+	v, _ := r.Context().Value(BasePathKey).(string)
+	return v
+}
+
+// cleanBasePath normalizes a base path to "/segment" form, or "" for the root.
+func cleanBasePath(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// SetBasePath mounts the router under prefix (e.g. "/app").
+// Handler and ServeHTTP strip the prefix, and URL includes it.
+func (r *Router) SetBasePath(prefix string) {
+	r.basePath = cleanBasePath(prefix)
+}
+
+// URL builds the path for a route named via Register, substituting
+// params given as key/value pairs and prepending the base path:
+//
+//	r.URL("user", "id", "42") // "/app/users/42"
+func (r *Router) URL(name string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("router: odd number of params for route %q", name)
+	}
+
+	var pattern string
+	for key, n := range r.names {
+		if n == name {
+			_, pattern, _ = strings.Cut(key, " ")
+			break
+		}
+	}
+	if pattern == "" {
+		return "", fmt.Errorf("router: no route named %q", name)
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	var missing string
+	path := routeParamPattern.ReplaceAllStringFunc(pattern, func(param string) string {
+		key, _, _ := strings.Cut(strings.Trim(param, "{}"), ":")
+		v, ok := values[key]
+		if !ok && missing == "" {
+			missing = key
+		}
+		return v
+	})
+	if missing != "" {
+		return "", fmt.Errorf("router: missing param %q for route %q", missing, name)
+	}
+
+	return r.basePath + path, nil
+}
+
+// routeParamPattern matches chi URL parameters such as {id} or {id:[0-9]+}.
+var routeParamPattern = regexp.MustCompile(`\{[^}]+\}`)
+
+// URL prepends the request's base path to an absolute path.
+// Use it for links and asset paths, e.g. ctx.URL("/static/app.css").
+func (c *Context) URL(path string) string {
+	base := BasePath(c.Request)
+	if base == "" || !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") {
+		return path
+	}
+	return base + path
+}
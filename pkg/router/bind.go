@@ -0,0 +1,191 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/stukennedy/irgo/pkg/values"
+)
+
+// Validator is implemented by bound structs with checks of their own,
+// run by BindAndValidate after the tag rules. Returning ValidationErrors
+// adds to the field errors; any other error is returned as is.
+type Validator interface {
+	Validate() error
+}
+
+// BindAndValidate fills the struct v points to from the request, then
+// validates it. Fields are bound by tag:
+//
+//	type EditTodo struct {
+//		ID    int64  `path:"id"`
+//		Page  int    `query:"page" validate:"min=1"`
+//		Title string `form:"title" validate:"required,max=200"`
+//	}
+//
+// form reads the body like Values: form fields, or a JSON object. Fields
+// may be strings, bools, ints, uints or floats; missing values leave the
+// field as it was. The validate tag takes comma-separated rules:
+// required (present and non-empty), and min=N and max=N (the value of
+// numbers, the length of strings).
+//
+// Failures are ValidationErrors, keyed by the parameter name with the
+// source in the message (e.g. "page": "must be an integer (query)"), so
+// a handler can return the error as is for a 422. A malformed body is an
+// HTTPError with status 400.
+func (c *Context) BindAndValidate(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("router: BindAndValidate needs a pointer to a struct, got %T", v)
+	}
+
+	body, err := c.Values()
+	if err != nil {
+		return NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	sources := []struct {
+		tag string
+		src values.Source
+	}{
+		{"path", values.SourceFunc(func(key string) (any, bool) {
+			s := c.Param(key)
+			return s, s != ""
+		})},
+		{"query", values.Query(c.Request)},
+		{"form", body},
+	}
+
+	errs := ValidationErrors{}
+	elem := rv.Elem()
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		for _, s := range sources {
+			name, ok := field.Tag.Lookup(s.tag)
+			if !ok || name == "" || name == "-" {
+				continue
+			}
+			raw, present := values.String(s.src, name), values.Has(s.src, name)
+			if present {
+				if err := setField(elem.Field(i), raw); err != nil {
+					errs.Add(name, err.Error()+" ("+s.tag+")")
+					break
+				}
+			}
+			if msg := checkRules(field.Tag.Get("validate"), elem.Field(i), present && raw != ""); msg != "" {
+				errs.Add(name, msg+" ("+s.tag+")")
+			}
+			break
+		}
+	}
+
+	if validator, ok := v.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			var verrs ValidationErrors
+			if !errors.As(err, &verrs) {
+				return err
+			}
+			for field, msg := range verrs {
+				errs.Add(field, msg)
+			}
+		}
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// setField parses s into a field of a supported kind.
+func setField(fv reflect.Value, s string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if s == "on" {
+			b, err = true, nil
+		}
+		if err != nil {
+			return errors.New("must be a boolean")
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("must be a non-negative integer")
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("can't be bound to a %s", fv.Type())
+	}
+	return nil
+}
+
+// checkRules returns the message for the first validate rule fv breaks,
+// or "" if it passes.
+func checkRules(rules string, fv reflect.Value, present bool) string {
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "required":
+			if !present {
+				return "is required"
+			}
+		case "min", "max":
+			if !present {
+				continue
+			}
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return fmt.Sprintf("has an invalid %s rule %q", name, arg)
+			}
+			n, isLength := measure(fv)
+			switch {
+			case name == "min" && n < limit && isLength:
+				return fmt.Sprintf("must be at least %s characters", arg)
+			case name == "min" && n < limit:
+				return "must be at least " + arg
+			case name == "max" && n > limit && isLength:
+				return fmt.Sprintf("must be at most %s characters", arg)
+			case name == "max" && n > limit:
+				return "must be at most " + arg
+			}
+		}
+	}
+	return ""
+}
+
+// measure returns what min and max compare: a string's length in
+// characters, or a number's value.
+func measure(fv reflect.Value) (n float64, isLength bool) {
+	switch fv.Kind() {
+	case reflect.String:
+		return float64(len([]rune(fv.String()))), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), false
+	}
+	return 0, false
+}
@@ -0,0 +1,164 @@
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ImmutableMaxAge is the max-age used for immutable responses: one year,
+// the conventional ceiling for fingerprinted assets.
+const ImmutableMaxAge = 365 * 24 * time.Hour
+
+// CachePolicy describes the Cache-Control header for a route.
+type CachePolicy struct {
+	MaxAge    time.Duration // How long the response may be reused (0 = revalidate every time)
+	Immutable bool          // Content never changes at this URL; MaxAge defaults to ImmutableMaxAge
+	Private   bool          // Only the client may cache, not shared caches
+}
+
+// Header returns the Cache-Control value for the policy.
+func (p CachePolicy) Header() string {
+	scope := "public"
+	if p.Private {
+		scope = "private"
+	}
+
+	maxAge := p.MaxAge
+	if p.Immutable && maxAge <= 0 {
+		maxAge = ImmutableMaxAge
+	}
+	if maxAge <= 0 {
+		return scope + ", no-cache"
+	}
+
+	value := scope + ", max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+	if p.Immutable {
+		value += ", immutable"
+	}
+	return value
+}
+
+// apply sets the Cache-Control header on w.
+func (p CachePolicy) apply(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", p.Header())
+}
+
+// RouteOptions configures a registered route. It is returned by the route
+// registration methods so options can be chained:
+//
+//	r.GET("/about", about).Cache(router.CachePolicy{MaxAge: time.Hour})
+type RouteOptions struct {
+	cache  *CachePolicy
+	swap   string
+	target string
+	roles  [][]string // Set by Require; each entry needs one of its roles
+}
+
+// Cache sets the Cache-Control policy for successful responses.
+// Error responses are never cached.
+func (o *RouteOptions) Cache(policy CachePolicy) *RouteOptions {
+	o.cache = &policy
+	return o
+}
+
+// applyCache sets the route's Cache-Control header, if any.
+func (o *RouteOptions) applyCache(w http.ResponseWriter) {
+	if o.cache != nil {
+		o.cache.apply(w)
+	}
+}
+
+// clearCache removes the route's Cache-Control header before an error
+// response so errors aren't cached.
+func (o *RouteOptions) clearCache(w http.ResponseWriter) {
+	if o.cache != nil {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+}
+
+// CacheMiddleware sets Cache-Control per policy on every response.
+func CacheMiddleware(policy CachePolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			policy.apply(w)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// FileServer is http.FileServer with caching headers for the webview:
+// content-hashed (fingerprinted) files are cached immutably, and files
+// without a modification time, such as embedded ones, get an ETag of their
+// content so repeat requests are answered 304 Not Modified. (Files on disk
+// get that from Last-Modified.) Router.Static uses it; use it directly to
+// serve files from another mux:
+//
+//	mux.Handle("/static/", http.StripPrefix("/static/", router.FileServer(desktop.StaticFiles())))
+func FileServer(root http.FileSystem) http.Handler {
+	etags := &contentETags{}
+	fs := http.FileServer(root)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		name := path.Clean("/" + req.URL.Path)
+		if f, err := root.Open(name); err == nil {
+			// Content-hashed files never change, so let the webview keep them
+			if IsFingerprinted(name) {
+				CachePolicy{Immutable: true}.apply(w)
+			}
+			// http.FileServer answers If-None-Match from the ETag
+			if etag := etags.get(name, f); etag != "" {
+				w.Header().Set("ETag", etag)
+			}
+			f.Close()
+		}
+		fs.ServeHTTP(w, req)
+	})
+}
+
+// contentETags computes and caches ETags from the content of static files
+// without a modification time, such as embedded ones. Those can't change
+// while the app runs, so each is hashed once.
+type contentETags struct {
+	etags sync.Map // name -> ETag
+}
+
+// get returns the ETag for the file name opened as f, or "" if it's a
+// directory or has a modification time (http.FileServer uses that instead).
+func (c *contentETags) get(name string, f http.File) string {
+	if etag, ok := c.etags.Load(name); ok {
+		return etag.(string)
+	}
+	info, err := f.Stat()
+	if err != nil || info.IsDir() || !info.ModTime().IsZero() {
+		return ""
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	c.etags.Store(name, etag)
+	return etag
+}
+
+// fingerprintPattern matches a content hash before the file extension,
+// e.g. app.3f2a9c1b.css or app-3f2a9c1b.js.
+var fingerprintPattern = regexp.MustCompile(`[.-][0-9a-fA-F]{8,}$`)
+
+// IsFingerprinted reports whether the file name carries a content hash,
+// meaning its content can never change and it may be cached immutably.
+func IsFingerprinted(name string) bool {
+	base := path.Base(name)
+	ext := path.Ext(base)
+	if ext == "" {
+		return false
+	}
+	return fingerprintPattern.MatchString(strings.TrimSuffix(base, ext))
+}
@@ -0,0 +1,374 @@
+package router
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultCompressionMinSize is the smallest response CompressionMiddleware
+// compresses when given a minSize of 0. Smaller bodies gain little and
+// cost a gzip header each.
+const DefaultCompressionMinSize = 1024
+
+// CompressionMiddleware returns middleware that gzip- or deflate-encodes
+// responses of at least minSize bytes (DefaultCompressionMinSize if 0)
+// for clients that accept it, setting Content-Encoding and
+// Vary: Accept-Encoding.
+//
+// Responses are left as they are if they're already encoded, of a
+// compressed type (images, video, archives, fonts), partial, or streamed:
+// SSE responses and any response flushed before reaching minSize.
+// WebSocket upgrades pass straight through.
+func CompressionMiddleware(minSize int) Middleware {
+	if minSize <= 0 {
+		minSize = DefaultCompressionMinSize
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead || strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header,
+// preferring gzip, or returns "" if neither is acceptable.
+func negotiateEncoding(accept string) string {
+	var gzipOK, deflateOK bool
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip", "*":
+			gzipOK = true
+		case "deflate":
+			deflateOK = true
+		}
+	}
+	switch {
+	case gzipOK:
+		return "gzip"
+	case deflateOK:
+		return "deflate"
+	}
+	return ""
+}
+
+// incompressibleTypes are content types that are already compressed.
+var incompressibleTypes = []string{
+	"image/", "video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip",
+	"application/x-7z-compressed", "application/x-rar-compressed",
+	"application/zstd", "application/wasm",
+	"text/event-stream",
+}
+
+// compressible reports whether a response with header h may be compressed.
+func compressible(h http.Header) bool {
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		// No or invalid type: net/http sniffs it from the body, which we
+		// can't know yet, so be conservative
+		return h.Get("Content-Type") == ""
+	}
+	if mediaType == "image/svg+xml" {
+		return true
+	}
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// compressWriter buffers a response until it reaches minSize, then either
+// compresses it or, if it's incompressible or ends or is flushed first,
+// writes it as is.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser // Set once decided to compress
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status != 0 || w.decided {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	// 1xx responses are sent straight away and don't end the headers
+	if status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	// Bodyless and partial responses are never compressed
+	if status == http.StatusNoContent || status == http.StatusNotModified ||
+		status == http.StatusPartialContent || !compressible(w.Header()) {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide commits the headers, compressing from here on if compress is
+// set, and writes out the buffered body.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	h := w.Header()
+	// net/http would sniff the type from the encoded bytes, so sniff it
+	// here from the plain ones
+	if compress && h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+		compress = compressible(h)
+	}
+	if compressible(h) {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	if compress {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		// The encoded bytes differ, so a strong validator no longer holds
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		w.enc = newEncoder(w.encoding, w.ResponseWriter)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush sends what's been written so far. A response flushed before it's
+// reached minSize is a stream and is sent uncompressed.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack supports connection takeover by handlers that need it.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close finishes the response: a body that never reached minSize is
+// written uncompressed.
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			// Nothing written; let net/http send its implicit 200
+			return
+		}
+		w.decide(false)
+		return
+	}
+	if w.enc != nil {
+		w.enc.Close()
+		putEncoder(w.encoding, w.enc)
+	}
+}
+
+var (
+	gzipPool  sync.Pool
+	flatePool sync.Pool
+)
+
+// newEncoder returns a pooled gzip or deflate writer onto dst.
+func newEncoder(encoding string, dst io.Writer) io.WriteCloser {
+	if encoding == "deflate" {
+		if fw, ok := flatePool.Get().(*flate.Writer); ok {
+			fw.Reset(dst)
+			return fw
+		}
+		fw, _ := flate.NewWriter(dst, flate.DefaultCompression)
+		return fw
+	}
+	if gw, ok := gzipPool.Get().(*gzip.Writer); ok {
+		gw.Reset(dst)
+		return gw
+	}
+	return gzip.NewWriter(dst)
+}
+
+// putEncoder returns a closed encoder to its pool.
+func putEncoder(encoding string, enc io.WriteCloser) {
+	if encoding == "deflate" {
+		flatePool.Put(enc)
+		return
+	}
+	gzipPool.Put(enc)
+}
+
+// DefaultMaxDecompressedSize is the largest decoded request body
+// DecompressionMiddleware allows when given a maxSize of 0.
+const DefaultMaxDecompressedSize int64 = 10 << 20
+
+// ErrBodyTooLarge is returned from reading a request body that decodes to
+// more than DecompressionMiddleware's limit. Returned from a handler, it
+// produces a 413 response.
+var ErrBodyTooLarge = &HTTPError{Status: http.StatusRequestEntityTooLarge, Message: "request body too large"}
+
+// DecompressionMiddleware returns middleware that decodes request bodies
+// sent with Content-Encoding gzip or deflate, so handlers read them as
+// plain bodies. Reading past maxSize decoded bytes
+// (DefaultMaxDecompressedSize if 0) fails with ErrBodyTooLarge, so a small
+// "zip bomb" can't expand without bound.
+//
+// Bodies in other encodings are rejected with 415 Unsupported Media Type
+// and corrupt ones with 400 Bad Request.
+func DecompressionMiddleware(maxSize int64) Middleware {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDecompressedSize
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			if encoding == "" || encoding == "identity" || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			dec, err := newDecoder(encoding, r.Body)
+			if err == errUnsupportedEncoding {
+				http.Error(w, "Unsupported Media Type: Content-Encoding "+encoding, http.StatusUnsupportedMediaType)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Bad Request: invalid "+encoding+" body", http.StatusBadRequest)
+				return
+			}
+
+			r.Body = &decodedBody{dec: dec, body: r.Body, remaining: maxSize}
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// errUnsupportedEncoding is returned by newDecoder for unknown encodings.
+var errUnsupportedEncoding = errors.New("unsupported Content-Encoding")
+
+// newDecoder returns a reader decoding body. "deflate" is meant to be
+// zlib-wrapped, but some clients send raw deflate, so both are accepted.
+func newDecoder(encoding string, body io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(body)
+	case "deflate":
+		br := bufio.NewReader(body)
+		if header, err := br.Peek(2); err == nil && isZlibHeader(header) {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	}
+	return nil, errUnsupportedEncoding
+}
+
+// isZlibHeader reports whether b starts a zlib stream (RFC 1950): the
+// deflate method with a valid header checksum.
+func isZlibHeader(b []byte) bool {
+	return b[0]&0x0f == 8 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0
+}
+
+// decodedBody is a decoded request body that fails once it passes its
+// size limit.
+type decodedBody struct {
+	dec       io.ReadCloser
+	body      io.Closer
+	remaining int64
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	// Read one byte past the limit to tell a body of exactly the limit
+	// from a larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.dec.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrBodyTooLarge
+	}
+	return n, err
+}
+
+func (b *decodedBody) Close() error {
+	b.dec.Close()
+	return b.body.Close()
+}
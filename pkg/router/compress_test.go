@@ -0,0 +1,188 @@
+package router
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompressionMiddleware(t *testing.T) {
+	page := "<ul>" + strings.Repeat("<li>Todo</li>", 200) + "</ul>"
+	r := New()
+	r.Use(CompressionMiddleware(0))
+	r.GET("/page", func(ctx *Context) (string, error) { return page, nil })
+	r.GET("/small", func(ctx *Context) (string, error) { return "<p>hi</p>", nil })
+	r.HandleFunc("/photo.png", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(page))
+	})
+	r.HandleFunc("/events", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: " + page + "\n\n"))
+	})
+	r.HandleFunc("/stream", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("<p>first</p>"))
+		http.NewResponseController(w).Flush()
+		w.Write([]byte(page))
+	})
+
+	tests := []struct {
+		path     string
+		accept   string
+		encoding string
+	}{
+		{"/page", "gzip, deflate, br", "gzip"},
+		{"/page", "deflate", "deflate"},
+		{"/page", "gzip;q=0, deflate", "deflate"},
+		{"/page", "", ""},
+		{"/small", "gzip", ""},
+		{"/photo.png", "gzip", ""},
+		{"/events", "gzip", ""},
+		{"/stream", "gzip", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept-Encoding", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+			t.Errorf("%s (%s): expected Content-Encoding %q, got %q", tt.path, tt.accept, tt.encoding, got)
+			continue
+		}
+
+		var body io.Reader = w.Body
+		switch tt.encoding {
+		case "gzip":
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.path, err)
+			}
+			body = zr
+		case "deflate":
+			body = flate.NewReader(w.Body)
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.path, err)
+		}
+		if tt.path == "/page" && string(data) != page {
+			t.Errorf("%s (%s): body not round-tripped", tt.path, tt.accept)
+		}
+		if tt.path == "/small" && string(data) != "<p>hi</p>" {
+			t.Errorf("expected the small body uncompressed, got %q", data)
+		}
+		if tt.encoding != "" {
+			if w.Header().Get("Vary") != "Accept-Encoding" || w.Header().Get("Content-Length") != "" {
+				t.Errorf("%s: unexpected headers %v", tt.path, w.Header())
+			}
+			if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+				t.Errorf("%s: expected the HTML content type, got %q", tt.path, w.Header().Get("Content-Type"))
+			}
+		}
+	}
+}
+
+func TestCompressionMiddlewareSniffsContentType(t *testing.T) {
+	page := "<!DOCTYPE html><html>" + strings.Repeat("<p>x</p>", 300) + "</html>"
+	handler := CompressionMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(page))
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip, got headers %v", w.Header())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("expected the type sniffed from the plain body, got %q", ct)
+	}
+}
+
+func TestDecompressionMiddleware(t *testing.T) {
+	r := New()
+	r.Use(DecompressionMiddleware(1024))
+	r.POST("/todos", func(ctx *Context) (string, error) {
+		return "<li>" + ctx.FormValue("title") + "</li>", nil
+	})
+	r.API(http.MethodPost, "/upload", func(ctx *Context) (any, error) {
+		data, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		return len(data), nil
+	})
+
+	encode := func(encoding, body string) *bytes.Buffer {
+		var buf bytes.Buffer
+		var w io.WriteCloser
+		switch encoding {
+		case "gzip":
+			w = gzip.NewWriter(&buf)
+		case "deflate":
+			w = zlib.NewWriter(&buf)
+		case "raw-deflate":
+			w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+		}
+		w.Write([]byte(body))
+		w.Close()
+		return &buf
+	}
+
+	for _, encoding := range []string{"gzip", "deflate", "raw-deflate"} {
+		req := httptest.NewRequest("POST", "/todos", encode(encoding, "title=Milk"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Content-Encoding", strings.TrimPrefix(encoding, "raw-"))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Body.String() != "<li>Milk</li>" {
+			t.Errorf("%s: expected the decoded form, got %d %q", encoding, w.Code, w.Body.String())
+		}
+	}
+
+	// A body within the cap decodes in full
+	req := httptest.NewRequest("POST", "/upload", encode("gzip", strings.Repeat("a", 1024)))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "1024" {
+		t.Errorf("expected 1024 bytes decoded, got %d %q", w.Code, w.Body.String())
+	}
+
+	// A small body that expands past the cap is cut off with 413
+	req = httptest.NewRequest("POST", "/upload", encode("gzip", strings.Repeat("a", 1<<20)))
+	req.Header.Set("Content-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 over the cap, got %d", w.Code)
+	}
+
+	tests := []struct {
+		encoding string
+		body     string
+		want     int
+	}{
+		{"br", "xyz", http.StatusUnsupportedMediaType},
+		{"gzip", "not gzip", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/upload", strings.NewReader(tt.body))
+		req.Header.Set("Content-Encoding", tt.encoding)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.encoding, tt.want, w.Code)
+		}
+	}
+}
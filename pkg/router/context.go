@@ -1,11 +1,17 @@
 package router
 
 import (
+	"context"
 	"encoding/json"
+	"mime"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/stukennedy/irgo/pkg/datastar"
+	"github.com/stukennedy/irgo/pkg/values"
 )
 
 // Context provides request data and response helpers for handlers.
@@ -13,6 +19,15 @@ type Context struct {
 	Request  *http.Request
 	Response http.ResponseWriter
 	written  bool
+	status   int    // Set by Status
+	route    string // Set by annotateRoute in dev mode
+	sse      *datastar.SSE
+
+	// Callbacks registered with OnFinish
+	finishMu  sync.Mutex
+	onFinish  []func()
+	finished  bool
+	stopWatch func() bool
 }
 
 // NewContext creates a new Context from the standard http types.
@@ -47,6 +62,21 @@ func (c *Context) FormValue(key string) string {
 	return c.Request.FormValue(key)
 }
 
+// Values returns the request's values as a values.Source.
+// JSON bodies are decoded (consuming the body) and checked before the query
+// string; otherwise form fields, including hx-vals, and the query are used.
+func (c *Context) Values() (values.Source, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	if mediaType != "application/json" || c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return values.Form(c.Request), nil
+	}
+	body, err := values.JSON(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	return values.Chain(body, values.Query(c.Request)), nil
+}
+
 // Header returns a request header value.
 func (c *Context) Header(key string) string {
 	return c.Request.Header.Get(key)
@@ -57,6 +87,30 @@ func (c *Context) SetHeader(key, value string) {
 	c.Response.Header().Set(key, value)
 }
 
+// SetTrailer sets a response trailer, sent after the body.
+// Before the response is written this also declares the trailer, so any
+// response carries it. Afterwards (e.g. a checksum at the end of a stream)
+// it's only delivered if the response was flushed, as streamed ones are.
+func (c *Context) SetTrailer(key, value string) {
+	if !c.written {
+		c.Response.Header().Add("Trailer", key)
+	}
+	c.Response.Header().Set(http.TrailerPrefix+key, value)
+}
+
+// --- HTMX Integration ---
+
+// IsHTMX returns true if this is an HTMX request (HX-Request: true).
+func (c *Context) IsHTMX() bool {
+	return IsHTMXRequest(c.Request)
+}
+
+// IsBoosted returns true if this is an hx-boost navigation, which should
+// render the full page rather than a fragment.
+func (c *Context) IsBoosted() bool {
+	return IsBoostedRequest(c.Request)
+}
+
 // --- Datastar Integration ---
 
 // IsDatastar returns true if this is a Datastar request.
@@ -66,10 +120,15 @@ func (c *Context) IsDatastar() bool {
 	return accept == "text/event-stream"
 }
 
-// SSE creates a new SSE writer for streaming Datastar responses.
-// Use this to send DOM patches, signal updates, and other SSE events.
+// SSE returns the SSE writer for streaming Datastar responses, starting the
+// stream on first use. Use this to send DOM patches, signal updates, and
+// other SSE events.
 func (c *Context) SSE() *datastar.SSE {
-	return datastar.NewSSE(c.Response, c.Request)
+	if c.sse == nil {
+		c.written = true
+		c.sse = datastar.NewSSE(c.Response, c.Request)
+	}
+	return c.sse
 }
 
 // ReadSignals extracts Datastar signals from the request body.
@@ -81,9 +140,26 @@ func (c *Context) ReadSignals(v any) error {
 
 // --- Standard HTTP Responses ---
 
-// HTML writes an HTML response with 200 status.
+// Status sets the status code used by HTML and JSON (and so for the
+// fragment a handler returns) in place of 200:
+//
+//	ctx.Status(http.StatusCreated)
+//	return "", ctx.JSON(todo)
+func (c *Context) Status(code int) {
+	c.status = code
+}
+
+// statusOr returns the status set with Status, or def.
+func (c *Context) statusOr(def int) int {
+	if c.status != 0 {
+		return c.status
+	}
+	return def
+}
+
+// HTML writes an HTML response with 200 status, or the one set with Status.
 func (c *Context) HTML(html string) {
-	c.HTMLStatus(http.StatusOK, html)
+	c.HTMLStatus(c.statusOr(http.StatusOK), html)
 }
 
 // HTMLStatus writes an HTML response with custom status.
@@ -91,25 +167,115 @@ func (c *Context) HTMLStatus(status int, html string) {
 	c.written = true
 	c.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
 	c.Response.WriteHeader(status)
-	c.Response.Write([]byte(html))
+	c.Response.Write([]byte(c.withRouteComment(html)))
 }
 
-// JSON writes a JSON response with 200 status.
-func (c *Context) JSON(data any) {
-	c.JSONStatus(http.StatusOK, data)
+// JSON writes a JSON response with 200 status, or the one set with Status.
+// If data can't be encoded nothing is written and the error is returned, so
+// a handler returning it still gets an error response.
+func (c *Context) JSON(data any) error {
+	return c.JSONStatus(c.statusOr(http.StatusOK), data)
 }
 
-// JSONStatus writes a JSON response with custom status.
-func (c *Context) JSONStatus(status int, data any) {
+// JSONStatus writes a JSON response with custom status. See JSON for errors.
+func (c *Context) JSONStatus(status int, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
 	c.written = true
 	c.Response.Header().Set("Content-Type", "application/json")
 	c.Response.WriteHeader(status)
-	json.NewEncoder(c.Response).Encode(data)
+	_, err = c.Response.Write(append(body, '\n'))
+	return err
+}
+
+// WantsJSON reports whether the client asked for JSON (Accept:
+// application/json) rather than HTML, as API clients do. Datastar requests
+// never do.
+func (c *Context) WantsJSON() bool {
+	if IsDatastarRequest(c.Request) {
+		return false
+	}
+	for _, accept := range strings.Split(c.Request.Header.Get("Accept"), ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(accept))
+		switch mediaType {
+		case "application/json":
+			return true
+		case "text/html":
+			return false
+		}
+	}
+	return false
+}
+
+// StreamJSONArray writes items as a JSON array with 200 status, encoding and
+// flushing each element as it arrives so large results never sit in memory.
+// The array is closed once items is closed. If ctx is cancelled or an element
+// fails to encode, the error is returned and the response is left truncated.
+func (c *Context) StreamJSONArray(ctx context.Context, items <-chan any) error {
+	c.written = true
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Response.WriteHeader(http.StatusOK)
+
+	// Flushing is best-effort; in-process responses are buffered anyway
+	rc := http.NewResponseController(c.Response)
+
+	if _, err := c.Response.Write([]byte("[")); err != nil {
+		return err
+	}
+	first := true
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case item, ok := <-items:
+			if !ok {
+				_, err := c.Response.Write([]byte("]"))
+				rc.Flush()
+				return err
+			}
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if !first {
+				data = append([]byte(","), data...)
+			}
+			first = false
+			if _, err := c.Response.Write(data); err != nil {
+				return err
+			}
+			rc.Flush()
+		}
+	}
 }
 
 // Error writes an error response.
+// The status comes from an HTTPError or ValidationErrors, defaulting to 500.
 func (c *Context) Error(err error) {
-	c.ErrorStatus(http.StatusInternalServerError, err.Error())
+	c.setDebugError(err)
+	c.ErrorStatus(errorStatus(err), err.Error())
+}
+
+// APIError writes err as a JSON error envelope for API clients.
+// ValidationErrors include per-field messages under "fields".
+func (c *Context) APIError(err error) {
+	c.setDebugError(err)
+	status, body := NewAPIError(err)
+	c.JSONStatus(status, body)
+}
+
+// setDebugError exposes err via ErrorHeader when debug errors are enabled,
+// along with the request ID so the console entry can be matched to server logs.
+func (c *Context) setDebugError(err error) {
+	if !IsDebugErrors(c.Request) {
+		return
+	}
+	c.SetHeader(ErrorHeader, sanitizeErrorHeader(err.Error()))
+	if id := middleware.GetReqID(c.Request.Context()); id != "" {
+		c.SetHeader(RequestIDHeader, id)
+	}
 }
 
 // ErrorStatus writes an error response with custom status.
@@ -136,10 +302,18 @@ func (c *Context) BadRequest(message string) {
 	c.ErrorStatus(http.StatusBadRequest, message)
 }
 
-// Redirect sends a standard HTTP redirect response.
+// Redirect navigates the client to url. Datastar requests are fetched, so
+// a redirect status would only swap the target page's HTML in; they get an
+// SSE event that sets window.location instead (also after other SSE
+// events). Other requests get 303 See Other.
+// Absolute paths are prefixed with the base path (see Context.URL).
 func (c *Context) Redirect(url string) {
+	if c.IsDatastar() {
+		c.SSE().Redirect(c.URL(url))
+		return
+	}
 	c.written = true
-	http.Redirect(c.Response, c.Request, url, http.StatusSeeOther)
+	http.Redirect(c.Response, c.Request, c.URL(url), http.StatusSeeOther)
 }
 
 // NoContent writes a 204 No Content response.
@@ -1,10 +1,24 @@
 package router
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/stukennedy/irgo/pkg/values"
 )
 
 func TestContextParam(t *testing.T) {
@@ -223,6 +237,88 @@ func TestContextJSON(t *testing.T) {
 	}
 }
 
+func TestContextStatusJSON(t *testing.T) {
+	r := New()
+	r.POST("/todos", func(ctx *Context) (string, error) {
+		ctx.Status(http.StatusCreated)
+		return "", ctx.JSON(map[string]any{"id": 1, "title": "Write tests"})
+	})
+	r.GET("/page", func(ctx *Context) (string, error) {
+		ctx.Status(http.StatusAccepted)
+		return "<p>queued</p>", nil
+	})
+
+	// Plain API client, no Datastar or HTML Accept header
+	req := httptest.NewRequest("POST", "/todos", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected status 201, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type 'application/json', got %q", ct)
+	}
+	var todo struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &todo); err != nil || todo.ID != 1 || todo.Title != "Write tests" {
+		t.Errorf("expected the todo as JSON, got %q (%v)", w.Body.String(), err)
+	}
+
+	// Status also applies to a returned fragment
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/page", nil))
+	if w.Code != http.StatusAccepted || !strings.Contains(w.Body.String(), "queued") {
+		t.Errorf("expected 202 fragment, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestContextJSONEncodeError(t *testing.T) {
+	r := New()
+	r.GET("/bad", func(ctx *Context) (string, error) {
+		return "", ctx.JSON(map[string]any{"ch": make(chan int)})
+	})
+
+	for _, accept := range []string{"application/json", "text/html"} {
+		req := httptest.NewRequest("GET", "/bad", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("%s: expected status 500, got %d", accept, w.Code)
+		}
+		isJSON := strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+		if isJSON != (accept == "application/json") {
+			t.Errorf("%s: got Content-Type %q, body %q", accept, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+}
+
+func TestContextWantsJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"text/html,application/xhtml+xml,*/*;q=0.8", false},
+		{"text/event-stream", false},
+		{"*/*", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", tt.accept)
+		if got := NewContext(httptest.NewRecorder(), req).WantsJSON(); got != tt.want {
+			t.Errorf("Accept %q: WantsJSON() = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
 func TestContextError(t *testing.T) {
 	r := New()
 
@@ -298,6 +394,38 @@ func TestContextRedirect(t *testing.T) {
 	}
 }
 
+func TestContextRedirectDatastar(t *testing.T) {
+	r := New()
+
+	r.POST("/save", func(ctx *Context) (string, error) {
+		ctx.SSE().PatchHTML(`<div id="status">Saved</div>`)
+		ctx.Redirect("/new-location")
+		return "", nil
+	})
+
+	req := httptest.NewRequest("POST", "/save", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("expected SSE response, got Content-Type %q", ct)
+	}
+	if loc := w.Header().Get("Location"); loc != "" {
+		t.Errorf("expected no Location header, got %q", loc)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Saved") {
+		t.Errorf("expected earlier patch to be kept, got %q", body)
+	}
+	if !strings.Contains(body, `window.location.href = "/new-location"`) {
+		t.Errorf("expected SSE redirect, got %q", body)
+	}
+}
+
 func TestContextNoContent(t *testing.T) {
 	r := New()
 
@@ -379,3 +507,594 @@ func TestContextSSE(t *testing.T) {
 		t.Error("expected SSE() to return non-nil")
 	}
 }
+
+func TestContextStreamJSONArray(t *testing.T) {
+	for _, n := range []int{0, 1, 50} {
+		r := New()
+		r.GET("/export", func(ctx *Context) (string, error) {
+			items := make(chan any)
+			go func() {
+				defer close(items)
+				for i := 0; i < n; i++ {
+					items <- map[string]int{"id": i}
+				}
+			}()
+			return "", ctx.StreamJSONArray(ctx.Request.Context(), items)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/export", nil))
+
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%d items: expected Content-Type 'application/json', got %q", n, ct)
+		}
+		if !w.Flushed && n > 0 {
+			t.Errorf("%d items: expected response to be flushed", n)
+		}
+
+		var got []map[string]int
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%d items: expected valid JSON, got %q: %v", n, w.Body.String(), err)
+		}
+		if len(got) != n {
+			t.Fatalf("%d items: expected %d elements, got %d", n, n, len(got))
+		}
+		for i, item := range got {
+			if item["id"] != i {
+				t.Errorf("%d items: element %d has id %d", n, i, item["id"])
+			}
+		}
+	}
+}
+
+func TestContextStreamJSONArrayCancelled(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/export", nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.StreamJSONArray(ctx, make(chan any)); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestContextValues(t *testing.T) {
+	r := New()
+	var got []string
+
+	r.POST("/search", func(ctx *Context) (string, error) {
+		src, err := ctx.Values()
+		if err != nil {
+			return "", err
+		}
+		got = append(got, values.String(src, "q")+"/"+values.String(src, "page"))
+		return "", nil
+	})
+
+	requests := []*http.Request{
+		httptest.NewRequest("POST", "/search?page=2", strings.NewReader(`{"q":"json"}`)),
+		httptest.NewRequest("POST", "/search?page=2", strings.NewReader("q=form")),
+	}
+	requests[0].Header.Set("Content-Type", "application/json; charset=utf-8")
+	requests[1].Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	for _, req := range requests {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(got) != 2 || got[0] != "json/2" || got[1] != "form/2" {
+		t.Errorf("expected [json/2 form/2], got %v", got)
+	}
+}
+
+// multipartRequest builds a POST with a text field followed by a file field.
+func multipartRequest(t *testing.T, field, filename string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("caption", "holiday")
+	fw, err := mw.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(content)
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestContextSaveUploadedFile(t *testing.T) {
+	dir := t.TempDir()
+	content := bytes.Repeat([]byte("photo"), 1000)
+
+	r := New()
+	var saved int64
+	r.POST("/upload", func(ctx *Context) (string, error) {
+		n, err := ctx.SaveUploadedFile("photo", filepath.Join(dir, "photo.jpg"))
+		saved = n
+		return "", err
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, multipartRequest(t, "photo", "IMG_0001.jpg", content))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if saved != int64(len(content)) {
+		t.Errorf("expected %d bytes saved, got %d", len(content), saved)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "photo.jpg"))
+	if err != nil || !bytes.Equal(got, content) {
+		t.Errorf("saved file doesn't match upload (err %v)", err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, multipartRequest(t, "other", "x.jpg", content))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for missing file field, got %d", w.Code)
+	}
+}
+
+func TestContextSaveUploadedFileSizeCap(t *testing.T) {
+	orig := MaxUploadSize
+	MaxUploadSize = 1024
+	t.Cleanup(func() { MaxUploadSize = orig })
+
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "video.mp4")
+	os.WriteFile(dst, []byte("previous"), 0644)
+
+	r := New()
+	r.POST("/upload", func(ctx *Context) (string, error) {
+		_, err := ctx.SaveUploadedFile("video", dst)
+		return "", err
+	})
+
+	// Exactly at the cap is allowed
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, multipartRequest(t, "video", "ok.mp4", bytes.Repeat([]byte("v"), 1024)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 at the cap, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, multipartRequest(t, "video", "big.mp4", bytes.Repeat([]byte("v"), 1025)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 over the cap, got %d", w.Code)
+	}
+	if got, _ := os.ReadFile(dst); len(got) != 1024 {
+		t.Errorf("expected rejected upload to leave the previous file, got %d bytes", len(got))
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no temp files left behind, got %d entries", len(entries))
+	}
+}
+
+func TestContextOpenUpload(t *testing.T) {
+	content := []byte("streamed contents")
+
+	// Streams from the body, and falls back to an already-parsed form
+	for _, parsed := range []bool{false, true} {
+		req := multipartRequest(t, "doc", "notes.txt", content)
+		ctx := NewContext(httptest.NewRecorder(), req)
+		if parsed {
+			ctx.FormValue("caption")
+		}
+
+		upload, err := ctx.OpenUpload("doc", 1<<20)
+		if err != nil {
+			t.Fatalf("parsed=%v: unexpected error: %v", parsed, err)
+		}
+		got, err := io.ReadAll(upload)
+		upload.Close()
+		if err != nil || !bytes.Equal(got, content) || upload.Filename != "notes.txt" {
+			t.Errorf("parsed=%v: got %q from %q (%v)", parsed, got, upload.Filename, err)
+		}
+	}
+
+	req := multipartRequest(t, "doc", "notes.txt", content)
+	upload, err := NewContext(httptest.NewRecorder(), req).OpenUpload("doc", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(upload); !errors.Is(err, ErrUploadTooLarge) || len(got) != 5 {
+		t.Errorf("expected ErrUploadTooLarge after 5 bytes, got %d bytes (%v)", len(got), err)
+	}
+}
+
+func TestContextOnFinish(t *testing.T) {
+	var order []string
+	var bodyAtFinish string
+	var w *httptest.ResponseRecorder
+
+	r := New()
+	r.GET("/report", func(ctx *Context) (string, error) {
+		ctx.OnFinish(func() { order = append(order, "first") })
+		ctx.OnFinish(func() {
+			order = append(order, "second")
+			bodyAtFinish = w.Body.String()
+		})
+		return "<p>report</p>", nil
+	})
+	r.API("GET", "/api/report", func(ctx *Context) (any, error) {
+		ctx.OnFinish(func() { order = append(order, "api") })
+		return nil, errors.New("failed")
+	})
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/report", nil))
+
+	if strings.Join(order, ",") != "second,first" {
+		t.Errorf("expected callbacks once each in reverse order, got %v", order)
+	}
+	if !strings.Contains(bodyAtFinish, "report") {
+		t.Errorf("expected callbacks to run after the response was written, body was %q", bodyAtFinish)
+	}
+
+	// Errors still finish
+	order = nil
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/report", nil))
+	if strings.Join(order, ",") != "api" {
+		t.Errorf("expected callback after an error, got %v", order)
+	}
+}
+
+func TestContextOnFinishCanceled(t *testing.T) {
+	var calls atomic.Int32
+	ran := make(chan struct{})
+	registered := make(chan struct{})
+	release := make(chan struct{})
+
+	r := New()
+	r.SSE("GET", "/stream", func(ctx *Context) error {
+		ctx.OnFinish(func() {
+			calls.Add(1)
+			close(ran)
+		})
+		close(registered)
+		<-release
+		return nil
+	})
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/stream", nil).WithContext(reqCtx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+
+	<-registered
+	cancel()
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("callback did not run on cancellation")
+	}
+
+	// The handler returning afterwards doesn't run it again
+	close(release)
+	<-done
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected callback to run once, ran %d times", n)
+	}
+
+	// Registering after the request finished runs immediately
+	ctx := NewContext(httptest.NewRecorder(), req)
+	ctx.finish()
+	late := false
+	ctx.OnFinish(func() { late = true })
+	if !late {
+		t.Error("expected a late callback to run immediately")
+	}
+}
+
+func TestContextParamInt(t *testing.T) {
+	r := New()
+	r.GET("/todos/{id}", func(ctx *Context) (string, error) {
+		id, err := ctx.ParamInt("id")
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(id, 10), nil
+	})
+	r.GET("/todos", func(ctx *Context) (string, error) {
+		_, err := ctx.ParamInt("id")
+		return "", err
+	})
+	r.GET("/page/{n}", func(ctx *Context) (string, error) {
+		return strconv.FormatInt(ctx.ParamIntDefault("n", 1), 10), nil
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/todos/42", http.StatusOK, "42"},
+		{"/todos/-7", http.StatusOK, "-7"},
+		{"/todos/abc", http.StatusBadRequest, `path parameter "id" must be an integer`},
+		{"/todos/99999999999999999999", http.StatusBadRequest, "must be an integer"},
+		{"/todos", http.StatusBadRequest, `missing path parameter "id"`},
+		{"/page/3", http.StatusOK, "3"},
+		{"/page/x", http.StatusOK, "1"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("GET %s: got %d %q, want %d containing %q", tt.path, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+}
+
+func TestContextParamIntAPIError(t *testing.T) {
+	r := New()
+	r.API("GET", "/api/todos/{id}", func(ctx *Context) (any, error) {
+		return ctx.ParamInt("id")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/todos/<script>", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "script") {
+		t.Errorf("expected the raw value left out of the error, got %q", w.Body.String())
+	}
+}
+
+func TestContextParamUUID(t *testing.T) {
+	const id = "3F2504E0-4F89-11D3-9A0C-0305E82C3301"
+	r := New()
+	var got string
+	r.GET("/items/{id}", func(ctx *Context) (string, error) {
+		var err error
+		got, err = ctx.ParamUUID("id")
+		return "", err
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/items/"+id, nil))
+	if w.Code != http.StatusOK || got != strings.ToLower(id) {
+		t.Errorf("expected %s, got %d %q", strings.ToLower(id), w.Code, got)
+	}
+
+	for _, bad := range []string{"42", "3f2504e0-4f89-11d3-9a0c-0305e82c330", "3f2504e0x4f89-11d3-9a0c-0305e82c3301", "3f2504e0-4f89-11d3-9a0c-0305e82c330g"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/items/"+bad, nil))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "must be a UUID") {
+			t.Errorf("%s: expected 400, got %d %q", bad, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestContextAttachment(t *testing.T) {
+	rows := [][]string{{"id", "title"}, {"1", "Learn irgo"}, {"2", `Say "hi", twice`}}
+	var want bytes.Buffer
+	csv.NewWriter(&want).WriteAll(rows)
+
+	r := New()
+	r.GET("/export.csv", func(ctx *Context) (string, error) {
+		return "", ctx.Attachment("todos.csv", "text/csv; charset=utf-8", func(w io.Writer) error {
+			return csv.NewWriter(w).WriteAll(rows)
+		})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/export.csv", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != "attachment; filename=todos.csv" {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	if got := w.Body.String(); got != want.String() {
+		t.Errorf("body = %q, want %q", got, want.String())
+	}
+}
+
+func TestContextAttachmentFilename(t *testing.T) {
+	for name, want := range map[string]string{
+		"my report.pdf": `attachment; filename="my report.pdf"`,
+		"résumé.pdf":    `attachment; filename*=utf-8''r%C3%A9sum%C3%A9.pdf`,
+	} {
+		w := httptest.NewRecorder()
+		ctx := NewContext(w, httptest.NewRequest("GET", "/", nil))
+		if err := ctx.Attachment(name, "application/pdf", func(io.Writer) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Header().Get("Content-Disposition"); got != want {
+			t.Errorf("%s: Content-Disposition = %q, want %q", name, got, want)
+		}
+		if !ctx.Written() || w.Body.Len() != 0 {
+			t.Errorf("%s: expected an empty attachment to be written", name)
+		}
+	}
+}
+
+func TestContextAttachmentError(t *testing.T) {
+	r := New()
+	r.GET("/report", func(ctx *Context) (string, error) {
+		return "", ctx.Attachment("report.pdf", "application/pdf", func(w io.Writer) error {
+			return NewHTTPError(http.StatusServiceUnavailable, "report not ready")
+		})
+	})
+	r.GET("/partial", func(ctx *Context) (string, error) {
+		return "", ctx.Attachment("data.csv", "text/csv", func(w io.Writer) error {
+			io.WriteString(w, "id\n")
+			return errors.New("database went away")
+		})
+	})
+
+	// Failing before any output leaves room for the error response
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/report", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Content-Disposition") != "" {
+		t.Errorf("expected a plain 503, got %d with %v", w.Code, w.Header())
+	}
+
+	// Once the download has started it's left as is
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/partial", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "id\n" {
+		t.Errorf("expected the partial download untouched, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestContextEventStream(t *testing.T) {
+	r := New()
+	stopped := make(chan struct{})
+	r.GET("/events", func(ctx *Context) (string, error) {
+		defer close(stopped)
+		stream := ctx.EventStream()
+		stream.Send("greeting", "hello")
+		stream.Send("", "line one\nline two")
+		stream.Comment("keep-alive")
+		stream.Send("count", "3")
+		stream.Flush()
+		<-stream.Done()
+		if err := stream.Send("late", "x"); err == nil {
+			t.Error("expected Send to fail after the client disconnected")
+		}
+		return "<p>ignored</p>", nil
+	})
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %q", ct)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("expected Cache-Control no-cache, got %q", cc)
+	}
+
+	// Read the three events while the handler is still streaming
+	want := "event: greeting\ndata: hello\n\n" +
+		"data: line one\ndata: line two\n\n" +
+		": keep-alive\n\n" +
+		"event: count\ndata: 3\n\n"
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(resp.Body, got); err != nil {
+		t.Fatalf("reading events: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("unexpected stream:\n%s", got)
+	}
+	resp.Body.Close()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler did not see the client disconnect")
+	}
+}
+
+func TestEventStreamEventName(t *testing.T) {
+	r := New()
+	r.GET("/events", func(ctx *Context) (string, error) {
+		ctx.EventStream().Send("bad\nevent: injected", "x")
+		return "", nil
+	})
+	req := httptest.NewRequest("GET", "/events", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if body := w.Body.String(); body != "event: badevent: injected\ndata: x\n\n" {
+		t.Errorf("unexpected stream %q", body)
+	}
+}
+
+type bindInput struct {
+	ID    int64  `path:"id"`
+	Page  int    `query:"page" validate:"min=1"`
+	Title string `form:"title" validate:"required,max=10"`
+	Done  bool   `form:"done"`
+}
+
+func (in *bindInput) Validate() error {
+	if in.Title == "forbidden" {
+		return ValidationErrors{"title": "is not allowed"}
+	}
+	return nil
+}
+
+func TestContextBindAndValidate(t *testing.T) {
+	r := New()
+	var got bindInput
+	r.API("POST", "/todos/{id}", func(ctx *Context) (any, error) {
+		got = bindInput{}
+		return "ok", ctx.BindAndValidate(&got)
+	})
+	post := func(path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	const form = "application/x-www-form-urlencoded"
+
+	w := post("/todos/42?page=3", form, "title=Milk&done=on")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	if got != (bindInput{ID: 42, Page: 3, Title: "Milk", Done: true}) {
+		t.Errorf("unexpected binding %+v", got)
+	}
+
+	// JSON bodies bind to form fields too
+	w = post("/todos/7?page=1", "application/json", `{"title": "Eggs", "done": true}`)
+	if w.Code != http.StatusOK || got != (bindInput{ID: 7, Page: 1, Title: "Eggs", Done: true}) {
+		t.Errorf("unexpected JSON binding %d %+v", w.Code, got)
+	}
+
+	// Each failing field is reported with its source
+	w = post("/todos/abc?page=0", form, "")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+	var resp APIError
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"id":    "must be an integer (path)",
+		"page":  "must be at least 1 (query)",
+		"title": "is required (form)",
+	}
+	if len(resp.Error.Fields) != len(want) {
+		t.Errorf("expected fields %v, got %v", want, resp.Error.Fields)
+	}
+	for field, msg := range want {
+		if resp.Error.Fields[field] != msg {
+			t.Errorf("field %s: expected %q, got %q", field, msg, resp.Error.Fields[field])
+		}
+	}
+
+	// Length limits and the struct's own Validate
+	w = post("/todos/1", form, "title=far+too+long+a+title")
+	if !strings.Contains(w.Body.String(), "must be at most 10 characters (form)") {
+		t.Errorf("expected a max length error, got %s", w.Body.String())
+	}
+	w = post("/todos/1", form, "title=forbidden")
+	if !strings.Contains(w.Body.String(), "is not allowed") {
+		t.Errorf("expected the Validate error, got %s", w.Body.String())
+	}
+
+	// A malformed body is a bad request
+	if w = post("/todos/1", "application/json", "{"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// handlerName returns the qualified function name of a handler,
+// e.g. "main.showTodo" or "main.setupRouter.func1" for closures.
+func handlerName(handler any) string {
+	v := reflect.ValueOf(handler)
+	if v.Kind() != reflect.Func {
+		return reflect.TypeOf(handler).String()
+	}
+	if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return "unknown"
+}
+
+// annotateRoute records the route serving c for DevRoutesMiddleware.
+// It sets RouteHeader and makes HTMLStatus prepend a comment.
+func (c *Context) annotateRoute(handler string) {
+	if !IsDevRoutes(c.Request) {
+		return
+	}
+
+	pattern := c.Request.URL.Path
+	if rctx := chi.RouteContext(c.Request.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		pattern = rctx.RoutePattern()
+	}
+	c.route = c.Request.Method + " " + pattern + " handler=" + handler
+	c.Response.Header().Set(RouteHeader, sanitizeErrorHeader(c.route))
+}
+
+// withRouteComment inserts the route comment at the start of html, after
+// any doctype so the page stays in standards mode.
+func (c *Context) withRouteComment(html string) string {
+	if c.route == "" {
+		return html
+	}
+	// "--" can't appear inside an HTML comment
+	comment := "<!-- irgo: route=" + strings.ReplaceAll(c.route, "--", "- -") + " -->"
+
+	if len(html) >= 9 && strings.EqualFold(html[:9], "<!doctype") {
+		if end := strings.IndexByte(html, '>'); end >= 0 {
+			return html[:end+1] + "\n" + comment + html[end+1:]
+		}
+	}
+	return comment + "\n" + html
+}
@@ -0,0 +1,62 @@
+package router
+
+import (
+	"io"
+	"mime"
+	"net/http"
+)
+
+// Attachment sends a file download named filename, with the body written
+// by write:
+//
+//	return "", ctx.Attachment("todos.csv", "text/csv", func(w io.Writer) error {
+//		return csv.NewWriter(w).WriteAll(rows)
+//	})
+//
+// Headers go out with the first byte written, so if write fails before
+// writing anything its error is returned with the response untouched, and
+// the handler's usual error response is sent instead. Over the loopback
+// server the body streams to the webview as it's written; the mobile
+// bridge buffers it, like every response.
+func (c *Context) Attachment(filename, contentType string, write func(io.Writer) error) error {
+	w := &attachmentWriter{c: c, filename: filename, contentType: contentType}
+	if err := write(w); err != nil {
+		return err
+	}
+	// An empty file still gets its headers
+	w.start()
+	return nil
+}
+
+// attachmentWriter writes the attachment headers before the first byte.
+type attachmentWriter struct {
+	c           *Context
+	filename    string
+	contentType string
+	started     bool
+}
+
+func (w *attachmentWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+	w.c.written = true
+
+	h := w.c.Response.Header()
+	h.Set("Content-Type", w.contentType)
+	// FormatMediaType quotes the name, or encodes it per RFC 2231 when it
+	// isn't ASCII
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": w.filename})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	h.Set("Content-Disposition", disposition)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.c.Response.WriteHeader(w.c.statusOr(http.StatusOK))
+}
+
+func (w *attachmentWriter) Write(p []byte) (int, error) {
+	w.start()
+	return w.c.Response.Write(p)
+}
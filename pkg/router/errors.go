@@ -0,0 +1,127 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// HTTPError is an error carrying an HTTP status code.
+// Return it from any handler to control the error response status.
+type HTTPError struct {
+	Status  int    // HTTP status code
+	Code    string // Machine-readable error code (defaults from Status)
+	Message string // Human-readable message
+	Err     error  // Underlying error, if any
+}
+
+// NewHTTPError creates an HTTPError with the given status and message.
+func NewHTTPError(status int, message string) *HTTPError {
+	return &HTTPError{Status: status, Message: message}
+}
+
+// Error implements error.
+func (e *HTTPError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return http.StatusText(e.Status)
+}
+
+// Unwrap returns the underlying error.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors maps field names to validation messages.
+// Handlers return it to report field-level input errors.
+type ValidationErrors map[string]string
+
+// Add records a validation message for a field.
+func (v ValidationErrors) Add(field, message string) {
+	v[field] = message
+}
+
+// HasErrors returns true if any field failed validation.
+func (v ValidationErrors) HasErrors() bool {
+	return len(v) > 0
+}
+
+// Error implements error, listing fields in sorted order.
+func (v ValidationErrors) Error() string {
+	fields := make([]string, 0, len(v))
+	for field := range v {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, field+": "+v[field])
+	}
+	return "validation failed: " + strings.Join(parts, ", ")
+}
+
+// APIError is the JSON error envelope written for API handlers:
+//
+//	{"error": {"code": "validation_failed", "message": "...", "fields": {"email": "required"}}}
+type APIError struct {
+	Error APIErrorBody `json:"error"`
+}
+
+// APIErrorBody holds the details of an APIError.
+type APIErrorBody struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// errorStatus returns the HTTP status for an error.
+// ValidationErrors map to 422, HTTPError to its status, everything else to 500.
+func errorStatus(err error) int {
+	var verrs ValidationErrors
+	if errors.As(err, &verrs) {
+		return http.StatusUnprocessableEntity
+	}
+	var herr *HTTPError
+	if errors.As(err, &herr) && herr.Status != 0 {
+		return herr.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// NewAPIError converts an error into the JSON error envelope and its status.
+func NewAPIError(err error) (int, *APIError) {
+	status := errorStatus(err)
+	body := APIErrorBody{
+		Code:    statusCode(status),
+		Message: err.Error(),
+	}
+
+	var verrs ValidationErrors
+	var herr *HTTPError
+	switch {
+	case errors.As(err, &verrs):
+		body.Code = "validation_failed"
+		body.Message = "Validation failed"
+		body.Fields = verrs
+	case errors.As(err, &herr) && herr.Code != "":
+		body.Code = herr.Code
+	}
+
+	return status, &APIError{Error: body}
+}
+
+// statusCode converts an HTTP status into a snake_case error code,
+// e.g. 404 -> "not_found".
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
@@ -0,0 +1,52 @@
+package router
+
+import (
+	"context"
+)
+
+// OnFinish registers fn to run once the request is done: after the
+// response has been written, or as soon as the request is canceled
+// (client gone, transport shut down), whichever comes first. Use it to
+// release resources a handler opens, such as temp files or streams.
+//
+// Callbacks run once each, in reverse order of registration like deferred
+// calls. On cancellation they run on another goroutine while the handler
+// may still be running. fn registered after the request finished runs
+// immediately.
+//
+// Only handlers registered on a Router finish automatically; a Context
+// made with NewContext runs its callbacks on cancellation only.
+func (c *Context) OnFinish(fn func()) {
+	c.finishMu.Lock()
+	if c.finished {
+		c.finishMu.Unlock()
+		fn()
+		return
+	}
+	c.onFinish = append(c.onFinish, fn)
+	if c.stopWatch == nil {
+		c.stopWatch = context.AfterFunc(c.Request.Context(), c.finish)
+	}
+	c.finishMu.Unlock()
+}
+
+// finish runs the OnFinish callbacks, once.
+func (c *Context) finish() {
+	c.finishMu.Lock()
+	if c.finished {
+		c.finishMu.Unlock()
+		return
+	}
+	c.finished = true
+	callbacks := c.onFinish
+	c.onFinish = nil
+	stop := c.stopWatch
+	c.finishMu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+	for i := len(callbacks) - 1; i >= 0; i-- {
+		callbacks[i]()
+	}
+}
@@ -0,0 +1,51 @@
+package router
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	r := New()
+	r.Use(LoggingMiddleware(slog.New(slog.NewTextHandler(&buf, nil))))
+	r.POST("/todos", func(ctx *Context) (string, error) {
+		ctx.Status(201)
+		return "<li>Milk</li>", nil
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/todos?list=home", nil))
+
+	line := buf.String()
+	for _, want := range []string{"msg=request", "method=POST", `path="/todos?list=home"`, "status=201", "duration="} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected %q in %q", want, line)
+		}
+	}
+
+	buf.Reset()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+	if line := buf.String(); !strings.Contains(line, "path=/missing") || !strings.Contains(line, "status=404") {
+		t.Errorf("expected the not found request logged, got %q", line)
+	}
+}
+
+func TestLoggingMiddlewareRedactQuery(t *testing.T) {
+	var buf bytes.Buffer
+	r := New()
+	r.Use(LoggingMiddleware(slog.New(slog.NewTextHandler(&buf, nil)), RedactQuery()))
+	r.GET("/reset", func(ctx *Context) (string, error) { return "<p>ok</p>", nil })
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reset?token=abc123&email=a%40b.c", nil))
+
+	line := buf.String()
+	if strings.Contains(line, "abc123") || strings.Contains(line, "a%40b.c") {
+		t.Errorf("expected query values redacted, got %q", line)
+	}
+	if !strings.Contains(line, `path="/reset?email=REDACTED&token=REDACTED"`) {
+		t.Errorf("expected redacted query parameters, got %q", line)
+	}
+}
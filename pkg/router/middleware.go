@@ -3,6 +3,7 @@ package router
 import (
 	"context"
 	"net/http"
+	"strings"
 )
 
 // contextKey is used for context values.
@@ -11,8 +12,29 @@ type contextKey string
 const (
 	// DatastarRequestKey is the context key for Datastar SSE request detection.
 	DatastarRequestKey contextKey = "datastar-request"
+
+	// DebugErrorsKey is the context key marking requests that may expose error details.
+	DebugErrorsKey contextKey = "debug-errors"
+
+	// DevRoutesKey is the context key marking requests whose responses are
+	// annotated with the route and handler that served them.
+	DevRoutesKey contextKey = "dev-routes"
 )
 
+// ErrorHeader carries a sanitized error message on error responses in debug mode.
+// The bridge script logs it to the devtools console along with the request ID.
+const ErrorHeader = "X-Irgo-Error"
+
+// RequestIDHeader carries the request ID alongside ErrorHeader.
+const RequestIDHeader = "X-Request-Id"
+
+// RouteHeader names the route and handler that served a response in dev
+// mode, e.g. "GET /todos/{id} handler=main.showTodo".
+const RouteHeader = "X-Irgo-Route"
+
+// maxErrorHeaderLen caps the length of ErrorHeader values.
+const maxErrorHeaderLen = 512
+
 // DatastarRequestMiddleware detects and tags Datastar SSE requests.
 // Datastar sends Accept: text/event-stream for SSE requests.
 func DatastarRequestMiddleware(next http.Handler) http.Handler {
@@ -31,16 +53,91 @@ func IsDatastarRequest(r *http.Request) bool {
 	return r.Header.Get("Accept") == "text/event-stream"
 }
 
+// IsHTMXRequest returns true if the request was made by HTMX (HX-Request: true).
+func IsHTMXRequest(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+// IsBoostedRequest returns true if the request is an hx-boost navigation
+// (HX-Boosted: true). HTMX swaps the whole body for these, so they expect
+// a full page rather than a fragment.
+func IsBoostedRequest(r *http.Request) bool {
+	return r.Header.Get("HX-Boosted") == "true"
+}
+
+// DebugErrorsMiddleware enables ErrorHeader on error responses.
+// Only install it in debug builds: error messages may reveal internals.
+func DebugErrorsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), DebugErrorsKey, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// IsDebugErrors returns true if error details may be exposed for this request.
+func IsDebugErrors(r *http.Request) bool {
+	v, _ := r.Context().Value(DebugErrorsKey).(bool)
+	return v
+}
+
+// DevRoutesMiddleware annotates responses with the route and handler that
+// served them: RouteHeader on every route registered through Router, and an
+// <!-- irgo: route=... --> comment at the start of HTML responses.
+// Only install it in dev builds; without it nothing is added.
+func DevRoutesMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), DevRoutesKey, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// IsDevRoutes returns true if responses to r should be annotated.
+func IsDevRoutes(r *http.Request) bool {
+	v, _ := r.Context().Value(DevRoutesKey).(bool)
+	return v
+}
+
+// sanitizeErrorHeader collapses message to a single printable ASCII line
+// suitable for an HTTP header value.
+func sanitizeErrorHeader(message string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range message {
+		if r < 0x20 || r == 0x7f || r > 0x7e {
+			r = ' '
+		}
+		if r == ' ' {
+			if space {
+				continue
+			}
+			space = true
+		} else {
+			space = false
+		}
+		b.WriteRune(r)
+		if b.Len() >= maxErrorHeaderLen {
+			break
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
 // LayoutWrapper wraps fragment responses in a full page layout
-// when the request is not from Datastar (direct browser navigation).
+// for direct browser navigation and hx-boost navigation. Datastar and
+// (non-boosted) HTMX requests get the bare fragment.
 type LayoutWrapper struct {
 	Layout func(content string) string
+
+	// BoostedLayout, if set, is used instead of Layout for hx-boost
+	// navigations, e.g. to leave out scripts the page already has.
+	BoostedLayout func(content string) string
 }
 
-// Wrap returns middleware that wraps non-Datastar responses in a layout.
+// Wrap returns middleware that wraps page and boosted responses in a layout.
 func (l *LayoutWrapper) Wrap(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if IsDatastarRequest(r) || l.Layout == nil {
+		layout := l.layoutFor(r)
+		if layout == nil {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -55,7 +152,7 @@ func (l *LayoutWrapper) Wrap(next http.Handler) http.Handler {
 		// If it's HTML and not an error, wrap in layout
 		contentType := rec.Header().Get("Content-Type")
 		if rec.statusCode < 400 && isHTML(contentType) {
-			wrapped := l.Layout(string(rec.body))
+			wrapped := layout(string(rec.body))
 			w.Header().Set("Content-Type", "text/html; charset=utf-8")
 			w.WriteHeader(rec.statusCode)
 			w.Write([]byte(wrapped))
@@ -67,6 +164,22 @@ func (l *LayoutWrapper) Wrap(next http.Handler) http.Handler {
 	})
 }
 
+// layoutFor returns the layout for r, or nil if r gets the bare fragment.
+func (l *LayoutWrapper) layoutFor(r *http.Request) func(content string) string {
+	switch {
+	case IsDatastarRequest(r):
+		return nil
+	case IsBoostedRequest(r):
+		if l.BoostedLayout != nil {
+			return l.BoostedLayout
+		}
+		return l.Layout
+	case IsHTMXRequest(r):
+		return nil
+	}
+	return l.Layout
+}
+
 // responseRecorder captures the response for post-processing.
 type responseRecorder struct {
 	http.ResponseWriter
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"html"
+	"net/http"
+)
+
+// RouteNotFoundHeader marks 404 responses produced because no route matched,
+// as opposed to a handler reporting that a resource doesn't exist.
+const RouteNotFoundHeader = "X-Irgo-Route-Not-Found"
+
+// notFoundPageStyle keeps the default 404 page readable without app CSS.
+const notFoundPageStyle = `body{margin:0;min-height:100vh;display:flex;align-items:center;justify-content:center;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,sans-serif;background:#f9fafb;color:#111827}` +
+	`.error{text-align:center;padding:2rem}.error h1{font-size:3rem;margin:0 0 .5rem}.error p{color:#6b7280;margin:0 0 1.5rem}.error a{color:#2563eb}`
+
+// NotFoundPage is the framework's default handler for unmatched routes.
+// Datastar requests get a styled error fragment; direct navigation
+// (including the mobile WebView's initial load) gets a complete page.
+func NotFoundPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(RouteNotFoundHeader, "1")
+
+	ctx := NewContext(w, r)
+	if IsDatastarRequest(r) {
+		ctx.NotFound("Page not found")
+		return
+	}
+
+	ctx.HTMLStatus(http.StatusNotFound, `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Not Found</title>
+<style>`+notFoundPageStyle+`</style>
+</head>
+<body>
+<div class="error" role="alert">
+<h1>404</h1>
+<p>No page at `+html.EscapeString(r.URL.Path)+`</p>
+<a href="`+html.EscapeString(BasePath(r)+"/")+`">Go home</a>
+</div>
+</body>
+</html>`)
+}
+
+// markRouteNotFound tags responses from a custom 404 handler with
+// RouteNotFoundHeader so callers can tell them apart from handler errors.
+func markRouteNotFound(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RouteNotFoundHeader, "1")
+		handler(w, r)
+	}
+}
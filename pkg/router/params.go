@@ -0,0 +1,85 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ParamInt returns the path parameter key as an int64. If it's missing or
+// not an integer the error is an HTTPError with status 400, so a handler
+// can return it as is:
+//
+//	id, err := ctx.ParamInt("id")
+//	if err != nil {
+//		return "", err
+//	}
+func (c *Context) ParamInt(key string) (int64, error) {
+	s := c.Param(key)
+	if s == "" {
+		return 0, missingParam(key)
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, invalidParam(key, "an integer", err)
+	}
+	return n, nil
+}
+
+// ParamIntDefault returns the path parameter key as an int64, or def if
+// it's missing or not an integer.
+func (c *Context) ParamIntDefault(key string, def int64) int64 {
+	n, err := c.ParamInt(key)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// ParamUUID returns the path parameter key as a lowercase UUID in the
+// canonical 8-4-4-4-12 hex form. Like ParamInt, errors are HTTPErrors with
+// status 400.
+func (c *Context) ParamUUID(key string) (string, error) {
+	s := c.Param(key)
+	if s == "" {
+		return "", missingParam(key)
+	}
+	if !isUUID(s) {
+		return "", invalidParam(key, "a UUID", fmt.Errorf("%q is not a UUID", s))
+	}
+	return strings.ToLower(s), nil
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f' || 'A' <= r && r <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func missingParam(key string) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("missing path parameter %q", key))
+}
+
+// invalidParam reports a malformed parameter. The message leaves out the
+// value, as error responses render the message as HTML.
+func invalidParam(key, want string, err error) *HTTPError {
+	return &HTTPError{
+		Status:  http.StatusBadRequest,
+		Message: fmt.Sprintf("path parameter %q must be %s", key, want),
+		Err:     err,
+	}
+}
@@ -0,0 +1,69 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/stukennedy/irgo/pkg/core"
+)
+
+// RecordRequests returns middleware that writes each request to w, one
+// JSON-encoded core.Request per line, so a bug can be reproduced by
+// replaying them (see testing.Client.ReplayAll). Request bodies are read
+// in full and passed on to the handler unchanged.
+//
+// Recordings hold cookies, form data and anything else the requests
+// carried, so only install it in development. The loopback secret header
+// is left out, and WebSocket upgrades aren't recorded.
+func RecordRequests(w io.Writer) Middleware {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+				next.ServeHTTP(rw, r)
+				return
+			}
+
+			var body []byte
+			if r.Body != nil {
+				var err error
+				if body, err = io.ReadAll(r.Body); err != nil {
+					http.Error(rw, "Bad Request: reading body", http.StatusBadRequest)
+					return
+				}
+				r.Body.Close()
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			mu.Lock()
+			enc.Encode(recordedRequest(r, body))
+			mu.Unlock()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// recordedRequest converts r to a core.Request, joining repeated headers.
+func recordedRequest(r *http.Request, body []byte) *core.Request {
+	headers := make(map[string]string, len(r.Header))
+	for key, values := range r.Header {
+		if key == "X-Irgo-Secret" {
+			continue
+		}
+		headers[key] = strings.Join(values, ", ")
+	}
+
+	req := core.NewRequest(r.Method, r.URL.RequestURI())
+	req.SetHeaders(headers)
+	if len(body) > 0 {
+		req.Body = body
+	}
+	return req
+}
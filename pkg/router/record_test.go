@@ -0,0 +1,52 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stukennedy/irgo/pkg/core"
+)
+
+func TestRecordRequests(t *testing.T) {
+	var recording bytes.Buffer
+	var got string
+	handler := RecordRequests(&recording)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = string(body)
+	}))
+
+	req := httptest.NewRequest("POST", "/todos?list=1", strings.NewReader("title=Milk"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Irgo-Secret", "s3cret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "title=Milk" {
+		t.Errorf("expected the handler to read the body, got %q", got)
+	}
+
+	upgrade := httptest.NewRequest("GET", "/ws", nil)
+	upgrade.Header.Set("Upgrade", "websocket")
+	handler.ServeHTTP(httptest.NewRecorder(), upgrade)
+
+	lines := strings.Split(strings.TrimSpace(recording.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 recorded request, got %d:\n%s", len(lines), recording.String())
+	}
+	var entry core.Request
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entry.Method != "POST" || entry.URL != "/todos?list=1" || entry.BodyString() != "title=Milk" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if entry.ContentType() != "application/x-www-form-urlencoded" {
+		t.Errorf("expected the Content-Type header, got %q", entry.ContentType())
+	}
+	if entry.GetHeader("X-Irgo-Secret") != "" {
+		t.Error("expected the secret header to be left out")
+	}
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"net/http"
+	"slices"
+)
+
+// ErrForbidden is returned for requests whose principal lacks the role a
+// route requires. Returned from a handler, it produces a 403 response.
+var ErrForbidden = &HTTPError{Status: http.StatusForbidden, Message: "Forbidden"}
+
+// HasRole reports whether the principal was granted role.
+func (p *Principal) HasRole(role string) bool {
+	return p != nil && slices.Contains(p.Roles, role)
+}
+
+// HasRole reports whether the request's principal (see AuthMiddleware) was
+// granted role. It's false for unauthenticated requests.
+func (c *Context) HasRole(role string) bool {
+	return c.Principal().HasRole(role)
+}
+
+// Require restricts the route to principals granted at least one of roles,
+// checked before the handler runs. Requests without a principal get 401
+// and those without a role 403, as a JSON envelope for API routes and
+// clients that want JSON, and otherwise an error fragment. Chained calls
+// must all pass:
+//
+//	admin.Use(router.AuthMiddleware(verifier))
+//	admin.DELETE("/users/{id}", deleteUser).Require("admin")
+//	admin.GET("/reports", reports).Require("admin", "auditor").Require("staff")
+func (o *RouteOptions) Require(roles ...string) *RouteOptions {
+	o.roles = append(o.roles, roles)
+	return o
+}
+
+// authorize checks the route's required roles, writing 401 or 403 and
+// returning false if the request's principal lacks them.
+func (o *RouteOptions) authorize(w http.ResponseWriter, req *http.Request, api bool) bool {
+	if len(o.roles) == 0 {
+		return true
+	}
+	p := PrincipalFromContext(req.Context())
+	err := ErrUnauthorized
+	if p != nil {
+		err = nil
+		for _, roles := range o.roles {
+			if !slices.ContainsFunc(roles, p.HasRole) {
+				err = ErrForbidden
+				break
+			}
+		}
+	}
+	if err == nil {
+		return true
+	}
+	if api {
+		NewContext(w, req).APIError(err)
+	} else {
+		deny(NewContext(w, req), err, "")
+	}
+	return false
+}
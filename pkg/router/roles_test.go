@@ -0,0 +1,100 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequire(t *testing.T) {
+	r := New()
+	r.Use(AuthMiddleware(BearerVerifier(lookupToken)))
+	r.GET("/admin", func(ctx *Context) (string, error) {
+		return "admin page", nil
+	}).Require("admin")
+	r.GET("/staff", func(ctx *Context) (string, error) {
+		return "staff page", nil
+	}).Require("admin", "staff")
+	r.GET("/both", func(ctx *Context) (string, error) {
+		return "both page", nil
+	}).Require("admin").Require("auditor")
+	r.API("GET", "/api/admin", func(ctx *Context) (any, error) {
+		return map[string]bool{"ok": true}, nil
+	}).Require("admin")
+	r.DSGet("/ds/admin", func(ctx *Context) error {
+		ctx.SSE().PatchSignals(map[string]bool{"ok": true})
+		return nil
+	}).Require("admin")
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+		body   string
+	}{
+		{"role granted", "/admin", "alice-token", http.StatusOK, "admin page"},
+		{"role missing", "/admin", "bob-token", http.StatusForbidden, "Forbidden"},
+		{"any of roles", "/staff", "alice-token", http.StatusOK, "staff page"},
+		{"all chained", "/both", "alice-token", http.StatusForbidden, "Forbidden"},
+		{"api granted", "/api/admin", "alice-token", http.StatusOK, `"ok":true`},
+		{"api missing", "/api/admin", "bob-token", http.StatusForbidden, `"forbidden"`},
+		{"sse granted", "/ds/admin", "alice-token", http.StatusOK, "datastar-patch-signals"},
+		{"sse missing", "/ds/admin", "bob-token", http.StatusForbidden, "Forbidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if strings.HasPrefix(tt.path, "/ds/") {
+				req.Header.Set("Accept", "text/event-stream")
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.status || !strings.Contains(rec.Body.String(), tt.body) {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.body, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireWithoutPrincipal(t *testing.T) {
+	// Without AuthMiddleware there's no principal: 401, not 403
+	r := New()
+	ran := false
+	r.GET("/admin", func(ctx *Context) (string, error) {
+		ran = true
+		return "admin page", nil
+	}).Require("admin").Swap("outerHTML")
+
+	req := httptest.NewRequest("GET", "/admin", nil)
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+	if ran {
+		t.Error("expected the handler not to run")
+	}
+	if got := rec.Header().Get("HX-Reswap"); got != "" {
+		t.Errorf("expected no route swap on the error, got %q", got)
+	}
+}
+
+func TestHasRole(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	ctx := NewContext(httptest.NewRecorder(), req)
+	if ctx.HasRole("admin") {
+		t.Error("expected no roles without a principal")
+	}
+
+	ctx.Request = req.WithContext(WithPrincipal(req.Context(), Principal{ID: "alice", Roles: []string{"admin", "staff"}}))
+	if !ctx.HasRole("admin") || !ctx.HasRole("staff") {
+		t.Error("expected alice's roles")
+	}
+	if ctx.HasRole("auditor") {
+		t.Error("expected no auditor role")
+	}
+}
@@ -18,90 +18,198 @@ type FragmentHandler func(ctx *Context) (string, error)
 // Handlers should use the SSE methods to patch elements and signals.
 type SSEHandler func(ctx *Context) error
 
+// APIHandler is a handler function for JSON API endpoints.
+// The returned value is encoded as JSON. If an error is returned, it is
+// written as a JSON error envelope (see APIError).
+type APIHandler func(ctx *Context) (any, error)
+
+// Middleware wraps an http.Handler, e.g. to check auth before it runs.
+type Middleware = func(http.Handler) http.Handler
+
 // Router wraps chi with hypermedia-specific conventions.
 type Router struct {
-	mux *chi.Mux
+	mux      *chi.Mux
+	prefix   string            // Pattern prefix for sub-routers created by Route
+	names    map[string]string // Route names by method and full pattern, shared with sub-routers
+	basePath string            // Mount prefix set with SetBasePath
+
+	// root is set for routers made by New, whose middleware is kept here
+	// and applied in Handler rather than by chi
+	root        bool
+	middlewares []Middleware
 }
 
+// Option configures a Router made by New or NewWithoutMiddleware.
+type Option func(*Router)
+
 // New creates a new Router with default middleware.
-func New() *Router {
-	r := chi.NewRouter()
+func New(opts ...Option) *Router {
+	r := NewWithoutMiddleware(opts...)
 
-	// Default middleware
+	// Default middleware, outside anything added with Use
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
 	r.Use(DatastarRequestMiddleware)
 
-	return &Router{mux: r}
+	return r
 }
 
 // NewWithoutMiddleware creates a Router without default middleware.
-func NewWithoutMiddleware() *Router {
-	return &Router{mux: chi.NewRouter()}
+// Unmatched routes still get the default NotFoundPage.
+func NewWithoutMiddleware(opts ...Option) *Router {
+	mux := chi.NewRouter()
+	mux.NotFound(NotFoundPage)
+	r := &Router{mux: mux, names: make(map[string]string), root: true}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // Handler returns the underlying http.Handler for use with the adapter.
+// It runs the middleware added with Use, then routes the request. If a base
+// path is set, the handler strips it first.
 func (r *Router) Handler() http.Handler {
-	return r.mux
+	var h http.Handler = r.mux
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		h = r.middlewares[i](h)
+	}
+	return BasePathMiddleware(r.basePath)(h)
 }
 
-// Use adds middleware to the router.
-func (r *Router) Use(middlewares ...func(http.Handler) http.Handler) {
+// Use adds middleware that wraps every request to the router, including
+// ones no route matches. Middleware runs in the order added: the first Use
+// is outermost, and New's defaults (panic recovery, request IDs, Datastar
+// detection) come before any of yours. On the root router Use may be
+// called before or after registering routes.
+//
+// On a group from Route, Prefix, Group or With, middleware only wraps the
+// group's routes and must be added before them.
+func (r *Router) Use(middlewares ...Middleware) {
+	if r.root {
+		r.middlewares = append(r.middlewares, middlewares...)
+		return
+	}
 	r.mux.Use(middlewares...)
 }
 
 // Fragment registers a handler that returns HTML fragments (for initial page loads).
-func (r *Router) Fragment(method, pattern string, handler FragmentHandler) {
+// A handler can instead write JSON with ctx.JSON; errors are then written as
+// a JSON envelope for clients that want JSON (see Context.WantsJSON).
+func (r *Router) Fragment(method, pattern string, handler FragmentHandler) *RouteOptions {
+	opts := &RouteOptions{}
+	name := handlerName(handler)
 	r.mux.Method(method, pattern, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !opts.authorize(w, req, false) {
+			return
+		}
+		opts.applyCache(w)
+		opts.applySwap(w)
 		ctx := NewContext(w, req)
+		defer ctx.finish()
+		ctx.annotateRoute(name)
 		html, err := handler(ctx)
 		if err != nil {
-			ctx.Error(err)
+			// Too late for an error response, e.g. a download that failed
+			// partway through
+			if ctx.Written() {
+				return
+			}
+			opts.clearCache(w)
+			if ctx.WantsJSON() {
+				ctx.APIError(err)
+			} else {
+				ctx.Error(err)
+			}
 			return
 		}
 		if !ctx.Written() {
 			ctx.HTML(html)
 		}
 	}))
+	return opts
 }
 
 // SSE registers a handler for Datastar SSE requests.
-func (r *Router) SSE(method, pattern string, handler SSEHandler) {
+func (r *Router) SSE(method, pattern string, handler SSEHandler) *RouteOptions {
+	opts := &RouteOptions{}
+	name := handlerName(handler)
 	r.mux.Method(method, pattern, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !opts.authorize(w, req, false) {
+			return
+		}
+		opts.applyCache(w)
 		ctx := NewContext(w, req)
+		defer ctx.finish()
+		ctx.annotateRoute(name)
 		if err := handler(ctx); err != nil {
 			// If not yet streaming, we can send an error response
 			if !ctx.Written() {
+				opts.clearCache(w)
 				ctx.Error(err)
 			}
 			// If already streaming, error was already logged via SSE.ConsoleError
 		}
 	}))
+	return opts
+}
+
+// API registers a JSON API handler.
+// Errors are returned as {"error": {"code", "message", "fields"}} with the
+// status taken from HTTPError or ValidationErrors.
+func (r *Router) API(method, pattern string, handler APIHandler) *RouteOptions {
+	opts := &RouteOptions{}
+	name := handlerName(handler)
+	r.mux.Method(method, pattern, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		markAPIRoute(req)
+		if !opts.authorize(w, req, true) {
+			return
+		}
+		opts.applyCache(w)
+		ctx := NewContext(w, req)
+		defer ctx.finish()
+		ctx.annotateRoute(name)
+		data, err := handler(ctx)
+		if err != nil {
+			if !ctx.Written() {
+				opts.clearCache(w)
+				ctx.APIError(err)
+			}
+			return
+		}
+		if !ctx.Written() {
+			if err := ctx.JSON(data); err != nil {
+				opts.clearCache(w)
+				ctx.APIError(err)
+			}
+		}
+	}))
+	return opts
 }
 
 // GET registers a GET handler that returns HTML fragments.
-func (r *Router) GET(pattern string, handler FragmentHandler) {
-	r.Fragment(http.MethodGet, pattern, handler)
+func (r *Router) GET(pattern string, handler FragmentHandler) *RouteOptions {
+	return r.Fragment(http.MethodGet, pattern, handler)
 }
 
 // POST registers a POST handler that returns HTML fragments.
-func (r *Router) POST(pattern string, handler FragmentHandler) {
-	r.Fragment(http.MethodPost, pattern, handler)
+func (r *Router) POST(pattern string, handler FragmentHandler) *RouteOptions {
+	return r.Fragment(http.MethodPost, pattern, handler)
 }
 
 // PUT registers a PUT handler that returns HTML fragments.
-func (r *Router) PUT(pattern string, handler FragmentHandler) {
-	r.Fragment(http.MethodPut, pattern, handler)
+func (r *Router) PUT(pattern string, handler FragmentHandler) *RouteOptions {
+	return r.Fragment(http.MethodPut, pattern, handler)
 }
 
 // PATCH registers a PATCH handler that returns HTML fragments.
-func (r *Router) PATCH(pattern string, handler FragmentHandler) {
-	r.Fragment(http.MethodPatch, pattern, handler)
+func (r *Router) PATCH(pattern string, handler FragmentHandler) *RouteOptions {
+	return r.Fragment(http.MethodPatch, pattern, handler)
 }
 
 // DELETE registers a DELETE handler that returns HTML fragments.
-func (r *Router) DELETE(pattern string, handler FragmentHandler) {
-	r.Fragment(http.MethodDelete, pattern, handler)
+func (r *Router) DELETE(pattern string, handler FragmentHandler) *RouteOptions {
+	return r.Fragment(http.MethodDelete, pattern, handler)
 }
 
 // --- Datastar SSE Handlers ---
@@ -109,28 +217,28 @@ func (r *Router) DELETE(pattern string, handler FragmentHandler) {
 // Use ctx.SSE() methods to stream DOM patches and signal updates.
 
 // DSGet registers a GET handler for Datastar SSE requests.
-func (r *Router) DSGet(pattern string, handler SSEHandler) {
-	r.SSE(http.MethodGet, pattern, handler)
+func (r *Router) DSGet(pattern string, handler SSEHandler) *RouteOptions {
+	return r.SSE(http.MethodGet, pattern, handler)
 }
 
 // DSPost registers a POST handler for Datastar SSE requests.
-func (r *Router) DSPost(pattern string, handler SSEHandler) {
-	r.SSE(http.MethodPost, pattern, handler)
+func (r *Router) DSPost(pattern string, handler SSEHandler) *RouteOptions {
+	return r.SSE(http.MethodPost, pattern, handler)
 }
 
 // DSPut registers a PUT handler for Datastar SSE requests.
-func (r *Router) DSPut(pattern string, handler SSEHandler) {
-	r.SSE(http.MethodPut, pattern, handler)
+func (r *Router) DSPut(pattern string, handler SSEHandler) *RouteOptions {
+	return r.SSE(http.MethodPut, pattern, handler)
 }
 
 // DSPatch registers a PATCH handler for Datastar SSE requests.
-func (r *Router) DSPatch(pattern string, handler SSEHandler) {
-	r.SSE(http.MethodPatch, pattern, handler)
+func (r *Router) DSPatch(pattern string, handler SSEHandler) *RouteOptions {
+	return r.SSE(http.MethodPatch, pattern, handler)
 }
 
 // DSDelete registers a DELETE handler for Datastar SSE requests.
-func (r *Router) DSDelete(pattern string, handler SSEHandler) {
-	r.SSE(http.MethodDelete, pattern, handler)
+func (r *Router) DSDelete(pattern string, handler SSEHandler) *RouteOptions {
+	return r.SSE(http.MethodDelete, pattern, handler)
 }
 
 // Handle registers a standard http.Handler.
@@ -152,7 +260,7 @@ func (r *Router) Mount(pattern string, handler http.Handler) {
 func (r *Router) Group(fn func(r *Router)) {
 	r.mux.Group(func(c chi.Router) {
 		// Create sub-router that wraps the chi Router interface
-		subRouter := &Router{mux: chi.NewRouter()}
+		subRouter := &Router{mux: chi.NewRouter(), prefix: r.prefix, names: r.names, basePath: r.basePath}
 		fn(subRouter)
 		// Mount the sub-router's routes
 		c.Mount("/", subRouter.mux)
@@ -162,19 +270,36 @@ func (r *Router) Group(fn func(r *Router)) {
 // Route creates a new route group at the given pattern.
 func (r *Router) Route(pattern string, fn func(r *Router)) {
 	r.mux.Route(pattern, func(c chi.Router) {
-		subRouter := &Router{mux: c.(*chi.Mux)}
+		subRouter := &Router{mux: c.(*chi.Mux), prefix: r.prefix + pattern, names: r.names, basePath: r.basePath}
 		fn(subRouter)
 	})
 }
 
+// Prefix returns a route group under prefix, for registering routes after
+// the call rather than in a Route closure:
+//
+//	todos := r.Prefix("/todos")
+//	todos.Use(requireAuth)
+//	todos.POST("/{id}/toggle", toggleTodo)
+//
+// The group's routes share the prefix and any middleware added with Use,
+// which must come before them. Groups nest, and the parent's Handler serves
+// everything registered on them.
+func (r *Router) Prefix(prefix string) *Router {
+	sub := chi.NewRouter()
+	r.mux.Mount(prefix, sub)
+	return &Router{mux: sub, prefix: r.prefix + prefix, names: r.names, basePath: r.basePath}
+}
+
 // With adds inline middleware for a route.
-func (r *Router) With(middlewares ...func(http.Handler) http.Handler) *Router {
-	return &Router{mux: r.mux.With(middlewares...).(*chi.Mux)}
+func (r *Router) With(middlewares ...Middleware) *Router {
+	return &Router{mux: r.mux.With(middlewares...).(*chi.Mux), prefix: r.prefix, names: r.names, basePath: r.basePath}
 }
 
-// NotFound registers a custom 404 handler.
+// NotFound registers a custom 404 handler, replacing NotFoundPage.
+// Responses are tagged with RouteNotFoundHeader.
 func (r *Router) NotFound(handler http.HandlerFunc) {
-	r.mux.NotFound(handler)
+	r.mux.NotFound(markRouteNotFound(handler))
 }
 
 // MethodNotAllowed registers a custom 405 handler.
@@ -190,10 +315,10 @@ func (r *Router) Static(pattern string, root http.FileSystem) {
 	}
 	pattern += "*"
 
+	pathPrefix := pattern[:len(pattern)-1]
+	fs := http.StripPrefix(pathPrefix, FileServer(root))
 	r.mux.Get(pattern, func(w http.ResponseWriter, req *http.Request) {
 		rctx := chi.RouteContext(req.Context())
-		pathPrefix := pattern[:len(pattern)-1]
-		fs := http.StripPrefix(pathPrefix, http.FileServer(root))
 		rctx.URLParams.Add("*", req.URL.Path[len(pathPrefix):])
 		fs.ServeHTTP(w, req)
 	})
@@ -201,5 +326,5 @@ func (r *Router) Static(pattern string, root http.FileSystem) {
 
 // ServeHTTP implements http.Handler.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	r.mux.ServeHTTP(w, req)
+	r.Handler().ServeHTTP(w, req)
 }
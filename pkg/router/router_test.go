@@ -1,11 +1,17 @@
 package router
 
 import (
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 func TestNew(t *testing.T) {
@@ -392,6 +398,566 @@ func TestDSGet(t *testing.T) {
 	}
 }
 
+func TestAPIValidationError(t *testing.T) {
+	r := New()
+	r.API(http.MethodPost, "/api/users", func(ctx *Context) (any, error) {
+		errs := ValidationErrors{}
+		if ctx.FormValue("email") == "" {
+			errs.Add("email", "is required")
+		}
+		if errs.HasErrors() {
+			return nil, errs
+		}
+		return map[string]string{"status": "created"}, nil
+	})
+
+	req := httptest.NewRequest("POST", "/api/users", strings.NewReader("name=Ann"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", ct)
+	}
+
+	var body APIError
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON body %q: %v", w.Body.String(), err)
+	}
+	if body.Error.Code != "validation_failed" {
+		t.Errorf("expected code 'validation_failed', got %q", body.Error.Code)
+	}
+	if body.Error.Fields["email"] != "is required" {
+		t.Errorf("expected email field error, got %v", body.Error.Fields)
+	}
+}
+
+func TestAPIHTTPError(t *testing.T) {
+	r := New()
+	r.API(http.MethodGet, "/api/users/{id}", func(ctx *Context) (any, error) {
+		return nil, NewHTTPError(http.StatusNotFound, "user not found")
+	})
+
+	req := httptest.NewRequest("GET", "/api/users/42", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+	expected := `{"error":{"code":"not_found","message":"user not found"}}`
+	if strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("expected body %s, got %s", expected, w.Body.String())
+	}
+}
+
+func TestAPISuccess(t *testing.T) {
+	r := New()
+	r.API(http.MethodGet, "/api/ping", func(ctx *Context) (any, error) {
+		return map[string]string{"pong": "ok"}, nil
+	})
+
+	req := httptest.NewRequest("GET", "/api/ping", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	if strings.TrimSpace(w.Body.String()) != `{"pong":"ok"}` {
+		t.Errorf("unexpected body %s", w.Body.String())
+	}
+}
+
+func TestFragmentHTTPErrorStatus(t *testing.T) {
+	r := New()
+	r.GET("/missing", func(ctx *Context) (string, error) {
+		return "", NewHTTPError(http.StatusNotFound, "gone")
+	})
+
+	req := httptest.NewRequest("GET", "/missing", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestDebugErrorHeader(t *testing.T) {
+	handler := func(ctx *Context) (string, error) {
+		return "", NewHTTPError(http.StatusBadRequest, "bad input\nline two")
+	}
+
+	r := New()
+	r.GET("/fail", handler)
+
+	req := httptest.NewRequest("GET", "/fail", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get(ErrorHeader); got != "" {
+		t.Errorf("expected no %s header outside debug mode, got %q", ErrorHeader, got)
+	}
+
+	debug := New()
+	debug.Use(DebugErrorsMiddleware)
+	debug.GET("/fail", handler)
+
+	w = httptest.NewRecorder()
+	debug.ServeHTTP(w, req)
+
+	if got := w.Header().Get(ErrorHeader); got != "bad input line two" {
+		t.Errorf("expected sanitized error message, got %q", got)
+	}
+	if w.Header().Get(RequestIDHeader) == "" {
+		t.Errorf("expected %s header alongside error", RequestIDHeader)
+	}
+}
+
+func TestDebugErrorHeaderAPI(t *testing.T) {
+	r := New()
+	r.Use(DebugErrorsMiddleware)
+	r.API("POST", "/api/users", func(ctx *Context) (any, error) {
+		return nil, ValidationErrors{"email": "required"}
+	})
+
+	req := httptest.NewRequest("POST", "/api/users", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get(ErrorHeader); got != "validation failed: email: required" {
+		t.Errorf("expected error message header, got %q", got)
+	}
+}
+
+func TestDebugErrorHeaderOnlyOnErrors(t *testing.T) {
+	r := New()
+	r.Use(DebugErrorsMiddleware)
+	r.GET("/ok", func(ctx *Context) (string, error) {
+		return "<div>ok</div>", nil
+	})
+
+	req := httptest.NewRequest("GET", "/ok", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get(ErrorHeader); got != "" {
+		t.Errorf("expected no %s header on success, got %q", ErrorHeader, got)
+	}
+}
+
+func TestSanitizeErrorHeader(t *testing.T) {
+	got := sanitizeErrorHeader("  a\r\n\tb  \x00c\u00e9 ")
+	if got != "a b c" {
+		t.Errorf("expected %q, got %q", "a b c", got)
+	}
+
+	long := sanitizeErrorHeader(strings.Repeat("x", 2*maxErrorHeaderLen))
+	if len(long) != maxErrorHeaderLen {
+		t.Errorf("expected header truncated to %d bytes, got %d", maxErrorHeaderLen, len(long))
+	}
+}
+
+func TestRegisterMatchesIndividualCalls(t *testing.T) {
+	home := func(ctx *Context) (string, error) { return "<div>Home</div>", nil }
+	user := func(ctx *Context) (string, error) { return "<div>User " + ctx.Param("id") + "</div>", nil }
+	save := func(ctx *Context) error { return ctx.SSE().PatchHTML("<div>Saved</div>") }
+	list := func(ctx *Context) (any, error) { return []string{"a", "b"}, nil }
+	health := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+	tag := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Tag", "admin")
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	individual := New()
+	individual.GET("/", home)
+	individual.With(tag).GET("/users/{id}", user)
+	individual.DSPost("/users", save)
+	individual.API("GET", "/api/users", list)
+	individual.mux.Method(http.MethodGet, "/health", health)
+
+	table := New()
+	table.Register([]Route{
+		{Method: "GET", Pattern: "/", Handler: home, Name: "home"},
+		{Method: "GET", Pattern: "/users/{id}", Handler: user, Name: "user", Middleware: []func(http.Handler) http.Handler{tag}},
+		{Method: "POST", Pattern: "/users", Handler: SSEHandler(save)},
+		{Method: "get", Pattern: "/api/users", Handler: APIHandler(list)},
+		{Pattern: "/health", Handler: health},
+	})
+
+	for _, path := range []string{"/", "/users/42", "/api/users", "/health", "/missing"} {
+		req := httptest.NewRequest("GET", path, nil)
+		want := httptest.NewRecorder()
+		individual.ServeHTTP(want, req)
+		got := httptest.NewRecorder()
+		table.ServeHTTP(got, req)
+
+		if got.Code != want.Code || got.Body.String() != want.Body.String() {
+			t.Errorf("%s: expected %d %q, got %d %q", path, want.Code, want.Body.String(), got.Code, got.Body.String())
+		}
+		if got.Header().Get("X-Tag") != want.Header().Get("X-Tag") {
+			t.Errorf("%s: expected route middleware to match", path)
+		}
+	}
+
+	wantRoutes := individual.Routes()
+	gotRoutes := table.Routes()
+	if len(gotRoutes) != len(wantRoutes) {
+		t.Fatalf("expected %d routes, got %d: %v", len(wantRoutes), len(gotRoutes), gotRoutes)
+	}
+	names := map[string]string{"/": "home", "/users/{id}": "user"}
+	for i, got := range gotRoutes {
+		want := wantRoutes[i]
+		if got.Method != want.Method || got.Pattern != want.Pattern {
+			t.Errorf("route %d: expected %s %s, got %s %s", i, want.Method, want.Pattern, got.Method, got.Pattern)
+		}
+		if want.Name != "" {
+			t.Errorf("expected individually registered routes to be unnamed, got %q", want.Name)
+		}
+		if got.Name != names[got.Pattern] {
+			t.Errorf("%s: expected name %q, got %q", got.Pattern, names[got.Pattern], got.Name)
+		}
+	}
+}
+
+func TestRegisterNamesInSubRouter(t *testing.T) {
+	r := New()
+	r.Route("/admin", func(r *Router) {
+		r.Register([]Route{
+			{Method: "GET", Pattern: "/users", Handler: func(ctx *Context) (string, error) { return "", nil }, Name: "admin.users"},
+		})
+	})
+
+	routes := r.Routes()
+	if len(routes) != 1 {
+		t.Fatalf("expected 1 route, got %v", routes)
+	}
+	if routes[0].Pattern != "/admin/users" || routes[0].Name != "admin.users" {
+		t.Errorf("unexpected route: %+v", routes[0])
+	}
+}
+
+func TestRegisterUnsupportedHandlerPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for unsupported handler type")
+		}
+	}()
+	New().Register([]Route{{Method: "GET", Pattern: "/", Handler: "nope"}})
+}
+
+func TestDefaultNotFoundPage(t *testing.T) {
+	r := New()
+
+	req := httptest.NewRequest("GET", "/missing", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+	if w.Header().Get(RouteNotFoundHeader) == "" {
+		t.Errorf("expected %s header", RouteNotFoundHeader)
+	}
+	if !strings.Contains(w.Body.String(), "<!DOCTYPE html>") || !strings.Contains(w.Body.String(), "/missing") {
+		t.Errorf("expected full 404 page, got %q", w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/missing", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if body := w.Body.String(); strings.Contains(body, "<html") || !strings.Contains(body, `class="error"`) {
+		t.Errorf("expected error fragment for Datastar request, got %q", body)
+	}
+}
+
+func TestHandlerNotFoundIsNotRouteNotFound(t *testing.T) {
+	r := New()
+	r.GET("/users/{id}", func(ctx *Context) (string, error) {
+		return "", NewHTTPError(http.StatusNotFound, "no such user")
+	})
+
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get(RouteNotFoundHeader) != "" {
+		t.Errorf("expected no %s header for handler 404", RouteNotFoundHeader)
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	r := New()
+	r.SetBasePath("/app/")
+	r.GET("/", func(ctx *Context) (string, error) {
+		return "<div>Home</div>", nil
+	})
+	r.GET("/users/{id}", func(ctx *Context) (string, error) {
+		return "<a href=\"" + ctx.URL("/static/app.css") + "\">" + ctx.Param("id") + "</a>", nil
+	})
+	r.POST("/login", func(ctx *Context) (string, error) {
+		ctx.Redirect("/users/1")
+		return "", nil
+	})
+
+	tests := []struct {
+		method, path string
+		status       int
+		body         string
+		location     string
+	}{
+		{"GET", "/app", http.StatusOK, "<div>Home</div>", ""},
+		{"GET", "/app/", http.StatusOK, "<div>Home</div>", ""},
+		{"GET", "/app/users/42", http.StatusOK, `<a href="/app/static/app.css">42</a>`, ""},
+		{"POST", "/app/login", http.StatusSeeOther, "", "/app/users/1"},
+		{"GET", "/", http.StatusFound, "", "/app/"},
+		{"GET", "/users/42", http.StatusNotFound, "", ""},
+		{"GET", "/application", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, w.Code)
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("%s %s: expected body %q, got %q", tt.method, tt.path, tt.body, w.Body.String())
+		}
+		if loc := w.Header().Get("Location"); loc != tt.location {
+			t.Errorf("%s %s: expected Location %q, got %q", tt.method, tt.path, tt.location, loc)
+		}
+	}
+}
+
+func TestRouterURL(t *testing.T) {
+	handler := func(ctx *Context) (string, error) { return "", nil }
+
+	r := New()
+	r.Register([]Route{
+		{Method: "GET", Pattern: "/users/{id}", Handler: handler, Name: "user"},
+		{Method: "GET", Pattern: "/posts/{year:[0-9]+}/{slug}", Handler: handler, Name: "post"},
+	})
+	r.Route("/admin", func(r *Router) {
+		r.Register([]Route{{Method: "GET", Pattern: "/", Handler: handler, Name: "admin"}})
+	})
+
+	tests := []struct {
+		name   string
+		params []string
+		want   string
+	}{
+		{"user", []string{"id", "42"}, "/users/42"},
+		{"post", []string{"year", "2024", "slug", "hello"}, "/posts/2024/hello"},
+		{"admin", nil, "/admin/"},
+	}
+	for _, tt := range tests {
+		got, err := r.URL(tt.name, tt.params...)
+		if err != nil || got != tt.want {
+			t.Errorf("URL(%q): expected %q, got %q (err %v)", tt.name, tt.want, got, err)
+		}
+	}
+
+	r.SetBasePath("app")
+	if got, _ := r.URL("user", "id", "7"); got != "/app/users/7" {
+		t.Errorf("expected base path in URL, got %q", got)
+	}
+
+	if _, err := r.URL("missing"); err == nil {
+		t.Error("expected error for unknown route name")
+	}
+	if _, err := r.URL("user"); err == nil {
+		t.Error("expected error for missing param")
+	}
+	if _, err := r.URL("user", "id"); err == nil {
+		t.Error("expected error for odd params")
+	}
+}
+
+func TestRouteCachePolicy(t *testing.T) {
+	r := New()
+	r.GET("/about", func(ctx *Context) (string, error) {
+		return "<div>About</div>", nil
+	}).Cache(CachePolicy{MaxAge: time.Hour})
+	r.GET("/me", func(ctx *Context) (string, error) {
+		return "<div>Me</div>", nil
+	}).Cache(CachePolicy{MaxAge: time.Minute, Private: true})
+	r.GET("/logo", func(ctx *Context) (string, error) {
+		return "<svg></svg>", nil
+	}).Cache(CachePolicy{Immutable: true})
+	r.GET("/broken", func(ctx *Context) (string, error) {
+		return "", NewHTTPError(http.StatusNotFound, "gone")
+	}).Cache(CachePolicy{MaxAge: time.Hour})
+	r.API("GET", "/api/config", func(ctx *Context) (any, error) {
+		return map[string]string{"theme": "dark"}, nil
+	}).Cache(CachePolicy{})
+	r.GET("/live", func(ctx *Context) (string, error) {
+		return "<div>Live</div>", nil
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/about", "public, max-age=3600"},
+		{"/me", "private, max-age=60"},
+		{"/logo", "public, max-age=31536000, immutable"},
+		{"/broken", "no-store"},
+		{"/api/config", "public, no-cache"},
+		{"/live", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("%s: expected Cache-Control %q, got %q", tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestStaticFingerprintedAssetsImmutable(t *testing.T) {
+	r := New()
+	r.Static("/static", http.FS(fstest.MapFS{
+		"app.css":              {Data: []byte("body{}")},
+		"app.3f2a9c1b.css":     {Data: []byte("body{}")},
+		"js/app-9e8d7c6b5a.js": {Data: []byte("//")},
+	}))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/static/app.3f2a9c1b.css", "public, max-age=31536000, immutable"},
+		{"/static/js/app-9e8d7c6b5a.js", "public, max-age=31536000, immutable"},
+		{"/static/app.css", ""},
+		{"/static/missing.3f2a9c1b.css", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("%s: expected Cache-Control %q, got %q", tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestStaticConditionalRequests(t *testing.T) {
+	modified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := New()
+	r.Static("/static", http.FS(fstest.MapFS{
+		"htmx.min.js": {Data: []byte("htmx")},
+		"app.css":     {Data: []byte("body{}"), ModTime: modified},
+	}))
+
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Embedded files have no modification time, so get a content ETag
+	w := get("/static/htmx.min.js", nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d %v", w.Code, w.Header())
+	}
+	if w = get("/static/htmx.min.js", nil); w.Header().Get("ETag") != etag {
+		t.Errorf("expected a stable ETag, got %q and %q", etag, w.Header().Get("ETag"))
+	}
+	w = get("/static/htmx.min.js", map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected 304 with no body for a matching ETag, got %d", w.Code)
+	}
+	if w = get("/static/htmx.min.js", map[string]string{"If-None-Match": `"stale"`}); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a stale ETag, got %d", w.Code)
+	}
+
+	// Files with a modification time use Last-Modified
+	w = get("/static/app.css", nil)
+	if w.Header().Get("ETag") != "" || w.Header().Get("Last-Modified") == "" {
+		t.Errorf("expected Last-Modified rather than an ETag, got %v", w.Header())
+	}
+	w = get("/static/app.css", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected 304 when not modified since, got %d", w.Code)
+	}
+}
+
+func TestRouteSwapAndTarget(t *testing.T) {
+	r := New()
+	r.GET("/items", func(ctx *Context) (string, error) {
+		return "<ul id=\"list\"></ul>", nil
+	}).Swap("outerHTML").Target("#list")
+	r.GET("/override", func(ctx *Context) (string, error) {
+		ctx.SetHeader("HX-Reswap", "beforeend")
+		ctx.SetHeader("HX-Retarget", "#other")
+		return "<li>Item</li>", nil
+	}).Swap("outerHTML").Target("#list")
+	r.GET("/plain", func(ctx *Context) (string, error) {
+		return "<div>Plain</div>", nil
+	})
+
+	tests := []struct {
+		path       string
+		wantSwap   string
+		wantTarget string
+	}{
+		{"/items", "outerHTML", "#list"},
+		{"/override", "beforeend", "#other"},
+		{"/plain", "", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Header.Set("HX-Request", "true")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Header().Get("HX-Reswap"); got != tt.wantSwap {
+			t.Errorf("%s: expected HX-Reswap %q, got %q", tt.path, tt.wantSwap, got)
+		}
+		if got := w.Header().Get("HX-Retarget"); got != tt.wantTarget {
+			t.Errorf("%s: expected HX-Retarget %q, got %q", tt.path, tt.wantTarget, got)
+		}
+	}
+}
+
+func TestIsFingerprinted(t *testing.T) {
+	for name, want := range map[string]bool{
+		"app.3f2a9c1b.css":                true,
+		"/static/app-0123456789abcdef.js": true,
+		"app.css":                         false,
+		"app.min.js":                      false,
+		"3f2a9c1b":                        false,
+		"logo.abc.png":                    false,
+	} {
+		if got := IsFingerprinted(name); got != want {
+			t.Errorf("IsFingerprinted(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
 // BenchmarkRouter benchmarks basic routing performance
 func BenchmarkRouter(b *testing.B) {
 	r := New()
@@ -414,3 +980,243 @@ func readBody(resp *http.Response) string {
 	resp.Body.Close()
 	return string(body)
 }
+
+func showTodo(ctx *Context) (string, error) {
+	return "<li>todo " + ctx.Param("id") + "</li>", nil
+}
+
+func TestDevRoutesAnnotation(t *testing.T) {
+	setup := func(dev bool) *Router {
+		r := New()
+		if dev {
+			r.Use(DevRoutesMiddleware)
+		}
+		r.GET("/todos/{id}", showTodo)
+		r.GET("/", func(ctx *Context) (string, error) {
+			return "<!DOCTYPE html><html></html>", nil
+		})
+		r.DSPost("/todos", func(ctx *Context) error {
+			ctx.NoContent()
+			return nil
+		})
+		return r
+	}
+
+	// Dev mode: comment and header name the route and handler
+	r := setup(true)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/todos/7", nil))
+	wantRoute := "GET /todos/{id} handler=github.com/stukennedy/irgo/pkg/router.showTodo"
+	if got := w.Header().Get(RouteHeader); got != wantRoute {
+		t.Errorf("expected %s %q, got %q", RouteHeader, wantRoute, got)
+	}
+	if want := "<!-- irgo: route=" + wantRoute + " -->\n<li>todo 7</li>"; w.Body.String() != want {
+		t.Errorf("expected annotated body %q, got %q", want, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if body := w.Body.String(); !strings.HasPrefix(body, "<!DOCTYPE html>\n<!-- irgo: route=GET / handler=") {
+		t.Errorf("expected comment after doctype, got %q", body)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/todos", nil))
+	if got := w.Header().Get(RouteHeader); !strings.HasPrefix(got, "POST /todos handler=") {
+		t.Errorf("expected route header on SSE route, got %q", got)
+	}
+
+	// Production: nothing added
+	r = setup(false)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/todos/7", nil))
+	if got := w.Header().Get(RouteHeader); got != "" {
+		t.Errorf("expected no %s without middleware, got %q", RouteHeader, got)
+	}
+	if w.Body.String() != "<li>todo 7</li>" {
+		t.Errorf("expected unannotated body, got %q", w.Body.String())
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tag := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Add("X-Mw", name)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+	ok := func(body string) FragmentHandler {
+		return func(ctx *Context) (string, error) { return body, nil }
+	}
+
+	r := New()
+	r.GET("/", ok("home"))
+
+	todos := r.Prefix("/todos")
+	todos.Use(tag("todos"))
+	todos.GET("/", ok("list"))
+	todos.POST("/{id}/toggle", func(ctx *Context) (string, error) {
+		return "toggle " + ctx.Param("id"), nil
+	})
+
+	admin := todos.Prefix("/admin")
+	admin.Use(tag("admin"))
+	admin.DELETE("/{id}", func(ctx *Context) (string, error) {
+		return "delete " + ctx.Param("id"), nil
+	})
+
+	tests := []struct {
+		method, path, body string
+		mw                 []string
+	}{
+		{"GET", "/", "home", nil},
+		{"GET", "/todos/", "list", []string{"todos"}},
+		{"POST", "/todos/42/toggle", "toggle 42", []string{"todos"}},
+		{"DELETE", "/todos/admin/7", "delete 7", []string{"todos", "admin"}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.Handler().ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != tt.body {
+			t.Errorf("%s %s: got %d %q, want %q", tt.method, tt.path, w.Code, w.Body.String(), tt.body)
+		}
+		if got := w.Header().Values("X-Mw"); strings.Join(got, ",") != strings.Join(tt.mw, ",") {
+			t.Errorf("%s %s: middleware %v, want %v", tt.method, tt.path, got, tt.mw)
+		}
+	}
+
+	// Unmatched paths under a group still get the default 404 page
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/todos/missing/path", nil))
+	if w.Code != http.StatusNotFound || w.Header().Get(RouteNotFoundHeader) == "" {
+		t.Errorf("expected default 404 under group, got %d", w.Code)
+	}
+
+	var patterns []string
+	for _, route := range r.Routes() {
+		patterns = append(patterns, route.Method+" "+route.Pattern)
+	}
+	for _, want := range []string{"GET /todos/", "POST /todos/{id}/toggle", "DELETE /todos/admin/{id}"} {
+		if !slices.Contains(patterns, want) {
+			t.Errorf("Routes() missing %q: %v", want, patterns)
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				order = append(order, name+">")
+				next.ServeHTTP(w, req)
+				order = append(order, "<"+name)
+			})
+		}
+	}
+
+	r := New()
+	r.Use(trace("first"))
+	r.GET("/test", func(ctx *Context) (string, error) {
+		order = append(order, "handler")
+		return "", nil
+	})
+	// Use after routes still wraps them
+	r.Use(trace("second"), trace("third"))
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+	want := "first> second> third> handler <third <second <first"
+	if got := strings.Join(order, " "); got != want {
+		t.Errorf("order = %q, want %q", got, want)
+	}
+
+	// Unmatched requests go through middleware too
+	order = nil
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+	if w.Code != http.StatusNotFound || len(order) != 6 {
+		t.Errorf("expected middleware around 404, got %d %v", w.Code, order)
+	}
+}
+
+func TestMiddlewareAfterDefaults(t *testing.T) {
+	r := New()
+	var reqID string
+	var datastar bool
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			reqID = middleware.GetReqID(req.Context())
+			datastar = IsDatastarRequest(req)
+			next.ServeHTTP(w, req)
+		})
+	})
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			panic("boom")
+		})
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if reqID == "" || !datastar {
+		t.Errorf("expected default middleware to run first, got request ID %q, datastar %v", reqID, datastar)
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected the default recoverer to catch the panic, got %d", w.Code)
+	}
+}
+
+func TestLayoutWrapperBoosted(t *testing.T) {
+	r := New()
+	wrapper := &LayoutWrapper{
+		Layout: func(content string) string {
+			return "<html><body>" + content + "</body></html>"
+		},
+		BoostedLayout: func(content string) string {
+			return "<body>" + content + "</body>"
+		},
+	}
+	r.Use(wrapper.Wrap)
+	r.GET("/todos", func(ctx *Context) (string, error) {
+		return "<ul>Todos</ul>", nil
+	})
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"page load", nil, "<html><body><ul>Todos</ul></body></html>"},
+		{"htmx fragment", map[string]string{"HX-Request": "true"}, "<ul>Todos</ul>"},
+		{"boosted", map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, "<body><ul>Todos</ul></body>"},
+		{"datastar", map[string]string{"Accept": "text/event-stream"}, "<ul>Todos</ul>"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/todos", nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+
+	// Without a BoostedLayout, boosted requests get the full layout
+	wrapper.BoostedLayout = nil
+	req := httptest.NewRequest("GET", "/todos", nil)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("HX-Boosted", "true")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got, want := w.Body.String(), "<html><body><ul>Todos</ul></body></html>"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Route describes a single route for table-driven registration with Register.
+//
+// Handler may be a FragmentHandler, SSEHandler, APIHandler, http.Handler or
+// http.HandlerFunc (or a plain func with one of those signatures).
+// Middleware applies to this route only.
+type Route struct {
+	Method     string
+	Pattern    string
+	Handler    any
+	Name       string
+	Middleware []func(http.Handler) http.Handler
+}
+
+// RouteInfo describes a registered route, as returned by Routes.
+type RouteInfo struct {
+	Method  string
+	Pattern string
+	Name    string // Empty unless registered with a name via Register
+}
+
+// Register registers each route in the table, in order.
+// It panics if a route has an unsupported handler type, like chi does
+// for invalid patterns.
+func (r *Router) Register(routes []Route) {
+	for _, route := range routes {
+		target := r
+		if len(route.Middleware) > 0 {
+			target = r.With(route.Middleware...)
+		}
+
+		method := strings.ToUpper(route.Method)
+		if method == "" {
+			method = http.MethodGet
+		}
+
+		switch h := route.Handler.(type) {
+		case FragmentHandler:
+			target.Fragment(method, route.Pattern, h)
+		case func(*Context) (string, error):
+			target.Fragment(method, route.Pattern, h)
+		case SSEHandler:
+			target.SSE(method, route.Pattern, h)
+		case func(*Context) error:
+			target.SSE(method, route.Pattern, h)
+		case APIHandler:
+			target.API(method, route.Pattern, h)
+		case func(*Context) (any, error):
+			target.API(method, route.Pattern, h)
+		case http.Handler:
+			target.mux.Method(method, route.Pattern, h)
+		case func(http.ResponseWriter, *http.Request):
+			target.mux.Method(method, route.Pattern, http.HandlerFunc(h))
+		default:
+			panic(fmt.Sprintf("router: unsupported handler type %T for %s %s", route.Handler, method, route.Pattern))
+		}
+
+		if route.Name != "" {
+			r.names[routeKey(method, r.prefix+route.Pattern)] = route.Name
+		}
+	}
+}
+
+// Routes returns every registered route with its method and full pattern.
+func (r *Router) Routes() []RouteInfo {
+	var routes []RouteInfo
+	chi.Walk(r.mux, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes = append(routes, RouteInfo{
+			Method:  method,
+			Pattern: route,
+			Name:    r.names[routeKey(method, r.prefix+route)],
+		})
+		return nil
+	})
+	return routes
+}
+
+// routeKey identifies a route in the names table.
+func routeKey(method, pattern string) string {
+	return method + " " + pattern
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"strings"
 )
@@ -87,6 +88,10 @@ func StrictOriginMiddleware(allowedOrigins ...string) func(http.Handler) http.Ha
 // WebSocketSecretMiddleware validates the secret for WebSocket upgrade requests.
 // Since the WebSocket API doesn't support custom headers, the secret is passed
 // as a query parameter: ?secret=xxx
+//
+// Upgrades without a matching secret are rejected with 403 before the
+// handshake. The parameter is removed before the request reaches next, so
+// the secret doesn't leak into handlers or session URLs.
 func WebSocketSecretMiddleware(secret string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -97,12 +102,18 @@ func WebSocketSecretMiddleware(secret string) func(http.Handler) http.Handler {
 			}
 
 			// Validate secret from query parameter
-			querySecret := r.URL.Query().Get("secret")
-			if querySecret != secret {
+			query := r.URL.Query()
+			querySecret := query.Get("secret")
+			if querySecret == "" || subtle.ConstantTimeCompare([]byte(querySecret), []byte(secret)) != 1 {
 				http.Error(w, "Forbidden", http.StatusForbidden)
 				return
 			}
 
+			query.Del("secret")
+			r = r.Clone(r.Context())
+			r.URL.RawQuery = query.Encode()
+			r.RequestURI = r.URL.RequestURI()
+
 			next.ServeHTTP(w, r)
 		})
 	}
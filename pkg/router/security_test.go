@@ -0,0 +1,46 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebSocketSecretMiddleware(t *testing.T) {
+	var gotQuery string
+	handler := WebSocketSecretMiddleware("s3cret")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		w.WriteHeader(http.StatusSwitchingProtocols)
+	}))
+
+	upgrade := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Connection", "Upgrade")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, target := range []string{"/ws", "/ws?secret=", "/ws?secret=wrong"} {
+		if w := upgrade(target); w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", target, w.Code)
+		}
+	}
+
+	w := upgrade("/ws?room=1&secret=s3cret")
+	if w.Code != http.StatusSwitchingProtocols {
+		t.Fatalf("expected upgrade with secret to pass, got %d", w.Code)
+	}
+	if gotQuery != "room=1" {
+		t.Errorf("expected secret stripped from query, got %q", gotQuery)
+	}
+
+	// Plain requests are not affected
+	req := httptest.NewRequest("GET", "/page", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusSwitchingProtocols {
+		t.Errorf("expected non-upgrade request to pass through, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,33 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/stukennedy/irgo/pkg/services"
+)
+
+// WithServices makes svc available to every handler through
+// Context.Services, instead of package globals:
+//
+//	r := router.New(router.WithServices(services.Services{"store": store}))
+func WithServices(svc services.Services) Option {
+	return func(r *Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				next.ServeHTTP(w, req.WithContext(services.WithContext(req.Context(), svc)))
+			})
+		})
+	}
+}
+
+// Services returns the services the router was created with (see
+// WithServices), or nil.
+func (c *Context) Services() services.Services {
+	return services.FromContext(c.Request.Context())
+}
+
+// Service returns the service registered as key, or nil. Use services.Get
+// for a typed lookup.
+func (c *Context) Service(key string) any {
+	return c.Services().Service(key)
+}
@@ -0,0 +1,89 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// EventStream writes plain server-sent events, for clients reading them
+// with EventSource rather than Datastar (for those use Context.SSE).
+type EventStream struct {
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+	ctx context.Context
+}
+
+// EventStream starts a text/event-stream response. The handler then owns
+// the response until it returns, and its returned HTML is ignored:
+//
+//	r.GET("/events", func(ctx *router.Context) (string, error) {
+//		stream := ctx.EventStream()
+//		for {
+//			select {
+//			case <-stream.Done():
+//				return "", nil
+//			case msg := <-updates:
+//				stream.Send("update", msg)
+//				stream.Flush()
+//			}
+//		}
+//	})
+//
+// The mobile bridge buffers responses, so there the events only arrive
+// once the handler returns.
+func (c *Context) EventStream() *EventStream {
+	c.written = true
+	h := c.Response.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	c.Response.WriteHeader(c.statusOr(http.StatusOK))
+	s := &EventStream{w: c.Response, rc: http.NewResponseController(c.Response), ctx: c.Request.Context()}
+	s.Flush()
+	return s
+}
+
+// Send writes an event; call Flush to deliver it. An empty event sends a
+// message event. Multi-line data is sent as one data line per line. It
+// returns the context's error once the client has gone.
+func (s *EventStream) Send(event, data string) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	var b strings.Builder
+	if event != "" {
+		// A newline would end the field early and start another
+		fmt.Fprintf(&b, "event: %s\n", strings.NewReplacer("\r", "", "\n", "").Replace(event))
+	}
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(s.w, b.String())
+	return err
+}
+
+// Comment writes a comment line, which clients ignore; send one
+// periodically to keep idle connections open.
+func (s *EventStream) Comment(text string) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(s.w, ": %s\n\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(text))
+	return err
+}
+
+// Flush sends buffered events to the client. It's best-effort; in-process
+// responses are buffered anyway.
+func (s *EventStream) Flush() {
+	s.rc.Flush()
+}
+
+// Done is closed when the client disconnects.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
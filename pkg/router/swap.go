@@ -0,0 +1,32 @@
+package router
+
+import "net/http"
+
+// Swap sets the HTMX swap strategy for the route's responses, e.g.
+// "outerHTML", overriding the client's hx-swap via the HX-Reswap header.
+// A handler can still set HX-Reswap itself, which wins.
+//
+//	r.GET("/items", items).Swap("outerHTML").Target("#list")
+func (o *RouteOptions) Swap(strategy string) *RouteOptions {
+	o.swap = strategy
+	return o
+}
+
+// Target sets the CSS selector the route's responses are swapped into,
+// overriding the client's hx-target via the HX-Retarget header.
+// A handler can still set HX-Retarget itself, which wins.
+func (o *RouteOptions) Target(selector string) *RouteOptions {
+	o.target = selector
+	return o
+}
+
+// applySwap sets the route's HX-Reswap and HX-Retarget headers, if any.
+// It runs before the handler so handler-set headers replace them.
+func (o *RouteOptions) applySwap(w http.ResponseWriter) {
+	if o.swap != "" {
+		w.Header().Set("HX-Reswap", o.swap)
+	}
+	if o.target != "" {
+		w.Header().Set("HX-Retarget", o.target)
+	}
+}
@@ -0,0 +1,132 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+)
+
+// MaxUploadSize caps the files SaveUploadedFile writes (default 32 MiB).
+// Use OpenUpload for a per-call limit.
+var MaxUploadSize int64 = 32 << 20
+
+// ErrUploadTooLarge is returned when an uploaded file exceeds its size cap.
+// Returned from a handler, it produces a 413 response.
+var ErrUploadTooLarge = &HTTPError{Status: http.StatusRequestEntityTooLarge, Message: "uploaded file too large"}
+
+// Upload is a file part of a multipart request, read as it arrives.
+type Upload struct {
+	Filename string
+	Header   textproto.MIMEHeader
+
+	r      io.Reader
+	closer io.Closer
+	max    int64
+	read   int64
+	tooBig bool
+}
+
+// Read reads the file, returning ErrUploadTooLarge once more than the cap
+// has been read.
+func (u *Upload) Read(p []byte) (int, error) {
+	if u.tooBig {
+		return 0, ErrUploadTooLarge
+	}
+	// Read one byte past the cap to tell "exactly max" from "too large"
+	if remaining := u.max + 1 - u.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := u.r.Read(p)
+	u.read += int64(n)
+	if u.read > u.max {
+		u.tooBig = true
+		return n - int(u.read-u.max), ErrUploadTooLarge
+	}
+	return n, err
+}
+
+// Close releases the part. The rest of the request body is not read.
+func (u *Upload) Close() error {
+	if u.closer != nil {
+		return u.closer.Close()
+	}
+	return nil
+}
+
+// OpenUpload returns the file field name of a multipart/form-data request
+// as a stream, so large files aren't buffered in memory. Reading more than
+// maxBytes returns ErrUploadTooLarge.
+//
+// Fields are streamed in order, so read the upload before other form values
+// (calling FormValue first makes net/http parse, and buffer, the whole body).
+// On mobile the adapter has already read the body into memory; streaming
+// still avoids a second copy but not the first.
+func (c *Context) OpenUpload(name string, maxBytes int64) (*Upload, error) {
+	missing := &HTTPError{Status: http.StatusBadRequest, Message: fmt.Sprintf("missing file %q", name), Err: http.ErrMissingFile}
+
+	// Already parsed (and buffered) by ParseMultipartForm
+	if c.Request.MultipartForm != nil {
+		file, header, err := c.Request.FormFile(name)
+		if err != nil {
+			return nil, missing
+		}
+		return &Upload{Filename: header.Filename, Header: header.Header, r: file, closer: file, max: maxBytes}, nil
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, &HTTPError{Status: http.StatusBadRequest, Message: "expected multipart/form-data", Err: err}
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, missing
+		}
+		if err != nil {
+			return nil, &HTTPError{Status: http.StatusBadRequest, Message: "reading multipart body", Err: err}
+		}
+		if part.FormName() == name && part.FileName() != "" {
+			return &Upload{Filename: part.FileName(), Header: part.Header, r: part, closer: part, max: maxBytes}, nil
+		}
+		part.Close()
+	}
+}
+
+// SaveUploadedFile streams the file field name to dstPath, returning the
+// number of bytes written. Files larger than MaxUploadSize are rejected with
+// ErrUploadTooLarge and nothing is left at dstPath. See OpenUpload for how
+// the body is read.
+func (c *Context) SaveUploadedFile(name, dstPath string) (int64, error) {
+	upload, err := c.OpenUpload(name, MaxUploadSize)
+	if err != nil {
+		return 0, err
+	}
+	defer upload.Close()
+
+	// Write to a temp file alongside dstPath so a failed upload never
+	// replaces an existing file
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, upload)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), dstPath); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,46 @@
+// Package services shares an app's long-lived dependencies (stores,
+// renderers, config) with its HTTP and WebSocket handlers, so they don't
+// need package globals and tests can build an app with their own:
+//
+//	svc := services.Services{"store": NewTodoStore()}
+//	r := router.New(router.WithServices(svc))
+//	hub.SetServices(svc)
+//
+//	// In a route handler
+//	store, _ := services.Get[*TodoStore](ctx.Services(), "store")
+//
+//	// In a WebSocket handler
+//	store, _ := services.Get[*TodoStore](session.Services(), "store")
+package services
+
+import "context"
+
+// Services maps names to shared services. Register everything before
+// serving; it's read concurrently by handlers and must not change after.
+type Services map[string]any
+
+// Service returns the service registered as key, or nil. Calling it on a
+// nil Services is fine.
+func (s Services) Service(key string) any {
+	return s[key]
+}
+
+// Get returns the service registered as key as a T. ok is false if it's
+// missing or not a T.
+func Get[T any](s Services, key string) (T, bool) {
+	v, ok := s[key].(T)
+	return v, ok
+}
+
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying s.
+func WithContext(ctx context.Context, s Services) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the Services carried by ctx, or nil.
+func FromContext(ctx context.Context) Services {
+	s, _ := ctx.Value(contextKey{}).(Services)
+	return s
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+type store struct{ name string }
+
+func TestGet(t *testing.T) {
+	s := Services{"store": &store{name: "todos"}, "title": "Todo"}
+
+	st, ok := Get[*store](s, "store")
+	if !ok || st.name != "todos" {
+		t.Errorf("expected the store, got %v %v", st, ok)
+	}
+	if _, ok := Get[string](s, "store"); ok {
+		t.Error("expected ok=false for the wrong type")
+	}
+	if _, ok := Get[*store](nil, "store"); ok {
+		t.Error("expected ok=false for nil Services")
+	}
+	if s.Service("missing") != nil || Services(nil).Service("store") != nil {
+		t.Error("expected nil for missing services")
+	}
+}
+
+func TestContext(t *testing.T) {
+	if FromContext(context.Background()) != nil {
+		t.Error("expected nil Services from a bare context")
+	}
+	s := Services{"title": "Todo"}
+	if got := FromContext(WithContext(context.Background(), s)); got.Service("title") != "Todo" {
+		t.Errorf("expected services from context, got %v", got)
+	}
+}
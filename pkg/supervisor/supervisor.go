@@ -0,0 +1,229 @@
+// Package supervisor runs an app's background goroutines (sync loops,
+// timers, watchers) so they stop with it. Jobs get a context that's
+// cancelled on shutdown, panics are recovered and logged, and a job that
+// panics is restarted according to its policy.
+//
+//	jobs := supervisor.New()
+//	jobs.Go("sync", func(ctx context.Context) {
+//		ticker := time.NewTicker(time.Minute)
+//		defer ticker.Stop()
+//		for {
+//			select {
+//			case <-ctx.Done():
+//				return
+//			case <-ticker.C:
+//				syncNow(ctx)
+//			}
+//		}
+//	})
+//	defer jobs.Shutdown(context.Background())
+//
+// desktop.App and the mobile bridge each own one (App.Go, mobile.Go).
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Restart says when a job is restarted.
+type Restart int
+
+const (
+	// RestartOnPanic restarts a job that panics, but not one that returns
+	RestartOnPanic Restart = iota
+	// RestartNever runs a job once
+	RestartNever
+	// RestartAlways restarts a job whenever it panics or returns
+	RestartAlways
+)
+
+// DefaultBackoff is how long a job waits before restarting by default.
+const DefaultBackoff = time.Second
+
+// Policy controls how a job is restarted.
+type Policy struct {
+	Restart     Restart       // When to restart (default RestartOnPanic)
+	MaxRestarts int           // Restarts allowed before giving up (0 = unlimited)
+	Backoff     time.Duration // Delay before each restart (default DefaultBackoff)
+}
+
+// Option configures a job's Policy.
+type Option func(*Policy)
+
+// WithRestart sets when the job is restarted.
+func WithRestart(r Restart) Option {
+	return func(p *Policy) {
+		p.Restart = r
+	}
+}
+
+// WithMaxRestarts limits how many times the job is restarted (0 = unlimited).
+func WithMaxRestarts(n int) Option {
+	return func(p *Policy) {
+		p.MaxRestarts = n
+	}
+}
+
+// WithBackoff sets the delay before each restart.
+func WithBackoff(d time.Duration) Option {
+	return func(p *Policy) {
+		p.Backoff = d
+	}
+}
+
+// JobStatus describes a running job.
+type JobStatus struct {
+	Name      string    `json:"name"`
+	Started   time.Time `json:"started"`
+	Restarts  int       `json:"restarts"`
+	LastPanic string    `json:"last_panic,omitempty"`
+}
+
+type job struct {
+	status JobStatus
+	policy Policy
+}
+
+// Supervisor owns a set of background jobs.
+type Supervisor struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	jobs    map[*job]struct{}
+	stopped bool
+}
+
+// New creates a Supervisor with no jobs.
+func New() *Supervisor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Supervisor{ctx: ctx, cancel: cancel, jobs: make(map[*job]struct{})}
+}
+
+// Go starts fn as a job named name (names are labels for Jobs and the
+// logs, and needn't be unique). fn should return when ctx is done. Jobs
+// started after Shutdown don't run.
+func (s *Supervisor) Go(name string, fn func(ctx context.Context), opts ...Option) {
+	policy := Policy{Backoff: DefaultBackoff}
+	for _, opt := range opts {
+		opt(&policy)
+	}
+	j := &job{status: JobStatus{Name: name, Started: time.Now()}, policy: policy}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.jobs[j] = struct{}{}
+	s.wg.Add(1)
+	go s.run(j, fn)
+}
+
+// run calls fn until the policy says to stop or the supervisor shuts down.
+func (s *Supervisor) run(j *job, fn func(ctx context.Context)) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.jobs, j)
+		s.mu.Unlock()
+	}()
+
+	for {
+		panicked := s.call(j, fn)
+		if s.ctx.Err() != nil {
+			return
+		}
+		switch j.policy.Restart {
+		case RestartNever:
+			return
+		case RestartOnPanic:
+			if !panicked {
+				return
+			}
+		}
+
+		s.mu.Lock()
+		restarts := j.status.Restarts
+		s.mu.Unlock()
+		if j.policy.MaxRestarts > 0 && restarts >= j.policy.MaxRestarts {
+			log.Printf("irgo: job %s stopped after %d restarts", j.status.Name, restarts)
+			return
+		}
+
+		timer := time.NewTimer(j.policy.Backoff)
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.mu.Lock()
+		j.status.Restarts++
+		s.mu.Unlock()
+	}
+}
+
+// call runs fn once, recovering and logging a panic.
+func (s *Supervisor) call(j *job, fn func(ctx context.Context)) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			msg := fmt.Sprint(r)
+			log.Printf("irgo: job %s panicked: %s\n%s", j.status.Name, msg, debug.Stack())
+			s.mu.Lock()
+			j.status.LastPanic = msg
+			s.mu.Unlock()
+		}
+	}()
+	fn(s.ctx)
+	return false
+}
+
+// Jobs returns the running jobs, sorted by name and then start time.
+func (s *Supervisor) Jobs() []JobStatus {
+	s.mu.Lock()
+	jobs := make([]JobStatus, 0, len(s.jobs))
+	for j := range s.jobs {
+		jobs = append(jobs, j.status)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(jobs, func(a, b int) bool {
+		if jobs[a].Name != jobs[b].Name {
+			return jobs[a].Name < jobs[b].Name
+		}
+		return jobs[a].Started.Before(jobs[b].Started)
+	})
+	return jobs
+}
+
+// Shutdown cancels every job's context and waits for them to return, or
+// for ctx to be done, in which case it returns ctx.Err(). Later calls to
+// Go are ignored.
+func (s *Supervisor) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,143 @@
+package supervisor
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// quietLogs discards the panic logs for the rest of the test.
+func quietLogs(t *testing.T) {
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(out) })
+}
+
+// waitFor polls cond until it's true or a second passes.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestShutdownCancelsJobs(t *testing.T) {
+	s := New()
+	var started, cancelled atomic.Int32
+	for _, name := range []string{"sync", "timer", "sync"} {
+		s.Go(name, func(ctx context.Context) {
+			started.Add(1)
+			<-ctx.Done()
+			cancelled.Add(1)
+		})
+	}
+	waitFor(t, "jobs to start", func() bool { return started.Load() == 3 })
+
+	jobs := s.Jobs()
+	if len(jobs) != 3 || jobs[0].Name != "sync" || jobs[1].Name != "sync" || jobs[2].Name != "timer" {
+		t.Errorf("unexpected jobs %+v", jobs)
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cancelled.Load() != 3 {
+		t.Errorf("expected 3 jobs cancelled, got %d", cancelled.Load())
+	}
+	if jobs := s.Jobs(); len(jobs) != 0 {
+		t.Errorf("expected no jobs after shutdown, got %+v", jobs)
+	}
+
+	// Jobs started after shutdown never run
+	s.Go("late", func(ctx context.Context) { started.Add(1) })
+	time.Sleep(10 * time.Millisecond)
+	if started.Load() != 3 {
+		t.Error("expected a job started after shutdown not to run")
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	s := New()
+	release := make(chan struct{})
+	defer close(release)
+	s.Go("stuck", func(ctx context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestPanickingJobRestarts(t *testing.T) {
+	quietLogs(t)
+	s := New()
+	defer s.Shutdown(context.Background())
+
+	var runs atomic.Int32
+	release := make(chan struct{})
+	s.Go("flaky", func(ctx context.Context) {
+		if runs.Add(1) < 3 {
+			panic("boom")
+		}
+		<-release
+	}, WithBackoff(time.Millisecond))
+
+	waitFor(t, "the job to restart", func() bool { return runs.Load() == 3 })
+	jobs := s.Jobs()
+	if len(jobs) != 1 || jobs[0].Restarts != 2 || jobs[0].LastPanic != "boom" {
+		t.Errorf("unexpected job status %+v", jobs)
+	}
+
+	// Returning normally ends a RestartOnPanic job
+	close(release)
+	waitFor(t, "the job to finish", func() bool { return len(s.Jobs()) == 0 })
+	if runs.Load() != 3 {
+		t.Errorf("expected 3 runs, got %d", runs.Load())
+	}
+}
+
+func TestRestartPolicies(t *testing.T) {
+	quietLogs(t)
+	tests := []struct {
+		name  string
+		fn    func(ctx context.Context)
+		opts  []Option
+		runs  int32
+		limit bool // keeps running until shutdown
+	}{
+		{"never", func(ctx context.Context) { panic("boom") }, []Option{WithRestart(RestartNever)}, 1, false},
+		{"max restarts", func(ctx context.Context) { panic("boom") }, []Option{WithMaxRestarts(2)}, 3, false},
+		{"always, max restarts", func(ctx context.Context) {}, []Option{WithRestart(RestartAlways), WithMaxRestarts(4)}, 5, false},
+		{"always", func(ctx context.Context) {}, []Option{WithRestart(RestartAlways)}, 10, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			var runs atomic.Int32
+			s.Go(tt.name, func(ctx context.Context) {
+				runs.Add(1)
+				tt.fn(ctx)
+			}, append(tt.opts, WithBackoff(time.Millisecond))...)
+
+			if tt.limit {
+				waitFor(t, "restarts", func() bool { return runs.Load() >= tt.runs })
+			} else {
+				waitFor(t, "the job to stop", func() bool { return len(s.Jobs()) == 0 })
+				if runs.Load() != tt.runs {
+					t.Errorf("expected %d runs, got %d", tt.runs, runs.Load())
+				}
+			}
+			if err := s.Shutdown(context.Background()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
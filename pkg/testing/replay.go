@@ -0,0 +1,70 @@
+package testing
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+
+	"github.com/stukennedy/irgo/pkg/core"
+)
+
+// ReadRecording decodes the requests written by router.RecordRequests.
+func ReadRecording(r io.Reader) ([]*core.Request, error) {
+	var requests []*core.Request
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var req core.Request
+		if err := dec.Decode(&req); err == io.EOF {
+			return requests, nil
+		} else if err != nil {
+			return requests, fmt.Errorf("reading recorded request %d: %w", len(requests)+1, err)
+		}
+		requests = append(requests, &req)
+	}
+}
+
+// Replay issues a recorded request against the client's handler, with the
+// client's headers on top of the recorded ones.
+func (c *Client) Replay(req *core.Request) *Response {
+	var body io.Reader
+	if len(req.Body) > 0 {
+		body = bytes.NewReader(req.Body)
+	}
+	httpReq := httptest.NewRequest(req.Method, req.URL, body)
+	for k, v := range req.GetHeaders() {
+		httpReq.Header.Set(k, v)
+	}
+	for k, v := range c.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	c.handler.ServeHTTP(w, httpReq)
+
+	return &Response{
+		StatusCode: w.Code,
+		Headers:    w.Header(),
+		Body:       w.Body.Bytes(),
+	}
+}
+
+// ReplayAll reads a recording from router.RecordRequests and replays its
+// requests in order, returning their responses.
+//
+//	f, _ := os.Open("testdata/bug-123.jsonl")
+//	responses, err := client.ReplayAll(f)
+//	responses[len(responses)-1].AssertStatus(t, 200)
+func (c *Client) ReplayAll(recording io.Reader) ([]*Response, error) {
+	requests, err := ReadRecording(recording)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]*Response, len(requests))
+	for i, req := range requests {
+		responses[i] = c.Replay(req)
+	}
+	return responses, nil
+}
@@ -24,6 +24,7 @@ package testing
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -212,6 +213,22 @@ func (r *Response) AssertRedirect(t *testing.T) {
 	}
 }
 
+// AssertRedirectTo asserts the response sends the client to url, either as
+// a 3xx with that Location or, for Datastar requests, as the SSE redirect
+// router.Context.Redirect sends.
+func (r *Response) AssertRedirectTo(t *testing.T, url string) {
+	t.Helper()
+	if r.StatusCode >= 300 && r.StatusCode < 400 {
+		if loc := r.Headers.Get("Location"); loc != url {
+			t.Errorf("expected redirect to %q, got Location %q", url, loc)
+		}
+		return
+	}
+	if !strings.Contains(r.BodyString(), fmt.Sprintf("window.location.href = %q", url)) {
+		t.Errorf("expected redirect to %q, got %d\nBody: %s", url, r.StatusCode, r.BodyString())
+	}
+}
+
 // AssertContains asserts the response body contains the given string.
 func (r *Response) AssertContains(t *testing.T, expected string) {
 	t.Helper()
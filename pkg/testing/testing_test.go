@@ -1,8 +1,12 @@
 package testing
 
 import (
+	"bytes"
 	"net/http"
+	"strings"
 	"testing"
+
+	"github.com/stukennedy/irgo/pkg/router"
 )
 
 func newTestHandler() http.Handler {
@@ -116,12 +120,29 @@ func TestResponseAssertions(t *testing.T) {
 	// Test Redirect assertion
 	resp = client.Get("/redirect")
 	resp.AssertRedirect(t)
+	resp.AssertRedirectTo(t, "/")
 
 	// Test JSON assertion
 	resp = client.Get("/json")
 	resp.AssertJSON(t)
 }
 
+func TestAssertRedirectToDatastar(t *testing.T) {
+	r := router.New()
+	r.GET("/save", func(ctx *router.Context) (string, error) {
+		ctx.Redirect("/done")
+		return "", nil
+	})
+	client := NewClient(r.Handler())
+
+	client.Get("/save").AssertRedirectTo(t, "/done")
+
+	resp := client.Datastar().Get("/save")
+	resp.AssertOK(t)
+	resp.AssertSSE(t)
+	resp.AssertRedirectTo(t, "/done")
+}
+
 func TestResponseContainsAll(t *testing.T) {
 	client := NewClient(newTestHandler())
 
@@ -196,3 +217,47 @@ func TestBodyReader(t *testing.T) {
 		t.Error("expected non-nil reader")
 	}
 }
+
+func TestReplayRecording(t *testing.T) {
+	newApp := func() http.Handler {
+		var todos []string
+		r := router.New()
+		r.POST("/todos", func(ctx *router.Context) (string, error) {
+			todos = append(todos, ctx.FormValue("title"))
+			return "<li>" + ctx.FormValue("title") + "</li>", nil
+		})
+		r.GET("/todos", func(ctx *router.Context) (string, error) {
+			return "<ul><li>" + strings.Join(todos, "</li><li>") + "</li></ul>", nil
+		})
+		return r.Handler()
+	}
+
+	// Record a session against one app
+	var recording bytes.Buffer
+	recorded := NewClient(router.RecordRequests(&recording)(newApp()))
+	want := []*Response{
+		recorded.PostForm("/todos", map[string]string{"title": "Milk"}),
+		recorded.PostForm("/todos", map[string]string{"title": "Eggs"}),
+		recorded.Get("/todos"),
+	}
+
+	// Replaying it against a fresh app reproduces the responses
+	responses, err := NewClient(newApp()).ReplayAll(&recording)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(responses) != len(want) {
+		t.Fatalf("expected %d responses, got %d", len(want), len(responses))
+	}
+	for i, resp := range responses {
+		resp.AssertStatus(t, want[i].StatusCode)
+		resp.AssertBodyEquals(t, want[i].BodyString())
+	}
+	responses[2].AssertBodyEquals(t, "<ul><li>Milk</li><li>Eggs</li></ul>")
+}
+
+func TestReadRecordingInvalid(t *testing.T) {
+	if _, err := ReadRecording(strings.NewReader("{\"Method\":\"GET\"}\nnot json\n")); err == nil {
+		t.Error("expected an error for an invalid entry")
+	}
+}
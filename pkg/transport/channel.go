@@ -2,6 +2,10 @@ package transport
 
 import (
 	"context"
+	"net/http"
+	"net/url"
+
+	ws "github.com/stukennedy/irgo/pkg/websocket"
 )
 
 // Channel represents a bidirectional communication channel (WebSocket-like).
@@ -14,6 +18,14 @@ type Channel interface {
 	// URL returns the connection URL (e.g., "/ws/chat").
 	URL() string
 
+	// ConnectHeaders returns the headers of the request that opened the
+	// channel, e.g. Authorization or Cookie. Empty if there was none.
+	ConnectHeaders() http.Header
+
+	// ConnectQuery returns the query parameters the channel was opened
+	// with, e.g. a room ID from "/ws/chat?room=42".
+	ConnectQuery() url.Values
+
 	// Send queues a message to be sent to the client.
 	// Returns ErrChannelClosed if the channel is closed.
 	// Returns ErrChannelFull if the buffer is full (non-blocking).
@@ -27,6 +39,10 @@ type Channel interface {
 	// After Close, Send returns ErrChannelClosed and Receive is closed.
 	Close() error
 
+	// CloseWithCode closes the channel like Close, giving the peer a
+	// WebSocket close code (e.g. websocket.ClosePolicyViolation) and reason.
+	CloseWithCode(code int, reason string) error
+
 	// Done returns a channel that's closed when the channel terminates.
 	// Use this for select statements to detect channel closure.
 	Done() <-chan struct{}
@@ -80,6 +96,43 @@ func (f ChannelHandlerFunc) OnMessage(ch Channel, msg *Message) (*Message, error
 func (f ChannelHandlerFunc) OnClose(ch Channel) {
 }
 
+// ChannelHandlerFuncs adapts any subset of callbacks to a ChannelHandler,
+// for handlers that need lifecycle events without a type of their own.
+// Omitted callbacks are no-ops.
+//
+//	tr.RegisterChannelHandler("/ws/feed", transport.ChannelHandlerFuncs{
+//		OnConnectFunc: func(ch transport.Channel) error { return feed.Subscribe(ch) },
+//		OnCloseFunc:   func(ch transport.Channel) { feed.Unsubscribe(ch) },
+//	})
+type ChannelHandlerFuncs struct {
+	OnConnectFunc func(ch Channel) error
+	OnMessageFunc func(ch Channel, msg *Message) (*Message, error)
+	OnCloseFunc   func(ch Channel)
+}
+
+// OnConnect implements ChannelHandler.
+func (f ChannelHandlerFuncs) OnConnect(ch Channel) error {
+	if f.OnConnectFunc == nil {
+		return nil
+	}
+	return f.OnConnectFunc(ch)
+}
+
+// OnMessage implements ChannelHandler.
+func (f ChannelHandlerFuncs) OnMessage(ch Channel, msg *Message) (*Message, error) {
+	if f.OnMessageFunc == nil {
+		return nil, nil
+	}
+	return f.OnMessageFunc(ch, msg)
+}
+
+// OnClose implements ChannelHandler.
+func (f ChannelHandlerFuncs) OnClose(ch Channel) {
+	if f.OnCloseFunc != nil {
+		f.OnCloseFunc(ch)
+	}
+}
+
 // Message represents a channel message.
 // This aligns with the existing websocket.Envelope and websocket.Request types.
 type Message struct {
@@ -92,7 +145,7 @@ type Message struct {
 	// Channel is the logical channel for routing (e.g., "ui", "json", "data")
 	Channel string `json:"channel,omitempty"`
 
-	// Format indicates the payload format: "html" or "json"
+	// Format indicates the payload format: "html", "json" or "binary"
 	Format string `json:"format,omitempty"`
 
 	// Target is the DOM selector for HTML swaps
@@ -139,6 +192,17 @@ func NewJSONMessage(channel string, payload []byte) *Message {
 	}
 }
 
+// NewBinaryMessage creates a message carrying data as raw bytes, sent as a
+// binary WebSocket frame by the loopback transport (base64 in JSON on the
+// mobile bridge).
+func NewBinaryMessage(channel string, data []byte) *Message {
+	return &Message{
+		Channel: channel,
+		Format:  ws.FormatBinary,
+		Payload: data,
+	}
+}
+
 // WithTarget sets the target selector and returns the message.
 func (m *Message) WithTarget(target string) *Message {
 	m.Target = target
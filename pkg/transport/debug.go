@@ -0,0 +1,44 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stukennedy/irgo/pkg/supervisor"
+	ws "github.com/stukennedy/irgo/pkg/websocket"
+)
+
+// DebugStatsPath serves the WebSocket hub's statistics (websocket.HubStats)
+// as JSON when Debug is enabled, for watching session counts and message
+// throughput during development.
+const DebugStatsPath = "/_irgo/stats"
+
+// DebugJobsPath serves the running background jobs (supervisor.JobStatus)
+// as JSON when Debug is enabled and Config.Jobs is set.
+const DebugJobsPath = "/_irgo/jobs"
+
+// debugStatsMiddleware answers GET DebugStatsPath with hub.Stats().
+func debugStatsMiddleware(hub *ws.Hub, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != DebugStatsPath || r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(hub.Stats())
+	})
+}
+
+// debugJobsMiddleware answers GET DebugJobsPath with jobs.Jobs().
+func debugJobsMiddleware(jobs *supervisor.Supervisor, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != DebugJobsPath || r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(jobs.Jobs())
+	})
+}
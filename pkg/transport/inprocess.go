@@ -8,6 +8,7 @@ import (
 
 	"github.com/stukennedy/irgo/pkg/adapter"
 	"github.com/stukennedy/irgo/pkg/core"
+	"github.com/stukennedy/irgo/pkg/router"
 	ws "github.com/stukennedy/irgo/pkg/websocket"
 )
 
@@ -18,6 +19,8 @@ type InProcessTransport struct {
 	adapter *adapter.HTTPAdapter
 	wsHub   *ws.Hub
 	config  *Config
+	limiter *ConcurrencyLimiter
+	gate    *pauseGate
 
 	handlers       map[string]ChannelHandler
 	defaultHandler ChannelHandler
@@ -33,16 +36,38 @@ func NewInProcessTransport(handler http.Handler, wsHub *ws.Hub, opts ...Option)
 	for _, opt := range opts {
 		opt(config)
 	}
+	// No server is started, so there's no address to report (desktop.App's
+	// URL and Port are then empty and 0)
+	config.Address = ""
+	config.Port = 0
 
 	// Create websocket hub if not provided
 	if wsHub == nil {
 		wsHub = ws.NewHub()
 	}
+	wsHub.SetMaxMessageSize(config.MaxMessageSize)
+
+	limiter := newLimiterFromConfig(config)
+	if limiter != nil {
+		handler = limiter.Wrap(handler)
+	}
+	if config.Debug {
+		handler = router.DebugErrorsMiddleware(handler)
+		handler = router.DevRoutesMiddleware(handler)
+		handler = debugStatsMiddleware(wsHub, handler)
+		if config.Jobs != nil {
+			handler = debugJobsMiddleware(config.Jobs, handler)
+		}
+	}
+	gate := newPauseGate()
+	handler = gate.Wrap(handler)
 
 	return &InProcessTransport{
 		adapter:  adapter.NewHTTPAdapter(handler),
 		wsHub:    wsHub,
 		config:   config,
+		limiter:  limiter,
+		gate:     gate,
 		handlers: make(map[string]ChannelHandler),
 	}
 }
@@ -69,13 +94,19 @@ func (t *InProcessTransport) OpenChannel(ctx context.Context, url string) (Chann
 	}
 	t.mu.RUnlock()
 
+	if t.config.StrictChannels && !t.wsHub.HasHandler(url) {
+		return nil, ErrNoHandler
+	}
+
 	// Create session in the hub
 	session, err := t.wsHub.Connect(url)
 	if err != nil {
 		return nil, err
 	}
 
-	return newInProcessChannel(session, t.config.ChannelBufferSize), nil
+	ch := newInProcessChannel(session, t.config.ChannelBufferSize)
+	ch.onClose = func() { t.wsHub.Disconnect(session.ID) }
+	return ch, nil
 }
 
 // RegisterChannelHandler sets the handler for channels matching a URL pattern.
@@ -126,6 +157,31 @@ func (t *InProcessTransport) Config() *Config {
 	return t.config
 }
 
+// Metrics returns a snapshot of request concurrency.
+func (t *InProcessTransport) Metrics() Metrics {
+	return t.limiter.metrics()
+}
+
+// Pause rejects new requests with 503 until Resume is called.
+func (t *InProcessTransport) Pause() {
+	t.gate.paused.Store(true)
+}
+
+// Resume undoes Pause.
+func (t *InProcessTransport) Resume() {
+	t.gate.paused.Store(false)
+}
+
+// Paused reports whether the transport is paused.
+func (t *InProcessTransport) Paused() bool {
+	return t.gate.paused.Load()
+}
+
+// ConnectionCount returns the number of open channels.
+func (t *InProcessTransport) ConnectionCount() int {
+	return t.wsHub.SessionCount()
+}
+
 // Hub returns the WebSocket hub for direct access.
 func (t *InProcessTransport) Hub() *ws.Hub {
 	return t.wsHub
@@ -2,6 +2,8 @@ package transport
 
 import (
 	"context"
+	"net/http"
+	"net/url"
 	"sync"
 
 	ws "github.com/stukennedy/irgo/pkg/websocket"
@@ -14,6 +16,10 @@ type InProcessChannel struct {
 	incoming chan *Message
 	done     chan struct{}
 
+	// onClose, if set, runs after the session closes (e.g. to remove it
+	// from the hub).
+	onClose func()
+
 	closed    bool
 	closeMu   sync.RWMutex
 	closeOnce sync.Once
@@ -44,6 +50,17 @@ func (c *InProcessChannel) URL() string {
 	return c.session.URL
 }
 
+// ConnectHeaders returns the session's connect headers, empty in-process.
+func (c *InProcessChannel) ConnectHeaders() http.Header {
+	return c.session.ConnectHeaders()
+}
+
+// ConnectQuery returns the query parameters of the URL the channel was
+// opened with.
+func (c *InProcessChannel) ConnectQuery() url.Values {
+	return c.session.ConnectQuery()
+}
+
 // Send queues a message to be sent to the client.
 func (c *InProcessChannel) Send(msg *Message) error {
 	c.closeMu.RLock()
@@ -67,14 +84,24 @@ func (c *InProcessChannel) Receive() <-chan *Message {
 
 // Close gracefully closes the channel.
 func (c *InProcessChannel) Close() error {
+	return c.CloseWithCode(ws.CloseNormalClosure, "")
+}
+
+// CloseWithCode closes the channel, closing its session with code and
+// reason (see websocket.Session.CloseWithCode).
+func (c *InProcessChannel) CloseWithCode(code int, reason string) error {
 	c.closeOnce.Do(func() {
 		c.closeMu.Lock()
 		c.closed = true
 		c.closeMu.Unlock()
 
-		c.session.Close()
+		c.session.CloseWithCode(code, reason)
 		close(c.done)
 		close(c.incoming)
+
+		if c.onClose != nil {
+			c.onClose()
+		}
 	})
 	return nil
 }
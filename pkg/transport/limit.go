@@ -0,0 +1,257 @@
+package transport
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConcurrencyLimiter is middleware that caps the number of requests a
+// transport's handler processes at once. Requests beyond the limit wait
+// for a free slot for up to the configured queue timeout and are then
+// rejected with 503 Service Unavailable and a Retry-After header.
+type ConcurrencyLimiter struct {
+	sem          chan struct{}
+	queueTimeout time.Duration
+	retryAfter   time.Duration
+
+	inFlight atomic.Int64
+	queued   atomic.Int64
+	rejected atomic.Uint64
+}
+
+// NewConcurrencyLimiter creates a limiter allowing max concurrent requests.
+// A queueTimeout of 0 rejects saturated requests immediately.
+func NewConcurrencyLimiter(max int, queueTimeout time.Duration) *ConcurrencyLimiter {
+	if max <= 0 {
+		max = 1
+	}
+	return &ConcurrencyLimiter{
+		sem:          make(chan struct{}, max),
+		queueTimeout: queueTimeout,
+		retryAfter:   time.Second,
+	}
+}
+
+// Wrap returns middleware that enforces the concurrency limit.
+func (l *ConcurrencyLimiter) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.acquire(r) {
+			l.rejected.Add(1)
+			w.Header().Set("Retry-After", strconv.Itoa(int(l.retryAfter/time.Second)))
+			http.Error(w, "Service Unavailable: too many concurrent requests", http.StatusServiceUnavailable)
+			return
+		}
+		defer l.release()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// acquire reserves a slot, waiting up to the queue timeout if saturated.
+func (l *ConcurrencyLimiter) acquire(r *http.Request) bool {
+	select {
+	case l.sem <- struct{}{}:
+		l.inFlight.Add(1)
+		return true
+	default:
+	}
+
+	if l.queueTimeout <= 0 {
+		return false
+	}
+
+	l.queued.Add(1)
+	defer l.queued.Add(-1)
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.sem <- struct{}{}:
+		l.inFlight.Add(1)
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func (l *ConcurrencyLimiter) release() {
+	l.inFlight.Add(-1)
+	<-l.sem
+}
+
+// Limit returns the maximum number of concurrent requests.
+func (l *ConcurrencyLimiter) Limit() int {
+	return cap(l.sem)
+}
+
+// InFlight returns the number of requests currently being processed.
+func (l *ConcurrencyLimiter) InFlight() int {
+	return int(l.inFlight.Load())
+}
+
+// Queued returns the number of requests waiting for a free slot.
+func (l *ConcurrencyLimiter) Queued() int {
+	return int(l.queued.Load())
+}
+
+// Rejected returns the total number of requests rejected with 503.
+func (l *ConcurrencyLimiter) Rejected() uint64 {
+	return l.rejected.Load()
+}
+
+// Metrics is a snapshot of a transport's request concurrency.
+type Metrics struct {
+	MaxConcurrentRequests int    // Configured limit (0 = unlimited)
+	InFlightRequests      int    // Requests currently being processed
+	QueuedRequests        int    // Requests waiting for a free slot
+	RejectedRequests      uint64 // Requests rejected because the limit was reached
+
+	// LoopbackTransport only
+	MaxWebSocketConnections int    // Configured connection cap (0 = unlimited)
+	WebSocketConnections    int    // WebSocket connections currently open
+	RejectedConnections     uint64 // Upgrades rejected because the cap was reached
+	RateLimitedRequests     uint64 // Requests rejected by the rate limit
+}
+
+// metrics builds a Metrics snapshot from an optional limiter.
+func (l *ConcurrencyLimiter) metrics() Metrics {
+	if l == nil {
+		return Metrics{}
+	}
+	return Metrics{
+		MaxConcurrentRequests: l.Limit(),
+		InFlightRequests:      l.InFlight(),
+		QueuedRequests:        l.Queued(),
+		RejectedRequests:      l.Rejected(),
+	}
+}
+
+// newLimiterFromConfig returns a limiter for the config, or nil if unlimited.
+func newLimiterFromConfig(c *Config) *ConcurrencyLimiter {
+	if c.MaxConcurrentRequests <= 0 {
+		return nil
+	}
+	return NewConcurrencyLimiter(c.MaxConcurrentRequests, c.RequestQueueTimeout)
+}
+
+// maxRateBuckets is how many clients a RateLimiter tracks before it drops
+// the ones that are back to a full bucket.
+const maxRateBuckets = 1024
+
+// RateLimiter is middleware that limits the request rate per client IP
+// with a token bucket: each client may make burst requests at once, then
+// rate per second. Requests over the limit are rejected with 429 Too Many
+// Requests and a Retry-After header.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+
+	rejected atomic.Uint64
+}
+
+// tokenBucket is one client's remaining tokens as of last.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing perSecond requests per client,
+// with bursts of up to burst requests. A burst below 1 is
+// max(1, perSecond).
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	b := float64(burst)
+	if b < 1 {
+		b = math.Max(1, perSecond)
+	}
+	return &RateLimiter{
+		rate:    perSecond,
+		burst:   b,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Wrap returns middleware that enforces the rate limit.
+func (l *RateLimiter) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wait, ok := l.allow(clientIP(r), time.Now()); !ok {
+			l.rejected.Add(1)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow takes a token from key's bucket, or returns how long until one is
+// available.
+func (l *RateLimiter) allow(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxRateBuckets {
+			l.prune(now)
+		}
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		if l.rate <= 0 {
+			return time.Second, false
+		}
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// prune drops the buckets that have refilled, which are the same as new ones.
+func (l *RateLimiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Rejected returns the total number of requests rejected with 429.
+func (l *RateLimiter) Rejected() uint64 {
+	if l == nil {
+		return 0
+	}
+	return l.rejected.Load()
+}
+
+// clientIP returns the IP of the client that made r.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// newRateLimiterFromConfig returns a rate limiter for the config, or nil if
+// unlimited.
+func newRateLimiterFromConfig(c *Config) *RateLimiter {
+	if c.RequestRateLimit <= 0 {
+		return nil
+	}
+	return NewRateLimiter(c.RequestRateLimit, c.RequestRateBurst)
+}
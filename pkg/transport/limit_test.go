@@ -0,0 +1,259 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stukennedy/irgo/pkg/core"
+	ws "github.com/stukennedy/irgo/pkg/websocket"
+)
+
+// blockingHandler blocks each request until release is closed.
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.Write([]byte("ok"))
+	})
+}
+
+func TestConcurrencyLimiterRejectsWhenSaturated(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	limiter := NewConcurrencyLimiter(1, 0)
+	handler := limiter.Wrap(blockingHandler(started, release))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	<-started
+
+	if limiter.InFlight() != 1 {
+		t.Errorf("expected 1 in-flight request, got %d", limiter.InFlight())
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+	if limiter.Rejected() != 1 {
+		t.Errorf("expected 1 rejected request, got %d", limiter.Rejected())
+	}
+
+	close(release)
+	wg.Wait()
+
+	if limiter.InFlight() != 0 {
+		t.Errorf("expected semaphore to be released, got %d in flight", limiter.InFlight())
+	}
+}
+
+func TestConcurrencyLimiterQueuesUntilSlotFree(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	limiter := NewConcurrencyLimiter(1, 2*time.Second)
+	handler := limiter.Wrap(blockingHandler(started, release))
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+			codes[i] = w.Code
+		}(i)
+	}
+
+	<-started
+	deadline := time.Now().Add(time.Second)
+	for limiter.Queued() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if limiter.Queued() != 1 {
+		t.Fatalf("expected 1 queued request, got %d", limiter.Queued())
+	}
+
+	close(release)
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("request %d: expected status 200, got %d", i, code)
+		}
+	}
+	if limiter.Rejected() != 0 {
+		t.Errorf("expected no rejected requests, got %d", limiter.Rejected())
+	}
+}
+
+func TestConcurrencyLimiterQueueTimeout(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	limiter := NewConcurrencyLimiter(1, 20*time.Millisecond)
+	handler := limiter.Wrap(blockingHandler(started, release))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	<-started
+	defer close(release)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 after queue timeout, got %d", w.Code)
+	}
+}
+
+func TestInProcessTransportMaxConcurrentRequests(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	tr := NewInProcessTransport(blockingHandler(started, release), nil,
+		WithMaxConcurrentRequests(1, 0),
+	)
+	tr.Start()
+	defer tr.Stop(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tr.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+	}()
+	<-started
+
+	resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", resp.Status)
+	}
+
+	m := tr.Metrics()
+	if m.MaxConcurrentRequests != 1 || m.InFlightRequests != 1 || m.RejectedRequests != 1 {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+
+	close(release)
+	<-done
+
+	if m := tr.Metrics(); m.InFlightRequests != 0 {
+		t.Errorf("expected 0 in-flight requests after release, got %d", m.InFlightRequests)
+	}
+}
+
+func TestUnlimitedTransportMetrics(t *testing.T) {
+	tr := NewInProcessTransport(http.NotFoundHandler(), nil)
+	if m := tr.Metrics(); m != (Metrics{}) {
+		t.Errorf("expected zero metrics without a limit, got %+v", m)
+	}
+}
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	limiter := NewRateLimiter(2, 3)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if _, ok := limiter.allow("127.0.0.1", now); !ok {
+			t.Fatalf("expected request %d of the burst to be allowed", i+1)
+		}
+	}
+	wait, ok := limiter.allow("127.0.0.1", now)
+	if ok {
+		t.Fatal("expected the request after the burst to be rejected")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("expected to wait 500ms for a token, got %v", wait)
+	}
+
+	// Clients have their own buckets
+	if _, ok := limiter.allow("127.0.0.2", now); !ok {
+		t.Error("expected another client to be allowed")
+	}
+
+	// Tokens refill at the rate
+	if _, ok := limiter.allow("127.0.0.1", now.Add(500*time.Millisecond)); !ok {
+		t.Error("expected a refilled token to be allowed")
+	}
+}
+
+func TestLoopbackTransportRateLimit(t *testing.T) {
+	tr := NewLoopbackTransport(http.NotFoundHandler(), nil, WithRateLimit(0.1, 2))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	for i := 0; i < 2; i++ {
+		resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Status != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", resp.Status)
+		}
+	}
+
+	resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, got %d", resp.Status)
+	}
+	if m := tr.Metrics(); m.RateLimitedRequests != 1 {
+		t.Errorf("expected 1 rate-limited request, got %d", m.RateLimitedRequests)
+	}
+}
+
+func TestLoopbackTransportMaxWebSocketConnections(t *testing.T) {
+	hub := ws.NewHub()
+	hub.HandleFunc("/ws", func(*ws.Session, *ws.Request) (*ws.Envelope, error) {
+		return nil, nil
+	})
+	tr := NewLoopbackTransport(http.NotFoundHandler(), hub, WithMaxWebSocketConnections(2))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	var channels []Channel
+	for i := 0; i < 2; i++ {
+		ch, err := tr.OpenChannel(context.Background(), "/ws")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		channels = append(channels, ch)
+	}
+
+	if _, err := tr.OpenChannel(context.Background(), "/ws"); err == nil {
+		t.Fatal("expected the connection over the cap to be rejected")
+	}
+	m := tr.Metrics()
+	if m.MaxWebSocketConnections != 2 || m.WebSocketConnections != 2 || m.RejectedConnections != 1 {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+
+	// Closing a connection frees its slot
+	channels[0].Close()
+	waitForCount(t, "loopback", tr, 1)
+	ch, err := tr.OpenChannel(context.Background(), "/ws")
+	if err != nil {
+		t.Fatalf("expected a connection after one closed, got %v", err)
+	}
+	ch.Close()
+	channels[1].Close()
+}
@@ -4,12 +4,19 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -26,6 +33,13 @@ type LoopbackTransport struct {
 	server   *http.Server
 	config   *Config
 	upgrader websocket.Upgrader
+	client   *http.Client // For HandleRequest, set up by Start
+	limiter  *ConcurrencyLimiter
+	rate     *RateLimiter
+	gate     *pauseGate
+
+	wsConns       atomic.Int64  // Open WebSocket connections, for MaxWebSocketConnections
+	rejectedConns atomic.Uint64 // Upgrades rejected by MaxWebSocketConnections
 
 	handlers       map[string]ChannelHandler
 	defaultHandler ChannelHandler
@@ -43,12 +57,21 @@ func NewLoopbackTransport(handler http.Handler, wsHub *ws.Hub, opts ...Option) *
 		opt(config)
 	}
 
+	if wsHub != nil {
+		wsHub.SetMaxMessageSize(config.MaxMessageSize)
+	}
+
 	t := &LoopbackTransport{
 		handler:  handler,
 		wsHub:    wsHub,
 		config:   config,
 		handlers: make(map[string]ChannelHandler),
+		limiter:  newLimiterFromConfig(config),
+		rate:     newRateLimiterFromConfig(config),
+		gate:     newPauseGate(),
 		upgrader: websocket.Upgrader{
+			ReadBufferSize:  config.WSReadBufferSize,
+			WriteBufferSize: config.WSWriteBufferSize,
 			CheckOrigin: func(r *http.Request) bool {
 				// Origin validation is handled by middleware
 				return true
@@ -69,7 +92,7 @@ func (t *LoopbackTransport) HandleRequest(ctx context.Context, req *core.Request
 	}
 	t.mu.RUnlock()
 
-	url := fmt.Sprintf("http://%s:%d%s", t.config.Address, t.config.Port, req.URL)
+	url := t.config.BaseURL() + req.URL
 
 	var body io.Reader
 	if len(req.Body) > 0 {
@@ -91,8 +114,7 @@ func (t *LoopbackTransport) HandleRequest(ctx context.Context, req *core.Request
 		httpReq.Header.Set("X-Irgo-Secret", t.config.Secret)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(httpReq)
+	resp, err := t.client.Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -108,13 +130,16 @@ func (t *LoopbackTransport) HandleRequest(ctx context.Context, req *core.Request
 		Body:   respBody,
 	}
 
-	respHeaders := make(map[string]string)
-	for k, v := range resp.Header {
+	result.SetHTTPHeader(resp.Header)
+
+	// Trailers are only populated once the body has been read
+	respTrailers := make(map[string]string)
+	for k, v := range resp.Trailer {
 		if len(v) > 0 {
-			respHeaders[k] = v[0]
+			respTrailers[k] = v[0]
 		}
 	}
-	result.SetHeaders(respHeaders)
+	result.SetTrailers(respTrailers)
 
 	return result, nil
 }
@@ -128,15 +153,19 @@ func (t *LoopbackTransport) OpenChannel(ctx context.Context, url string) (Channe
 	}
 	t.mu.RUnlock()
 
-	wsURL := fmt.Sprintf("ws://%s:%d%s", t.config.Address, t.config.Port, url)
+	wsURL := "ws" + strings.TrimPrefix(t.config.BaseURL(), "http") + url
 	if t.config.Secret != "" {
-		wsURL += "?secret=" + t.config.Secret
+		sep := "?"
+		if strings.Contains(url, "?") {
+			sep = "&"
+		}
+		wsURL += sep + "secret=" + t.config.Secret
 	}
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
+	dialer, err := t.dialer()
+	if err != nil {
+		return nil, err
 	}
-
 	conn, _, err := dialer.DialContext(ctx, wsURL, nil)
 	if err != nil {
 		return nil, err
@@ -145,6 +174,23 @@ func (t *LoopbackTransport) OpenChannel(ctx context.Context, url string) (Channe
 	return newLoopbackChannel(conn, url), nil
 }
 
+// dialer returns the WebSocket dialer for OpenChannel.
+func (t *LoopbackTransport) dialer() (*websocket.Dialer, error) {
+	dialer := &websocket.Dialer{
+		HandshakeTimeout: 10 * time.Second,
+		ReadBufferSize:   t.config.WSReadBufferSize,
+		WriteBufferSize:  t.config.WSWriteBufferSize,
+	}
+	if t.config.TLS {
+		tlsConfig, err := clientTLSConfig(t.config)
+		if err != nil {
+			return nil, err
+		}
+		dialer.TLSClientConfig = tlsConfig
+	}
+	return dialer, nil
+}
+
 // RegisterChannelHandler sets the handler for channels matching a URL pattern.
 func (t *LoopbackTransport) RegisterChannelHandler(pattern string, handler ChannelHandler) {
 	t.handlersMu.Lock()
@@ -153,7 +199,7 @@ func (t *LoopbackTransport) RegisterChannelHandler(pattern string, handler Chann
 
 	// Also register with the websocket hub
 	if t.wsHub != nil {
-		t.wsHub.Handle(pattern, &hubHandlerAdapter{handler: handler})
+		t.wsHub.Handle(pattern, &hubHandlerAdapter{handler: handler, bufferSize: t.config.ChannelBufferSize})
 	}
 }
 
@@ -164,7 +210,7 @@ func (t *LoopbackTransport) SetDefaultChannelHandler(handler ChannelHandler) {
 	t.defaultHandler = handler
 
 	if t.wsHub != nil {
-		t.wsHub.SetDefaultHandler(&hubHandlerAdapter{handler: handler})
+		t.wsHub.SetDefaultHandler(&hubHandlerAdapter{handler: handler, bufferSize: t.config.ChannelBufferSize})
 	}
 }
 
@@ -188,7 +234,10 @@ func (t *LoopbackTransport) Start() error {
 	}
 
 	// Generate secret if not provided
-	if t.config.Secret == "" {
+	if t.config.DisableSecret {
+		t.config.Secret = ""
+		log.Print(secretDisabledWarning)
+	} else if t.config.Secret == "" {
 		// Import would be circular, so we generate inline
 		secret, err := generateSecret()
 		if err != nil {
@@ -198,26 +247,59 @@ func (t *LoopbackTransport) Start() error {
 	}
 
 	// Set allowed origins to include our own origin
-	origin := fmt.Sprintf("http://%s:%d", t.config.Address, t.config.Port)
 	if len(t.config.AllowedOrigins) == 0 {
-		t.config.AllowedOrigins = []string{origin}
+		t.config.AllowedOrigins = []string{t.config.BaseURL()}
 	}
 
 	// Wrap handler with security middleware
 	handler := t.handler
 
+	if t.config.Compression {
+		handler = router.CompressionMiddleware(0)(handler)
+	}
+
+	// Concurrency limit applies to regular requests only, not long-lived
+	// WebSocket connections
+	if t.limiter != nil {
+		handler = t.limiter.Wrap(handler)
+	}
+
+	if t.config.Debug {
+		handler = router.DebugErrorsMiddleware(handler)
+		handler = router.DevRoutesMiddleware(handler)
+		if t.wsHub != nil {
+			handler = debugStatsMiddleware(t.wsHub, handler)
+		}
+		if t.config.Jobs != nil {
+			handler = debugJobsMiddleware(t.config.Jobs, handler)
+		}
+	}
+
 	// WebSocket upgrade handler
 	handler = t.wrapWithWebSocketHandler(handler)
 
+	// Pausing also refuses new WebSocket connections; open ones are unaffected
+	handler = t.gate.Wrap(handler)
+
+	// Rate limiting covers WebSocket upgrades too, so a page reconnecting
+	// in a loop is slowed down
+	if t.rate != nil {
+		handler = t.rate.Wrap(handler)
+	}
+
 	// Security middleware (applied in reverse order)
-	handler = router.WebSocketSecretMiddleware(t.config.Secret)(handler)
-	handler = router.SecretValidationMiddleware(t.config.Secret, []string{"/static/", "/api/"})(handler)
+	if !t.config.DisableSecret {
+		handler = router.WebSocketSecretMiddleware(t.config.Secret)(handler)
+		handler = router.SecretValidationMiddleware(t.config.Secret, []string{"/static/", "/api/"})(handler)
+	}
 	handler = router.StrictOriginMiddleware(t.config.AllowedOrigins...)(handler)
 	handler = router.CORSMiddleware(t.config.AllowedOrigins...)(handler)
 
 	t.server = &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", t.config.Address, t.config.Port),
 		Handler: handler,
+		// Connection-level errors, e.g. failed TLS handshakes
+		ErrorLog: slog.NewLogLogger(t.logger().Handler(), slog.LevelWarn),
 	}
 
 	// Create a listener first so we know the server is ready
@@ -225,20 +307,44 @@ func (t *LoopbackTransport) Start() error {
 	if err != nil {
 		return fmt.Errorf("listening on %s: %w", t.server.Addr, err)
 	}
+	if t.config.TLS {
+		tlsConfig, err := serverTLSConfig(t.config)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	t.client = &http.Client{Timeout: 30 * time.Second}
+	if t.config.TLS {
+		tlsConfig, err := clientTLSConfig(t.config)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		t.client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
 
 	t.wg.Add(1)
 	go func() {
 		defer t.wg.Done()
 		if err := t.server.Serve(listener); err != http.ErrServerClosed {
-			fmt.Printf("Loopback transport server error: %v\n", err)
+			t.logger().Error("loopback transport server failed", slog.Any("error", err))
 		}
 	}()
 
 	t.running = true
+	t.logger().Info("loopback transport started",
+		slog.String("url", t.config.BaseURL()),
+		slog.Bool("secret", t.config.Secret != ""),
+	)
 	return nil
 }
 
-// Stop gracefully shuts down the transport.
+// Stop gracefully shuts down the transport, waiting for in-flight requests
+// until ctx is done or, if ctx has no deadline, Config.ShutdownTimeout
+// elapses. Connections still open then are closed and ctx's error returned.
 func (t *LoopbackTransport) Stop(ctx context.Context) error {
 	t.mu.Lock()
 	if !t.running {
@@ -248,9 +354,18 @@ func (t *LoopbackTransport) Stop(ctx context.Context) error {
 	t.running = false
 	t.mu.Unlock()
 
+	if _, ok := ctx.Deadline(); !ok && t.config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.config.ShutdownTimeout)
+		defer cancel()
+	}
+
+	var err error
 	if t.server != nil {
-		if err := t.server.Shutdown(ctx); err != nil {
-			return err
+		if err = t.server.Shutdown(ctx); err != nil {
+			// Out of time: drop the connections that are still draining
+			t.logger().Warn("loopback transport shutdown timed out; closing open connections", slog.Any("error", err))
+			t.server.Close()
 		}
 	}
 
@@ -259,14 +374,60 @@ func (t *LoopbackTransport) Stop(ctx context.Context) error {
 	}
 
 	t.wg.Wait()
-	return nil
+	t.logger().Info("loopback transport stopped")
+	return err
+}
+
+// logger returns Config.Logger, or a logger that discards everything.
+func (t *LoopbackTransport) logger() *slog.Logger {
+	if t.config.Logger != nil {
+		return t.config.Logger
+	}
+	return discardLogger
 }
 
+// discardLogger is the default transport logger.
+var discardLogger = slog.New(slog.DiscardHandler)
+
 // Config returns the transport configuration.
 func (t *LoopbackTransport) Config() *Config {
 	return t.config
 }
 
+// Metrics returns a snapshot of request concurrency, WebSocket
+// connections and rate limiting.
+func (t *LoopbackTransport) Metrics() Metrics {
+	m := t.limiter.metrics()
+	m.MaxWebSocketConnections = t.config.MaxWebSocketConnections
+	m.WebSocketConnections = int(t.wsConns.Load())
+	m.RejectedConnections = t.rejectedConns.Load()
+	m.RateLimitedRequests = t.rate.Rejected()
+	return m
+}
+
+// Pause rejects new requests with 503 until Resume is called.
+func (t *LoopbackTransport) Pause() {
+	t.gate.paused.Store(true)
+}
+
+// Resume undoes Pause.
+func (t *LoopbackTransport) Resume() {
+	t.gate.paused.Store(false)
+}
+
+// Paused reports whether the transport is paused.
+func (t *LoopbackTransport) Paused() bool {
+	return t.gate.paused.Load()
+}
+
+// ConnectionCount returns the number of open WebSocket connections.
+func (t *LoopbackTransport) ConnectionCount() int {
+	if t.wsHub == nil {
+		return 0
+	}
+	return t.wsHub.SessionCount()
+}
+
 // wrapWithWebSocketHandler adds WebSocket upgrade handling to the handler chain.
 func (t *LoopbackTransport) wrapWithWebSocketHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -276,18 +437,44 @@ func (t *LoopbackTransport) wrapWithWebSocketHandler(next http.Handler) http.Han
 			return
 		}
 
+		if t.config.StrictChannels && (t.wsHub == nil || !t.wsHub.HasHandler(r.URL.Path)) {
+			t.logger().Warn("websocket rejected: no channel handler", slog.String("path", r.URL.Path))
+			http.Error(w, "Not Found: no channel handler for "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+
+		// Reserve a connection slot; wsReader releases it
+		if n := t.wsConns.Add(1); t.config.MaxWebSocketConnections > 0 && n > int64(t.config.MaxWebSocketConnections) {
+			t.wsConns.Add(-1)
+			t.rejectedConns.Add(1)
+			t.logger().Warn("websocket rejected: connection limit reached",
+				slog.String("path", r.URL.Path),
+				slog.Int("max", t.config.MaxWebSocketConnections),
+			)
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Service Unavailable: too many WebSocket connections", http.StatusServiceUnavailable)
+			return
+		}
+
 		// Upgrade to WebSocket
 		conn, err := t.upgrader.Upgrade(w, r, nil)
 		if err != nil {
+			// The upgrader has already replied with an error status
+			t.wsConns.Add(-1)
+			t.logger().Warn("websocket upgrade failed", slog.String("path", r.URL.Path), slog.Any("error", err))
 			return
 		}
 
-		// Create session in hub
-		session, err := t.wsHub.Connect(r.URL.Path)
+		// Create session in hub, keeping the request's headers and query
+		// (the secret has been removed) for OnConnect
+		session, err := t.wsHub.ConnectRequest(r)
 		if err != nil {
+			t.wsConns.Add(-1)
+			t.logger().Error("websocket session rejected", slog.String("path", r.URL.Path), slog.Any("error", err))
 			conn.Close()
 			return
 		}
+		t.logger().Debug("websocket connected", slog.String("path", r.URL.Path), slog.String("session", session.ID))
 
 		// Start goroutines for reading/writing
 		go t.wsWriter(conn, session)
@@ -298,31 +485,116 @@ func (t *LoopbackTransport) wrapWithWebSocketHandler(next http.Handler) http.Han
 func (t *LoopbackTransport) wsWriter(conn *websocket.Conn, session *ws.Session) {
 	defer conn.Close()
 
-	for envelope := range session.SendChan {
-		data, err := envelope.JSON()
-		if err != nil {
-			continue
-		}
-		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
-			return
+	// A nil channel never fires, so no pings without keep-alive
+	var ping <-chan time.Time
+	if interval, _ := t.keepAlive(); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		select {
+		case envelope, ok := <-session.SendChan:
+			if !ok {
+				// Tell the client why the session closed
+				code, reason := session.CloseStatus()
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+				return
+			}
+			// Binary payloads go as-is in binary frames, without the
+			// envelope around them
+			if envelope.Format == ws.FormatBinary {
+				if err := conn.WriteMessage(websocket.BinaryMessage, envelope.Bytes()); err != nil {
+					return
+				}
+				continue
+			}
+			data, err := envelope.JSON()
+			if err != nil {
+				continue
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ping:
+			// A failed ping closes conn, so the reader exits and
+			// disconnects the session
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+				return
+			}
 		}
 	}
 }
 
+// keepAlive returns the ping interval and read timeout, or zeros if
+// keep-alive is off.
+func (t *LoopbackTransport) keepAlive() (interval, timeout time.Duration) {
+	interval = t.config.PingInterval
+	if interval <= 0 {
+		return 0, 0
+	}
+	timeout = t.config.PongTimeout
+	if timeout <= interval {
+		timeout = 2 * interval
+	}
+	return interval, timeout
+}
+
 func (t *LoopbackTransport) wsReader(conn *websocket.Conn, session *ws.Session) {
+	// The session's close status, for handlers' OnClose
+	code, reason := ws.CloseNormalClosure, ""
 	defer func() {
-		t.wsHub.Disconnect(session.ID)
+		t.wsConns.Add(-1)
+		t.wsHub.DisconnectWithCode(session.ID, code, reason)
 		conn.Close()
+		t.logger().Debug("websocket disconnected",
+			slog.String("session", session.ID),
+			slog.Int("code", code),
+			slog.String("reason", reason),
+		)
 	}()
 
+	// Oversized frames fail the read (closing with 1009) before they're
+	// buffered
+	if t.config.MaxMessageSize > 0 {
+		conn.SetReadLimit(int64(t.config.MaxMessageSize))
+	}
+
+	// A peer that neither answers pings nor sends anything for the
+	// timeout fails the read, disconnecting the session
+	_, timeout := t.keepAlive()
+	extend := func() {
+		if timeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(timeout))
+		}
+	}
+	extend()
+	conn.SetPongHandler(func(string) error {
+		extend()
+		return nil
+	})
+
 	for {
 		_, data, err := conn.ReadMessage()
 		if err != nil {
+			var ce *websocket.CloseError
+			var ne net.Error
+			switch {
+			case errors.As(err, &ce):
+				code, reason = ce.Code, ce.Text
+			case errors.Is(err, websocket.ErrReadLimit):
+				code, reason = ws.CloseMessageTooBig, "message too large"
+			case errors.As(err, &ne) && ne.Timeout():
+				code, reason = ws.CloseGoingAway, "keep-alive timeout"
+			}
 			return
 		}
+		extend()
 
 		envelope, err := t.wsHub.HandleMessage(session.ID, data)
 		if err != nil {
+			t.logger().Warn("websocket message failed", slog.String("session", session.ID), slog.Any("error", err))
 			continue
 		}
 		if envelope != nil {
@@ -335,6 +607,10 @@ func isWebSocketUpgrade(r *http.Request) bool {
 	return r.Header.Get("Upgrade") == "websocket"
 }
 
+// secretDisabledWarning is logged when a transport starts with DisableSecret.
+const secretDisabledWarning = "irgo: WARNING: per-launch secret disabled (DisableSecret); " +
+	"any local process can send requests to this app's handlers"
+
 // generateSecret creates a cryptographically secure random secret.
 func generateSecret() (string, error) {
 	b := make([]byte, 32)
@@ -344,19 +620,56 @@ func generateSecret() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
-// hubHandlerAdapter adapts ChannelHandler to ws.MessageHandler.
+// hubHandlerAdapter adapts ChannelHandler to ws.MessageHandler, keeping
+// one channel per session so messages reach both OnMessage and Receive.
 type hubHandlerAdapter struct {
-	handler ChannelHandler
+	handler    ChannelHandler
+	bufferSize int
+
+	mu       sync.Mutex
+	channels map[*ws.Session]*sessionChannelAdapter
+}
+
+// channel returns session's channel, creating it on first use.
+func (a *hubHandlerAdapter) channel(session *ws.Session) *sessionChannelAdapter {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.channels == nil {
+		a.channels = make(map[*ws.Session]*sessionChannelAdapter)
+	}
+	ch, ok := a.channels[session]
+	if !ok {
+		ch = newSessionChannelAdapter(session, a.bufferSize)
+		a.channels[session] = ch
+	}
+	return ch
+}
+
+// remove forgets session's channel and closes its Receive channel.
+func (a *hubHandlerAdapter) remove(session *ws.Session) *sessionChannelAdapter {
+	a.mu.Lock()
+	ch, ok := a.channels[session]
+	delete(a.channels, session)
+	a.mu.Unlock()
+	if !ok {
+		ch = newSessionChannelAdapter(session, a.bufferSize)
+	}
+	ch.closeIncoming()
+	return ch
 }
 
 func (a *hubHandlerAdapter) OnConnect(session *ws.Session) error {
-	ch := &sessionChannelAdapter{session: session}
-	return a.handler.OnConnect(ch)
+	err := a.handler.OnConnect(a.channel(session))
+	if err != nil {
+		a.remove(session)
+	}
+	return err
 }
 
 func (a *hubHandlerAdapter) OnMessage(session *ws.Session, req *ws.Request) (*ws.Envelope, error) {
-	ch := &sessionChannelAdapter{session: session}
+	ch := a.channel(session)
 	msg := wsRequestToMessage(req)
+	ch.deliver(msg)
 
 	resp, err := a.handler.OnMessage(ch, msg)
 	if err != nil {
@@ -370,27 +683,59 @@ func (a *hubHandlerAdapter) OnMessage(session *ws.Session, req *ws.Request) (*ws
 }
 
 func (a *hubHandlerAdapter) OnClose(session *ws.Session) {
-	ch := &sessionChannelAdapter{session: session}
-	a.handler.OnClose(ch)
+	a.handler.OnClose(a.remove(session))
 }
 
 // sessionChannelAdapter adapts ws.Session to Channel.
 type sessionChannelAdapter struct {
 	session *ws.Session
+
+	// incoming buffers client messages for Receive; closed on disconnect
+	incoming chan *Message
+	closeMu  sync.RWMutex
+	closed   bool
+}
+
+func newSessionChannelAdapter(session *ws.Session, bufferSize int) *sessionChannelAdapter {
+	if bufferSize <= 0 {
+		bufferSize = 100
+	}
+	return &sessionChannelAdapter{session: session, incoming: make(chan *Message, bufferSize)}
+}
+
+// deliver queues msg for Receive, dropping it if the buffer is full (a
+// handler using only OnMessage never drains it).
+func (a *sessionChannelAdapter) deliver(msg *Message) {
+	a.closeMu.RLock()
+	defer a.closeMu.RUnlock()
+	if a.closed {
+		return
+	}
+	select {
+	case a.incoming <- msg:
+	default:
+	}
+}
+
+func (a *sessionChannelAdapter) closeIncoming() {
+	a.closeMu.Lock()
+	defer a.closeMu.Unlock()
+	if !a.closed {
+		a.closed = true
+		close(a.incoming)
+	}
 }
 
 func (a *sessionChannelAdapter) ID() string  { return a.session.ID }
 func (a *sessionChannelAdapter) URL() string { return a.session.URL }
+func (a *sessionChannelAdapter) ConnectHeaders() http.Header {
+	return a.session.ConnectHeaders()
+}
+func (a *sessionChannelAdapter) ConnectQuery() url.Values {
+	return a.session.ConnectQuery()
+}
 func (a *sessionChannelAdapter) Done() <-chan struct{} {
-	// Session doesn't expose a done channel, create one
-	done := make(chan struct{})
-	go func() {
-		for range a.session.SendChan {
-			// Drain until closed
-		}
-		close(done)
-	}()
-	return done
+	return a.session.Done()
 }
 
 func (a *sessionChannelAdapter) Send(msg *Message) error {
@@ -403,9 +748,10 @@ func (a *sessionChannelAdapter) Send(msg *Message) error {
 	return nil
 }
 
+// Receive returns the session's incoming messages, which are also passed
+// to the handler's OnMessage. It's closed when the session disconnects.
 func (a *sessionChannelAdapter) Receive() <-chan *Message {
-	// Loopback transport receives messages via the hub, not this channel
-	return make(chan *Message)
+	return a.incoming
 }
 
 func (a *sessionChannelAdapter) Close() error {
@@ -413,6 +759,32 @@ func (a *sessionChannelAdapter) Close() error {
 	return nil
 }
 
+func (a *sessionChannelAdapter) CloseWithCode(code int, reason string) error {
+	a.session.CloseWithCode(code, reason)
+	return nil
+}
+
+// SendStream sends messages from a stream until it's closed, stopping
+// with an error if ctx is cancelled or a send fails because the session
+// is closed or its buffer is full. Implements StreamingChannel.
+func (a *sessionChannelAdapter) SendStream(ctx context.Context, stream <-chan *Message) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-a.session.Done():
+			return ErrChannelClosed
+		case msg, ok := <-stream:
+			if !ok {
+				return nil // Stream completed
+			}
+			if err := a.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func (a *sessionChannelAdapter) Set(key string, value any) {
 	a.session.Set(key, value)
 }
@@ -421,6 +793,9 @@ func (a *sessionChannelAdapter) Get(key string) (any, bool) {
 	return a.session.Get(key)
 }
 
+// Verify sessionChannelAdapter implements StreamingChannel
+var _ StreamingChannel = (*sessionChannelAdapter)(nil)
+
 // Conversion helpers
 
 func wsRequestToMessage(req *ws.Request) *Message {
@@ -2,9 +2,16 @@ package transport
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
+	ws "github.com/stukennedy/irgo/pkg/websocket"
 )
 
 // LoopbackChannel wraps a real WebSocket connection to implement the Channel interface.
@@ -21,6 +28,11 @@ type LoopbackChannel struct {
 	closed    bool
 	closeMu   sync.RWMutex
 	closeOnce sync.Once
+
+	// closeCode and closeReason are from the server's close frame; guarded
+	// by closeMu
+	closeCode   int
+	closeReason string
 }
 
 // newLoopbackChannel creates a new channel wrapping a WebSocket connection.
@@ -50,6 +62,20 @@ func (c *LoopbackChannel) URL() string {
 	return c.url
 }
 
+// ConnectHeaders returns an empty header: this is the client end, which
+// sent no extra headers.
+func (c *LoopbackChannel) ConnectHeaders() http.Header {
+	return http.Header{}
+}
+
+// ConnectQuery returns the query parameters of the URL the channel was
+// opened with (without the secret).
+func (c *LoopbackChannel) ConnectQuery() url.Values {
+	_, query, _ := strings.Cut(c.url, "?")
+	values, _ := url.ParseQuery(query)
+	return values
+}
+
 // Send sends a message through the WebSocket connection.
 func (c *LoopbackChannel) Send(msg *Message) error {
 	c.closeMu.RLock()
@@ -82,6 +108,31 @@ func (c *LoopbackChannel) Receive() <-chan *Message {
 
 // Close gracefully closes the channel.
 func (c *LoopbackChannel) Close() error {
+	return c.CloseWithCode(websocket.CloseNormalClosure, "")
+}
+
+// CloseWithCode sends the server a close frame with code and reason, then
+// closes the channel.
+func (c *LoopbackChannel) CloseWithCode(code int, reason string) error {
+	c.closeMu.RLock()
+	closed := c.closed
+	c.closeMu.RUnlock()
+	if !closed {
+		c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	}
+	c.close()
+	return nil
+}
+
+// CloseStatus returns the code and reason the server closed the channel
+// with, or 0 and "" if it hasn't.
+func (c *LoopbackChannel) CloseStatus() (code int, reason string) {
+	c.closeMu.RLock()
+	defer c.closeMu.RUnlock()
+	return c.closeCode, c.closeReason
+}
+
+func (c *LoopbackChannel) close() {
 	c.closeOnce.Do(func() {
 		c.closeMu.Lock()
 		c.closed = true
@@ -91,7 +142,6 @@ func (c *LoopbackChannel) Close() error {
 		close(c.done)
 		close(c.incoming)
 	})
-	return nil
 }
 
 // Done returns a channel that's closed when the channel terminates.
@@ -135,11 +185,26 @@ func (c *LoopbackChannel) SendStream(ctx context.Context, stream <-chan *Message
 
 // readLoop reads messages from the WebSocket and delivers them to the incoming channel.
 func (c *LoopbackChannel) readLoop() {
-	defer c.Close()
+	defer c.close()
 
 	for {
+		messageType, raw, err := c.conn.ReadMessage()
+		if err != nil {
+			var ce *websocket.CloseError
+			if errors.As(err, &ce) {
+				c.closeMu.Lock()
+				c.closeCode, c.closeReason = ce.Code, ce.Text
+				c.closeMu.Unlock()
+			}
+			return
+		}
+
+		if messageType == websocket.BinaryMessage {
+			c.deliver(&Message{Format: ws.FormatBinary, Payload: raw})
+			continue
+		}
 		var data map[string]any
-		if err := c.conn.ReadJSON(&data); err != nil {
+		if err := json.Unmarshal(raw, &data); err != nil {
 			return
 		}
 
@@ -169,13 +234,17 @@ func (c *LoopbackChannel) readLoop() {
 			msg.Values = values
 		}
 
-		select {
-		case c.incoming <- msg:
-		case <-c.done:
-			return
-		default:
-			// Buffer full, drop message
-		}
+		c.deliver(msg)
+	}
+}
+
+// deliver queues an incoming message, dropping it if the buffer is full.
+func (c *LoopbackChannel) deliver(msg *Message) {
+	select {
+	case c.incoming <- msg:
+	case <-c.done:
+	default:
+		// Buffer full, drop message
 	}
 }
 
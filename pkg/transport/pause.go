@@ -0,0 +1,31 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// pauseGate is middleware that rejects requests with 503 Service Unavailable
+// while the transport is paused. Open channels are unaffected.
+type pauseGate struct {
+	paused     atomic.Bool
+	retryAfter time.Duration
+}
+
+func newPauseGate() *pauseGate {
+	return &pauseGate{retryAfter: time.Second}
+}
+
+// Wrap returns middleware that enforces the pause state.
+func (g *pauseGate) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.paused.Load() {
+			w.Header().Set("Retry-After", strconv.Itoa(int(g.retryAfter/time.Second)))
+			http.Error(w, "Service Unavailable: transport paused", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
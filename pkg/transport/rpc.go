@@ -0,0 +1,134 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// RPCChannel is the logical channel RPC replies are sent on.
+const RPCChannel = "rpc"
+
+// ErrUnknownMethod is reported to the client when it calls a method that
+// isn't registered.
+var ErrUnknownMethod = errors.New("unknown RPC method")
+
+// RPCFunc handles a call. params is the call's raw JSON params ("null" if it
+// had none); the result is marshaled to JSON for the reply.
+type RPCFunc func(ch Channel, params json.RawMessage) (any, error)
+
+// RPCMethod adapts a typed function to an RPCFunc, decoding params into P.
+//
+//	rpc.Register("todos.add", transport.RPCMethod(func(ch transport.Channel, p AddParams) (*Todo, error) {
+//		return store.Add(p.Title)
+//	}))
+func RPCMethod[P, R any](fn func(ch Channel, params P) (R, error)) RPCFunc {
+	return func(ch Channel, raw json.RawMessage) (any, error) {
+		var params P
+		if len(raw) > 0 && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &params); err != nil {
+				return nil, fmt.Errorf("invalid params: %w", err)
+			}
+		}
+		return fn(ch, params)
+	}
+}
+
+// RPCHandler is a ChannelHandler that exposes Go functions to the client as
+// RPC methods, for apps that want data over the channel alongside HTML swaps.
+//
+// A call names the method and its params, either in the message's values
+// (what the bridge sends):
+//
+//	{"type": "request", "request_id": "7", "values": {"method": "todos.add", "params": {"title": "Milk"}}}
+//
+// or as a JSON payload of the same shape. The reply is a JSON message on
+// RPCChannel with the same request ID, whose payload is {"result": ...} or,
+// if the method is unknown or fails, {"error": {"message": "..."}}.
+type RPCHandler struct {
+	mu      sync.RWMutex
+	methods map[string]RPCFunc
+}
+
+// NewRPCHandler creates an RPCHandler with no methods.
+func NewRPCHandler() *RPCHandler {
+	return &RPCHandler{methods: make(map[string]RPCFunc)}
+}
+
+// Register exposes fn as method name, replacing any method of that name.
+func (h *RPCHandler) Register(name string, fn RPCFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.methods[name] = fn
+}
+
+// OnConnect implements ChannelHandler (no-op).
+func (h *RPCHandler) OnConnect(ch Channel) error {
+	return nil
+}
+
+// OnMessage implements ChannelHandler, calling the requested method.
+func (h *RPCHandler) OnMessage(ch Channel, msg *Message) (*Message, error) {
+	method, params, err := parseRPCCall(msg)
+	if err != nil {
+		return rpcReply(msg.ID, nil, err), nil
+	}
+
+	h.mu.RLock()
+	fn, ok := h.methods[method]
+	h.mu.RUnlock()
+	if !ok {
+		return rpcReply(msg.ID, nil, fmt.Errorf("%w %q", ErrUnknownMethod, method)), nil
+	}
+
+	result, err := fn(ch, params)
+	return rpcReply(msg.ID, result, err), nil
+}
+
+// OnClose implements ChannelHandler (no-op).
+func (h *RPCHandler) OnClose(ch Channel) {
+}
+
+// rpcCall is the JSON payload form of a call.
+type rpcCall struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// parseRPCCall reads the method and params from msg's payload, or failing
+// that its values.
+func parseRPCCall(msg *Message) (string, json.RawMessage, error) {
+	var call rpcCall
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, &call); err != nil {
+			return "", nil, fmt.Errorf("invalid RPC call: %w", err)
+		}
+	} else {
+		call.Method = msg.GetStringValue("method")
+		params, err := json.Marshal(msg.GetValue("params"))
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid RPC params: %w", err)
+		}
+		call.Params = params
+	}
+	if call.Method == "" {
+		return "", nil, errors.New("invalid RPC call: no method")
+	}
+	if len(call.Params) == 0 {
+		call.Params = json.RawMessage("null")
+	}
+	return call.Method, call.Params, nil
+}
+
+// rpcReply builds the reply to request id.
+func rpcReply(id string, result any, err error) *Message {
+	var payload []byte
+	if err == nil {
+		payload, err = json.Marshal(map[string]any{"result": result})
+	}
+	if err != nil {
+		payload, _ = json.Marshal(map[string]any{"error": map[string]string{"message": err.Error()}})
+	}
+	return NewJSONMessage(RPCChannel, payload).WithID(id)
+}
@@ -0,0 +1,91 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	ws "github.com/stukennedy/irgo/pkg/websocket"
+)
+
+type addParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func newTestRPCHandler() *RPCHandler {
+	h := NewRPCHandler()
+	h.Register("add", RPCMethod(func(ch Channel, p addParams) (int, error) {
+		return p.A + p.B, nil
+	}))
+	h.Register("fail", func(Channel, json.RawMessage) (any, error) {
+		return nil, errors.New("out of stock")
+	})
+	return h
+}
+
+func TestRPCHandlerResult(t *testing.T) {
+	h := newTestRPCHandler()
+
+	// As sent by the bridge, through the hub
+	adapter := &hubHandlerAdapter{handler: h}
+	session := ws.NewSession("s1", "/ws/rpc", adapter)
+	env, err := session.HandleMessage([]byte(`{"type": "request", "request_id": "7", "values": {"method": "add", "params": {"a": 2, "b": 3}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env == nil || env.Payload != `{"result":5}` {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+	if env.RequestID != "7" || env.Channel != RPCChannel || env.Format != "json" {
+		t.Errorf("expected a JSON reply to request 7 on the rpc channel, got %+v", env)
+	}
+
+	// As a JSON payload
+	msg := NewJSONMessage(RPCChannel, []byte(`{"method": "add", "params": {"a": 1, "b": 1}}`)).WithID("8")
+	reply, err := h.OnMessage(nil, msg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reply.PayloadString() != `{"result":2}` || reply.ID != "8" {
+		t.Errorf("unexpected reply %+v", reply)
+	}
+}
+
+func TestRPCHandlerErrors(t *testing.T) {
+	h := newTestRPCHandler()
+
+	tests := []struct {
+		name    string
+		msg     *Message
+		wantErr string
+	}{
+		{"unknown method", &Message{ID: "1", Values: map[string]any{"method": "nope"}}, `unknown RPC method "nope"`},
+		{"method fails", &Message{ID: "2", Values: map[string]any{"method": "fail"}}, "out of stock"},
+		{"bad params", &Message{ID: "3", Payload: []byte(`{"method": "add", "params": "x"}`)}, "invalid params"},
+		{"no method", &Message{ID: "4"}, "invalid RPC call: no method"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, err := h.OnMessage(nil, tt.msg)
+			if err != nil {
+				t.Fatalf("expected an error reply, got error %v", err)
+			}
+			if reply.ID != tt.msg.ID || reply.Channel != RPCChannel {
+				t.Errorf("unexpected reply %+v", reply)
+			}
+			var payload struct {
+				Error struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(reply.Payload, &payload); err != nil {
+				t.Fatalf("reply is not JSON: %v", err)
+			}
+			if got := payload.Error.Message; !strings.HasPrefix(got, tt.wantErr) {
+				t.Errorf("expected error %q, got %q", tt.wantErr, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+)
+
+// selfSignedValidity is how long a generated certificate is valid for.
+const selfSignedValidity = 365 * 24 * time.Hour
+
+// serverTLSConfig returns the TLS config for the loopback server,
+// generating a self-signed certificate into c if it has none.
+func serverTLSConfig(c *Config) (*tls.Config, error) {
+	if len(c.TLSCertPEM) == 0 && len(c.TLSKeyPEM) == 0 {
+		certPEM, keyPEM, err := generateSelfSignedCert(c.Address)
+		if err != nil {
+			return nil, fmt.Errorf("generating TLS certificate: %w", err)
+		}
+		c.TLSCertPEM, c.TLSKeyPEM = certPEM, keyPEM
+	}
+	cert, err := tls.X509KeyPair(c.TLSCertPEM, c.TLSKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+// clientTLSConfig returns a TLS config that trusts the server's own
+// certificate, for HandleRequest and OpenChannel.
+func clientTLSConfig(c *Config) (*tls.Config, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(c.TLSCertPEM) {
+		return nil, errors.New("no certificate in TLSCertPEM")
+	}
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// generateSelfSignedCert creates a PEM certificate and key for address,
+// 127.0.0.1 and localhost.
+func generateSelfSignedCert(address string) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ips := []net.IP{net.IPv4(127, 0, 0, 1)}
+	if ip := net.ParseIP(address); ip != nil && !ip.Equal(ips[0]) {
+		ips = append(ips, ip)
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "irgo loopback"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           ips,
+		DNSNames:              []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
@@ -6,8 +6,12 @@ package transport
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/stukennedy/irgo/pkg/core"
+	"github.com/stukennedy/irgo/pkg/supervisor"
 )
 
 var (
@@ -53,6 +57,22 @@ type Transport interface {
 	// Config returns the transport configuration.
 	// Returns nil for transports that don't require configuration.
 	Config() *Config
+
+	// Metrics returns a snapshot of request concurrency.
+	Metrics() Metrics
+
+	// Pause rejects new requests with 503 Service Unavailable until Resume
+	// is called. Open channels keep working.
+	Pause()
+
+	// Resume undoes Pause.
+	Resume()
+
+	// Paused reports whether the transport is paused.
+	Paused() bool
+
+	// ConnectionCount returns the number of open channels.
+	ConnectionCount() int
 }
 
 // Config holds transport configuration.
@@ -61,12 +81,75 @@ type Config struct {
 	Secret         string   // Per-launch authentication secret
 	AllowedOrigins []string // Origins allowed for CORS/security
 
+	// DisableSecret skips the per-launch secret: none is generated and
+	// requests and WebSocket upgrades aren't checked for one. Origin checks
+	// still apply. Any local process can then call the app's handlers, so
+	// only use it where the machine and user are trusted, e.g. when the
+	// webview loads content through a scheme that can't carry the secret.
+	// A warning is logged when the transport starts.
+	DisableSecret bool
+
 	// Server settings (LoopbackTransport only)
 	Port    int    // Port number (0 for auto-select)
 	Address string // Bind address (always "127.0.0.1" for security)
 
+	// TLS serves https:// and wss:// instead of plain HTTP (LoopbackTransport
+	// only), with the PEM certificate and key given, which must cover
+	// Address. If they're empty Start generates a self-signed certificate
+	// for 127.0.0.1 and localhost and stores it here; the webview must
+	// trust it for pages to load.
+	TLS        bool
+	TLSCertPEM []byte
+	TLSKeyPEM  []byte
+
 	// Channel settings
-	ChannelBufferSize int // Buffer size for channel messages (default: 100)
+	ChannelBufferSize int  // Buffer size for channel messages (default: 100)
+	StrictChannels    bool // Reject channels to URLs with no registered or default handler
+	MaxMessageSize    int  // Largest incoming channel message in bytes (default: 1 MiB, 0 = unlimited)
+
+	// Request limiting
+	MaxConcurrentRequests int           // Max requests handled at once (0 = unlimited)
+	RequestQueueTimeout   time.Duration // How long saturated requests wait before 503 (0 = reject immediately)
+
+	// Compression gzips responses for clients that accept it (see
+	// router.CompressionMiddleware; LoopbackTransport only)
+	Compression bool
+
+	// Runaway client protection (LoopbackTransport only)
+	MaxWebSocketConnections int     // Max open WebSocket connections; more upgrades get 503 (0 = unlimited)
+	RequestRateLimit        float64 // Requests per second per client IP; more get 429 (0 = unlimited)
+	RequestRateBurst        int     // Requests a client may make at once (default: RequestRateLimit, at least 1)
+
+	// WebSocket I/O buffer sizes in bytes for the server's upgrader and
+	// OpenChannel's dialer (LoopbackTransport only; 0 = 4 KiB). Larger
+	// buffers move big fragments in fewer syscalls at the cost of memory
+	// per connection.
+	WSReadBufferSize  int
+	WSWriteBufferSize int
+
+	// WebSocket keep-alive (LoopbackTransport only): a ping is sent every
+	// PingInterval, and a connection that hasn't answered (or sent
+	// anything) for PongTimeout is closed and its session disconnected.
+	// Zero PingInterval disables both.
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+
+	// ShutdownTimeout bounds Stop when its context has no deadline; open
+	// connections still running afterwards are closed (LoopbackTransport only)
+	ShutdownTimeout time.Duration
+
+	// Debug exposes handler error messages to the webview via router.ErrorHeader,
+	// annotates responses with their route (router.DevRoutesMiddleware) and
+	// serves WebSocket hub statistics at DebugStatsPath
+	Debug bool
+
+	// Jobs are the app's background jobs, listed at DebugJobsPath in Debug mode
+	Jobs *supervisor.Supervisor
+
+	// Logger receives the transport's lifecycle events, WebSocket upgrade
+	// and session failures, and server errors (LoopbackTransport only;
+	// nil discards them)
+	Logger *slog.Logger
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -74,7 +157,23 @@ func DefaultConfig() *Config {
 	return &Config{
 		Address:           "127.0.0.1",
 		ChannelBufferSize: 100,
+		MaxMessageSize:    DefaultMaxMessageSize,
+		PingInterval:      DefaultPingInterval,
+		PongTimeout:       DefaultPongTimeout,
+	}
+}
+
+// BaseURL returns the server's URL, e.g. "http://127.0.0.1:8080" or
+// "https://..." with TLS, or "" if there's no server (InProcessTransport).
+func (c *Config) BaseURL() string {
+	if c.Address == "" {
+		return ""
+	}
+	scheme := "http"
+	if c.TLS {
+		scheme = "https"
 	}
+	return fmt.Sprintf("%s://%s:%d", scheme, c.Address, c.Port)
 }
 
 // Option configures a Transport.
@@ -87,6 +186,17 @@ func WithPort(port int) Option {
 	}
 }
 
+// WithTLS serves HTTPS with the given PEM certificate and key, or with a
+// self-signed certificate generated at Start if both are nil (see
+// Config.TLS). Plain HTTP is the default.
+func WithTLS(certPEM, keyPEM []byte) Option {
+	return func(c *Config) {
+		c.TLS = true
+		c.TLSCertPEM = certPEM
+		c.TLSKeyPEM = keyPEM
+	}
+}
+
 // WithSecret sets the authentication secret.
 func WithSecret(secret string) Option {
 	return func(c *Config) {
@@ -94,6 +204,13 @@ func WithSecret(secret string) Option {
 	}
 }
 
+// WithDisableSecret turns off the per-launch secret (see Config.DisableSecret).
+func WithDisableSecret(disable bool) Option {
+	return func(c *Config) {
+		c.DisableSecret = disable
+	}
+}
+
 // WithAllowedOrigins sets the allowed origins for CORS/security.
 func WithAllowedOrigins(origins ...string) Option {
 	return func(c *Config) {
@@ -107,3 +224,121 @@ func WithChannelBufferSize(size int) Option {
 		c.ChannelBufferSize = size
 	}
 }
+
+// WithStrictChannels rejects WebSocket upgrades (404) and OpenChannel calls
+// (ErrNoHandler) for URLs with no registered handler and no default, before
+// any connection or session is created.
+func WithStrictChannels(strict bool) Option {
+	return func(c *Config) {
+		c.StrictChannels = strict
+	}
+}
+
+// DefaultMaxMessageSize is the default Config.MaxMessageSize.
+const DefaultMaxMessageSize = 1 << 20
+
+// WithMaxMessageSize limits incoming channel messages to size bytes, so a
+// huge frame can't exhaust memory. Over the loopback WebSocket an oversized
+// frame closes the connection (close code 1009); sent in-process it's
+// rejected with websocket.ErrMessageTooLarge. 0 means no limit.
+func WithMaxMessageSize(size int) Option {
+	return func(c *Config) {
+		c.MaxMessageSize = size
+	}
+}
+
+// Default WebSocket keep-alive settings, see Config.PingInterval.
+const (
+	DefaultPingInterval = 30 * time.Second
+	DefaultPongTimeout  = 60 * time.Second
+)
+
+// WithKeepAlive pings WebSocket connections every interval and closes
+// those that stay silent for timeout, so half-open connections (a webview
+// that went away without closing) don't linger. timeout should be more
+// than interval; if it isn't, twice the interval is used. An interval of 0
+// disables keep-alive (LoopbackTransport only).
+func WithKeepAlive(interval, timeout time.Duration) Option {
+	return func(c *Config) {
+		c.PingInterval = interval
+		c.PongTimeout = timeout
+	}
+}
+
+// WithMaxConcurrentRequests limits how many requests are handled at once.
+// Requests beyond the limit wait up to queueTimeout for a free slot and are
+// then rejected with 503 Service Unavailable.
+func WithMaxConcurrentRequests(max int, queueTimeout time.Duration) Option {
+	return func(c *Config) {
+		c.MaxConcurrentRequests = max
+		c.RequestQueueTimeout = queueTimeout
+	}
+}
+
+// WithCompression turns response compression on or off (see
+// Config.Compression).
+func WithCompression(enabled bool) Option {
+	return func(c *Config) {
+		c.Compression = enabled
+	}
+}
+
+// WithMaxWebSocketConnections caps the number of open WebSocket connections;
+// upgrades beyond it are rejected with 503 Service Unavailable
+// (LoopbackTransport only).
+func WithMaxWebSocketConnections(max int) Option {
+	return func(c *Config) {
+		c.MaxWebSocketConnections = max
+	}
+}
+
+// WithWSBufferSizes sets the WebSocket read and write buffer sizes (see
+// Config.WSReadBufferSize; LoopbackTransport only).
+func WithWSBufferSizes(read, write int) Option {
+	return func(c *Config) {
+		c.WSReadBufferSize = read
+		c.WSWriteBufferSize = write
+	}
+}
+
+// WithRateLimit limits each client IP to perSecond requests, including
+// WebSocket upgrades, with bursts of up to burst. Requests beyond it are
+// rejected with 429 Too Many Requests (LoopbackTransport only).
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(c *Config) {
+		c.RequestRateLimit = perSecond
+		c.RequestRateBurst = burst
+	}
+}
+
+// WithShutdownTimeout sets how long Stop waits for in-flight requests and
+// streams to finish before closing them (LoopbackTransport only).
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.ShutdownTimeout = d
+	}
+}
+
+// WithJobs lists the app's background jobs at DebugJobsPath in Debug mode.
+func WithJobs(jobs *supervisor.Supervisor) Option {
+	return func(c *Config) {
+		c.Jobs = jobs
+	}
+}
+
+// WithLogger sets the logger for transport events (see Config.Logger).
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) {
+		c.Logger = logger
+	}
+}
+
+// WithDebug exposes handler error details and the route serving each
+// response to the webview devtools console, and serves hub statistics at
+// DebugStatsPath.
+// Never enable this in release builds.
+func WithDebug(debug bool) Option {
+	return func(c *Config) {
+		c.Debug = debug
+	}
+}
@@ -0,0 +1,1343 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stukennedy/irgo/pkg/core"
+	"github.com/stukennedy/irgo/pkg/router"
+	"github.com/stukennedy/irgo/pkg/supervisor"
+	ws "github.com/stukennedy/irgo/pkg/websocket"
+)
+
+func TestInProcessTransportDebugErrors(t *testing.T) {
+	r := router.New()
+	r.GET("/fail", func(ctx *router.Context) (string, error) {
+		return "", errors.New("database unavailable")
+	})
+
+	for _, debug := range []bool{false, true} {
+		tr := NewInProcessTransport(r.Handler(), nil, WithDebug(debug))
+		tr.Start()
+
+		resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/fail"))
+		tr.Stop(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Status != http.StatusInternalServerError {
+			t.Errorf("debug=%v: expected status 500, got %d", debug, resp.Status)
+		}
+
+		got := resp.GetHeader(router.ErrorHeader)
+		switch {
+		case debug && got != "database unavailable":
+			t.Errorf("expected error detail in debug mode, got %q", got)
+		case !debug && got != "":
+			t.Errorf("expected no error detail without debug, got %q", got)
+		}
+	}
+}
+
+func TestLoopbackTransportWebSocketSecret(t *testing.T) {
+	tr := NewLoopbackTransport(http.NotFoundHandler(), ws.NewHub(), WithSecret("launch-secret"))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	base := fmt.Sprintf("ws://%s:%d/ws", tr.Config().Address, tr.Config().Port)
+	dialer := websocket.Dialer{HandshakeTimeout: time.Second}
+
+	for _, tc := range []struct {
+		name string
+		url  string
+		ok   bool
+	}{
+		{"missing secret", base, false},
+		{"wrong secret", base + "?secret=guess", false},
+		{"empty secret", base + "?secret=", false},
+		{"valid secret", base + "?secret=launch-secret", true},
+		{"valid secret with query", base + "?room=1&secret=launch-secret", true},
+	} {
+		conn, resp, err := dialer.Dial(tc.url, nil)
+		if tc.ok {
+			if err != nil {
+				t.Errorf("%s: expected upgrade to succeed, got %v", tc.name, err)
+				continue
+			}
+			conn.Close()
+			continue
+		}
+		if err == nil {
+			conn.Close()
+			t.Errorf("%s: expected upgrade to be rejected", tc.name)
+			continue
+		}
+		if resp == nil || resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %v", tc.name, resp)
+		}
+	}
+}
+
+func TestLoopbackTransportOpenChannelSendsSecret(t *testing.T) {
+	tr := NewLoopbackTransport(http.NotFoundHandler(), ws.NewHub())
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	ch, err := tr.OpenChannel(context.Background(), "/ws?room=1")
+	if err != nil {
+		t.Fatalf("expected channel with generated secret to connect, got %v", err)
+	}
+	ch.Close()
+}
+
+func TestLoopbackTransportMaxMessageSize(t *testing.T) {
+	hub := ws.NewHub()
+	hub.HandleFunc("/ws", func(s *ws.Session, req *ws.Request) (*ws.Envelope, error) {
+		return ws.NewEnvelope("ok"), nil
+	})
+	tr := NewLoopbackTransport(http.NotFoundHandler(), hub, WithSecret("s"), WithMaxMessageSize(64))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	url := fmt.Sprintf("ws://%s:%d/ws?secret=s", tr.Config().Address, tr.Config().Port)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"request"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, data, err := conn.ReadMessage(); err != nil || !strings.Contains(string(data), `"payload":"ok"`) {
+		t.Fatalf("expected a reply to a small message, got %q, %v", data, err)
+	}
+
+	big := `{"type":"request","values":{"text":"` + strings.Repeat("x", 100) + `"}}`
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(big)); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Errorf("expected the connection to close with 1009, got %v", err)
+	}
+
+	// The same limit applies to messages handled through the hub directly
+	if got := hub.MaxMessageSize(); got != 64 {
+		t.Errorf("expected the hub limit to be 64, got %d", got)
+	}
+}
+
+func TestLoopbackTransportKeepAlive(t *testing.T) {
+	hub := ws.NewHub()
+	hub.HandleFunc("/ws/", func(s *ws.Session, req *ws.Request) (*ws.Envelope, error) {
+		return nil, nil
+	})
+	tr := NewLoopbackTransport(http.NotFoundHandler(), hub, WithSecret("s"),
+		WithKeepAlive(20*time.Millisecond, 150*time.Millisecond))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+	base := fmt.Sprintf("ws://%s:%d", tr.Config().Address, tr.Config().Port)
+
+	// Reading lets gorilla answer pings with pongs
+	live, _, err := websocket.DefaultDialer.Dial(base+"/ws/live?secret=s", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer live.Close()
+	go func() {
+		for {
+			if _, _, err := live.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Never reading, this peer never answers
+	dead, _, err := websocket.DefaultDialer.Dial(base+"/ws/dead?secret=s", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer dead.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(hub.SessionsForURL("/ws/dead")) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(hub.SessionsForURL("/ws/dead")); n != 0 {
+		t.Errorf("expected the silent peer to be reaped, %d sessions left", n)
+	}
+	if n := len(hub.SessionsForURL("/ws/live")); n != 1 {
+		t.Errorf("expected the responsive peer to stay connected, got %d sessions", n)
+	}
+}
+
+// closeStatusHandler closes channels sent "close" with a policy violation.
+type closeStatusHandler struct{}
+
+func (h *closeStatusHandler) OnConnect(ch Channel) error { return nil }
+func (h *closeStatusHandler) OnMessage(ch Channel, msg *Message) (*Message, error) {
+	if msg.GetStringValue("action") == "close" {
+		ch.CloseWithCode(ws.ClosePolicyViolation, "not allowed")
+	}
+	return nil, nil
+}
+func (h *closeStatusHandler) OnClose(ch Channel) {}
+
+func TestLoopbackTransportCloseCodes(t *testing.T) {
+	hub := ws.NewHub()
+	closed := make(chan [2]any, 4)
+	hub.OnSessionDestroyed(func(s *ws.Session) {
+		code, reason := s.CloseStatus()
+		closed <- [2]any{code, reason}
+	})
+	tr := NewLoopbackTransport(http.NotFoundHandler(), hub, WithSecret("s"))
+	tr.RegisterChannelHandler("/ws", &closeStatusHandler{})
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	// Server closes: the client sees the code and reason in the close frame
+	ch, err := tr.OpenChannel(context.Background(), "/ws")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s:%d/ws?secret=s", tr.Config().Address, tr.Config().Port), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"request","values":{"action":"close"}}`))
+	_, _, err = conn.ReadMessage()
+	var ce *websocket.CloseError
+	if !errors.As(err, &ce) || ce.Code != ws.ClosePolicyViolation || ce.Text != "not allowed" {
+		t.Errorf("expected close 1008 \"not allowed\", got %v", err)
+	}
+	select {
+	case got := <-closed:
+		if got != [2]any{ws.ClosePolicyViolation, "not allowed"} {
+			t.Errorf("unexpected server close status %v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("session never closed")
+	}
+
+	// Client closes: the server session gets its code and reason
+	ch.CloseWithCode(4001, "logged out")
+	select {
+	case got := <-closed:
+		if got != [2]any{4001, "logged out"} {
+			t.Errorf("unexpected server close status %v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("session never closed")
+	}
+}
+
+func TestInProcessChannelCloseWithCode(t *testing.T) {
+	tr := NewInProcessTransport(http.NotFoundHandler(), nil)
+	tr.RegisterChannelHandler("/ws", &closeStatusHandler{})
+	tr.Start()
+	defer tr.Stop(context.Background())
+
+	ch, err := tr.OpenChannel(context.Background(), "/ws")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	session := ch.(*InProcessChannel).Session()
+	ch.CloseWithCode(ws.CloseGoingAway, "shutting down")
+	if code, reason := session.CloseStatus(); code != ws.CloseGoingAway || reason != "shutting down" {
+		t.Errorf("expected 1001 \"shutting down\", got %d %q", code, reason)
+	}
+}
+
+// connectInfoHandler records what OnConnect sees of the connect request.
+type connectInfoHandler struct {
+	got chan string
+}
+
+func (h *connectInfoHandler) OnConnect(ch Channel) error {
+	q := ch.ConnectQuery()
+	h.got <- q.Get("room") + " " + ch.ConnectHeaders().Get("X-Token") + " " + q.Get("secret")
+	return nil
+}
+func (h *connectInfoHandler) OnMessage(ch Channel, msg *Message) (*Message, error) { return nil, nil }
+func (h *connectInfoHandler) OnClose(ch Channel)                                   {}
+
+func TestLoopbackTransportConnectInfo(t *testing.T) {
+	h := &connectInfoHandler{got: make(chan string, 1)}
+	tr := NewLoopbackTransport(http.NotFoundHandler(), ws.NewHub(), WithSecret("s"))
+	tr.RegisterChannelHandler("/ws/chat", h)
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	url := fmt.Sprintf("ws://%s:%d/ws/chat?room=42&secret=s", tr.Config().Address, tr.Config().Port)
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"X-Token": {"abc"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+
+	select {
+	case got := <-h.got:
+		if got != "42 abc " {
+			t.Errorf("expected room 42, token abc and no secret, got %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnConnect was never called")
+	}
+}
+
+func TestInProcessTransportConnectQuery(t *testing.T) {
+	h := &connectInfoHandler{got: make(chan string, 1)}
+	tr := NewInProcessTransport(http.NotFoundHandler(), nil)
+	tr.RegisterChannelHandler("/ws/chat", h)
+	tr.Start()
+	defer tr.Stop(context.Background())
+
+	ch, err := tr.OpenChannel(context.Background(), "/ws/chat?room=7")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ch.Close()
+	if got := <-h.got; got != "7  " {
+		t.Errorf("expected room 7, got %q", got)
+	}
+	if got := ch.ConnectQuery().Get("room"); got != "7" {
+		t.Errorf("expected the channel to report room 7, got %q", got)
+	}
+}
+
+func TestInProcessTransportPauseResume(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	tr := NewInProcessTransport(ok, nil)
+	tr.Start()
+	defer tr.Stop(context.Background())
+
+	tr.Pause()
+	if !tr.Paused() {
+		t.Error("expected transport to report paused")
+	}
+	resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 while paused, got %d", resp.Status)
+	}
+	if resp.GetHeader("Retry-After") == "" {
+		t.Error("expected Retry-After header while paused")
+	}
+
+	tr.Resume()
+	if tr.Paused() {
+		t.Error("expected transport to report resumed")
+	}
+	resp, err = tr.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != http.StatusOK || string(resp.Body) != "ok" {
+		t.Errorf("expected 200 ok after resume, got %d %q", resp.Status, resp.Body)
+	}
+}
+
+func TestLoopbackTransportPauseRejectsRequests(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	tr := NewLoopbackTransport(ok, ws.NewHub())
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	tr.Pause()
+	resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 while paused, got %d", resp.Status)
+	}
+	if _, err := tr.OpenChannel(context.Background(), "/ws"); err == nil {
+		t.Error("expected channel to be refused while paused")
+	}
+
+	tr.Resume()
+	resp, err = tr.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != http.StatusOK {
+		t.Errorf("expected status 200 after resume, got %d", resp.Status)
+	}
+}
+
+func TestConnectionCount(t *testing.T) {
+	hub := ws.NewHub()
+	hub.HandleFunc("/ws", func(*ws.Session, *ws.Request) (*ws.Envelope, error) {
+		return nil, nil
+	})
+
+	transports := map[string]Transport{
+		"inprocess": NewInProcessTransport(http.NotFoundHandler(), hub),
+		"loopback":  NewLoopbackTransport(http.NotFoundHandler(), hub),
+	}
+	for name, tr := range transports {
+		if err := tr.Start(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		var channels []Channel
+		for i := 0; i < 2; i++ {
+			ch, err := tr.OpenChannel(context.Background(), "/ws")
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", name, err)
+			}
+			channels = append(channels, ch)
+		}
+		waitForCount(t, name, tr, 2)
+
+		channels[0].Close()
+		waitForCount(t, name, tr, 1)
+
+		tr.Stop(context.Background())
+		if n := tr.ConnectionCount(); n != 0 {
+			t.Errorf("%s: expected 0 connections after stop, got %d", name, n)
+		}
+	}
+}
+
+// waitForCount polls until tr reports want connections; loopback sessions
+// open and close asynchronously with the socket.
+func waitForCount(t *testing.T, name string, tr Transport, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for tr.ConnectionCount() != want && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := tr.ConnectionCount(); n != want {
+		t.Errorf("%s: expected %d connections, got %d", name, want, n)
+	}
+}
+
+func TestLoopbackTransportTrailers(t *testing.T) {
+	r := router.New()
+	r.GET("/page", func(ctx *router.Context) (string, error) {
+		ctx.SetTrailer("X-Status", "complete")
+		ctx.HTML("page")
+		return "", nil
+	})
+	r.GET("/stream", func(ctx *router.Context) (string, error) {
+		items := make(chan any, 2)
+		items <- "chunk-1"
+		items <- "chunk-2"
+		close(items)
+		if err := ctx.StreamJSONArray(ctx.Request.Context(), items); err != nil {
+			return "", err
+		}
+		ctx.SetTrailer("X-Checksum", "abc123")
+		return "", nil
+	})
+
+	tr := NewLoopbackTransport(r.Handler(), ws.NewHub())
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/page"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := resp.GetTrailer("X-Status"); got != "complete" {
+		t.Errorf("expected trailer X-Status=complete, got %q (trailers %s)", got, resp.Trailers)
+	}
+
+	resp, err = tr.HandleRequest(context.Background(), core.NewRequest("GET", "/stream"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp.Body) != `["chunk-1","chunk-2"]` {
+		t.Errorf("unexpected body %q", resp.Body)
+	}
+	if got := resp.GetTrailer("X-Checksum"); got != "abc123" {
+		t.Errorf("expected trailer X-Checksum=abc123, got %q (trailers %s)", got, resp.Trailers)
+	}
+	if got := resp.GetHeader("X-Checksum"); got != "" {
+		t.Errorf("expected trailer not to be sent as a header, got %q", got)
+	}
+}
+
+func TestTransportsKeepMultipleCookies(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+		w.Write([]byte("ok"))
+	})
+	for name, tr := range map[string]Transport{
+		"inprocess": NewInProcessTransport(handler, nil),
+		"loopback":  NewLoopbackTransport(handler, ws.NewHub()),
+	} {
+		if err := tr.Start(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+		tr.Stop(context.Background())
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		cookies := resp.GetHeaderValues("set-cookie")
+		if len(cookies) != 2 || cookies[0] != "session=abc" || cookies[1] != "theme=dark" {
+			t.Errorf("%s: expected both cookies, got %q", name, cookies)
+		}
+	}
+}
+
+func TestInProcessTransportTrailers(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+		w.Header().Set(http.TrailerPrefix+"X-Status", "complete")
+	})
+	tr := NewInProcessTransport(handler, nil)
+	tr.Start()
+	defer tr.Stop(context.Background())
+
+	resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := resp.GetTrailer("x-status"); got != "complete" {
+		t.Errorf("expected trailer X-Status=complete, got %q", got)
+	}
+}
+
+func TestLoopbackTransportStrictChannels(t *testing.T) {
+	noop := func(*ws.Session, *ws.Request) (*ws.Envelope, error) { return nil, nil }
+
+	hub := ws.NewHub()
+	hub.HandleFunc("/ws/chat", noop)
+	tr := NewLoopbackTransport(http.NotFoundHandler(), hub, WithSecret("s"), WithStrictChannels(true))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	base := fmt.Sprintf("ws://%s:%d", tr.Config().Address, tr.Config().Port)
+	dialer := websocket.Dialer{HandshakeTimeout: time.Second}
+
+	conn, _, err := dialer.Dial(base+"/ws/chat?secret=s", nil)
+	if err != nil {
+		t.Fatalf("expected registered pattern to connect, got %v", err)
+	}
+	conn.Close()
+
+	_, resp, err := dialer.Dial(base+"/ws/bogus?secret=s", nil)
+	if err == nil {
+		t.Fatal("expected unregistered pattern to be rejected in strict mode")
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404, got %v", resp)
+	}
+	if n := tr.ConnectionCount(); n > 1 {
+		t.Errorf("expected no session for rejected upgrade, got %d sessions", n)
+	}
+
+	// A default handler accepts any URL, even in strict mode
+	hub.SetDefaultHandler(ws.MessageHandlerFunc(noop))
+	conn, _, err = dialer.Dial(base+"/ws/bogus?secret=s", nil)
+	if err != nil {
+		t.Fatalf("expected default handler to accept in strict mode, got %v", err)
+	}
+	conn.Close()
+}
+
+func TestLoopbackTransportNonStrictUsesDefault(t *testing.T) {
+	hub := ws.NewHub()
+	hub.SetDefaultHandler(ws.MessageHandlerFunc(func(*ws.Session, *ws.Request) (*ws.Envelope, error) {
+		return nil, nil
+	}))
+	tr := NewLoopbackTransport(http.NotFoundHandler(), hub, WithSecret("s"))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	url := fmt.Sprintf("ws://%s:%d/ws/anything?secret=s", tr.Config().Address, tr.Config().Port)
+	conn, _, err := (&websocket.Dialer{HandshakeTimeout: time.Second}).Dial(url, nil)
+	if err != nil {
+		t.Fatalf("expected default handler to accept unregistered URL, got %v", err)
+	}
+	defer conn.Close()
+	waitForCount(t, "loopback", tr, 1)
+}
+
+func TestInProcessTransportStrictChannels(t *testing.T) {
+	tr := NewInProcessTransport(http.NotFoundHandler(), nil, WithStrictChannels(true))
+	tr.Start()
+	defer tr.Stop(context.Background())
+
+	if _, err := tr.OpenChannel(context.Background(), "/ws/bogus"); !errors.Is(err, ErrNoHandler) {
+		t.Errorf("expected ErrNoHandler, got %v", err)
+	}
+	if n := tr.ConnectionCount(); n != 0 {
+		t.Errorf("expected no sessions, got %d", n)
+	}
+}
+
+func TestLoopbackTransportShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantErr error
+	}{
+		{"short cuts off slow request", 50 * time.Millisecond, context.DeadlineExceeded},
+		{"long lets slow request finish", 5 * time.Second, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				time.Sleep(300 * time.Millisecond)
+				w.Write([]byte("done"))
+			})
+			tr := NewLoopbackTransport(slow, ws.NewHub(), WithShutdownTimeout(tt.timeout))
+			if err := tr.Start(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result := make(chan *core.Response, 1)
+			go func() {
+				resp, _ := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+				result <- resp
+			}()
+			<-started
+
+			begin := time.Now()
+			err := tr.Stop(context.Background())
+			elapsed := time.Since(begin)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected Stop error %v, got %v", tt.wantErr, err)
+			}
+			resp := <-result
+			if tt.wantErr != nil {
+				if elapsed >= 250*time.Millisecond {
+					t.Errorf("expected Stop to give up after %v, took %v", tt.timeout, elapsed)
+				}
+				if resp != nil && resp.Status == http.StatusOK {
+					t.Error("expected slow request to be cut off")
+				}
+			} else if resp == nil || resp.Status != http.StatusOK {
+				t.Errorf("expected slow request to complete, got %+v", resp)
+			}
+		})
+	}
+}
+
+func TestLoopbackTransportStopContextDeadlineWins(t *testing.T) {
+	started := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+	})
+	tr := NewLoopbackTransport(slow, ws.NewHub(), WithShutdownTimeout(5*time.Second))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	go tr.HandleRequest(context.Background(), core.NewRequest("GET", "/"))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := tr.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected caller's deadline to apply, got %v", err)
+	}
+}
+
+func TestInProcessTransportDebugStats(t *testing.T) {
+	hub := ws.NewHub()
+	hub.HandleFunc("/ws/chat/", func(s *ws.Session, req *ws.Request) (*ws.Envelope, error) { return nil, nil })
+
+	for _, debug := range []bool{false, true} {
+		tr := NewInProcessTransport(http.NotFoundHandler(), hub, WithDebug(debug))
+		tr.Start()
+		ch, err := tr.OpenChannel(context.Background(), "/ws/chat/lobby")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", DebugStatsPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ch.Close()
+		tr.Stop(context.Background())
+
+		if !debug {
+			if resp.Status != http.StatusNotFound {
+				t.Errorf("without debug: expected 404, got %d", resp.Status)
+			}
+			continue
+		}
+		var stats ws.HubStats
+		if err := json.Unmarshal(resp.Body, &stats); err != nil {
+			t.Fatalf("decoding stats: %v (%s)", err, resp.Body)
+		}
+		if stats.Patterns["/ws/chat/"].Sessions != 1 || stats.URLs["/ws/chat/lobby"] != 1 {
+			t.Errorf("stats = %+v", stats)
+		}
+	}
+}
+
+func TestInProcessTransportDebugJobs(t *testing.T) {
+	jobs := supervisor.New()
+	defer jobs.Shutdown(context.Background())
+	jobs.Go("sync", func(ctx context.Context) { <-ctx.Done() })
+
+	for _, debug := range []bool{false, true} {
+		tr := NewInProcessTransport(http.NotFoundHandler(), nil, WithDebug(debug), WithJobs(jobs))
+		tr.Start()
+		resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", DebugJobsPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr.Stop(context.Background())
+
+		if !debug {
+			if resp.Status != http.StatusNotFound {
+				t.Errorf("without debug: expected 404, got %d", resp.Status)
+			}
+			continue
+		}
+		var got []supervisor.JobStatus
+		if err := json.Unmarshal(resp.Body, &got); err != nil {
+			t.Fatalf("decoding jobs: %v (%s)", err, resp.Body)
+		}
+		if len(got) != 1 || got[0].Name != "sync" {
+			t.Errorf("jobs = %+v", got)
+		}
+	}
+}
+
+func TestLoopbackTransportDisableSecret(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	r := router.New()
+	r.POST("/todos", func(ctx *router.Context) (string, error) {
+		return "<li>created</li>", nil
+	})
+	hub := ws.NewHub()
+	hub.SetDefaultHandler(ws.MessageHandlerFunc(func(*ws.Session, *ws.Request) (*ws.Envelope, error) { return nil, nil }))
+
+	tr := NewLoopbackTransport(r.Handler(), hub, WithSecret("ignored"), WithDisableSecret(true))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	if !strings.Contains(logs.String(), "WARNING: per-launch secret disabled") {
+		t.Errorf("expected a warning to be logged, got %q", logs.String())
+	}
+	if tr.Config().Secret != "" {
+		t.Errorf("expected no secret, got %q", tr.Config().Secret)
+	}
+
+	// A state-changing request with no secret header goes through
+	base := fmt.Sprintf("%s:%d", tr.Config().Address, tr.Config().Port)
+	resp, err := http.Post("http://"+base+"/todos", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected POST without secret to succeed, got %d", resp.StatusCode)
+	}
+
+	// So does a WebSocket upgrade
+	dialer := websocket.Dialer{HandshakeTimeout: time.Second}
+	conn, _, err := dialer.Dial("ws://"+base+"/ws", nil)
+	if err != nil {
+		t.Fatalf("expected upgrade without secret to succeed, got %v", err)
+	}
+	conn.Close()
+}
+
+func TestLoopbackTransportRequiresSecret(t *testing.T) {
+	r := router.New()
+	r.POST("/todos", func(ctx *router.Context) (string, error) {
+		return "<li>created</li>", nil
+	})
+	tr := NewLoopbackTransport(r.Handler(), ws.NewHub())
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	resp, err := http.Post(fmt.Sprintf("http://%s:%d/todos", tr.Config().Address, tr.Config().Port), "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected POST without secret to be rejected by default, got %d", resp.StatusCode)
+	}
+}
+
+// binaryEchoHandler replies to every message with binaryData.
+type binaryEchoHandler struct{}
+
+var binaryData = []byte{0x00, 0xff, 0x7f, 0x80, '\n'}
+
+func (h *binaryEchoHandler) OnConnect(ch Channel) error { return nil }
+func (h *binaryEchoHandler) OnMessage(ch Channel, msg *Message) (*Message, error) {
+	return NewBinaryMessage("audio", binaryData), nil
+}
+func (h *binaryEchoHandler) OnClose(ch Channel) {}
+
+func TestLoopbackTransportBinaryMessages(t *testing.T) {
+	tr := NewLoopbackTransport(http.NotFoundHandler(), ws.NewHub(), WithSecret("s"))
+	tr.RegisterChannelHandler("/ws", &binaryEchoHandler{})
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	// A raw client gets a binary frame holding exactly the bytes
+	conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s:%d/ws?secret=s", tr.Config().Address, tr.Config().Port), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"request"}`))
+	kind, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if kind != websocket.BinaryMessage || !bytes.Equal(data, binaryData) {
+		t.Errorf("expected binary frame %v, got type %d %v", binaryData, kind, data)
+	}
+
+	// So does a LoopbackChannel
+	ch, err := tr.OpenChannel(context.Background(), "/ws")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ch.Close()
+	ch.Send(&Message{Type: "request"})
+	select {
+	case msg := <-ch.Receive():
+		if msg.Format != ws.FormatBinary || !bytes.Equal(msg.Payload, binaryData) {
+			t.Errorf("expected binary message %v, got %q %v", binaryData, msg.Format, msg.Payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestInProcessChannelBinaryMessages(t *testing.T) {
+	tr := NewInProcessTransport(http.NotFoundHandler(), nil)
+	tr.RegisterChannelHandler("/ws", &binaryEchoHandler{})
+	tr.Start()
+	defer tr.Stop(context.Background())
+
+	ch, err := tr.OpenChannel(context.Background(), "/ws")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ch.Close()
+	if err := ch.Send(NewBinaryMessage("audio", binaryData)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case env := <-ch.(*InProcessChannel).Session().SendChan:
+		if env.Format != ws.FormatBinary || !bytes.Equal(env.Bytes(), binaryData) {
+			t.Errorf("expected binary envelope %v, got %q %v", binaryData, env.Format, env.Bytes())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no envelope sent")
+	}
+}
+
+func TestLoopbackChannelSendStream(t *testing.T) {
+	hub := ws.NewHub()
+	hub.SetSendBuffer(3)
+	tr := NewLoopbackTransport(http.NotFoundHandler(), hub)
+	tr.RegisterChannelHandler("/ws", &binaryEchoHandler{})
+	session, err := hub.Connect("/ws")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ch StreamingChannel = &sessionChannelAdapter{session: session}
+
+	// Messages are sent in order until ctx is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := make(chan *Message)
+	result := make(chan error, 1)
+	go func() { result <- ch.SendStream(ctx, stream) }()
+	for i := 0; i < 5; i++ {
+		stream <- NewHTMLMessage("#log", fmt.Sprintf("line %d", i))
+		if env := <-session.SendChan; env.Payload != fmt.Sprintf("line %d", i) {
+			t.Errorf("expected line %d, got %q", i, env.Payload)
+		}
+	}
+	cancel()
+	if err := <-result; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// A full buffer stops the stream
+	stream = make(chan *Message, 4)
+	for i := 0; i < 4; i++ {
+		stream <- NewHTMLMessage("#log", "line")
+	}
+	close(stream)
+	if err := ch.SendStream(context.Background(), stream); err != ErrChannelFull {
+		t.Errorf("expected ErrChannelFull, got %v", err)
+	}
+
+	// So does closing the session
+	session.Close()
+	if err := ch.SendStream(context.Background(), make(chan *Message)); err != ErrChannelClosed {
+		t.Errorf("expected ErrChannelClosed, got %v", err)
+	}
+}
+
+func TestChannelHandlerFuncs(t *testing.T) {
+	var events []string
+	h := ChannelHandlerFuncs{
+		OnConnectFunc: func(ch Channel) error { events = append(events, "connect "+ch.URL()); return nil },
+		OnMessageFunc: func(ch Channel, msg *Message) (*Message, error) {
+			events = append(events, "message "+msg.PayloadString())
+			return NewHTMLMessage("#out", "ok"), nil
+		},
+		OnCloseFunc: func(ch Channel) { events = append(events, "close") },
+	}
+	tr := NewInProcessTransport(http.NotFoundHandler(), nil)
+	tr.RegisterChannelHandler("/ws", h)
+	tr.Start()
+	defer tr.Stop(context.Background())
+
+	ch, err := tr.OpenChannel(context.Background(), "/ws")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reply, err := h.OnMessage(ch, NewMessage([]byte("hi")))
+	if err != nil || reply.PayloadString() != "ok" {
+		t.Errorf("expected reply ok, got %v %v", reply, err)
+	}
+	ch.Close()
+	if got := strings.Join(events, ", "); got != "connect /ws, message hi, close" {
+		t.Errorf("unexpected events %q", got)
+	}
+
+	// Omitted callbacks are no-ops
+	var empty ChannelHandlerFuncs
+	if err := empty.OnConnect(ch); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if reply, err := empty.OnMessage(ch, NewMessage([]byte("hi"))); reply != nil || err != nil {
+		t.Errorf("expected no reply, got %v %v", reply, err)
+	}
+	empty.OnClose(ch)
+}
+
+// doneHandler watches ch.Done() and replies to every message.
+type doneHandler struct {
+	closed chan struct{}
+}
+
+func (h *doneHandler) OnConnect(ch Channel) error {
+	go func() {
+		<-ch.Done()
+		close(h.closed)
+	}()
+	return nil
+}
+func (h *doneHandler) OnMessage(ch Channel, msg *Message) (*Message, error) {
+	return NewHTMLMessage("#out", "pong"), nil
+}
+func (h *doneHandler) OnClose(ch Channel) {}
+
+func TestLoopbackChannelDoneKeepsMessages(t *testing.T) {
+	h := &doneHandler{closed: make(chan struct{})}
+	tr := NewLoopbackTransport(http.NotFoundHandler(), ws.NewHub(), WithSecret("s"))
+	tr.RegisterChannelHandler("/ws", h)
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s:%d/ws?secret=s", tr.Config().Address, tr.Config().Port), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for i := 0; i < 5; i++ {
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"request"}`))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("reply %d never arrived: %v", i, err)
+		}
+		if !strings.Contains(string(data), "pong") {
+			t.Errorf("unexpected reply %s", data)
+		}
+	}
+	select {
+	case <-h.closed:
+		t.Fatal("Done closed while the connection was open")
+	default:
+	}
+
+	conn.Close()
+	select {
+	case <-h.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done never closed")
+	}
+}
+
+func TestLoopbackChannelReceive(t *testing.T) {
+	loopDone := make(chan struct{})
+	h := ChannelHandlerFuncs{
+		OnConnectFunc: func(ch Channel) error {
+			go func() {
+				defer close(loopDone)
+				for msg := range ch.Receive() {
+					ch.Send(NewHTMLMessage("#out", "echo "+msg.GetStringValue("text")))
+				}
+			}()
+			return nil
+		},
+	}
+	tr := NewLoopbackTransport(http.NotFoundHandler(), ws.NewHub(), WithSecret("s"))
+	tr.RegisterChannelHandler("/ws", h)
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s:%d/ws?secret=s", tr.Config().Address, tr.Config().Port), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for _, text := range []string{"one", "two", "three"} {
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"request","values":{"text":"`+text+`"}}`))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(data), "echo "+text) {
+			t.Errorf("expected echo %s, got %s", text, data)
+		}
+	}
+
+	// Disconnecting closes Receive, ending the loop
+	conn.Close()
+	select {
+	case <-loopDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Receive was never closed")
+	}
+}
+
+func TestInProcessTransportRoundTrip(t *testing.T) {
+	r := router.New()
+	r.GET("/hello", func(ctx *router.Context) (string, error) {
+		return "<p>hello " + ctx.Query("name") + "</p>", nil
+	})
+	tr := NewInProcessTransport(r.Handler(), nil, WithPort(8080))
+	tr.RegisterChannelHandler("/ws/echo", ChannelHandlerFunc(func(ch Channel, msg *Message) (*Message, error) {
+		return NewHTMLMessage("#out", "echo "+msg.GetStringValue("text")).WithID(msg.ID), nil
+	}))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	// There's no server, so no address
+	if cfg := tr.Config(); cfg.Address != "" || cfg.Port != 0 {
+		t.Errorf("expected no address or port, got %q %d", cfg.Address, cfg.Port)
+	}
+
+	resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/hello?name=irgo"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != http.StatusOK || resp.BodyString() != "<p>hello irgo</p>" {
+		t.Errorf("unexpected response %d %q", resp.Status, resp.BodyString())
+	}
+
+	ch, err := tr.OpenChannel(context.Background(), "/ws/echo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ch.Close()
+	env, err := ch.(*InProcessChannel).Session().HandleMessage([]byte(`{"type":"request","request_id":"1","values":{"text":"hi"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env == nil || env.Payload != "echo hi" || env.Target != "#out" || env.RequestID != "1" {
+		t.Errorf("unexpected reply %+v", env)
+	}
+}
+
+func TestLoopbackTransportTLS(t *testing.T) {
+	r := router.New()
+	r.GET("/hello", func(ctx *router.Context) (string, error) { return "<p>hello</p>", nil })
+	tr := NewLoopbackTransport(r.Handler(), ws.NewHub(), WithTLS(nil, nil))
+	tr.RegisterChannelHandler("/ws", &binaryEchoHandler{})
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	cfg := tr.Config()
+	if want := fmt.Sprintf("https://127.0.0.1:%d", cfg.Port); cfg.BaseURL() != want {
+		t.Errorf("expected %s, got %s", want, cfg.BaseURL())
+	}
+	if len(cfg.TLSCertPEM) == 0 || len(cfg.TLSKeyPEM) == 0 {
+		t.Fatal("expected the generated certificate in the config")
+	}
+
+	resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.BodyString() != "<p>hello</p>" {
+		t.Errorf("unexpected response %q", resp.BodyString())
+	}
+
+	// Channels dial wss://
+	ch, err := tr.OpenChannel(context.Background(), "/ws")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ch.Close()
+	ch.Send(&Message{Type: "request"})
+	select {
+	case msg := <-ch.Receive():
+		if !bytes.Equal(msg.Payload, binaryData) {
+			t.Errorf("unexpected message %v", msg.Payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+
+	// Plain HTTP isn't served
+	plain, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/hello", cfg.Port))
+	if err == nil {
+		plain.Body.Close()
+		if plain.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected plain HTTP to be refused, got %d", plain.StatusCode)
+		}
+	}
+}
+
+func TestLoopbackTransportTLSWithCertificate(t *testing.T) {
+	certPEM, keyPEM, err := generateSelfSignedCert("127.0.0.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tr := NewLoopbackTransport(http.NotFoundHandler(), nil, WithTLS(certPEM, keyPEM))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	if !bytes.Equal(tr.Config().TLSCertPEM, certPEM) {
+		t.Error("expected the given certificate to be kept")
+	}
+	resp, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/missing"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", resp.Status)
+	}
+
+	// A bad key pair fails Start
+	bad := NewLoopbackTransport(http.NotFoundHandler(), nil, WithTLS(certPEM, []byte("nope")))
+	if err := bad.Start(); err == nil {
+		bad.Stop(context.Background())
+		t.Error("expected an error for an invalid key")
+	}
+}
+
+// logBuffer collects log output written from several goroutines.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLoopbackTransportLogger(t *testing.T) {
+	var logs logBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	hub := ws.NewHub()
+	hub.HandleFunc("/ws", func(*ws.Session, *ws.Request) (*ws.Envelope, error) {
+		return nil, nil
+	})
+	tr := NewLoopbackTransport(http.NotFoundHandler(), hub, WithLogger(logger), WithStrictChannels(true))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(logs.String(), "loopback transport started") {
+		t.Errorf("expected a start event, got:\n%s", logs.String())
+	}
+
+	// A handshake without a key fails the upgrade
+	base := tr.Config().BaseURL()
+	req, _ := http.NewRequest("GET", base+"/ws?secret="+tr.Config().Secret, nil)
+	req.Header.Set("Origin", base)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		resp.Body.Close()
+	}
+	if _, err := tr.OpenChannel(context.Background(), "/missing"); err == nil {
+		t.Error("expected a strict channel rejection")
+	}
+
+	ch, err := tr.OpenChannel(context.Background(), "/ws")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ch.Close()
+	waitForCount(t, "loopback", tr, 0)
+	tr.Stop(context.Background())
+
+	for _, want := range []string{
+		`msg="websocket upgrade failed" path=/ws`,
+		`msg="websocket rejected: no channel handler" path=/missing`,
+		`msg="websocket connected" path=/ws`,
+		`msg="websocket disconnected"`,
+		`msg="loopback transport stopped"`,
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("expected log %s, got:\n%s", want, logs.String())
+		}
+	}
+}
+
+func TestLoopbackTransportCompression(t *testing.T) {
+	page := "<ul>" + strings.Repeat("<li>Todo</li>", 200) + "</ul>"
+	r := router.New()
+	r.GET("/todos", func(ctx *router.Context) (string, error) { return page, nil })
+	tr := NewLoopbackTransport(r.Handler(), nil, WithCompression(true))
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+
+	req, _ := http.NewRequest("GET", tr.Config().BaseURL()+"/todos", nil)
+	req.Header.Set("X-Irgo-Secret", tr.Config().Secret)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Errorf("expected a gzipped response, got headers %v", resp.Header)
+	}
+
+	// HandleRequest's client decodes it transparently
+	got, err := tr.HandleRequest(context.Background(), core.NewRequest("GET", "/todos"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.BodyString() != page {
+		t.Errorf("expected the page, got %d bytes", len(got.Body))
+	}
+}
+
+func TestLoopbackTransportWSBufferSizes(t *testing.T) {
+	// Defaults leave gorilla's 4 KiB buffers
+	tr := NewLoopbackTransport(http.NotFoundHandler(), nil)
+	if tr.upgrader.ReadBufferSize != 0 || tr.upgrader.WriteBufferSize != 0 {
+		t.Errorf("expected default upgrader buffers, got %d/%d", tr.upgrader.ReadBufferSize, tr.upgrader.WriteBufferSize)
+	}
+
+	page := strings.Repeat("<li>Todo</li>", 20000)
+	hub := ws.NewHub()
+	hub.HandleFunc("/ws", func(s *ws.Session, req *ws.Request) (*ws.Envelope, error) {
+		return ws.NewEnvelope(page), nil
+	})
+	tr = NewLoopbackTransport(http.NotFoundHandler(), hub, WithSecret("s"), WithWSBufferSizes(64<<10, 128<<10))
+	if got := tr.upgrader; got.ReadBufferSize != 64<<10 || got.WriteBufferSize != 128<<10 {
+		t.Errorf("expected upgrader buffers 65536/131072, got %d/%d", got.ReadBufferSize, got.WriteBufferSize)
+	}
+	dialer, err := tr.dialer()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dialer.ReadBufferSize != 64<<10 || dialer.WriteBufferSize != 128<<10 {
+		t.Errorf("expected dialer buffers 65536/131072, got %d/%d", dialer.ReadBufferSize, dialer.WriteBufferSize)
+	}
+
+	// Large fragments still go through
+	if err := tr.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tr.Stop(context.Background())
+	ch, err := tr.OpenChannel(context.Background(), "/ws")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ch.Close()
+	ch.Send(&Message{Type: "request"})
+	select {
+	case msg := <-ch.Receive():
+		if string(msg.Payload) != page {
+			t.Errorf("expected the %d byte page, got %d bytes", len(page), len(msg.Payload))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
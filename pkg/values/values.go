@@ -0,0 +1,193 @@
+// Package values reads request values the same way regardless of where
+// they came from: query strings, form posts (including hx-vals), JSON
+// bodies or WebSocket request values.
+//
+// Shared handler logic takes a Source and uses the typed getters:
+//
+//	func pageSize(src values.Source) int {
+//		n, err := values.Int(src, "limit")
+//		if err != nil || n <= 0 {
+//			return 20
+//		}
+//		return n
+//	}
+//
+//	src, _ := ctx.Values()           // router.Context
+//	pageSize(src)
+//	pageSize(values.Map(req.Values)) // websocket.Request
+package values
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ErrMissing is returned by the typed getters when a key is not present.
+var ErrMissing = errors.New("value not present")
+
+// Source looks up request values by key.
+type Source interface {
+	// Lookup returns the value for key and whether it was present.
+	// Values are strings for URL-encoded sources and decoded JSON
+	// (string, float64/json.Number, bool, ...) for JSON sources.
+	Lookup(key string) (any, bool)
+}
+
+// SourceFunc adapts a function to the Source interface.
+type SourceFunc func(key string) (any, bool)
+
+// Lookup calls f(key).
+func (f SourceFunc) Lookup(key string) (any, bool) {
+	return f(key)
+}
+
+// Map returns a Source backed by m, such as websocket.Request.Values
+// or a decoded JSON object.
+func Map(m map[string]any) Source {
+	return SourceFunc(func(key string) (any, bool) {
+		v, ok := m[key]
+		return v, ok
+	})
+}
+
+// URL returns a Source backed by URL-encoded values.
+// The first value is used when a key is repeated.
+func URL(v url.Values) Source {
+	return SourceFunc(func(key string) (any, bool) {
+		vs, ok := v[key]
+		if !ok || len(vs) == 0 {
+			return nil, false
+		}
+		return vs[0], true
+	})
+}
+
+// Query returns a Source backed by the request's query string.
+func Query(r *http.Request) Source {
+	return URL(r.URL.Query())
+}
+
+// Form returns a Source backed by the request's form values, including
+// the query string and hx-vals sent as form fields.
+func Form(r *http.Request) Source {
+	// Errors leave r.Form partially populated, matching Request.FormValue
+	_ = r.ParseMultipartForm(32 << 20)
+	return URL(r.Form)
+}
+
+// JSON decodes a JSON object from r into a Source.
+// Numbers are kept as json.Number so large integers aren't rounded.
+func JSON(r io.Reader) (Source, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("decoding JSON values: %w", err)
+	}
+	return Map(m), nil
+}
+
+// Chain returns a Source that looks key up in each source in turn.
+func Chain(sources ...Source) Source {
+	return SourceFunc(func(key string) (any, bool) {
+		for _, s := range sources {
+			if v, ok := s.Lookup(key); ok {
+				return v, true
+			}
+		}
+		return nil, false
+	})
+}
+
+// Has reports whether key is present in src.
+func Has(src Source, key string) bool {
+	_, ok := src.Lookup(key)
+	return ok
+}
+
+// String returns the value for key formatted as a string,
+// or "" if it isn't present.
+func String(src Source, key string) string {
+	v, ok := src.Lookup(key)
+	if !ok || v == nil {
+		return ""
+	}
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// Int returns the value for key as an int.
+// Strings are parsed; JSON numbers must be whole.
+func Int(src Source, key string) (int, error) {
+	v, ok := src.Lookup(key)
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", key, ErrMissing)
+	}
+	switch v := v.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("%s: %v is not an integer", key, v)
+		}
+		return int(v), nil
+	}
+	n, err := strconv.Atoi(String(src, key))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %q is not an integer", key, String(src, key))
+	}
+	return n, nil
+}
+
+// Float returns the value for key as a float64.
+func Float(src Source, key string) (float64, error) {
+	v, ok := src.Lookup(key)
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", key, ErrMissing)
+	}
+	if f, ok := v.(float64); ok {
+		return f, nil
+	}
+	f, err := strconv.ParseFloat(String(src, key), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %q is not a number", key, String(src, key))
+	}
+	return f, nil
+}
+
+// Bool returns the value for key as a bool.
+// Checkbox values ("on") count as true.
+func Bool(src Source, key string) (bool, error) {
+	v, ok := src.Lookup(key)
+	if !ok {
+		return false, fmt.Errorf("%s: %w", key, ErrMissing)
+	}
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	s := String(src, key)
+	if s == "on" {
+		return true, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("%s: %q is not a boolean", key, s)
+	}
+	return b, nil
+}
@@ -0,0 +1,98 @@
+package values
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// pageSize is the kind of shared helper Source is meant for.
+func pageSize(src Source) int {
+	n, err := Int(src, "limit")
+	if err != nil || n <= 0 {
+		return 20
+	}
+	return n
+}
+
+func TestHelperAcrossSources(t *testing.T) {
+	jsonSrc, err := JSON(strings.NewReader(`{"limit": 5}`))
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+
+	form := httptest.NewRequest("POST", "/items", strings.NewReader("limit=5"))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	sources := map[string]Source{
+		"query":     Query(httptest.NewRequest("GET", "/items?limit=5", nil)),
+		"form":      Form(form),
+		"json":      jsonSrc,
+		"websocket": Map(map[string]any{"limit": float64(5)}), // as decoded from the WS message
+		"url":       URL(url.Values{"limit": {"5", "9"}}),
+	}
+	for name, src := range sources {
+		if got := pageSize(src); got != 5 {
+			t.Errorf("%s: expected 5, got %d", name, got)
+		}
+	}
+
+	if got := pageSize(Map(nil)); got != 20 {
+		t.Errorf("missing value: expected default 20, got %d", got)
+	}
+}
+
+func TestTypedGetters(t *testing.T) {
+	src, err := JSON(strings.NewReader(`{"name":"Ada","age":36,"ratio":0.5,"admin":true,"big":9007199254740993}`))
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+
+	if got := String(src, "name"); got != "Ada" {
+		t.Errorf("String: expected Ada, got %q", got)
+	}
+	if got := String(src, "big"); got != "9007199254740993" {
+		t.Errorf("String of large number: got %q", got)
+	}
+	if got, err := Int(src, "age"); err != nil || got != 36 {
+		t.Errorf("Int: expected 36, got %d (%v)", got, err)
+	}
+	if got, err := Float(src, "ratio"); err != nil || got != 0.5 {
+		t.Errorf("Float: expected 0.5, got %v (%v)", got, err)
+	}
+	if got, err := Bool(src, "admin"); err != nil || !got {
+		t.Errorf("Bool: expected true, got %v (%v)", got, err)
+	}
+	if _, err := Int(src, "ratio"); err == nil {
+		t.Error("expected error for non-integer")
+	}
+	if _, err := Int(src, "missing"); !errors.Is(err, ErrMissing) {
+		t.Errorf("expected ErrMissing, got %v", err)
+	}
+
+	form := URL(url.Values{"done": {"on"}, "count": {"x"}})
+	if got, err := Bool(form, "done"); err != nil || !got {
+		t.Errorf("checkbox: expected true, got %v (%v)", got, err)
+	}
+	if _, err := Int(form, "count"); err == nil {
+		t.Error("expected parse error")
+	}
+	if Has(form, "missing") || !Has(form, "done") {
+		t.Error("Has reported wrong presence")
+	}
+}
+
+func TestChain(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?page=2&q=query", nil)
+	src := Chain(Map(map[string]any{"q": "body"}), Query(r))
+
+	if got := String(src, "q"); got != "body" {
+		t.Errorf("expected first source to win, got %q", got)
+	}
+	if got, _ := Int(src, "page"); got != 2 {
+		t.Errorf("expected fallback to query, got %d", got)
+	}
+}
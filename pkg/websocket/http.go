@@ -0,0 +1,97 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"github.com/stukennedy/irgo/pkg/core"
+)
+
+// HTTPHandler returns a MessageHandler that serves each message as a POST
+// to its Path, so HTTP handler logic can answer WebSocket messages too.
+// Requests carry core.WebSocketMessageHeader.
+// handle is usually an adapter.HTTPAdapter's HandleRequest:
+//
+//	a := adapter.NewHTTPAdapter(r.Handler())
+//	hub.Handle("/ws/chat", websocket.HTTPHandler(a.HandleRequest))
+func HTTPHandler(handle func(*core.Request) *core.Response) MessageHandler {
+	return MessageHandlerFunc(func(session *Session, req *Request) (*Envelope, error) {
+		coreReq, err := req.CoreRequest()
+		if err != nil {
+			return nil, err
+		}
+		coreReq.SetHeader(core.WebSocketMessageHeader, session.URL)
+		return req.ResponseEnvelope(handle(coreReq)), nil
+	})
+}
+
+// CoreRequest converts r into a POST to r.Path with r's headers. Values
+// become the body: JSON if the Content-Type header is application/json,
+// form-encoded otherwise.
+func (r *Request) CoreRequest() (*core.Request, error) {
+	path := r.Path
+	if path == "" {
+		path = "/"
+	}
+	req := core.NewRequest(http.MethodPost, path)
+
+	headers := make(map[string]string, len(r.Headers)+1)
+	for k, v := range r.Headers {
+		headers[k] = v
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.GetHeader("Content-Type"))
+	if mediaType == "application/json" {
+		body, err := json.Marshal(r.Values)
+		if err != nil {
+			return nil, fmt.Errorf("encoding values: %w", err)
+		}
+		req.Body = body
+	} else {
+		if mediaType == "" {
+			headers["Content-Type"] = "application/x-www-form-urlencoded"
+		}
+		req.Body = []byte(formValues(r.Values).Encode())
+	}
+	req.SetHeaders(headers)
+	return req, nil
+}
+
+// formValues form-encodes values; a slice becomes a repeated key.
+func formValues(values map[string]any) url.Values {
+	form := url.Values{}
+	for k, v := range values {
+		switch v := v.(type) {
+		case nil:
+			form.Add(k, "")
+		case []any:
+			for _, item := range v {
+				form.Add(k, fmt.Sprint(item))
+			}
+		case []string:
+			for _, item := range v {
+				form.Add(k, item)
+			}
+		default:
+			form.Add(k, fmt.Sprint(v))
+		}
+	}
+	return form
+}
+
+// ResponseEnvelope converts resp, the response to r's CoreRequest, into a
+// reply to r. JSON responses get the json format; anything else is sent as
+// HTML, including error pages. It returns nil if resp has no body, such as
+// a 204, as there is nothing to send.
+func (r *Request) ResponseEnvelope(resp *core.Response) *Envelope {
+	if resp == nil || len(resp.Body) == 0 {
+		return nil
+	}
+	env := ReplyEnvelope(r.RequestID, resp.BodyString())
+	if mediaType, _, _ := mime.ParseMediaType(resp.GetHeader("Content-Type")); mediaType == "application/json" {
+		env.AsJSON()
+	}
+	return env
+}
@@ -0,0 +1,150 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stukennedy/irgo/pkg/adapter"
+	"github.com/stukennedy/irgo/pkg/core"
+	"github.com/stukennedy/irgo/pkg/router"
+	"github.com/stukennedy/irgo/pkg/services"
+)
+
+func TestHTTPHandlerRoundTrip(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /ws/chat", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parsing form: %v", err)
+		}
+		if got := r.Header.Get("X-Custom"); got != "value" {
+			t.Errorf("expected X-Custom header, got %q", got)
+		}
+		if got := r.Header.Get(core.WebSocketMessageHeader); got != "/ws/chat" {
+			t.Errorf("expected the socket URL in %s, got %q", core.WebSocketMessageHeader, got)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<p>" + r.FormValue("message") + " x" + r.FormValue("count") +
+			" " + strings.Join(r.Form["tags"], ",") + "</p>"))
+	})
+	a := adapter.NewHTTPAdapter(mux)
+
+	session := NewSession("s1", "/ws/chat", HTTPHandler(a.HandleRequest))
+	env, err := session.HandleMessage([]byte(`{
+		"type": "request",
+		"request_id": "req-1",
+		"path": "/ws/chat",
+		"headers": {"X-Custom": "value"},
+		"values": {"message": "hello", "count": 2, "tags": ["a", "b"]}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env == nil {
+		t.Fatal("expected an envelope")
+	}
+	if env.Payload != "<p>hello x2 a,b</p>" {
+		t.Errorf("unexpected payload %q", env.Payload)
+	}
+	if env.RequestID != "req-1" || env.Channel != "ui" || env.Format != "html" {
+		t.Errorf("unexpected envelope %+v", env)
+	}
+}
+
+func TestCoreRequestJSON(t *testing.T) {
+	req := &Request{
+		Path:    "/api/items",
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Values:  map[string]any{"name": "widget", "qty": float64(3)},
+	}
+	coreReq, err := req.CoreRequest()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if coreReq.Method != "POST" || coreReq.URL != "/api/items" {
+		t.Errorf("expected POST /api/items, got %s %s", coreReq.Method, coreReq.URL)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(coreReq.Body, &body); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	if body["name"] != "widget" || body["qty"] != float64(3) {
+		t.Errorf("unexpected body %v", body)
+	}
+	if ct := coreReq.ContentType(); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", ct)
+	}
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	req := &Request{RequestID: "req-2"}
+
+	if env := req.ResponseEnvelope(nil); env != nil {
+		t.Errorf("expected no envelope for nil response, got %+v", env)
+	}
+
+	a := adapter.NewHTTPAdapter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	coreReq, _ := (&Request{Path: "/empty"}).CoreRequest()
+	if env := req.ResponseEnvelope(a.HandleRequest(coreReq)); env != nil {
+		t.Errorf("expected no envelope for 204, got %+v", env)
+	}
+
+	coreReq, _ = (&Request{Path: "/json"}).CoreRequest()
+	env := req.ResponseEnvelope(a.HandleRequest(coreReq))
+	if env == nil || env.Format != "json" || env.Payload != `{"ok":true}` || env.RequestID != "req-2" {
+		t.Errorf("unexpected envelope %+v", env)
+	}
+}
+
+func TestServicesSharedWithHTTP(t *testing.T) {
+	type counter struct{ n int }
+	svc := services.Services{"counter": &counter{}}
+
+	r := router.New(router.WithServices(svc))
+	r.POST("/ws/count", func(ctx *router.Context) (string, error) {
+		c, ok := services.Get[*counter](ctx.Services(), "counter")
+		if !ok {
+			return "", errors.New("no counter service")
+		}
+		c.n++
+		return "<p>" + strconv.Itoa(c.n) + "</p>", nil
+	})
+
+	hub := NewHub()
+	hub.SetServices(svc)
+	hub.Handle("/ws/count", HTTPHandler(adapter.NewHTTPAdapter(r.Handler()).HandleRequest))
+	hub.HandleFunc("/ws/peek", func(s *Session, req *Request) (*Envelope, error) {
+		c, _ := s.Service("counter").(*counter)
+		return NewEnvelope("<p>" + strconv.Itoa(c.n) + "</p>"), nil
+	})
+
+	count, err := hub.Connect("/ws/count")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	env, err := count.HandleMessage([]byte(`{"path": "/ws/count"}`))
+	if err != nil || env == nil || env.Payload != "<p>1</p>" {
+		t.Fatalf("expected the HTTP route to use the counter, got %+v, %v", env, err)
+	}
+
+	peek, err := hub.Connect("/ws/peek")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	env, err = peek.HandleMessage([]byte(`{"path": "/ws/peek"}`))
+	if err != nil || env == nil || env.Payload != "<p>1</p>" {
+		t.Errorf("expected the WebSocket handler to see the same counter, got %+v, %v", env, err)
+	}
+}
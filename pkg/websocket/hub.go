@@ -2,10 +2,15 @@ package websocket
 
 import (
 	"errors"
+	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/stukennedy/irgo/pkg/services"
 )
 
 var (
@@ -17,17 +22,47 @@ var (
 
 	// ErrNoHandler is returned when no handler is registered for a URL.
 	ErrNoHandler = errors.New("no handler registered for URL")
+
+	// ErrSendTimeout is returned when a session's send buffer stays full.
+	ErrSendTimeout = errors.New("websocket send timed out")
+
+	// ErrMessageTooLarge is returned by HandleMessage for messages over
+	// the hub's limit (see SetMaxMessageSize).
+	ErrMessageTooLarge = errors.New("websocket message too large")
 )
 
 // Hub manages all WebSocket sessions and message routing.
 type Hub struct {
 	sessions    map[string]*Session
 	handlers    map[string]MessageHandler // URL pattern → handler
+	pingIntervals map[string]time.Duration // URL pattern → keep-alive interval
 	defaultHandler MessageHandler
 	sessionsMu  sync.RWMutex
 	handlersMu  sync.RWMutex
 	counter     uint64
 
+	// Message counters by handler pattern, for Stats
+	counters map[string]*sessionCounters
+	statsMu  sync.RWMutex
+
+	// maxPerURL limits open sessions per URL (0 = unlimited); guarded by
+	// sessionsMu. coalesced counts sessions closed to stay within it.
+	maxPerURL int
+	coalesced atomic.Uint64
+
+	// maxMessageSize limits incoming messages in bytes (0 = unlimited)
+	maxMessageSize atomic.Int64
+
+	// services are given to each new session, with a send buffer of
+	// sendBuffer envelopes and onSendDropped; guarded by handlersMu
+	services      services.Services
+	sendBuffer    int
+	onSendDropped func(*Session, *Envelope)
+
+	// tickers are the running Tickers, stopped by Close
+	tickers   map[*Ticker]struct{}
+	tickersMu sync.Mutex
+
 	// Callback for when sessions are created/destroyed
 	onSessionCreated  func(session *Session)
 	onSessionDestroyed func(session *Session)
@@ -36,8 +71,11 @@ type Hub struct {
 // NewHub creates a new WebSocket hub.
 func NewHub() *Hub {
 	return &Hub{
-		sessions: make(map[string]*Session),
-		handlers: make(map[string]MessageHandler),
+		sessions:      make(map[string]*Session),
+		handlers:      make(map[string]MessageHandler),
+		pingIntervals: make(map[string]time.Duration),
+		counters:      make(map[string]*sessionCounters),
+		sendBuffer:    DefaultSendBuffer,
 	}
 }
 
@@ -61,6 +99,145 @@ func (h *Hub) SetDefaultHandler(handler MessageHandler) {
 	h.defaultHandler = handler
 }
 
+// SetServices shares svc with every session connected from now on, for
+// handlers to reach through Session.Services. Pass the same Services as
+// router.WithServices so HTTP and WebSocket handlers use the same store.
+func (h *Hub) SetServices(svc services.Services) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+	h.services = svc
+}
+
+// SetSendBuffer sets how many envelopes each session connected from now on
+// queues before Send drops them (DefaultSendBuffer unless set). A larger
+// buffer rides out bursts, such as a busy chat room, for slow clients.
+func (h *Hub) SetSendBuffer(n int) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+	if n <= 0 {
+		n = DefaultSendBuffer
+	}
+	h.sendBuffer = n
+}
+
+// OnSendDropped sets a callback for when an envelope is dropped because a
+// session's send buffer is full, from Send, a broadcast or a timed-out
+// SendTimeout. Use it to detect slow clients, for example to disconnect
+// them or have them reload so their UI doesn't silently fall out of date.
+// It runs on the sending goroutine and applies to sessions connected from
+// now on.
+func (h *Hub) OnSendDropped(fn func(session *Session, envelope *Envelope)) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+	h.onSendDropped = fn
+}
+
+// newSession creates a session with the hub's settings.
+func (h *Hub) newSession(sessionID, url string, handler MessageHandler) *Session {
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+	session := newSession(sessionID, url, handler, h.sendBuffer)
+	session.services = h.services
+	session.onDropped = h.onSendDropped
+	return session
+}
+
+// SetPingInterval enables keep-alive pings for sessions connecting to URLs
+// matching pattern (exact, or prefix when it ends in "/").
+// Idle sessions receive a PingEnvelope every interval until they close;
+// sessions that sent anything more recently are skipped. Zero disables.
+func (h *Hub) SetPingInterval(pattern string, interval time.Duration) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+	if interval <= 0 {
+		delete(h.pingIntervals, pattern)
+		return
+	}
+	h.pingIntervals[pattern] = interval
+}
+
+// pingInterval returns the keep-alive interval for url, or 0.
+func (h *Hub) pingInterval(url string) time.Duration {
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+	for pattern, interval := range h.pingIntervals {
+		if h.matchURL(url, pattern) {
+			return interval
+		}
+	}
+	return 0
+}
+
+// HasHandler reports whether Connect would find a handler for url,
+// including the default handler.
+func (h *Hub) HasHandler(url string) bool {
+	if _, handler := h.findHandler(url); handler != nil {
+		return true
+	}
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+	return h.defaultHandler != nil
+}
+
+// SetMaxSessionsPerURL limits how many sessions may be open for the same
+// URL (path and query; the host is ignored). When a connect would exceed
+// it, the oldest sessions for that URL are disconnected first, so reconnect
+// races in a webview can't leak sessions. Evictions are counted in
+// HubStats.Coalesced. Zero, the default, means no limit.
+func (h *Hub) SetMaxSessionsPerURL(n int) {
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+	h.maxPerURL = n
+}
+
+// evictForURL removes the oldest sessions sharing session's URL so that,
+// with session added, there are at most maxPerURL. Must hold sessionsMu.
+func (h *Hub) evictForURL(session *Session) []*Session {
+	if h.maxPerURL <= 0 {
+		return nil
+	}
+	key := extractPath(session.URL)
+	var same []*Session
+	for _, s := range h.sessions {
+		if extractPath(s.URL) == key {
+			same = append(same, s)
+		}
+	}
+	excess := len(same) + 1 - h.maxPerURL
+	if excess <= 0 {
+		return nil
+	}
+	sort.Slice(same, func(i, j int) bool { return same[i].seq < same[j].seq })
+	evicted := same[:excess]
+	for _, s := range evicted {
+		delete(h.sessions, s.ID)
+	}
+	return evicted
+}
+
+// closeEvicted closes sessions removed by evictForURL.
+func (h *Hub) closeEvicted(evicted []*Session) {
+	for _, s := range evicted {
+		h.coalesced.Add(1)
+		s.Close()
+		if h.onSessionDestroyed != nil {
+			h.onSessionDestroyed(s)
+		}
+	}
+}
+
+// SetMaxMessageSize limits incoming messages to n bytes: HandleMessage
+// rejects larger ones with ErrMessageTooLarge, before parsing them. Zero,
+// the default, means no limit.
+func (h *Hub) SetMaxMessageSize(n int) {
+	h.maxMessageSize.Store(int64(max(n, 0)))
+}
+
+// MaxMessageSize returns the limit set by SetMaxMessageSize.
+func (h *Hub) MaxMessageSize() int {
+	return int(h.maxMessageSize.Load())
+}
+
 // OnSessionCreated sets a callback for when sessions are created.
 func (h *Hub) OnSessionCreated(fn func(*Session)) {
 	h.onSessionCreated = fn
@@ -71,23 +248,41 @@ func (h *Hub) OnSessionDestroyed(fn func(*Session)) {
 	h.onSessionDestroyed = fn
 }
 
-// Connect creates a new session for the given URL.
-// Returns the session ID and the session.
+// Connect creates a new session for the given URL. Its query parameters
+// are available from Session.ConnectQuery.
 func (h *Hub) Connect(url string) (*Session, error) {
-	handler := h.findHandler(url)
+	return h.connect(url, nil, queryOf(url))
+}
+
+// ConnectRequest creates a session for a WebSocket upgrade request, routed
+// by its path. Its headers and query parameters are available from
+// Session.ConnectHeaders and ConnectQuery, including in OnConnect.
+func (h *Hub) ConnectRequest(r *http.Request) (*Session, error) {
+	return h.connect(r.URL.Path, r.Header.Clone(), r.URL.Query())
+}
+
+func (h *Hub) connect(url string, header http.Header, query url.Values) (*Session, error) {
+	pattern, handler := h.findHandler(url)
 	if handler == nil && h.defaultHandler == nil {
 		return nil, ErrNoHandler
 	}
 	if handler == nil {
-		handler = h.defaultHandler
+		pattern, handler = DefaultPattern, h.defaultHandler
 	}
 
 	sessionID := h.generateSessionID()
-	session := NewSession(sessionID, url, handler)
+	session := h.newSession(sessionID, url, handler)
+	session.connectHeader = header
+	session.connectQuery = query
+	session.pattern = pattern
+	session.counters = h.patternCounters(pattern)
+	session.seq = atomic.AddUint64(&h.counter, 1)
 
 	h.sessionsMu.Lock()
+	evicted := h.evictForURL(session)
 	h.sessions[sessionID] = session
 	h.sessionsMu.Unlock()
+	h.closeEvicted(evicted)
 
 	// Call OnConnect
 	if err := handler.OnConnect(session); err != nil {
@@ -97,6 +292,12 @@ func (h *Hub) Connect(url string) (*Session, error) {
 		return nil, err
 	}
 
+	session.counters.connects.Add(1)
+
+	if interval := h.pingInterval(url); interval > 0 {
+		session.startPing(interval)
+	}
+
 	if h.onSessionCreated != nil {
 		h.onSessionCreated(session)
 	}
@@ -106,23 +307,30 @@ func (h *Hub) Connect(url string) (*Session, error) {
 
 // ConnectWithID creates a session with a specific ID (for reconnection).
 func (h *Hub) ConnectWithID(sessionID, url string) (*Session, error) {
-	handler := h.findHandler(url)
+	pattern, handler := h.findHandler(url)
 	if handler == nil && h.defaultHandler == nil {
 		return nil, ErrNoHandler
 	}
 	if handler == nil {
-		handler = h.defaultHandler
+		pattern, handler = DefaultPattern, h.defaultHandler
 	}
 
-	session := NewSession(sessionID, url, handler)
+	session := h.newSession(sessionID, url, handler)
+	session.connectQuery = queryOf(url)
+	session.pattern = pattern
+	session.counters = h.patternCounters(pattern)
+	session.seq = atomic.AddUint64(&h.counter, 1)
 
 	h.sessionsMu.Lock()
 	// If session already exists, close the old one
 	if old, exists := h.sessions[sessionID]; exists {
 		old.Close()
+		delete(h.sessions, sessionID)
 	}
+	evicted := h.evictForURL(session)
 	h.sessions[sessionID] = session
 	h.sessionsMu.Unlock()
+	h.closeEvicted(evicted)
 
 	if err := handler.OnConnect(session); err != nil {
 		h.sessionsMu.Lock()
@@ -131,6 +339,12 @@ func (h *Hub) ConnectWithID(sessionID, url string) (*Session, error) {
 		return nil, err
 	}
 
+	session.counters.connects.Add(1)
+
+	if interval := h.pingInterval(url); interval > 0 {
+		session.startPing(interval)
+	}
+
 	if h.onSessionCreated != nil {
 		h.onSessionCreated(session)
 	}
@@ -140,6 +354,12 @@ func (h *Hub) ConnectWithID(sessionID, url string) (*Session, error) {
 
 // Disconnect closes and removes a session.
 func (h *Hub) Disconnect(sessionID string) {
+	h.DisconnectWithCode(sessionID, CloseNormalClosure, "")
+}
+
+// DisconnectWithCode closes and removes a session, with a close code and
+// reason for the client (see Session.CloseWithCode).
+func (h *Hub) DisconnectWithCode(sessionID string, code int, reason string) {
 	h.sessionsMu.Lock()
 	session, exists := h.sessions[sessionID]
 	if exists {
@@ -148,7 +368,7 @@ func (h *Hub) Disconnect(sessionID string) {
 	h.sessionsMu.Unlock()
 
 	if exists {
-		session.Close()
+		session.CloseWithCode(code, reason)
 		if h.onSessionDestroyed != nil {
 			h.onSessionDestroyed(session)
 		}
@@ -165,6 +385,9 @@ func (h *Hub) GetSession(sessionID string) (*Session, bool) {
 
 // HandleMessage processes an incoming message for a session.
 func (h *Hub) HandleMessage(sessionID string, data []byte) (*Envelope, error) {
+	if limit := h.maxMessageSize.Load(); limit > 0 && int64(len(data)) > limit {
+		return nil, ErrMessageTooLarge
+	}
 	session, ok := h.GetSession(sessionID)
 	if !ok {
 		return nil, ErrSessionNotFound
@@ -187,13 +410,43 @@ func (h *Hub) Send(sessionID string, envelope *Envelope) error {
 	return nil
 }
 
+// SendTimeout sends an envelope to a specific session, waiting up to
+// timeout for buffer space rather than dropping the message.
+func (h *Hub) SendTimeout(sessionID string, envelope *Envelope, timeout time.Duration) error {
+	session, ok := h.GetSession(sessionID)
+	if !ok {
+		return ErrSessionNotFound
+	}
+	return session.SendTimeout(envelope, timeout)
+}
+
 // SendHTML sends an HTML fragment to a session.
 func (h *Hub) SendHTML(sessionID, target, html string) error {
 	return h.Send(sessionID, HTMLEnvelope(target, html))
 }
 
-// Broadcast sends an envelope to all sessions.
+// BroadcastResult counts the sessions a broadcast reached.
+type BroadcastResult struct {
+	// Delivered counts sessions the envelope was queued for.
+	Delivered int `json:"delivered"`
+
+	// Dropped counts sessions whose send buffer was full, so they missed
+	// the envelope (see Hub.OnSendDropped).
+	Dropped int `json:"dropped"`
+
+	// Closed counts sessions that closed during the broadcast.
+	Closed int `json:"closed"`
+}
+
+// Broadcast sends an envelope to all sessions without blocking. Sessions
+// with a full send buffer miss it; use BroadcastResult to find out.
 func (h *Hub) Broadcast(envelope *Envelope) {
+	h.BroadcastResult(envelope)
+}
+
+// BroadcastResult sends an envelope to all sessions like Broadcast, and
+// reports how many got it.
+func (h *Hub) BroadcastResult(envelope *Envelope) BroadcastResult {
 	h.sessionsMu.RLock()
 	sessions := make([]*Session, 0, len(h.sessions))
 	for _, s := range h.sessions {
@@ -201,9 +454,22 @@ func (h *Hub) Broadcast(envelope *Envelope) {
 	}
 	h.sessionsMu.RUnlock()
 
+	return broadcast(sessions, envelope)
+}
+
+func broadcast(sessions []*Session, envelope *Envelope) BroadcastResult {
+	var result BroadcastResult
 	for _, s := range sessions {
-		s.Send(envelope)
+		switch s.trySend(envelope) {
+		case sendOK:
+			result.Delivered++
+		case sendDropped:
+			result.Dropped++
+		case sendClosed:
+			result.Closed++
+		}
 	}
+	return result
 }
 
 // BroadcastHTML sends HTML to all sessions.
@@ -213,6 +479,12 @@ func (h *Hub) BroadcastHTML(target, html string) {
 
 // BroadcastToURL sends to all sessions connected to URLs matching the pattern.
 func (h *Hub) BroadcastToURL(urlPattern string, envelope *Envelope) {
+	h.BroadcastToURLResult(urlPattern, envelope)
+}
+
+// BroadcastToURLResult sends like BroadcastToURL, and reports how many
+// sessions got the envelope.
+func (h *Hub) BroadcastToURLResult(urlPattern string, envelope *Envelope) BroadcastResult {
 	h.sessionsMu.RLock()
 	sessions := make([]*Session, 0)
 	for _, s := range h.sessions {
@@ -222,9 +494,7 @@ func (h *Hub) BroadcastToURL(urlPattern string, envelope *Envelope) {
 	}
 	h.sessionsMu.RUnlock()
 
-	for _, s := range sessions {
-		s.Send(envelope)
-	}
+	return broadcast(sessions, envelope)
 }
 
 // Sessions returns the number of active sessions.
@@ -248,6 +518,36 @@ func (h *Hub) SessionsForURL(urlPattern string) []*Session {
 	return result
 }
 
+// SessionsWhere returns the open sessions pred matches, e.g. those with
+// some metadata:
+//
+//	mine := hub.SessionsWhere(func(s *websocket.Session) bool {
+//		return s.GetString("userID") == "42"
+//	})
+//
+// pred is called with the hub's session lock held, so sessions connecting
+// or disconnecting meanwhile are either all in or all out. It mustn't call
+// back into the hub.
+func (h *Hub) SessionsWhere(pred func(*Session) bool) []*Session {
+	h.sessionsMu.RLock()
+	defer h.sessionsMu.RUnlock()
+
+	var result []*Session
+	for _, s := range h.sessions {
+		if !s.IsClosed() && pred(s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// SendWhere sends an envelope to the sessions pred matches, like
+// BroadcastResult, so apps can message a group such as a user's devices
+// without tracking session IDs. See SessionsWhere for how pred is called.
+func (h *Hub) SendWhere(pred func(*Session) bool, envelope *Envelope) BroadcastResult {
+	return broadcast(h.SessionsWhere(pred), envelope)
+}
+
 // AllSessions returns all active sessions.
 func (h *Hub) AllSessions() []*Session {
 	h.sessionsMu.RLock()
@@ -274,8 +574,10 @@ func (h *Hub) CleanupExpired(ttl time.Duration) {
 	}
 }
 
-// Close closes all sessions and cleans up the hub.
+// Close stops the hub's tickers, closes all sessions and cleans up the hub.
 func (h *Hub) Close() {
+	h.stopTickers()
+
 	h.sessionsMu.Lock()
 	sessions := make([]*Session, 0, len(h.sessions))
 	for _, s := range h.sessions {
@@ -292,35 +594,40 @@ func (h *Hub) Close() {
 	}
 }
 
-func (h *Hub) findHandler(url string) MessageHandler {
+// findHandler returns the handler registered for url and the pattern it
+// was registered under, or a nil handler.
+func (h *Hub) findHandler(url string) (string, MessageHandler) {
 	h.handlersMu.RLock()
 	defer h.handlersMu.RUnlock()
 
+	// Route on the path; the query is for the handler (ConnectQuery)
+	url, _, _ = strings.Cut(url, "?")
+
 	// Exact match first
 	if handler, ok := h.handlers[url]; ok {
-		return handler
+		return url, handler
 	}
 
 	// Prefix match
 	for pattern, handler := range h.handlers {
 		if strings.HasSuffix(pattern, "/") && strings.HasPrefix(url, pattern) {
-			return handler
+			return pattern, handler
 		}
 	}
 
 	// Path extraction (remove protocol and host)
 	path := extractPath(url)
 	if handler, ok := h.handlers[path]; ok {
-		return handler
+		return path, handler
 	}
 
 	for pattern, handler := range h.handlers {
 		if strings.HasSuffix(pattern, "/") && strings.HasPrefix(path, pattern) {
-			return handler
+			return pattern, handler
 		}
 	}
 
-	return nil
+	return "", nil
 }
 
 func (h *Hub) matchURL(url, pattern string) bool {
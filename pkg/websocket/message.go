@@ -4,7 +4,10 @@
 package websocket
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"strconv"
+	"time"
 )
 
 // Request represents a message from the client via WebSocket.
@@ -49,6 +52,10 @@ func (r *Request) CurrentURL() string {
 
 // Envelope represents a message from the server to the client.
 // Used for WebSocket-based real-time updates.
+//
+// For the "ui" channel, Payload is inserted into the page as HTML without
+// further escaping: escape user input with render.EscapeHTML (or build the
+// payload with render.HTMLf or a templ component) before sending it.
 type Envelope struct {
 	Channel   string `json:"channel,omitempty"`    // Channel identifier (default: "ui")
 	Format    string `json:"format,omitempty"`     // Message format (default: "html")
@@ -59,6 +66,7 @@ type Envelope struct {
 }
 
 // NewEnvelope creates a new UI/HTML envelope with the given payload.
+// payload is rendered as HTML; see Envelope.
 func NewEnvelope(payload string) *Envelope {
 	return &Envelope{
 		Channel: "ui",
@@ -97,6 +105,51 @@ func (e *Envelope) ToChannel(channel string) *Envelope {
 	return e
 }
 
+// FormatBinary is the Format of envelopes carrying raw bytes in Payload.
+// The loopback transport sends them as binary WebSocket frames; in JSON
+// (the mobile bridge) the payload is base64-encoded.
+const FormatBinary = "binary"
+
+// BinaryEnvelope creates an envelope carrying data as raw bytes.
+func BinaryEnvelope(channel string, data []byte) *Envelope {
+	return &Envelope{
+		Channel: channel,
+		Format:  FormatBinary,
+		Payload: string(data),
+	}
+}
+
+// Bytes returns the payload as bytes.
+func (e *Envelope) Bytes() []byte {
+	return []byte(e.Payload)
+}
+
+// jsonEnvelope is Envelope without its JSON methods.
+type jsonEnvelope Envelope
+
+// MarshalJSON encodes the envelope, base64-encoding binary payloads.
+func (e Envelope) MarshalJSON() ([]byte, error) {
+	if e.Format == FormatBinary {
+		e.Payload = base64.StdEncoding.EncodeToString([]byte(e.Payload))
+	}
+	return json.Marshal(jsonEnvelope(e))
+}
+
+// UnmarshalJSON decodes an envelope, decoding base64 binary payloads.
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*jsonEnvelope)(e)); err != nil {
+		return err
+	}
+	if e.Format == FormatBinary {
+		payload, err := base64.StdEncoding.DecodeString(e.Payload)
+		if err != nil {
+			return err
+		}
+		e.Payload = string(payload)
+	}
+	return nil
+}
+
 // JSON encodes the envelope to JSON bytes.
 func (e *Envelope) JSON() ([]byte, error) {
 	return json.Marshal(e)
@@ -121,6 +174,7 @@ func ParseRequest(data []byte) (*Request, error) {
 }
 
 // HTMLEnvelope creates an envelope for HTML content targeting a specific element.
+// html is rendered as-is; see Envelope.
 func HTMLEnvelope(target, html string) *Envelope {
 	return &Envelope{
 		Channel: "ui",
@@ -163,3 +217,17 @@ func JSONEnvelope(channel string, data any) (*Envelope, error) {
 		Payload: string(payload),
 	}, nil
 }
+
+// PingChannel is the channel used for application-level keep-alive envelopes.
+// The bridge script treats these as liveness signals rather than UI updates.
+const PingChannel = "ping"
+
+// PingEnvelope creates a keep-alive envelope carrying the send time
+// (Unix milliseconds) as its payload.
+func PingEnvelope() *Envelope {
+	return &Envelope{
+		Channel: PingChannel,
+		Format:  "text",
+		Payload: strconv.FormatInt(time.Now().UnixMilli(), 10),
+	}
+}
@@ -0,0 +1,35 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBinaryEnvelopeJSON(t *testing.T) {
+	data := []byte{0x00, 0xff, 0x10, 'h', 'i', 0x80}
+	env := BinaryEnvelope("audio", data)
+
+	encoded, err := env.JSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"payload":"AP8QaGmA"`) || !strings.Contains(string(encoded), `"format":"binary"`) {
+		t.Errorf("expected a base64 payload, got %s", encoded)
+	}
+
+	var decoded Envelope
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(decoded.Bytes(), data) || decoded.Channel != "audio" {
+		t.Errorf("round trip changed the envelope: %+v", decoded)
+	}
+
+	// Other formats are unchanged
+	encoded, _ = NewEnvelope("hi").JSON()
+	if !strings.Contains(string(encoded), `"payload":"hi"`) {
+		t.Errorf("unexpected HTML envelope %s", encoded)
+	}
+}
@@ -2,8 +2,14 @@ package websocket
 
 import (
 	"context"
+	"net/http"
+	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/stukennedy/irgo/pkg/services"
 )
 
 // Session represents a virtual WebSocket connection.
@@ -29,8 +35,41 @@ type Session struct {
 	metadataMu sync.RWMutex
 
 	// closed tracks if the session has been closed.
+	// mu is read-locked for the duration of every send so Close can't
+	// close SendChan underneath a sender.
 	closed bool
 	mu     sync.RWMutex
+
+	// closeCode and closeReason are set by the first close; guarded by mu
+	closeCode   int
+	closeReason string
+
+	// done is closed at the start of Close to wake blocked senders.
+	done     chan struct{}
+	doneOnce sync.Once
+
+	// lastSend is the UnixNano time of the last queued envelope, used to
+	// skip keep-alive pings on busy sessions.
+	lastSend atomic.Int64
+
+	// pattern is the hub handler pattern the session matched, and counters
+	// its message counts (nil outside a Hub).
+	pattern  string
+	counters *sessionCounters
+
+	// seq orders sessions by connect time within a Hub
+	seq uint64
+
+	// services are the Hub's, see Hub.SetServices
+	services services.Services
+
+	// onDropped is the Hub's, see Hub.OnSendDropped
+	onDropped func(*Session, *Envelope)
+
+	// connectHeader and connectQuery are from the request that opened the
+	// session; set before OnConnect and read-only after
+	connectHeader http.Header
+	connectQuery  url.Values
 }
 
 type pendingRequest struct {
@@ -68,34 +107,120 @@ func (f MessageHandlerFunc) OnMessage(session *Session, req *Request) (*Envelope
 func (f MessageHandlerFunc) OnClose(session *Session) {
 }
 
+// DefaultSendBuffer is how many envelopes a session queues before Send
+// starts dropping them.
+const DefaultSendBuffer = 100
+
 // NewSession creates a new WebSocket session.
 func NewSession(id, url string, handler MessageHandler) *Session {
+	return newSession(id, url, handler, DefaultSendBuffer)
+}
+
+func newSession(id, url string, handler MessageHandler, buffer int) *Session {
 	return &Session{
 		ID:        id,
 		URL:       url,
 		CreatedAt: time.Now(),
-		SendChan:  make(chan *Envelope, 100), // Buffered to prevent blocking
+		SendChan:  make(chan *Envelope, buffer), // Buffered to prevent blocking
 		Handler:   handler,
 		pending:   make(map[string]*pendingRequest),
 		metadata:  make(map[string]any),
+		done:      make(chan struct{}),
 	}
 }
 
-// Send queues an envelope to be sent to the client.
+// Send queues an envelope to be sent to the client without blocking.
+// Returns false if the session is closed or the buffer is full, in which
+// case the envelope is dropped.
+//
+// Ordering: envelopes sent from the same goroutine are delivered in the
+// order they were sent (FIFO per sender). Envelopes from different
+// goroutines are interleaved in the order their sends complete.
 func (s *Session) Send(envelope *Envelope) bool {
+	return s.trySend(envelope) == sendOK
+}
+
+type sendResult uint8
+
+const (
+	sendOK sendResult = iota
+	sendDropped
+	sendClosed
+)
+
+// trySend queues envelope without blocking.
+func (s *Session) trySend(envelope *Envelope) sendResult {
 	s.mu.RLock()
 	if s.closed {
 		s.mu.RUnlock()
-		return false
+		return sendClosed
 	}
-	s.mu.RUnlock()
 
 	select {
 	case s.SendChan <- envelope:
-		return true
+		s.lastSend.Store(time.Now().UnixNano())
+		s.counters.sent(true)
+		s.mu.RUnlock()
+		return sendOK
 	default:
 		// Channel full, drop the message
-		return false
+		s.counters.sent(false)
+		s.mu.RUnlock()
+		s.dropped(envelope)
+		return sendDropped
+	}
+}
+
+// dropped reports an envelope dropped because the buffer was full. It's
+// called without mu held, so the hook may close the session.
+func (s *Session) dropped(envelope *Envelope) {
+	if s.onDropped != nil {
+		s.onDropped(s, envelope)
+	}
+}
+
+// SendTimeout queues an envelope, waiting up to timeout for buffer space
+// instead of dropping it. Use this for messages that must not be lost,
+// such as ordered UI updates. Per-sender FIFO ordering is the same as Send.
+// Returns ErrSendTimeout if the buffer stays full, or ErrSessionClosed if
+// the session is (or becomes) closed.
+func (s *Session) SendTimeout(envelope *Envelope, timeout time.Duration) error {
+	err := s.sendTimeout(envelope, timeout)
+	if err == ErrSendTimeout {
+		s.dropped(envelope)
+	}
+	return err
+}
+
+func (s *Session) sendTimeout(envelope *Envelope, timeout time.Duration) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrSessionClosed
+	}
+
+	// Fast path: buffer has room
+	select {
+	case s.SendChan <- envelope:
+		s.lastSend.Store(time.Now().UnixNano())
+		s.counters.sent(true)
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case s.SendChan <- envelope:
+		s.lastSend.Store(time.Now().UnixNano())
+		s.counters.sent(true)
+		return nil
+	case <-s.done:
+		return ErrSessionClosed
+	case <-timer.C:
+		s.counters.sent(false)
+		return ErrSendTimeout
 	}
 }
 
@@ -116,6 +241,7 @@ func (s *Session) HandleMessage(data []byte) (*Envelope, error) {
 	if err != nil {
 		return nil, err
 	}
+	s.counters.received()
 
 	// Track pending request for response matching
 	if req.RequestID != "" {
@@ -128,14 +254,36 @@ func (s *Session) HandleMessage(data []byte) (*Envelope, error) {
 	return nil, nil
 }
 
-// Close marks the session as closed and cleans up.
+// WebSocket close codes (RFC 6455) for CloseWithCode. Applications may
+// also use 4000–4999.
+const (
+	CloseNormalClosure     = 1000
+	CloseGoingAway         = 1001
+	ClosePolicyViolation   = 1008
+	CloseMessageTooBig     = 1009
+	CloseInternalServerErr = 1011
+)
+
+// Close marks the session as closed and cleans up, with a normal closure.
 func (s *Session) Close() {
+	s.CloseWithCode(CloseNormalClosure, "")
+}
+
+// CloseWithCode closes the session like Close, telling the client why: the
+// code and reason are sent in the WebSocket close frame (loopback) or to the
+// native OnClose callback (mobile), so clients can tell a normal closure
+// from, say, a policy violation. Only the first close sets them.
+func (s *Session) CloseWithCode(code int, reason string) {
+	// Wake any senders blocked in SendTimeout so they release mu
+	s.doneOnce.Do(func() { close(s.done) })
+
 	s.mu.Lock()
 	if s.closed {
 		s.mu.Unlock()
 		return
 	}
 	s.closed = true
+	s.closeCode, s.closeReason = code, reason
 	s.mu.Unlock()
 
 	close(s.SendChan)
@@ -145,6 +293,28 @@ func (s *Session) Close() {
 	}
 }
 
+// startPing sends a PingEnvelope whenever the session has been idle for
+// interval, until the session closes.
+func (s *Session) startPing(interval time.Duration) {
+	s.lastSend.Store(time.Now().UnixNano())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case now := <-ticker.C:
+				// Allow for ticker jitter so an idle session pings every tick
+				idle := now.Sub(time.Unix(0, s.lastSend.Load()))
+				if idle >= interval-interval/10 {
+					s.Send(PingEnvelope())
+				}
+			}
+		}
+	}()
+}
+
 // IsClosed returns true if the session has been closed.
 func (s *Session) IsClosed() bool {
 	s.mu.RLock()
@@ -152,6 +322,62 @@ func (s *Session) IsClosed() bool {
 	return s.closed
 }
 
+// Done returns a channel that's closed when the session closes.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// ConnectHeaders returns the headers of the WebSocket upgrade request that
+// opened the session (see Hub.ConnectRequest), e.g. an Authorization
+// header or cookies. It's empty for sessions opened without one, such as
+// over the mobile bridge.
+func (s *Session) ConnectHeaders() http.Header {
+	if s.connectHeader == nil {
+		return http.Header{}
+	}
+	return s.connectHeader
+}
+
+// ConnectQuery returns the query parameters the session was opened with,
+// e.g. a room ID or token from "/ws/chat?room=42". Handlers can read both
+// in OnConnect.
+func (s *Session) ConnectQuery() url.Values {
+	if s.connectQuery == nil {
+		return url.Values{}
+	}
+	return s.connectQuery
+}
+
+// queryOf parses the query string of rawURL, ignoring malformed pairs.
+func queryOf(rawURL string) url.Values {
+	_, query, ok := strings.Cut(rawURL, "?")
+	if !ok {
+		return nil
+	}
+	values, _ := url.ParseQuery(query)
+	return values
+}
+
+// CloseStatus returns the code and reason the session was closed with, or
+// 0 and "" while it's open.
+func (s *Session) CloseStatus() (code int, reason string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.closeCode, s.closeReason
+}
+
+// Services returns the services shared by the session's Hub (see
+// Hub.SetServices), or nil.
+func (s *Session) Services() services.Services {
+	return s.services
+}
+
+// Service returns the service registered as key, or nil. Use services.Get
+// for a typed lookup.
+func (s *Session) Service(key string) any {
+	return s.services.Service(key)
+}
+
 // Set stores metadata on the session.
 func (s *Session) Set(key string, value any) {
 	s.metadataMu.Lock()
@@ -0,0 +1,478 @@
+package websocket
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionSendPreservesPerSenderOrder(t *testing.T) {
+	s := NewSession("s1", "/ws", nil)
+
+	const senders = 4
+	const perSender = 200
+
+	received := make(map[string][]int)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for env := range s.SendChan {
+			sender, seq, _ := strings.Cut(env.Payload, ":")
+			n, _ := strconv.Atoi(seq)
+			received[sender] = append(received[sender], n)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(sender string) {
+			defer wg.Done()
+			for n := 0; n < perSender; n++ {
+				env := NewEnvelope(sender + ":" + strconv.Itoa(n))
+				if err := s.SendTimeout(env, time.Second); err != nil {
+					t.Errorf("sender %s: unexpected error: %v", sender, err)
+					return
+				}
+			}
+		}("sender" + strconv.Itoa(i))
+	}
+	wg.Wait()
+	s.Close()
+	<-done
+
+	for sender, seqs := range received {
+		if len(seqs) != perSender {
+			t.Errorf("%s: expected %d messages, got %d", sender, perSender, len(seqs))
+		}
+		for i, n := range seqs {
+			if n != i {
+				t.Errorf("%s: out of order at %d: got %d", sender, i, n)
+				break
+			}
+		}
+	}
+	if len(received) != senders {
+		t.Errorf("expected messages from %d senders, got %d", senders, len(received))
+	}
+}
+
+func TestSessionSendTimeout(t *testing.T) {
+	s := NewSession("s1", "/ws", nil)
+	for i := 0; i < cap(s.SendChan); i++ {
+		if !s.Send(NewEnvelope("fill")) {
+			t.Fatalf("unexpected drop filling buffer at %d", i)
+		}
+	}
+
+	if s.Send(NewEnvelope("dropped")) {
+		t.Error("expected non-blocking Send to drop on full buffer")
+	}
+
+	err := s.SendTimeout(NewEnvelope("late"), 10*time.Millisecond)
+	if !errors.Is(err, ErrSendTimeout) {
+		t.Errorf("expected ErrSendTimeout, got %v", err)
+	}
+
+	// Draining one slot lets a blocked sender through
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-s.SendChan
+	}()
+	if err := s.SendTimeout(NewEnvelope("waited"), time.Second); err != nil {
+		t.Errorf("expected blocked send to succeed after drain, got %v", err)
+	}
+}
+
+func TestSessionCloseWakesBlockedSender(t *testing.T) {
+	s := NewSession("s1", "/ws", nil)
+	for i := 0; i < cap(s.SendChan); i++ {
+		s.Send(NewEnvelope("fill"))
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.SendTimeout(NewEnvelope("blocked"), 5*time.Second)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	s.Close()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, ErrSessionClosed) {
+			t.Errorf("expected ErrSessionClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not wake blocked sender")
+	}
+
+	if err := s.SendTimeout(NewEnvelope("after"), time.Millisecond); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed after close, got %v", err)
+	}
+}
+
+func TestHubPingInterval(t *testing.T) {
+	h := NewHub()
+	h.HandleFunc("/ws/", func(s *Session, r *Request) (*Envelope, error) { return nil, nil })
+	h.SetPingInterval("/ws/live", 20*time.Millisecond)
+
+	quiet, err := h.Connect("/ws/other")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	live, err := h.Connect("/ws/live")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	var pings []time.Time
+	for len(pings) < 3 {
+		select {
+		case env := <-live.SendChan:
+			if env.Channel != PingChannel {
+				t.Fatalf("expected ping envelope, got %+v", env)
+			}
+			pings = append(pings, time.Now())
+		case <-time.After(time.Second):
+			t.Fatalf("expected pings every 20ms, got %d", len(pings))
+		}
+	}
+	if elapsed := pings[0].Sub(start); elapsed < 15*time.Millisecond {
+		t.Errorf("first ping arrived too early: %v", elapsed)
+	}
+
+	select {
+	case env := <-quiet.SendChan:
+		t.Errorf("expected no pings for sessions without an interval, got %+v", env)
+	default:
+	}
+
+	h.Disconnect(live.ID)
+	time.Sleep(50 * time.Millisecond)
+	for env := range live.SendChan {
+		t.Errorf("expected pings to stop after close, got %+v", env)
+	}
+}
+
+func TestSessionPingSkipsBusySession(t *testing.T) {
+	s := NewSession("s1", "/ws", nil)
+	s.startPing(30 * time.Millisecond)
+	defer s.Close()
+
+	// Keep the session busy for several intervals
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		s.Send(NewEnvelope("update"))
+		env := <-s.SendChan
+		if env.Channel == PingChannel {
+			t.Fatal("expected no ping while the session is busy")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHubHasHandler(t *testing.T) {
+	hub := NewHub()
+	hub.HandleFunc("/ws/chat", func(*Session, *Request) (*Envelope, error) { return nil, nil })
+	hub.HandleFunc("/ws/rooms/", func(*Session, *Request) (*Envelope, error) { return nil, nil })
+
+	for url, want := range map[string]bool{
+		"/ws/chat":                 true,
+		"/ws/rooms/42":             true,
+		"ws://localhost/ws/chat":   true,
+		"/ws/other":                false,
+		"ws://localhost/ws/other/": false,
+	} {
+		if got := hub.HasHandler(url); got != want {
+			t.Errorf("HasHandler(%q): expected %v, got %v", url, want, got)
+		}
+	}
+
+	hub.SetDefaultHandler(MessageHandlerFunc(func(*Session, *Request) (*Envelope, error) { return nil, nil }))
+	if !hub.HasHandler("/ws/other") {
+		t.Error("expected default handler to match any URL")
+	}
+}
+
+func TestHubMaxSessionsPerURL(t *testing.T) {
+	hub := NewHub()
+	hub.HandleFunc("/ws/chat/", func(*Session, *Request) (*Envelope, error) { return nil, nil })
+	hub.SetMaxSessionsPerURL(1)
+
+	var destroyed sync.Map
+	hub.OnSessionDestroyed(func(s *Session) { destroyed.Store(s.ID, true) })
+
+	// Rapid reconnects to one URL keep only the newest session
+	var sessions []*Session
+	for i := 0; i < 50; i++ {
+		s, err := hub.Connect("/ws/chat/lobby")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions = append(sessions, s)
+	}
+	if got := hub.Stats().URLs["/ws/chat/lobby"]; got != 1 {
+		t.Errorf("expected 1 session for lobby, got %d", got)
+	}
+	latest := sessions[len(sessions)-1]
+	if got, _ := hub.GetSession(latest.ID); got != latest || latest.IsClosed() {
+		t.Error("expected the newest session to survive")
+	}
+	for _, s := range sessions[:len(sessions)-1] {
+		if !s.IsClosed() {
+			t.Errorf("expected replaced session %s closed", s.ID)
+		}
+		if _, ok := destroyed.Load(s.ID); !ok {
+			t.Errorf("expected OnSessionDestroyed for %s", s.ID)
+		}
+	}
+	if got := hub.Stats().Coalesced; got != 49 {
+		t.Errorf("expected 49 coalesced, got %d", got)
+	}
+
+	// Concurrent connects stay bounded too, and the host doesn't matter
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			url := "/ws/chat/lobby"
+			if i%2 == 0 {
+				url = "ws://localhost/ws/chat/lobby"
+			}
+			hub.Connect(url)
+		}(i)
+	}
+	wg.Wait()
+	if got := hub.SessionCount(); got != 1 {
+		t.Errorf("expected 1 session after concurrent connects, got %d", got)
+	}
+
+	// Other URLs are limited separately
+	room, _ := hub.Connect("/ws/chat/room")
+	if hub.SessionCount() != 2 || room.IsClosed() {
+		t.Errorf("expected a second URL to get its own session, have %d", hub.SessionCount())
+	}
+
+	// Reconnecting with an ID replaces the session for its URL as well
+	if _, err := hub.ConnectWithID("reconnect", "/ws/chat/room"); err != nil {
+		t.Fatal(err)
+	}
+	if !room.IsClosed() || hub.SessionCount() != 2 {
+		t.Errorf("expected ConnectWithID to replace the room session, have %d", hub.SessionCount())
+	}
+}
+
+func TestHubMaxSessionsPerURLLimit(t *testing.T) {
+	hub := NewHub()
+	hub.SetDefaultHandler(MessageHandlerFunc(func(*Session, *Request) (*Envelope, error) { return nil, nil }))
+
+	// No limit by default
+	for i := 0; i < 5; i++ {
+		hub.Connect("/ws/feed")
+	}
+	if got := hub.SessionCount(); got != 5 {
+		t.Fatalf("expected no limit by default, got %d sessions", got)
+	}
+
+	// Lowering the limit applies on the next connect, oldest first
+	hub.SetMaxSessionsPerURL(3)
+	newest, _ := hub.Connect("/ws/feed")
+	if got := hub.SessionCount(); got != 3 {
+		t.Errorf("expected 3 sessions, got %d", got)
+	}
+	if newest.IsClosed() {
+		t.Error("expected the new session to survive")
+	}
+}
+
+func TestHubBroadcastResult(t *testing.T) {
+	hub := NewHub()
+	hub.SetSendBuffer(1)
+	hub.HandleFunc("/ws/chat/", func(*Session, *Request) (*Envelope, error) { return nil, nil })
+
+	var mu sync.Mutex
+	var dropped []string
+	hub.OnSendDropped(func(s *Session, env *Envelope) {
+		mu.Lock()
+		dropped = append(dropped, s.URL+" "+env.Payload)
+		mu.Unlock()
+	})
+
+	slow, _ := hub.Connect("/ws/chat/a")
+	fast, _ := hub.Connect("/ws/chat/a")
+	other, _ := hub.Connect("/ws/chat/b")
+	if cap(slow.SendChan) != 1 {
+		t.Fatalf("expected a send buffer of 1, got %d", cap(slow.SendChan))
+	}
+
+	if got := hub.BroadcastResult(NewEnvelope("1")); got != (BroadcastResult{Delivered: 3}) {
+		t.Errorf("unexpected first broadcast result %+v", got)
+	}
+	<-fast.SendChan
+	<-other.SendChan
+
+	if got := hub.BroadcastToURLResult("/ws/chat/a", NewEnvelope("2")); got != (BroadcastResult{Delivered: 1, Dropped: 1}) {
+		t.Errorf("unexpected room broadcast result %+v", got)
+	}
+
+	hub.Disconnect(fast.ID)
+	if got := hub.BroadcastResult(NewEnvelope("3")); got != (BroadcastResult{Delivered: 1, Dropped: 1}) {
+		t.Errorf("unexpected broadcast result after disconnect %+v", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(dropped, ",") != "/ws/chat/a 2,/ws/chat/a 3" {
+		t.Errorf("unexpected drops %q", dropped)
+	}
+	if got := hub.Stats().Dropped; got != 2 {
+		t.Errorf("expected 2 drops in stats, got %d", got)
+	}
+}
+
+func TestSendDroppedHookCanCloseSession(t *testing.T) {
+	hub := NewHub()
+	hub.SetSendBuffer(1)
+	hub.HandleFunc("/ws", func(*Session, *Request) (*Envelope, error) { return nil, nil })
+	hub.OnSendDropped(func(s *Session, _ *Envelope) { hub.Disconnect(s.ID) })
+
+	s, _ := hub.Connect("/ws")
+	s.Send(NewEnvelope("1"))
+	if s.Send(NewEnvelope("2")) {
+		t.Fatal("expected the second send to be dropped")
+	}
+	if !s.IsClosed() || hub.SessionCount() != 0 {
+		t.Error("expected the hook to disconnect the slow session")
+	}
+	if got := hub.BroadcastResult(NewEnvelope("3")); got != (BroadcastResult{}) {
+		t.Errorf("unexpected result %+v", got)
+	}
+}
+
+func TestHubSessionsWhere(t *testing.T) {
+	hub := NewHub()
+	hub.HandleFunc("/ws", func(*Session, *Request) (*Envelope, error) { return nil, nil })
+
+	users := []string{"42", "42", "7", ""}
+	sessions := make([]*Session, len(users))
+	for i, user := range users {
+		sessions[i], _ = hub.Connect("/ws")
+		if user != "" {
+			sessions[i].Set("userID", user)
+		}
+	}
+	isUser42 := func(s *Session) bool { return s.GetString("userID") == "42" }
+
+	if got := hub.SessionsWhere(isUser42); len(got) != 2 {
+		t.Fatalf("expected 2 sessions for user 42, got %d", len(got))
+	}
+
+	if got := hub.SendWhere(isUser42, NewEnvelope("hi")); got != (BroadcastResult{Delivered: 2}) {
+		t.Errorf("unexpected result %+v", got)
+	}
+	for i, s := range sessions {
+		if got, want := len(s.SendChan), map[bool]int{true: 1}[users[i] == "42"]; got != want {
+			t.Errorf("session %d: expected %d queued envelopes, got %d", i, want, got)
+		}
+	}
+
+	hub.Disconnect(sessions[0].ID)
+	if got := hub.SessionsWhere(isUser42); len(got) != 1 || got[0] != sessions[1] {
+		t.Errorf("expected only the remaining session for user 42, got %v", got)
+	}
+}
+
+func TestHubSessionsWhereConcurrent(t *testing.T) {
+	hub := NewHub()
+	hub.HandleFunc("/ws", func(*Session, *Request) (*Envelope, error) { return nil, nil })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				s, err := hub.Connect("/ws")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				s.Set("room", "lobby")
+				hub.SendWhere(func(s *Session) bool { return s.GetString("room") == "lobby" }, NewEnvelope("x"))
+				hub.Disconnect(s.ID)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := hub.SessionsWhere(func(*Session) bool { return true }); len(got) != 0 {
+		t.Errorf("expected no sessions left, got %d", len(got))
+	}
+}
+
+func TestSessionCloseWithCode(t *testing.T) {
+	hub := NewHub()
+	var gotCode int
+	var gotReason string
+	hub.Handle("/ws", closeHandler(func(s *Session) { gotCode, gotReason = s.CloseStatus() }))
+
+	s, _ := hub.Connect("/ws")
+	if code, reason := s.CloseStatus(); code != 0 || reason != "" {
+		t.Errorf("expected no close status while open, got %d %q", code, reason)
+	}
+
+	hub.DisconnectWithCode(s.ID, ClosePolicyViolation, "banned")
+	s.CloseWithCode(CloseGoingAway, "later")
+	if gotCode != ClosePolicyViolation || gotReason != "banned" {
+		t.Errorf("expected OnClose to see 1008 banned, got %d %q", gotCode, gotReason)
+	}
+	if code, reason := s.CloseStatus(); code != ClosePolicyViolation || reason != "banned" {
+		t.Errorf("expected the first close to win, got %d %q", code, reason)
+	}
+
+	s2 := NewSession("s2", "/ws", nil)
+	s2.Close()
+	if code, _ := s2.CloseStatus(); code != CloseNormalClosure {
+		t.Errorf("expected Close to be a normal closure, got %d", code)
+	}
+}
+
+// closeHandler is a MessageHandler that only handles OnClose.
+type closeHandler func(*Session)
+
+func (h closeHandler) OnConnect(*Session) error                        { return nil }
+func (h closeHandler) OnMessage(*Session, *Request) (*Envelope, error) { return nil, nil }
+func (h closeHandler) OnClose(s *Session)                              { h(s) }
+
+func TestHubConnectQuery(t *testing.T) {
+	hub := NewHub()
+	var room string
+	hub.Handle("/ws/chat", connectHandler(func(s *Session) error {
+		room = s.ConnectQuery().Get("room")
+		return nil
+	}))
+
+	s, err := hub.Connect("/ws/chat?room=42")
+	if err != nil {
+		t.Fatalf("expected the query to be ignored for routing, got %v", err)
+	}
+	if room != "42" {
+		t.Errorf("expected OnConnect to see room 42, got %q", room)
+	}
+	if s.ConnectHeaders().Get("Authorization") != "" {
+		t.Error("expected no connect headers")
+	}
+}
+
+// connectHandler is a MessageHandler that only handles OnConnect.
+type connectHandler func(*Session) error
+
+func (h connectHandler) OnConnect(s *Session) error                      { return h(s) }
+func (h connectHandler) OnMessage(*Session, *Request) (*Envelope, error) { return nil, nil }
+func (h connectHandler) OnClose(*Session)                                {}
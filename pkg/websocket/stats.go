@@ -0,0 +1,120 @@
+package websocket
+
+import (
+	"sync/atomic"
+)
+
+// DefaultPattern is the HubStats.Patterns key for sessions served by the
+// default handler.
+const DefaultPattern = "*"
+
+// HubStats is a snapshot of a hub's sessions and message throughput.
+// Message counts are cumulative since the hub was created, so they include
+// sessions that have since closed.
+type HubStats struct {
+	Sessions    int    `json:"sessions"`
+	MessagesIn  uint64 `json:"messagesIn"`
+	MessagesOut uint64 `json:"messagesOut"`
+	Dropped     uint64 `json:"dropped"`
+
+	// Coalesced counts sessions closed because a newer one connected to the
+	// same URL (see SetMaxSessionsPerURL).
+	Coalesced uint64 `json:"coalesced"`
+
+	// Patterns breaks the counts down by the handler pattern sessions
+	// matched (DefaultPattern for the default handler).
+	Patterns map[string]PatternStats `json:"patterns"`
+
+	// URLs counts open sessions by URL path, e.g. per chat room under a
+	// "/ws/chat/" pattern.
+	URLs map[string]int `json:"urls"`
+}
+
+// PatternStats counts activity for the sessions of one handler pattern.
+type PatternStats struct {
+	Sessions    int    `json:"sessions"`
+	Connects    uint64 `json:"connects"`
+	MessagesIn  uint64 `json:"messagesIn"`
+	MessagesOut uint64 `json:"messagesOut"`
+	Dropped     uint64 `json:"dropped"`
+}
+
+// sessionCounters accumulates message counts for a handler pattern. Every
+// session of the pattern holds a pointer to it; sessions created directly
+// with NewSession have none.
+type sessionCounters struct {
+	connects atomic.Uint64
+	in       atomic.Uint64
+	out      atomic.Uint64
+	dropped  atomic.Uint64
+}
+
+func (c *sessionCounters) received() {
+	if c != nil {
+		c.in.Add(1)
+	}
+}
+
+// sent records an envelope queued (ok) or dropped because the send buffer
+// stayed full.
+func (c *sessionCounters) sent(ok bool) {
+	if c == nil {
+		return
+	}
+	if ok {
+		c.out.Add(1)
+	} else {
+		c.dropped.Add(1)
+	}
+}
+
+// patternCounters returns the counters for pattern, creating them on first use.
+func (h *Hub) patternCounters(pattern string) *sessionCounters {
+	h.statsMu.Lock()
+	defer h.statsMu.Unlock()
+	c, ok := h.counters[pattern]
+	if !ok {
+		c = &sessionCounters{}
+		h.counters[pattern] = c
+	}
+	return c
+}
+
+// Stats returns session counts by pattern and URL along with message
+// counts. Messages in are those passed to HandleMessage; out and dropped
+// are envelopes queued to, or dropped by, Send and SendTimeout (including
+// broadcasts). Sends to closed sessions aren't counted.
+func (h *Hub) Stats() HubStats {
+	stats := HubStats{
+		Patterns: make(map[string]PatternStats),
+		URLs:     make(map[string]int),
+	}
+
+	stats.Coalesced = h.coalesced.Load()
+
+	h.statsMu.RLock()
+	for pattern, c := range h.counters {
+		ps := PatternStats{
+			Connects:    c.connects.Load(),
+			MessagesIn:  c.in.Load(),
+			MessagesOut: c.out.Load(),
+			Dropped:     c.dropped.Load(),
+		}
+		stats.Patterns[pattern] = ps
+		stats.MessagesIn += ps.MessagesIn
+		stats.MessagesOut += ps.MessagesOut
+		stats.Dropped += ps.Dropped
+	}
+	h.statsMu.RUnlock()
+
+	h.sessionsMu.RLock()
+	defer h.sessionsMu.RUnlock()
+	stats.Sessions = len(h.sessions)
+	for _, s := range h.sessions {
+		ps := stats.Patterns[s.pattern]
+		ps.Sessions++
+		stats.Patterns[s.pattern] = ps
+		stats.URLs[extractPath(s.URL)]++
+	}
+	return stats
+}
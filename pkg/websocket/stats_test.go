@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"testing"
+)
+
+func TestHubStats(t *testing.T) {
+	h := NewHub()
+	echo := func(s *Session, req *Request) (*Envelope, error) { return nil, nil }
+	h.HandleFunc("/ws/chat/", echo)
+	h.HandleFunc("/ws/feed", echo)
+	h.SetDefaultHandler(MessageHandlerFunc(echo))
+
+	room1, _ := h.Connect("/ws/chat/room1")
+	h.Connect("ws://localhost/ws/chat/room1")
+	room2, _ := h.Connect("/ws/chat/room2")
+	feed, _ := h.Connect("/ws/feed")
+	other, _ := h.Connect("/ws/other")
+
+	for _, s := range []*Session{room1, room1, room2, feed} {
+		if _, err := h.HandleMessage(s.ID, []byte(`{"type":"request"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.BroadcastToURL("/ws/chat/", HTMLEnvelope("#msgs", "hi"))
+	h.Send(other.ID, HTMLEnvelope("#x", "y"))
+
+	stats := h.Stats()
+	if stats.Sessions != 5 || stats.MessagesIn != 4 || stats.MessagesOut != 4 || stats.Dropped != 0 {
+		t.Errorf("totals = %+v", stats)
+	}
+	want := map[string]PatternStats{
+		"/ws/chat/":    {Sessions: 3, Connects: 3, MessagesIn: 3, MessagesOut: 3},
+		"/ws/feed":     {Sessions: 1, Connects: 1, MessagesIn: 1},
+		DefaultPattern: {Sessions: 1, Connects: 1, MessagesOut: 1},
+	}
+	if len(stats.Patterns) != len(want) {
+		t.Errorf("patterns = %+v", stats.Patterns)
+	}
+	for pattern, ps := range want {
+		if stats.Patterns[pattern] != ps {
+			t.Errorf("pattern %q = %+v, want %+v", pattern, stats.Patterns[pattern], ps)
+		}
+	}
+	if stats.URLs["/ws/chat/room1"] != 2 || stats.URLs["/ws/chat/room2"] != 1 || stats.URLs["/ws/feed"] != 1 {
+		t.Errorf("urls = %v", stats.URLs)
+	}
+
+	// Disconnects drop session counts but keep message history
+	h.Disconnect(room1.ID)
+	h.Disconnect(room2.ID)
+	stats = h.Stats()
+	chat := stats.Patterns["/ws/chat/"]
+	if stats.Sessions != 3 || chat.Sessions != 1 || chat.Connects != 3 || chat.MessagesIn != 3 {
+		t.Errorf("after disconnect: sessions = %d, chat = %+v", stats.Sessions, chat)
+	}
+	if _, ok := stats.URLs["/ws/chat/room2"]; ok || stats.URLs["/ws/chat/room1"] != 1 {
+		t.Errorf("after disconnect: urls = %v", stats.URLs)
+	}
+
+	// Reconnecting with an ID counts as another connect
+	if _, err := h.ConnectWithID(room1.ID, "/ws/chat/room1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Stats().Patterns["/ws/chat/"]; got.Sessions != 2 || got.Connects != 4 {
+		t.Errorf("after reconnect: chat = %+v", got)
+	}
+}
+
+func TestHubStatsDropped(t *testing.T) {
+	h := NewHub()
+	h.HandleFunc("/ws", func(s *Session, req *Request) (*Envelope, error) { return nil, nil })
+	s, _ := h.Connect("/ws")
+
+	// Nothing reads SendChan, so sends past its buffer are dropped
+	capacity := cap(s.SendChan)
+	for i := 0; i < capacity+3; i++ {
+		s.Send(NewEnvelope("x"))
+	}
+	if err := s.SendTimeout(NewEnvelope("x"), 0); err != ErrSendTimeout {
+		t.Fatalf("SendTimeout = %v", err)
+	}
+
+	ps := h.Stats().Patterns["/ws"]
+	if ps.MessagesOut != uint64(capacity) || ps.Dropped != 4 {
+		t.Errorf("out = %d, dropped = %d; want %d, 4", ps.MessagesOut, ps.Dropped, capacity)
+	}
+
+	// Sends to a closed session aren't counted
+	h.Disconnect(s.ID)
+	s.Send(NewEnvelope("x"))
+	if got := h.Stats().Patterns["/ws"]; got != (PatternStats{Connects: 1, MessagesOut: uint64(capacity), Dropped: 4}) {
+		t.Errorf("after close = %+v", got)
+	}
+}
@@ -0,0 +1,107 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// Ticker periodically broadcasts envelopes from a hub; see Hub.Ticker.
+type Ticker struct {
+	hub  *Hub
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// Ticker broadcasts the envelope returned by fn to every session once per
+// interval, for server-pushed updates such as a clock or live metrics:
+//
+//	hub.Ticker(time.Second, func() *websocket.Envelope {
+//		return websocket.HTMLEnvelope("#clock", time.Now().Format(time.Kitchen))
+//	})
+//
+// fn isn't called while there are no sessions, and a nil envelope skips
+// the tick. Sessions with a full send buffer miss the tick, as with
+// Broadcast. The ticker runs until it's stopped or the hub is closed.
+// It panics if interval isn't positive.
+func (h *Hub) Ticker(interval time.Duration, fn func() *Envelope) *Ticker {
+	return h.startTicker("", interval, fn)
+}
+
+// TickerToURL is like Ticker, but broadcasts only to sessions connected to
+// URLs matching urlPattern, as BroadcastToURL does.
+func (h *Hub) TickerToURL(urlPattern string, interval time.Duration, fn func() *Envelope) *Ticker {
+	return h.startTicker(urlPattern, interval, fn)
+}
+
+func (h *Hub) startTicker(urlPattern string, interval time.Duration, fn func() *Envelope) *Ticker {
+	clock := time.NewTicker(interval)
+	t := &Ticker{
+		hub:  h,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	h.tickersMu.Lock()
+	if h.tickers == nil {
+		h.tickers = make(map[*Ticker]struct{})
+	}
+	h.tickers[t] = struct{}{}
+	h.tickersMu.Unlock()
+
+	go func() {
+		defer close(t.done)
+		defer clock.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-clock.C:
+				sessions := h.tickerSessions(urlPattern)
+				if len(sessions) == 0 {
+					continue
+				}
+				if envelope := fn(); envelope != nil {
+					broadcast(sessions, envelope)
+				}
+			}
+		}
+	}()
+	return t
+}
+
+// tickerSessions returns the sessions matching urlPattern, or all of them
+// if it's empty.
+func (h *Hub) tickerSessions(urlPattern string) []*Session {
+	if urlPattern == "" {
+		return h.AllSessions()
+	}
+	return h.SessionsForURL(urlPattern)
+}
+
+// Stop stops the ticker. Once it returns, no more envelopes are sent, so
+// it mustn't be called from the ticker's own fn. Stopping a stopped ticker
+// does nothing.
+func (t *Ticker) Stop() {
+	t.once.Do(func() {
+		close(t.stop)
+		t.hub.tickersMu.Lock()
+		delete(t.hub.tickers, t)
+		t.hub.tickersMu.Unlock()
+	})
+	<-t.done
+}
+
+// stopTickers stops every running ticker, for Close.
+func (h *Hub) stopTickers() {
+	h.tickersMu.Lock()
+	tickers := make([]*Ticker, 0, len(h.tickers))
+	for t := range h.tickers {
+		tickers = append(tickers, t)
+	}
+	h.tickersMu.Unlock()
+
+	for _, t := range tickers {
+		t.Stop()
+	}
+}
@@ -0,0 +1,142 @@
+package websocket
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHubTicker(t *testing.T) {
+	h := NewHub()
+	h.HandleFunc("/ws/", func(s *Session, r *Request) (*Envelope, error) { return nil, nil })
+
+	a, _ := h.Connect("/ws/dash")
+	b, _ := h.Connect("/ws/other")
+
+	var n atomic.Int64
+	start := time.Now()
+	ticker := h.Ticker(20*time.Millisecond, func() *Envelope {
+		return HTMLEnvelope("#tick", itoa(uint64(n.Add(1))))
+	})
+	defer ticker.Stop()
+
+	for _, s := range []*Session{a, b} {
+		for i := 0; i < 3; i++ {
+			select {
+			case env := <-s.SendChan:
+				if env.Target != "#tick" {
+					t.Fatalf("expected tick envelope, got %+v", env)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("session %s: expected a tick every 20ms, got %d", s.URL, i)
+			}
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected 3 ticks to take at least 3 intervals, took %v", elapsed)
+	}
+
+	ticker.Stop()
+	ticker.Stop() // Stopping twice is fine
+	drain(a)
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case env := <-a.SendChan:
+		t.Errorf("expected no ticks after Stop, got %+v", env)
+	default:
+	}
+}
+
+func TestHubTickerToURL(t *testing.T) {
+	h := NewHub()
+	defer h.Close()
+	h.HandleFunc("/ws/", func(s *Session, r *Request) (*Envelope, error) { return nil, nil })
+
+	var calls atomic.Int64
+	h.TickerToURL("/ws/dash", 10*time.Millisecond, func() *Envelope {
+		calls.Add(1)
+		return NewEnvelope("tick")
+	})
+
+	// fn isn't called until a session matches
+	other, _ := h.Connect("/ws/other")
+	time.Sleep(40 * time.Millisecond)
+	if c := calls.Load(); c != 0 {
+		t.Errorf("expected no calls without matching sessions, got %d", c)
+	}
+
+	dash, _ := h.Connect("/ws/dash")
+	select {
+	case <-dash.SendChan:
+	case <-time.After(time.Second):
+		t.Fatal("expected a tick for the matching session")
+	}
+	select {
+	case env := <-other.SendChan:
+		t.Errorf("expected no ticks for other URLs, got %+v", env)
+	default:
+	}
+}
+
+func TestHubTickerNilEnvelopeSkips(t *testing.T) {
+	h := NewHub()
+	defer h.Close()
+	h.HandleFunc("/ws/", func(s *Session, r *Request) (*Envelope, error) { return nil, nil })
+	s, _ := h.Connect("/ws/dash")
+
+	var calls atomic.Int64
+	h.Ticker(10*time.Millisecond, func() *Envelope {
+		calls.Add(1)
+		return nil
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	if calls.Load() == 0 {
+		t.Fatal("expected fn to be called")
+	}
+	select {
+	case env := <-s.SendChan:
+		t.Errorf("expected nil envelopes to be skipped, got %+v", env)
+	default:
+	}
+}
+
+func TestHubCloseStopsTickers(t *testing.T) {
+	h := NewHub()
+	h.HandleFunc("/ws/", func(s *Session, r *Request) (*Envelope, error) { return nil, nil })
+	h.Connect("/ws/dash")
+
+	var calls atomic.Int64
+	ticker := h.Ticker(5*time.Millisecond, func() *Envelope {
+		calls.Add(1)
+		return NewEnvelope("tick")
+	})
+	time.Sleep(20 * time.Millisecond)
+
+	h.Close()
+	select {
+	case <-ticker.done:
+	default:
+		t.Fatal("expected Close to stop the ticker")
+	}
+
+	// Sessions connected after Close don't revive it
+	h.Connect("/ws/dash")
+	before := calls.Load()
+	time.Sleep(30 * time.Millisecond)
+	if after := calls.Load(); after != before {
+		t.Errorf("expected no ticks after Close, got %d more", after-before)
+	}
+	ticker.Stop()
+}
+
+// drain empties a session's send buffer.
+func drain(s *Session) {
+	for {
+		select {
+		case <-s.SendChan:
+		default:
+			return
+		}
+	}
+}